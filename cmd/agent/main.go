@@ -411,7 +411,7 @@ func registerAgent(cmd *cobra.Command, args []string) {
 	var apiKey string
 	if serverAPIKey != "" {
 		apiKey = serverAPIKey
-		log.Printf("🔑 API Token: %s (来自命令行参数)", maskToken(apiKey))
+		log.Printf("🔑 API Token: %s (来自命令行参数)", utils.MaskToken(apiKey))
 	} else {
 		for {
 			fmt.Print("🔑 请输入 API Token: ")
@@ -443,7 +443,7 @@ func registerAgent(cmd *cobra.Command, args []string) {
 	log.Println("─────────────────────────────────────")
 	log.Println("📋 配置信息:")
 	log.Printf("   服务端地址: %s", endpoint)
-	log.Printf("   API Token: %s", maskToken(apiKey))
+	log.Printf("   API Token: %s", utils.MaskToken(apiKey))
 	log.Printf("   探针名称: %s", name)
 	log.Println("─────────────────────────────────────")
 	log.Println()
@@ -527,14 +527,6 @@ func registerAgent(cmd *cobra.Command, args []string) {
 	log.Println()
 }
 
-// maskToken 对 Token 进行部分遮蔽显示
-func maskToken(token string) string {
-	if len(token) <= 8 {
-		return "****"
-	}
-	return token[:4] + "****" + token[len(token)-4:]
-}
-
 func getHomeLogPath() string {
 	homeDir := utils.GetSafeHomeDir()
 	return filepath.Join(homeDir, ".pika", "logs", "agent.log")
@@ -563,7 +555,7 @@ func showInfo(cmd *cobra.Command, args []string) {
 	// 服务端信息
 	fmt.Println("🌐 服务端配置:")
 	fmt.Printf("   服务端地址: %s\n", cfg.Server.Endpoint)
-	fmt.Printf("   API Token: %s\n", maskToken(cfg.Server.APIKey))
+	fmt.Printf("   API Token: %s\n", utils.MaskToken(cfg.Server.APIKey))
 	fmt.Println()
 
 	// 采集器配置
@@ -12,6 +12,7 @@ import (
 
 	"github.com/dushixiang/pika/internal/config"
 	"github.com/dushixiang/pika/internal/handler"
+	"github.com/dushixiang/pika/internal/metricstore"
 	"github.com/dushixiang/pika/internal/migrate"
 	"github.com/dushixiang/pika/internal/models"
 	"github.com/dushixiang/pika/internal/scheduler"
@@ -104,6 +105,22 @@ func setup(app *orz.App) error {
 	go components.DDNSService.Run(ctx)
 	// 启动公网 IP 采集定时任务
 	go components.PublicIPService.Run(ctx)
+	// 启动路由快照清理任务
+	go components.RouteService.Run(ctx)
+	// 启动故障记录清理任务
+	go components.UptimeService.Run(ctx)
+	// 启动 Prometheus remote-write 转发任务
+	go components.RemoteWriteService.Run(ctx)
+	// 启动降采样任务
+	go components.RollupService.Run(ctx)
+	// 启动磁盘容量预测任务
+	go components.DiskForecastService.Run(ctx)
+	// 启动系统日志清理任务
+	go components.LogForwardService.Run(ctx)
+	// 启动内置时序存储的过期数据清理任务
+	if runner, ok := components.MetricStore.(metricstore.Runner); ok {
+		go runner.Run(ctx)
+	}
 
 	// 设置API
 	setupApi(app, components)
@@ -189,6 +206,15 @@ func setupApi(app *orz.App, components *AppComponents) {
 		publicApi.GET("/agent/version", components.AgentHandler.GetAgentVersion)
 		publicApi.GET("/agent/downloads/:filename", components.AgentHandler.DownloadAgent)
 		publicApi.GET("/agent/install.sh", components.AgentHandler.GetInstallScript)
+
+		// 公开状态页及访客订阅
+		publicApi.GET("/status-pages/:slug", components.StatusPageHandler.GetPublic)
+		publicApi.POST("/status-pages/:slug/subscribe", components.StatusPageHandler.Subscribe)
+		publicApi.GET("/status-pages/:slug/confirm", components.StatusPageHandler.ConfirmSubscription)
+		publicApi.GET("/status-pages/:slug/unsubscribe", components.StatusPageHandler.Unsubscribe)
+
+		// Prometheus 抓取接口（使用抓取令牌认证）
+		publicApi.GET("/prometheus/metrics", components.PrometheusHandler.Metrics)
 	}
 
 	// 公开接口（支持可选认证）- 已登录返回全部数据，未登录只返回公开数据
@@ -208,6 +234,8 @@ func setupApi(app *orz.App, components *AppComponents) {
 		publicApiWithOptionalAuth.GET("/monitors/:id/stats", components.MonitorHandler.GetStatsByID)
 		publicApiWithOptionalAuth.GET("/monitors/:id/agents", components.MonitorHandler.GetAgentStatsByID)
 		publicApiWithOptionalAuth.GET("/monitors/:id/history", components.MonitorHandler.GetHistoryByID)
+		publicApiWithOptionalAuth.GET("/monitors/:id/uptime", components.MonitorHandler.GetUptimeByID)
+		publicApiWithOptionalAuth.GET("/monitors/:id/incidents", components.MonitorHandler.GetIncidentsByID)
 
 		// Logo（公开访问）- 用于公共页面只获取 Logo
 		publicApiWithOptionalAuth.GET("/logo", components.PropertyHandler.GetLogo)
@@ -246,6 +274,9 @@ func setupApi(app *orz.App, components *AppComponents) {
 		adminApi.GET("/agents/tags", components.AgentHandler.GetTags)
 		adminApi.GET("/agents/:id", components.AgentHandler.GetForAdmin)
 		adminApi.GET("/agents/:id/metrics/latest", components.AgentHandler.GetAdminLatestMetrics)
+		adminApi.GET("/agents/:id/custom-metrics", components.AgentHandler.GetAvailableCustomMetrics)
+		adminApi.GET("/agents/:id/disk-forecast", components.AgentHandler.GetDiskForecast)
+		adminApi.GET("/agents/:id/metrics/export", components.AgentHandler.ExportMetrics)
 		adminApi.PUT("/agents/:id", components.AgentHandler.UpdateInfo)
 		adminApi.POST("/agents/batch/tags", components.AgentHandler.BatchUpdateTags)
 		adminApi.DELETE("/agents/:id", components.AgentHandler.Delete)
@@ -260,6 +291,9 @@ func setupApi(app *orz.App, components *AppComponents) {
 		adminApi.GET("/agents/:id/audit/result", components.AgentHandler.GetAuditResult)
 		adminApi.GET("/agents/:id/audit/results", components.AgentHandler.ListAuditResults)
 
+		// 探针诊断信息（管理员访问），通过 diagnostics 指令收集
+		adminApi.GET("/agents/:id/diagnostics", components.AgentHandler.GetDiagnostics)
+
 		// 防篡改管理（管理员功能）
 		adminApi.GET("/agents/:id/tamper/config", components.TamperHandler.GetConfig)
 		adminApi.PUT("/agents/:id/tamper/config", components.TamperHandler.UpdateConfig)
@@ -272,6 +306,17 @@ func setupApi(app *orz.App, components *AppComponents) {
 		adminApi.GET("/agents/:id/ssh-login/events", components.SSHLoginHandler.ListEvents)
 		adminApi.DELETE("/agents/:id/ssh-login/events", components.SSHLoginHandler.DeleteEvents)
 
+		// 配置模板
+		adminApi.GET("/agents/:id/config-profile", components.AgentConfigProfileHandler.GetEffective)
+
+		// 日志监控事件
+		adminApi.DELETE("/agents/:id/log-watch/events", components.LogWatchHandler.DeleteEvents)
+
+		// 系统日志转发
+		adminApi.GET("/agents/:id/log-forward/config", components.LogForwardHandler.GetConfig)
+		adminApi.POST("/agents/:id/log-forward/config", components.LogForwardHandler.UpdateConfig)
+		adminApi.DELETE("/agents/:id/logs", components.LogForwardHandler.DeleteEntries)
+
 		// 通用属性管理
 		adminApi.GET("/properties/:id", components.PropertyHandler.GetProperty)
 		adminApi.PUT("/properties/:id", components.PropertyHandler.SetProperty)
@@ -289,6 +334,33 @@ func setupApi(app *orz.App, components *AppComponents) {
 		adminApi.GET("/monitors/:id", components.MonitorHandler.Get)
 		adminApi.PUT("/monitors/:id", components.MonitorHandler.Update)
 		adminApi.DELETE("/monitors/:id", components.MonitorHandler.Delete)
+		adminApi.GET("/monitors/:id/routes", components.MonitorHandler.GetRouteSnapshots)
+		adminApi.GET("/monitors/:id/history/export", components.MonitorHandler.ExportHistory)
+
+		// 自定义 PromQL 查询（结果限制在可见探针范围内）
+		adminApi.GET("/metrics/query", components.MetricQueryHandler.Query)
+		adminApi.POST("/metrics/query", components.MetricQueryHandler.Query)
+		adminApi.GET("/metrics/query_range", components.MetricQueryHandler.QueryRange)
+		adminApi.POST("/metrics/query_range", components.MetricQueryHandler.QueryRange)
+
+		// 探针分组汇总（按标签）
+		adminApi.GET("/fleet/overview", components.FleetHandler.GetOverview)
+		adminApi.GET("/fleet/groups", components.FleetHandler.GetGroup)
+		adminApi.GET("/fleet/at-risk-disks", components.FleetHandler.GetAtRiskDisks)
+
+		// 状态页管理
+		adminApi.GET("/status-pages", components.StatusPageHandler.Paging)
+		adminApi.POST("/status-pages", components.StatusPageHandler.Create)
+		adminApi.GET("/status-pages/:id", components.StatusPageHandler.Get)
+		adminApi.PUT("/status-pages/:id", components.StatusPageHandler.Update)
+		adminApi.DELETE("/status-pages/:id", components.StatusPageHandler.Delete)
+		adminApi.GET("/status-pages/:id/incidents", components.StatusPageHandler.ListIncidents)
+		adminApi.POST("/status-pages/:id/incidents", components.StatusPageHandler.CreateIncident)
+		adminApi.GET("/status-pages/:id/incidents/:incidentId", components.StatusPageHandler.GetIncident)
+		adminApi.POST("/status-pages/:id/incidents/:incidentId/updates", components.StatusPageHandler.AddIncidentUpdate)
+		adminApi.DELETE("/status-pages/:id/incidents/:incidentId", components.StatusPageHandler.DeleteIncident)
+		adminApi.GET("/status-pages/:id/subscribers", components.StatusPageHandler.ListSubscribers)
+		adminApi.DELETE("/status-pages/:id/subscribers/:subscriberId", components.StatusPageHandler.DeleteSubscriber)
 
 		// DNS Provider 管理
 		adminApi.GET("/dns-providers", components.DNSProviderHandler.GetAll)
@@ -305,6 +377,24 @@ func setupApi(app *orz.App, components *AppComponents) {
 		adminApi.POST("/ddns/:id/disable", components.DDNSHandler.Disable)
 		adminApi.GET("/ddns/:id/records", components.DDNSHandler.GetRecords)
 		adminApi.POST("/ddns/:id/trigger", components.DDNSHandler.TriggerUpdate)
+
+		// 探针配置模板管理
+		adminApi.GET("/agent-config-profiles", components.AgentConfigProfileHandler.Paging)
+		adminApi.POST("/agent-config-profiles", components.AgentConfigProfileHandler.Create)
+		adminApi.GET("/agent-config-profiles/:id", components.AgentConfigProfileHandler.Get)
+		adminApi.PUT("/agent-config-profiles/:id", components.AgentConfigProfileHandler.Update)
+		adminApi.DELETE("/agent-config-profiles/:id", components.AgentConfigProfileHandler.Delete)
+
+		// 日志监控规则管理
+		adminApi.GET("/log-watch-rules", components.LogWatchHandler.Paging)
+		adminApi.POST("/log-watch-rules", components.LogWatchHandler.Create)
+		adminApi.GET("/log-watch-rules/:id", components.LogWatchHandler.Get)
+		adminApi.PUT("/log-watch-rules/:id", components.LogWatchHandler.Update)
+		adminApi.DELETE("/log-watch-rules/:id", components.LogWatchHandler.Delete)
+		adminApi.GET("/log-watch/events", components.LogWatchHandler.ListEvents)
+
+		// 系统日志检索
+		adminApi.GET("/logs/search", components.LogForwardHandler.Search)
 	}
 
 	// OIDC 认证路由（如果启用）
@@ -317,17 +407,29 @@ func setupApi(app *orz.App, components *AppComponents) {
 func autoMigrate(database *gorm.DB) error {
 	// 自动迁移数据库表
 	return database.AutoMigrate(
-		&models.Agent{},         // 探针
-		&models.ApiKey{},        // ApiKey
-		&models.AuditResult{},   // 审计历史
-		&models.Property{},      // 系统属性
-		&models.AlertRecord{},   // 告警记录
-		&models.AlertState{},    // 告警状态
-		&models.MonitorTask{},   // 服务监控
-		&models.TamperEvent{},   // 防篡改事件
-		&models.DDNSConfig{},    // DDNS 配置
-		&models.DDNSRecord{},    // DDNS 记录
-		&models.SSHLoginEvent{}, // SSH 登录事件
+		&models.Agent{},                    // 探针
+		&models.ApiKey{},                   // ApiKey
+		&models.AuditResult{},              // 审计历史
+		&models.Property{},                 // 系统属性
+		&models.AlertRecord{},              // 告警记录
+		&models.AlertState{},               // 告警状态
+		&models.MonitorTask{},              // 服务监控
+		&models.TamperEvent{},              // 防篡改事件
+		&models.DDNSConfig{},               // DDNS 配置
+		&models.DDNSRecord{},               // DDNS 记录
+		&models.AgentConfigProfile{},       // 探针配置模板
+		&models.SSHLoginEvent{},            // SSH 登录事件
+		&models.LogWatchRule{},             // 日志监控规则
+		&models.LogWatchEvent{},            // 日志匹配事件
+		&models.LogEntry{},                 // 转发的系统日志
+		&models.MonitorRouteSnapshot{},     // 路由追踪快照
+		&models.MonitorIncident{},          // 监控故障记录
+		&models.StatusPage{},               // 状态页
+		&models.StatusPageIncident{},       // 状态页故障与维护公告
+		&models.StatusPageIncidentUpdate{}, // 状态页公告进展
+		&models.StatusPageSubscriber{},     // 状态页订阅者
+		&models.MetricSeries{},             // 内置时序存储序列
+		&models.MetricSample{},             // 内置时序存储样本
 	)
 }
 
@@ -426,6 +528,31 @@ func startMetricsMonitoring(ctx context.Context, components *AppComponents, logg
 				if err := components.AlertService.CheckMetrics(ctx, agent.ID, cpuUsage, memoryUsage, diskUsage, networkSpeed); err != nil {
 					logger.Error("检查告警规则失败", zap.String("agentId", agent.ID), zap.Error(err))
 				}
+
+				// 检查自定义指标告警
+				if err := components.AlertService.CheckCustomMetrics(ctx, agent.ID, latest.Custom); err != nil {
+					logger.Error("检查自定义指标告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+				}
+
+				// 检查 systemd 单元与容器状态告警
+				if err := components.AlertService.CheckServiceStates(ctx, agent.ID, latest.Services); err != nil {
+					logger.Error("检查服务状态告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+				}
+
+				// 检查 RAID 阵列与磁盘健康告警
+				if err := components.AlertService.CheckHardwareHealth(ctx, agent.ID, latest.Hardware); err != nil {
+					logger.Error("检查硬件健康告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+				}
+
+				// 检查 CPU steal、PSI 等内核指标告警
+				if err := components.AlertService.CheckKernelMetrics(ctx, agent.ID, latest); err != nil {
+					logger.Error("检查内核指标告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+				}
+
+				// 检查指标是否偏离基线
+				if err := components.AlertService.CheckAnomalies(ctx, agent.ID, latest); err != nil {
+					logger.Error("检查异常检测告警失败", zap.String("agentId", agent.ID), zap.Error(err))
+				}
 			}
 
 			// 检查监控相关告警（证书和服务下线）
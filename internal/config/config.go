@@ -2,12 +2,18 @@ package config
 
 // AppConfig 应用配置
 type AppConfig struct {
-	JWT             JWTConfig          `json:"JWT"`
-	Users           map[string]string  `json:"Users"`           // 用户名 -> bcrypt加密的密码
-	OIDC            *OIDCConfig        `json:"OIDC"`            // OIDC配置（可选）
-	GitHub          *GitHubOAuthConfig `json:"GitHub"`          // GitHub OAuth配置（可选）
-	GeoIP           *GeoIPConfig       `json:"GeoIP"`           // GeoIP配置（可选）
-	VictoriaMetrics *VMConfig          `json:"VictoriaMetrics"` // VictoriaMetrics配置（可选）
+	JWT             JWTConfig              `json:"JWT"`
+	Users           map[string]string      `json:"Users"`           // 用户名 -> bcrypt加密的密码
+	OIDC            *OIDCConfig            `json:"OIDC"`            // OIDC配置（可选）
+	GitHub          *GitHubOAuthConfig     `json:"GitHub"`          // GitHub OAuth配置（可选）
+	GeoIP           *GeoIPConfig           `json:"GeoIP"`           // GeoIP配置（可选）
+	VictoriaMetrics *VMConfig              `json:"VictoriaMetrics"` // VictoriaMetrics配置（可选）
+	EmbeddedMetrics *EmbeddedMetricsConfig `json:"EmbeddedMetrics"` // 内置时序存储配置（未启用VictoriaMetrics时生效）
+	RemoteWrite     []RemoteWriteConfig    `json:"RemoteWrite"`     // Prometheus remote-write 转发目标（可选）
+	Prometheus      *PrometheusConfig      `json:"Prometheus"`      // Prometheus 抓取接口配置（可选，未配置时接口不可用）
+	Rollup          *RollupConfig          `json:"Rollup"`          // 降采样配置（未配置时使用默认层级）
+	LogForward      *LogForwardConfig      `json:"LogForward"`      // 系统日志转发存储配置（可选）
+	ExternalURL     string                 `json:"ExternalURL"`     // 服务对外访问地址（如 https://pika.example.com），用于生成邮件中的链接
 }
 
 // JWTConfig JWT配置
@@ -39,6 +45,7 @@ type GeoIPConfig struct {
 	Enabled    bool   `json:"Enabled"`    // 是否启用GeoIP查询
 	DBPath     string `json:"DBPath"`     // GeoIP数据库文件路径（如：GeoLite2-City.mmdb）
 	DBLanguage string `json:"DBLanguage"` // 数据库语言（如：zh-CN、en）
+	ASNDBPath  string `json:"ASNDBPath"`  // ASN 数据库文件路径（可选，如：GeoLite2-ASN.mmdb）
 }
 
 // VMConfig VictoriaMetrics配置
@@ -49,3 +56,44 @@ type VMConfig struct {
 	WriteTimeout  int    `json:"WriteTimeout"`  // 写入超时（秒）
 	QueryTimeout  int    `json:"QueryTimeout"`  // 查询超时（秒）
 }
+
+// EmbeddedMetricsConfig 内置时序存储配置
+type EmbeddedMetricsConfig struct {
+	RetentionDays int `json:"RetentionDays"` // 数据保留天数，默认 7 天
+}
+
+// LogForwardConfig 系统日志转发存储配置
+type LogForwardConfig struct {
+	RetentionDays int `json:"RetentionDays"` // 日志保留天数，默认 7 天
+}
+
+// RollupConfig 降采样配置
+type RollupConfig struct {
+	Enabled            bool               `json:"Enabled"`            // 是否启用降采样
+	Tiers              []RollupTierConfig `json:"Tiers"`              // 降采样层级，默认 1m 保留 30 天、1h 保留 730 天
+	VictoriaMetricsURL string             `json:"VictoriaMetricsURL"` // 降采样数据单独写入的 VictoriaMetrics 地址（可选，便于为长期数据设置独立的保留时长）
+}
+
+// RollupTierConfig 降采样层级配置
+type RollupTierConfig struct {
+	Resolution    string `json:"Resolution"`    // 聚合粒度，如 1m、5m、1h
+	RetentionDays int    `json:"RetentionDays"` // 数据保留天数
+}
+
+// PrometheusConfig Prometheus 抓取接口配置
+type PrometheusConfig struct {
+	ScrapeToken string `json:"ScrapeToken"` // 抓取令牌，通过 Authorization: Bearer <token> 请求头传递，为空时关闭抓取接口
+}
+
+// RemoteWriteConfig Prometheus remote-write 转发目标配置
+type RemoteWriteConfig struct {
+	Name        string            `json:"Name"`        // 目标名称，用于日志
+	URL         string            `json:"URL"`         // remote-write 地址，如 http://prometheus:9090/api/v1/write
+	Username    string            `json:"Username"`    // Basic Auth 用户名（可选）
+	Password    string            `json:"Password"`    // Basic Auth 密码（可选）
+	BearerToken string            `json:"BearerToken"` // Bearer Token（可选，优先于 Basic Auth）
+	Headers     map[string]string `json:"Headers"`     // 额外请求头（可选）
+	Timeout     int               `json:"Timeout"`     // 请求超时（秒），默认 30
+	BatchSize   int               `json:"BatchSize"`   // 单次发送的序列数，默认 2000
+	MaxBuffer   int               `json:"MaxBuffer"`   // 远端不可用时最多缓存的序列数，默认 100000
+}
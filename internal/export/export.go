@@ -0,0 +1,256 @@
+// Package export 将图表序列（metric.Series）导出为 CSV、NDJSON 或 Parquet 文件
+package export
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dushixiang/pika/internal/metric"
+)
+
+// Format 导出格式
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+// ParseFormat 解析导出格式，空字符串默认为 CSV
+func ParseFormat(value string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(value))) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatNDJSON, "jsonl", "json":
+		return FormatNDJSON, nil
+	case FormatParquet:
+		return FormatParquet, nil
+	default:
+		return "", fmt.Errorf("不支持的导出格式: %s", value)
+	}
+}
+
+// ContentType 返回导出格式对应的 MIME 类型
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension 返回导出格式对应的文件扩展名
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Writer 流式写出器，按时间顺序逐批写入序列，每批在内存中对齐后立即写出
+type Writer interface {
+	// WriteBatch 写入一批序列，批次之间的时间范围不能重叠
+	WriteBatch(series []metric.Series) error
+	// Close 写出剩余内容（CSV 表头、Parquet 文件尾），不关闭底层 io.Writer
+	Close() error
+}
+
+// NewWriter 创建指定格式的写出器，columns 为 CSV 与 Parquet 的列名（需按 Columns 的顺序），NDJSON 忽略该参数
+func NewWriter(w io.Writer, format Format, columns []string) Writer {
+	switch format {
+	case FormatNDJSON:
+		return newNDJSONWriter(w)
+	case FormatParquet:
+		return newParquetWriter(w, columns)
+	default:
+		return newCSVWriter(w, columns)
+	}
+}
+
+// Write 按指定格式写出一批序列
+func Write(w io.Writer, format Format, series []metric.Series) error {
+	writer := NewWriter(w, format, Columns(series))
+	if err := writer.WriteBatch(series); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// Source 按时间顺序逐批产出序列，每批调用一次 yield
+type Source func(yield func(series []metric.Series) error) error
+
+// Export 流式导出，内存中只保留单个批次：NDJSON 逐批直接写出；
+// CSV 与 Parquet 的列需要在写出前确定，先将批次暂存到临时文件并收集列名，再逐批读回写出
+// w 实现 Flush() 时每批写出后刷新，便于客户端尽早收到数据
+func Export(w io.Writer, format Format, source Source) error {
+	if format == FormatNDJSON {
+		writer := newNDJSONWriter(w)
+		err := source(func(series []metric.Series) error {
+			if err := writer.WriteBatch(series); err != nil {
+				return err
+			}
+			if err := writer.bw.Flush(); err != nil {
+				return err
+			}
+			flush(w)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return writer.Close()
+	}
+
+	spool, err := os.CreateTemp("", "pika-export-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = spool.Close()
+		_ = os.Remove(spool.Name())
+	}()
+
+	// 第一遍：暂存批次并收集列名
+	seen := make(map[string]struct{})
+	bw := bufio.NewWriter(spool)
+	encoder := gob.NewEncoder(bw)
+	batches := 0
+	err = source(func(series []metric.Series) error {
+		for _, s := range series {
+			seen[ColumnName(s)] = struct{}{}
+		}
+		batches++
+		return encoder.Encode(series)
+	})
+	if err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	columns := make([]string, 0, len(seen))
+	for name := range seen {
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+
+	// 第二遍：逐批读回并写出
+	writer := NewWriter(w, format, columns)
+	decoder := gob.NewDecoder(bufio.NewReader(spool))
+	for i := 0; i < batches; i++ {
+		var series []metric.Series
+		if err := decoder.Decode(&series); err != nil {
+			return err
+		}
+		if err := writer.WriteBatch(series); err != nil {
+			return err
+		}
+		flush(w)
+	}
+	return writer.Close()
+}
+
+// flush 刷新支持 Flush 的 io.Writer（如 HTTP 响应）
+func flush(w io.Writer) {
+	if f, ok := w.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+}
+
+// Columns 返回序列的列名，按名称排序并去重
+func Columns(series []metric.Series) []string {
+	seen := make(map[string]struct{}, len(series))
+	columns := make([]string, 0, len(series))
+	for _, s := range series {
+		name := ColumnName(s)
+		if _, ok := seen[name]; !ok {
+			seen[name] = struct{}{}
+			columns = append(columns, name)
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+// ColumnName 生成序列的列名：名称 + 排序后的标签，如 upload{interface="eth0"}
+func ColumnName(s metric.Series) string {
+	if len(s.Labels) == 0 {
+		return s.Name
+	}
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(s.Name)
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", k, s.Labels[k])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// table 宽表：每个序列一列，按时间戳对齐，缺失的数据点为 NaN
+type table struct {
+	timestamps []int64
+	values     [][]float64 // values[列][行]
+}
+
+// newTable 将一批序列按时间戳对齐为宽表，列顺序与 columns 一致，同名列合并
+func newTable(columns []string, series []metric.Series) (*table, error) {
+	order := make(map[string]int, len(columns))
+	for i, name := range columns {
+		order[name] = i
+	}
+
+	tsSet := make(map[int64]struct{})
+	for _, s := range series {
+		if _, ok := order[ColumnName(s)]; !ok {
+			return nil, fmt.Errorf("unknown export column: %s", ColumnName(s))
+		}
+		for _, p := range s.Data {
+			tsSet[p.Timestamp] = struct{}{}
+		}
+	}
+	timestamps := make([]int64, 0, len(tsSet))
+	for ts := range tsSet {
+		timestamps = append(timestamps, ts)
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	row := make(map[int64]int, len(timestamps))
+	for i, ts := range timestamps {
+		row[ts] = i
+	}
+
+	values := make([][]float64, len(columns))
+	for i := range values {
+		values[i] = make([]float64, len(timestamps))
+		for j := range values[i] {
+			values[i][j] = math.NaN()
+		}
+	}
+	for _, s := range series {
+		col := order[ColumnName(s)]
+		for _, p := range s.Data {
+			values[col][row[p.Timestamp]] = p.Value
+		}
+	}
+
+	return &table{timestamps: timestamps, values: values}, nil
+}
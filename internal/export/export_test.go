@@ -0,0 +1,261 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/golang/snappy"
+)
+
+var testSeries = []metric.Series{
+	{Name: "upload", Labels: map[string]string{"interface": "eth0"}, Data: []metric.DataPoint{{Timestamp: 1000, Value: 1.5}, {Timestamp: 2000, Value: 2}}},
+	{Name: "cpu.usage", Data: []metric.DataPoint{{Timestamp: 2000, Value: 30}, {Timestamp: 3000, Value: 40}}},
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testSeries); err != nil {
+		t.Fatal(err)
+	}
+	want := `timestamp,time,cpu.usage,"upload{interface=""eth0""}"
+1000,1970-01-01T00:00:01Z,,1.5
+2000,1970-01-01T00:00:02Z,30,2
+3000,1970-01-01T00:00:03Z,40,
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected csv:\n%s", buf.String())
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, testSeries); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[0] != `{"time":"1970-01-01T00:00:01Z","timestamp":1000,"series":"upload{interface=\"eth0\"}","name":"upload","labels":{"interface":"eth0"},"value":1.5}` {
+		t.Fatalf("unexpected ndjson:\n%s", buf.String())
+	}
+}
+
+// thriftReader 测试用的 Thrift compact 协议解码器，结构体解码为 map[字段编号]值
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.buf[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.buf[r.pos]
+		r.pos++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i] = r.value(header & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		fields := make(map[int16]any)
+		var last int16
+		for {
+			header := r.buf[r.pos]
+			r.pos++
+			if header == 0 {
+				return fields
+			}
+			id := last + int16(header>>4)
+			if header>>4 == 0 {
+				id = int16(r.zigzag())
+			}
+			fields[id] = r.value(header & 0x0f)
+			last = id
+		}
+	}
+	panic("unsupported thrift type")
+}
+
+func TestWriteParquet(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, testSeries); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, parquetMagic) || !bytes.HasSuffix(data, parquetMagic) {
+		t.Fatalf("missing magic")
+	}
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footerStart := len(data) - 8 - footerLen
+	r := &thriftReader{buf: data[footerStart : len(data)-8]}
+	meta := r.value(thriftStruct).(map[int16]any)
+	if r.pos != footerLen || meta[3] != int64(3) {
+		t.Fatalf("unexpected footer: %v", meta)
+	}
+
+	schema := meta[2].([]any)
+	var names []string
+	for _, e := range schema {
+		names = append(names, e.(map[int16]any)[4].(string))
+	}
+	if strings.Join(names, "|") != `schema|timestamp|cpu.usage|upload{interface="eth0"}` {
+		t.Fatalf("unexpected schema: %v", names)
+	}
+
+	columns := meta[4].([]any)[0].(map[int16]any)[1].([]any)
+	if len(columns) != 3 {
+		t.Fatalf("unexpected columns: %v", columns)
+	}
+
+	// 读取 cpu.usage 列：定义级别为 [0,1,1]，非空值为 30、40
+	column := columns[1].(map[int16]any)[3].(map[int16]any)
+	offset := int(column[9].(int64))
+	page := &thriftReader{buf: data[offset:footerStart]}
+	header := page.value(thriftStruct).(map[int16]any)
+	compressedSize := int(header[3].(int64))
+	body, err := snappy.Decode(nil, page.buf[page.pos:page.pos+compressedSize])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(body) != int(header[2].(int64)) || int64(page.pos+compressedSize) != column[7].(int64) {
+		t.Fatalf("unexpected page sizes: %v %v", header, column)
+	}
+
+	levelsLen := int(binary.LittleEndian.Uint32(body))
+	levels := body[4 : 4+levelsLen]
+	if !bytes.Equal(levels, []byte{1 << 1, 0, 2 << 1, 1}) {
+		t.Fatalf("unexpected definition levels: %v", levels)
+	}
+	values := body[4+levelsLen:]
+	if len(values) != 16 ||
+		math.Float64frombits(binary.LittleEndian.Uint64(values)) != 30 ||
+		math.Float64frombits(binary.LittleEndian.Uint64(values[8:])) != 40 {
+		t.Fatalf("unexpected values: %v", values)
+	}
+}
+
+// TestWriteParquetGolden 按 parquet.thrift 与 Thrift compact 协议逐字节核对输出，不依赖本文件中的解码器
+func TestWriteParquetGolden(t *testing.T) {
+	series := []metric.Series{
+		{Name: "v", Data: []metric.DataPoint{{Timestamp: 1000, Value: 1.5}, {Timestamp: 2000, Value: math.NaN()}}},
+	}
+
+	// DataPageHeader：type=DATA_PAGE，uncompressed=16，compressed=18，
+	// num_values=2，encoding=PLAIN，definition/repetition_level_encoding=RLE
+	pageHeader := []byte{0x15, 0x00, 0x15, 0x20, 0x15, 0x24, 0x2c, 0x15, 0x04, 0x15, 0x00, 0x15, 0x06, 0x15, 0x06, 0x00, 0x00}
+	// 16 字节的 snappy 字面量块：长度 varint + 字面量标记 (16-1)<<2
+	snappyLiteral := []byte{0x10, 0x3c}
+
+	var want []byte
+	want = append(want, "PAR1"...)
+	// timestamp 列（offset 4）：1000、2000 的 INT64 小端
+	want = append(want, pageHeader...)
+	want = append(want, snappyLiteral...)
+	want = append(want, 0xe8, 0x03, 0, 0, 0, 0, 0, 0, 0xd0, 0x07, 0, 0, 0, 0, 0, 0)
+	// v 列（offset 39）：定义级别长度 4，RLE 游程 (1 个 1)(1 个 0)，非空值 1.5
+	want = append(want, pageHeader...)
+	want = append(want, snappyLiteral...)
+	want = append(want, 0x04, 0, 0, 0, 0x02, 0x01, 0x02, 0x00, 0, 0, 0, 0, 0, 0, 0xf8, 0x3f)
+
+	footer := []byte{
+		0x15, 0x02, // version=1
+		0x19, 0x3c, // schema: list<struct> 3 个元素
+		0x48, 0x06, 's', 'c', 'h', 'e', 'm', 'a', 0x15, 0x04, 0x00, // name=schema, num_children=2
+		0x15, 0x04, 0x25, 0x00, 0x18, 0x09, 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p', 0x25, 0x12, 0x00, // INT64 REQUIRED TIMESTAMP_MILLIS
+		0x15, 0x0a, 0x25, 0x02, 0x18, 0x01, 'v', 0x00, // DOUBLE OPTIONAL
+		0x16, 0x04, // num_rows=2
+		0x19, 0x1c, // row_groups: list<struct> 1 个元素
+		0x19, 0x2c, // columns: list<struct> 2 个元素
+		// timestamp：file_offset=4，INT64，[PLAIN,RLE]，path，SNAPPY，num_values=2，大小 33/35，data_page_offset=4
+		0x26, 0x08, 0x1c, 0x15, 0x04, 0x19, 0x25, 0x00, 0x06, 0x19, 0x18, 0x09, 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p',
+		0x15, 0x02, 0x16, 0x04, 0x16, 0x42, 0x16, 0x46, 0x26, 0x08, 0x00, 0x00,
+		// v：file_offset=39，DOUBLE
+		0x26, 0x4e, 0x1c, 0x15, 0x0a, 0x19, 0x25, 0x00, 0x06, 0x19, 0x18, 0x01, 'v',
+		0x15, 0x02, 0x16, 0x04, 0x16, 0x42, 0x16, 0x46, 0x26, 0x4e, 0x00, 0x00,
+		0x16, 0x84, 0x01, 0x16, 0x04, 0x00, // total_byte_size=66，num_rows=2
+		0x28, 0x04, 'p', 'i', 'k', 'a', // created_by
+		0x00,
+	}
+	want = append(want, footer...)
+	want = binary.LittleEndian.AppendUint32(want, uint32(len(footer)))
+	want = append(want, "PAR1"...)
+
+	var buf bytes.Buffer
+	if err := WriteParquet(&buf, series); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("unexpected parquet bytes:\n got % x\nwant % x", buf.Bytes(), want)
+	}
+}
+
+func TestExportBatches(t *testing.T) {
+	upload := testSeries[0]
+	source := func(yield func([]metric.Series) error) error {
+		// 按时间窗口分批，cpu.usage 在第二批才出现，列需要在写出前合并
+		first := metric.Series{Name: upload.Name, Labels: upload.Labels, Data: upload.Data[:1]}
+		if err := yield([]metric.Series{first}); err != nil {
+			return err
+		}
+		second := metric.Series{Name: upload.Name, Labels: upload.Labels, Data: upload.Data[1:]}
+		return yield([]metric.Series{second, testSeries[1]})
+	}
+
+	var buf bytes.Buffer
+	if err := Export(&buf, FormatCSV, source); err != nil {
+		t.Fatal(err)
+	}
+	// 与一次性写出的结果一致
+	var want bytes.Buffer
+	if err := WriteCSV(&want, testSeries); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != want.String() {
+		t.Fatalf("unexpected csv:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := Export(&buf, FormatParquet, source); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	r := &thriftReader{buf: data[len(data)-8-footerLen : len(data)-8]}
+	meta := r.value(thriftStruct).(map[int16]any)
+	if meta[3] != int64(3) || len(meta[4].([]any)) != 2 || len(meta[2].([]any)) != 4 {
+		t.Fatalf("unexpected footer: %v", meta)
+	}
+
+	buf.Reset()
+	if err := Export(&buf, FormatNDJSON, source); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 4 {
+		t.Fatalf("unexpected ndjson:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,351 @@
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/golang/snappy"
+)
+
+// Parquet 格式常量（parquet.thrift）
+const (
+	parquetTypeInt64  = 2
+	parquetTypeDouble = 5
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetConvertedTimestampMillis = 9
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetCodecSnappy = 1
+
+	parquetPageData = 0
+)
+
+// Thrift compact 协议类型
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+var parquetMagic = []byte("PAR1")
+
+// WriteParquet 以宽表写出 Parquet 文件：timestamp 列（TIMESTAMP_MILLIS）以及每个序列一个可空的 DOUBLE 列
+func WriteParquet(w io.Writer, series []metric.Series) error {
+	return Write(w, FormatParquet, series)
+}
+
+// parquetWriter 流式 Parquet 写出器，每批数据写为一个行组，每列一个 snappy 压缩的 PLAIN 编码数据页，
+// 内存中只保留已写出行组的元数据，Close 时写出文件尾
+type parquetWriter struct {
+	cw        *countingWriter
+	columns   []string
+	numRows   int64
+	rowGroups []parquetRowGroup
+}
+
+// parquetRowGroup 已写出的行组信息
+type parquetRowGroup struct {
+	numRows int64
+	chunks  []parquetColumnChunk
+}
+
+func newParquetWriter(w io.Writer, columns []string) *parquetWriter {
+	return &parquetWriter{cw: &countingWriter{w: w}, columns: columns}
+}
+
+// writeMagic 在写出第一个字节前写出文件头
+func (p *parquetWriter) writeMagic() error {
+	if p.cw.n > 0 {
+		return nil
+	}
+	_, err := p.cw.Write(parquetMagic)
+	return err
+}
+
+func (p *parquetWriter) WriteBatch(series []metric.Series) error {
+	t, err := newTable(p.columns, series)
+	if err != nil {
+		return err
+	}
+	if err := p.writeMagic(); err != nil {
+		return err
+	}
+	numRows := int64(len(t.timestamps))
+	if numRows == 0 {
+		return nil
+	}
+
+	timestamps := make([]byte, 0, len(t.timestamps)*8)
+	for _, ts := range t.timestamps {
+		timestamps = binary.LittleEndian.AppendUint64(timestamps, uint64(ts))
+	}
+	chunk, err := writeParquetPage(p.cw, "timestamp", parquetTypeInt64, numRows, timestamps)
+	if err != nil {
+		return err
+	}
+	chunks := []parquetColumnChunk{chunk}
+
+	for col, name := range p.columns {
+		chunk, err := writeParquetPage(p.cw, name, parquetTypeDouble, numRows, encodeOptionalDoubles(t.values[col]))
+		if err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	p.numRows += numRows
+	p.rowGroups = append(p.rowGroups, parquetRowGroup{numRows: numRows, chunks: chunks})
+	return nil
+}
+
+func (p *parquetWriter) Close() error {
+	if err := p.writeMagic(); err != nil {
+		return err
+	}
+	footer := encodeParquetFooter(p.columns, p.numRows, p.rowGroups)
+	if _, err := p.cw.Write(footer); err != nil {
+		return err
+	}
+	if _, err := p.cw.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer)))); err != nil {
+		return err
+	}
+	_, err := p.cw.Write(parquetMagic)
+	return err
+}
+
+// parquetColumnChunk 已写出的列块信息，用于生成文件元数据
+type parquetColumnChunk struct {
+	name             string
+	typ              int32
+	offset           int64
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+}
+
+// writeParquetPage 写出单个数据页（页头 + 压缩后的页数据）
+func writeParquetPage(cw *countingWriter, name string, typ int32, numValues int64, body []byte) (parquetColumnChunk, error) {
+	compressed := snappy.Encode(nil, body)
+
+	var h thriftWriter
+	h.i32(1, parquetPageData)
+	h.i32(2, int32(len(body)))
+	h.i32(3, int32(len(compressed)))
+	h.beginStruct(5) // DataPageHeader
+	h.i32(1, int32(numValues))
+	h.i32(2, parquetEncodingPlain)
+	h.i32(3, parquetEncodingRLE)
+	h.i32(4, parquetEncodingRLE)
+	h.endStruct()
+	h.stop()
+
+	chunk := parquetColumnChunk{
+		name:             name,
+		typ:              typ,
+		offset:           cw.n,
+		numValues:        numValues,
+		uncompressedSize: int64(len(h.buf) + len(body)),
+		compressedSize:   int64(len(h.buf) + len(compressed)),
+	}
+	if _, err := cw.Write(h.buf); err != nil {
+		return chunk, err
+	}
+	_, err := cw.Write(compressed)
+	return chunk, err
+}
+
+// encodeOptionalDoubles 编码可空 DOUBLE 列的页数据：定义级别（RLE，位宽 1，带 4 字节长度前缀）+ 非空值
+func encodeOptionalDoubles(values []float64) []byte {
+	var levels []byte
+	for i := 0; i < len(values); {
+		present := !math.IsNaN(values[i])
+		j := i + 1
+		for j < len(values) && !math.IsNaN(values[j]) == present {
+			j++
+		}
+		// RLE 游程：头部为 (长度 << 1)，随后是 1 字节的级别值
+		levels = binary.AppendUvarint(levels, uint64(j-i)<<1)
+		if present {
+			levels = append(levels, 1)
+		} else {
+			levels = append(levels, 0)
+		}
+		i = j
+	}
+
+	body := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	body = append(body, levels...)
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			body = binary.LittleEndian.AppendUint64(body, math.Float64bits(v))
+		}
+	}
+	return body
+}
+
+// encodeParquetFooter 编码 FileMetaData
+func encodeParquetFooter(columns []string, numRows int64, rowGroups []parquetRowGroup) []byte {
+	var w thriftWriter
+	w.i32(1, 1) // version
+
+	w.listBegin(2, thriftStruct, len(columns)+2) // schema
+	w.elemBegin()
+	w.binary(4, "schema")
+	w.i32(5, int32(len(columns)+1))
+	w.elemEnd()
+	w.elemBegin()
+	w.i32(1, parquetTypeInt64)
+	w.i32(3, parquetRequired)
+	w.binary(4, "timestamp")
+	w.i32(6, parquetConvertedTimestampMillis)
+	w.elemEnd()
+	for _, name := range columns {
+		w.elemBegin()
+		w.i32(1, parquetTypeDouble)
+		w.i32(3, parquetOptional)
+		w.binary(4, name)
+		w.elemEnd()
+	}
+
+	w.i64(3, numRows)
+
+	w.listBegin(4, thriftStruct, len(rowGroups)) // row_groups
+	for _, rg := range rowGroups {
+		var totalSize int64
+		for _, c := range rg.chunks {
+			totalSize += c.uncompressedSize
+		}
+		w.elemBegin()
+		w.listBegin(1, thriftStruct, len(rg.chunks)) // columns
+		for _, c := range rg.chunks {
+			w.elemBegin()
+			w.i64(2, c.offset) // file_offset
+			w.beginStruct(3)   // ColumnMetaData
+			w.i32(1, c.typ)
+			w.listBegin(2, thriftI32, 2)
+			w.varint(parquetEncodingPlain)
+			w.varint(parquetEncodingRLE)
+			w.listBegin(3, thriftBinary, 1)
+			w.rawBinary(c.name)
+			w.i32(4, parquetCodecSnappy)
+			w.i64(5, c.numValues)
+			w.i64(6, c.uncompressedSize)
+			w.i64(7, c.compressedSize)
+			w.i64(9, c.offset) // data_page_offset
+			w.endStruct()
+			w.elemEnd()
+		}
+		w.i64(2, totalSize)
+		w.i64(3, rg.numRows)
+		w.elemEnd()
+	}
+
+	w.binary(6, "pika")
+	w.stop()
+	return w.buf
+}
+
+// thriftWriter Thrift compact 协议编码器，仅支持生成 Parquet 元数据所需的类型
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // 各层结构体中上一个字段的编号
+}
+
+func (w *thriftWriter) lastID() int16 {
+	if len(w.last) == 0 {
+		w.last = append(w.last, 0)
+	}
+	return w.last[len(w.last)-1]
+}
+
+func (w *thriftWriter) fieldHeader(id int16, typ byte) {
+	delta := id - w.lastID()
+	if delta > 0 && delta <= 15 {
+		w.buf = append(w.buf, byte(delta)<<4|typ)
+	} else {
+		w.buf = append(w.buf, typ)
+		w.varint(int64(id))
+	}
+	w.last[len(w.last)-1] = id
+}
+
+// varint 写出 zigzag 编码的整数
+func (w *thriftWriter) varint(v int64) {
+	w.buf = binary.AppendUvarint(w.buf, uint64((v<<1)^(v>>63)))
+}
+
+func (w *thriftWriter) rawBinary(s string) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *thriftWriter) i32(id int16, v int32) {
+	w.fieldHeader(id, thriftI32)
+	w.varint(int64(v))
+}
+
+func (w *thriftWriter) i64(id int16, v int64) {
+	w.fieldHeader(id, thriftI64)
+	w.varint(v)
+}
+
+func (w *thriftWriter) binary(id int16, s string) {
+	w.fieldHeader(id, thriftBinary)
+	w.rawBinary(s)
+}
+
+func (w *thriftWriter) listBegin(id int16, elemType byte, size int) {
+	w.fieldHeader(id, thriftList)
+	if size < 15 {
+		w.buf = append(w.buf, byte(size)<<4|elemType)
+	} else {
+		w.buf = append(w.buf, 0xf0|elemType)
+		w.buf = binary.AppendUvarint(w.buf, uint64(size))
+	}
+}
+
+func (w *thriftWriter) beginStruct(id int16) {
+	w.fieldHeader(id, thriftStruct)
+	w.elemBegin()
+}
+
+func (w *thriftWriter) endStruct() {
+	w.elemEnd()
+}
+
+// elemBegin 开始一个列表中的结构体元素
+func (w *thriftWriter) elemBegin() {
+	w.lastID()
+	w.last = append(w.last, 0)
+}
+
+// elemEnd 结束结构体（写出 STOP）
+func (w *thriftWriter) elemEnd() {
+	w.stop()
+	w.last = w.last[:len(w.last)-1]
+}
+
+func (w *thriftWriter) stop() {
+	w.buf = append(w.buf, 0)
+}
+
+// countingWriter 记录已写出的字节数，用于计算列块偏移
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,128 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+)
+
+// WriteCSV 以宽表写出 CSV：timestamp（毫秒）、time（RFC3339）以及每个序列一列，缺失值留空
+func WriteCSV(w io.Writer, series []metric.Series) error {
+	return Write(w, FormatCSV, series)
+}
+
+// csvWriter 流式 CSV 写出器，表头在第一批数据（或 Close）时写出
+type csvWriter struct {
+	cw      *csv.Writer
+	columns []string
+	header  bool
+}
+
+func newCSVWriter(w io.Writer, columns []string) *csvWriter {
+	return &csvWriter{cw: csv.NewWriter(w), columns: columns}
+}
+
+func (c *csvWriter) writeHeader() error {
+	if c.header {
+		return nil
+	}
+	c.header = true
+	return c.cw.Write(append([]string{"timestamp", "time"}, c.columns...))
+}
+
+func (c *csvWriter) WriteBatch(series []metric.Series) error {
+	t, err := newTable(c.columns, series)
+	if err != nil {
+		return err
+	}
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+
+	record := make([]string, len(c.columns)+2)
+	for i, ts := range t.timestamps {
+		record[0] = strconv.FormatInt(ts, 10)
+		record[1] = time.UnixMilli(ts).UTC().Format(time.RFC3339)
+		for col := range c.columns {
+			v := t.values[col][i]
+			if math.IsNaN(v) {
+				record[col+2] = ""
+			} else {
+				record[col+2] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+		if err := c.cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.writeHeader(); err != nil {
+		return err
+	}
+	c.cw.Flush()
+	return c.cw.Error()
+}
+
+// ndjsonRecord NDJSON 的单行记录，每个数据点一行
+type ndjsonRecord struct {
+	Time      string            `json:"time"`
+	Timestamp int64             `json:"timestamp"`
+	Series    string            `json:"series"`
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Value     float64           `json:"value"`
+}
+
+// WriteNDJSON 逐个数据点写出 NDJSON，无需在内存中对齐时间戳
+func WriteNDJSON(w io.Writer, series []metric.Series) error {
+	return Write(w, FormatNDJSON, series)
+}
+
+// ndjsonWriter 流式 NDJSON 写出器
+type ndjsonWriter struct {
+	bw      *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newNDJSONWriter(w io.Writer) *ndjsonWriter {
+	bw := bufio.NewWriter(w)
+	return &ndjsonWriter{bw: bw, encoder: json.NewEncoder(bw)}
+}
+
+func (n *ndjsonWriter) WriteBatch(series []metric.Series) error {
+	for _, s := range series {
+		column := ColumnName(s)
+		for _, p := range s.Data {
+			// NaN/Inf 无法编码为 JSON
+			if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+				continue
+			}
+			if err := n.encoder.Encode(ndjsonRecord{
+				Time:      time.UnixMilli(p.Timestamp).UTC().Format(time.RFC3339),
+				Timestamp: p.Timestamp,
+				Series:    column,
+				Name:      s.Name,
+				Labels:    s.Labels,
+				Value:     p.Value,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (n *ndjsonWriter) Close() error {
+	return n.bw.Flush()
+}
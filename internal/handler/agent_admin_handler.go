@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -59,6 +60,16 @@ func (h *AgentHandler) GetAdminLatestMetrics(c echo.Context) error {
 	return orz.Ok(c, metrics)
 }
 
+// GetDiskForecast 获取探针各挂载点的磁盘容量预测
+// GET /api/admin/agents/:id/disk-forecast
+func (h *AgentHandler) GetDiskForecast(c echo.Context) error {
+	id := c.Param("id")
+	if _, err := h.agentService.GetAgent(c.Request().Context(), id); err != nil {
+		return err
+	}
+	return orz.Ok(c, h.diskForecast.GetAgentForecasts(id))
+}
+
 // SendCommand 向探针发送指令
 func (h *AgentHandler) SendCommand(c echo.Context) error {
 	agentID := c.Param("id")
@@ -117,6 +128,19 @@ func (h *AgentHandler) GetAuditResult(c echo.Context) error {
 	return orz.Ok(c, result)
 }
 
+// GetDiagnostics 获取最新的诊断信息，通过 diagnostics 指令收集
+func (h *AgentHandler) GetDiagnostics(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	bundle, err := h.agentService.GetDiagnostics(ctx, agentID)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, bundle)
+}
+
 // ListAuditResults 获取审计结果列表
 func (h *AgentHandler) ListAuditResults(c echo.Context) error {
 	agentID := c.Param("id")
@@ -167,6 +191,10 @@ func (h *AgentHandler) UpdateInfo(c echo.Context) error {
 		return err
 	}
 
+	// 标签变化可能改变探针匹配的配置模板与日志监控规则
+	go h.configProfile.SyncAgents(context.Background(), []string{agentID})
+	go h.logWatch.SyncAgents(context.Background(), []string{agentID})
+
 	return orz.Ok(c, orz.Map{})
 }
 
@@ -264,6 +292,10 @@ func (h *AgentHandler) BatchUpdateTags(c echo.Context) error {
 		return err
 	}
 
+	// 标签变化可能改变探针匹配的配置模板与日志监控规则
+	go h.configProfile.SyncAgents(context.Background(), req.AgentIDs)
+	go h.logWatch.SyncAgents(context.Background(), req.AgentIDs)
+
 	return orz.Ok(c, orz.Map{
 		"message": "批量更新标签成功",
 		"count":   len(req.AgentIDs),
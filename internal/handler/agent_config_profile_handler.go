@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+)
+
+type AgentConfigProfileHandler struct {
+	logger         *zap.Logger
+	profileService *service.AgentConfigProfileService
+}
+
+func NewAgentConfigProfileHandler(logger *zap.Logger, profileService *service.AgentConfigProfileService) *AgentConfigProfileHandler {
+	return &AgentConfigProfileHandler{
+		logger:         logger,
+		profileService: profileService,
+	}
+}
+
+// AgentConfigProfileRequest 创建/更新配置模板请求
+type AgentConfigProfileRequest struct {
+	Name        string                     `json:"name" validate:"required"`
+	Description string                     `json:"description"`
+	Priority    int                        `json:"priority"`
+	AgentIDs    []string                   `json:"agentIds"`
+	Tags        []string                   `json:"tags"`
+	Config      protocol.RemoteAgentConfig `json:"config"`
+}
+
+// Paging 配置模板分页查询
+func (h *AgentConfigProfileHandler) Paging(c echo.Context) error {
+	name := c.QueryParam("name")
+
+	pr := orz.GetPageRequest(c, "priority", "created_at", "name")
+
+	builder := orz.NewPageBuilder(h.profileService.ProfileRepo).
+		PageRequest(pr).
+		Contains("name", name)
+
+	ctx := c.Request().Context()
+	page, err := builder.Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"items": page.Items,
+		"total": page.Total,
+	})
+}
+
+// Create 创建配置模板
+func (h *AgentConfigProfileHandler) Create(c echo.Context) error {
+	var req AgentConfigProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	profile := &models.AgentConfigProfile{
+		Name:        req.Name,
+		Description: req.Description,
+		Priority:    req.Priority,
+		AgentIDs:    req.AgentIDs,
+		Tags:        req.Tags,
+		Config:      datatypes.NewJSONType(req.Config),
+	}
+
+	ctx := c.Request().Context()
+	if err := h.profileService.Create(ctx, profile); err != nil {
+		h.logger.Error("failed to create agent config profile", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, profile)
+}
+
+// Get 获取配置模板详情
+func (h *AgentConfigProfileHandler) Get(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	profile, err := h.profileService.ProfileRepo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, profile)
+}
+
+// Update 更新配置模板
+func (h *AgentConfigProfileHandler) Update(c echo.Context) error {
+	id := c.Param("id")
+
+	var req AgentConfigProfileRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	existing, err := h.profileService.ProfileRepo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	existing.Name = req.Name
+	existing.Description = req.Description
+	existing.Priority = req.Priority
+	existing.AgentIDs = req.AgentIDs
+	existing.Tags = req.Tags
+	existing.Config = datatypes.NewJSONType(req.Config)
+
+	if err := h.profileService.Update(ctx, &existing); err != nil {
+		h.logger.Error("failed to update agent config profile", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// Delete 删除配置模板
+func (h *AgentConfigProfileHandler) Delete(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	if err := h.profileService.Delete(ctx, id); err != nil {
+		h.logger.Error("failed to delete agent config profile", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// GetEffective 获取探针当前生效的配置模板及应用状态
+func (h *AgentConfigProfileHandler) GetEffective(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	effective, err := h.profileService.GetEffective(ctx, agentID)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, effective)
+}
@@ -18,6 +18,10 @@ type AgentHandler struct {
 	sshLoginService *service.SSHLoginService
 	apiKeyService   *service.ApiKeyService
 	propertyService *service.PropertyService
+	diskForecast    *service.DiskForecastService
+	configProfile   *service.AgentConfigProfileService
+	logWatch        *service.LogWatchService
+	logForward      *service.LogForwardService
 	wsManager       *ws.Manager
 	upgrader        websocket.Upgrader
 }
@@ -25,7 +29,9 @@ type AgentHandler struct {
 func NewAgentHandler(logger *zap.Logger, agentService *service.AgentService, trafficService *service.TrafficService,
 	metricService *service.MetricService, monitorService *service.MonitorService, tamperService *service.TamperService,
 	ddnsService *service.DDNSService, sshLoginService *service.SSHLoginService, apiKeyService *service.ApiKeyService,
-	propertyService *service.PropertyService, wsManager *ws.Manager) *AgentHandler {
+	propertyService *service.PropertyService, diskForecastService *service.DiskForecastService,
+	configProfileService *service.AgentConfigProfileService, logWatchService *service.LogWatchService,
+	logForwardService *service.LogForwardService, wsManager *ws.Manager) *AgentHandler {
 
 	h := &AgentHandler{
 		logger:          logger,
@@ -38,6 +44,10 @@ func NewAgentHandler(logger *zap.Logger, agentService *service.AgentService, tra
 		sshLoginService: sshLoginService,
 		apiKeyService:   apiKeyService,
 		propertyService: propertyService,
+		diskForecast:    diskForecastService,
+		configProfile:   configProfileService,
+		logWatch:        logWatchService,
+		logForward:      logForwardService,
 		wsManager:       wsManager,
 	}
 
@@ -45,6 +55,8 @@ func NewAgentHandler(logger *zap.Logger, agentService *service.AgentService, tra
 	h.upgrader = websocket.Upgrader{
 		ReadBufferSize:  1024 * 32,
 		WriteBufferSize: 1024 * 32,
+		// 探针请求时启用 permessage-deflate 压缩，未请求的旧版本探针不受影响
+		EnableCompression: true,
 	}
 
 	// 设置WebSocket消息处理器
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"time"
 
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/utils"
 	"github.com/go-orz/orz"
 	"github.com/labstack/echo/v4"
@@ -12,7 +16,15 @@ import (
 
 var validMetricTypes = map[string]struct{}{
 	"cpu": {}, "memory": {}, "disk": {}, "network": {}, "network_connection": {},
-	"disk_io": {}, "gpu": {}, "temperature": {}, "monitor": {},
+	"disk_io": {}, "gpu": {}, "temperature": {}, "monitor": {}, "custom": {},
+	"process": {}, "process_watch": {}, "systemd": {}, "container": {},
+	"cpu_times": {}, "pressure": {}, "kernel": {}, "agent_buffer": {}, "agent_health": {},
+	"smart": {}, "raid": {}, "ecc": {},
+}
+
+// privateMetricTypes 仅登录用户可见的指标类型，进程、服务与容器名称可能暴露部署细节
+var privateMetricTypes = map[string]struct{}{
+	"process": {}, "process_watch": {}, "systemd": {}, "container": {},
 }
 
 var timeRangeMilliseconds = map[string]int64{
@@ -118,6 +130,26 @@ func (h *AgentHandler) GetMetrics(c echo.Context) error {
 		return orz.NewError(400, err.Error())
 	}
 
+	if _, ok := privateMetricTypes[metricType]; ok && !utils.IsAuthenticated(c) {
+		return orz.NewError(401, "未登录")
+	}
+
+	// 自定义指标可能包含业务数据，仅登录用户可见，且需要指定指标名称
+	if metricType == "custom" {
+		if !utils.IsAuthenticated(c) {
+			return orz.NewError(401, "未登录")
+		}
+		name := c.QueryParam("metric")
+		if name == "" {
+			return orz.NewError(400, "自定义指标名称不能为空")
+		}
+		metrics, err := h.metricService.GetCustomMetrics(ctx, agentID, name, start, end, aggregation)
+		if err != nil {
+			return orz.NewError(400, "无效的自定义指标名称")
+		}
+		return orz.Ok(c, metrics)
+	}
+
 	// GetMetrics 内部会自动计算最优聚合间隔
 	metrics, err := h.metricService.GetMetrics(ctx, agentID, metricType, start, end, interfaceName, aggregation)
 	if err != nil {
@@ -146,6 +178,19 @@ func (h *AgentHandler) GetLatestMetrics(c echo.Context) error {
 	if !isAuthenticated {
 		sanitized := *metrics
 		sanitized.NetworkInterfaces = nil
+		sanitized.Custom = nil
+		sanitized.Processes = nil
+		sanitized.Services = nil
+		if metrics.Hardware != nil {
+			// 磁盘序列号不对外展示
+			hardware := *metrics.Hardware
+			hardware.Disks = make([]protocol.SmartDiskData, len(metrics.Hardware.Disks))
+			for i, disk := range metrics.Hardware.Disks {
+				disk.Serial = ""
+				hardware.Disks[i] = disk
+			}
+			sanitized.Hardware = &hardware
+		}
 		return orz.Ok(c, &sanitized)
 	}
 
@@ -171,3 +216,70 @@ func (h *AgentHandler) GetAvailableNetworkInterfaces(c echo.Context) error {
 		"interfaces": interfaces,
 	})
 }
+
+// GetAvailableCustomMetrics 获取探针已上报的自定义指标名称
+func (h *AgentHandler) GetAvailableCustomMetrics(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	if _, err := h.agentService.GetAgentByAuth(ctx, id, true); err != nil {
+		return err
+	}
+
+	names, err := h.metricService.GetAvailableCustomMetrics(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"metrics": names,
+	})
+}
+
+// defaultAgentCollectInterval 探针未使用模板设置采集间隔时的默认采集间隔（与 agent.yaml 的默认值一致）
+const defaultAgentCollectInterval = 5 * time.Second
+
+// collectInterval 返回探针的数据采集间隔，作为导出的默认步长：生效模板设置了间隔时使用模板值，否则为探针默认的采集间隔
+func (h *AgentHandler) collectInterval(ctx context.Context, agent *models.Agent) (time.Duration, error) {
+	profile, err := h.configProfile.Resolve(ctx, agent)
+	if err != nil {
+		return 0, err
+	}
+	if profile != nil {
+		if interval := profile.Config.Data().Interval; interval > 0 {
+			return time.Duration(interval) * time.Second, nil
+		}
+	}
+	return defaultAgentCollectInterval, nil
+}
+
+// ExportMetrics 导出探针指标数据
+// GET /api/admin/agents/:id/metrics/export?types=cpu,network&format=csv|ndjson|parquet&start=&end=&step=
+func (h *AgentHandler) ExportMetrics(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	agent, err := h.agentService.GetAgentByAuth(ctx, agentID, true)
+	if err != nil {
+		return err
+	}
+	rawStep, err := h.collectInterval(ctx, agent)
+	if err != nil {
+		return err
+	}
+
+	metricTypes, err := parseExportMetricTypes(c.QueryParam("types"))
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+	start, end, step, format, err := parseExportRequest(c, rawStep)
+	if err != nil {
+		return err
+	}
+	interfaceName := normalizeInterfaceName(c.QueryParam("interface"))
+	aggregation := normalizeAggregation(c.QueryParam("aggregation"))
+
+	return writeExport(c, "agent-"+agentID, start, end, format, func(yield func([]metric.Series) error) error {
+		return h.metricService.ExportAgentMetrics(ctx, agentID, metricTypes, start, end, step, interfaceName, aggregation, yield)
+	})
+}
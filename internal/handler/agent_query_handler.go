@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/utils"
 	"github.com/go-orz/orz"
 	"github.com/labstack/echo/v4"
@@ -26,6 +27,10 @@ func (h *AgentHandler) Get(c echo.Context) error {
 	// 隐藏敏感配置
 	agent.SSHLoginConfig = datatypes.JSONType[models.SSHLoginConfigData]{}
 	agent.TamperProtectConfig = datatypes.JSONType[models.TamperProtectConfigData]{}
+	agent.ConfigProfileStatus = datatypes.JSONType[models.ConfigProfileStatusData]{}
+	agent.Health = datatypes.JSONType[protocol.AgentHealth]{}
+	agent.LogWatchStatus = datatypes.JSONType[models.LogWatchStatusData]{}
+	agent.LogForwardConfig = datatypes.JSONType[models.LogForwardConfigData]{}
 
 	// 未登录时隐藏敏感信息
 	if !isAuthenticated {
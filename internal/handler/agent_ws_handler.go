@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/dushixiang/pika/internal/models"
 	"github.com/dushixiang/pika/internal/protocol"
 	ws "github.com/dushixiang/pika/internal/websocket"
 	"github.com/gorilla/websocket"
@@ -62,6 +63,21 @@ func (h *AgentHandler) HandleWebSocket(c echo.Context) error {
 		h.logger.Error("failed to send public ip config", zap.Error(err))
 		// 配置下发失败不中断连接，只记录日志
 	}
+	// 下发配置模板
+	if err := h.sendAgentConfig(conn, agent); err != nil {
+		h.logger.Error("failed to send agent config", zap.Error(err))
+		// 配置下发失败不中断连接，只记录日志
+	}
+	// 下发日志监控规则
+	if err := h.sendLogWatchConfig(conn, agent); err != nil {
+		h.logger.Error("failed to send log watch config", zap.Error(err))
+		// 配置下发失败不中断连接，只记录日志
+	}
+	// 下发系统日志转发配置
+	if err := h.sendLogForwardConfig(conn, agent); err != nil {
+		h.logger.Error("failed to send log forward config", zap.Error(err))
+		// 配置下发失败不中断连接，只记录日志
+	}
 
 	// 创建客户端并注册到管理器
 	client := h.newClient(agent.ID, conn)
@@ -83,6 +99,9 @@ func (h *AgentHandler) handleWebSocketMessage(ctx context.Context, agentID strin
 	case protocol.MessageTypeMetrics:
 		return h.handleMetricsMessage(ctx, agentID, data)
 
+	case protocol.MessageTypeMetricsBatch:
+		return h.handleMetricsBatchMessage(ctx, agentID, data)
+
 	case protocol.MessageTypeCommandResp:
 		return h.handleCommandResponseMessage(ctx, agentID, data)
 
@@ -104,6 +123,21 @@ func (h *AgentHandler) handleWebSocketMessage(ctx context.Context, agentID strin
 	case protocol.MessageTypeTamperProtect:
 		return h.handleTamperProtectMessage(ctx, agentID, data)
 
+	case protocol.MessageTypeAgentConfigResult:
+		return h.handleAgentConfigResultMessage(ctx, agentID, data)
+
+	case protocol.MessageTypeLogWatchConfigResult:
+		return h.handleLogWatchConfigResultMessage(ctx, agentID, data)
+
+	case protocol.MessageTypeLogWatchEvent:
+		return h.handleLogWatchEventMessage(ctx, agentID, data)
+
+	case protocol.MessageTypeLogForwardConfigResult:
+		return h.handleLogForwardConfigResultMessage(ctx, agentID, data)
+
+	case protocol.MessageTypeLogEntries:
+		return h.handleLogEntriesMessage(ctx, agentID, data)
+
 	default:
 		h.logger.Warn("unknown message type", zap.String("type", messageType))
 		return nil
@@ -159,15 +193,43 @@ func (h *AgentHandler) handleHeartbeatMessage(ctx context.Context, agentID strin
 }
 
 func (h *AgentHandler) handleMetricsMessage(ctx context.Context, agentID string, data json.RawMessage) error {
-	var metricsWrapper protocol.MetricsPayload
+	var metricsWrapper protocol.InputMetricsPayload
 	if err := json.Unmarshal(data, &metricsWrapper); err != nil {
 		return err
 	}
-	metricsData, err := json.Marshal(metricsWrapper.Data)
-	if err != nil {
+	return h.metricService.HandleMetricData(ctx, agentID, string(metricsWrapper.Type), metricsWrapper.Data, metricsWrapper.Timestamp)
+}
+
+func (h *AgentHandler) handleMetricsBatchMessage(ctx context.Context, agentID string, data json.RawMessage) error {
+	var batch protocol.InputMetricsBatch
+	if err := json.Unmarshal(data, &batch); err != nil {
 		return err
 	}
-	return h.metricService.HandleMetricData(ctx, agentID, string(metricsWrapper.Type), metricsData, metricsWrapper.Timestamp)
+	err := h.metricService.HandleMetricBatch(ctx, agentID, batch.Items)
+	if batch.ID != 0 {
+		// 探针收到确认后才删除本地缓存，写入失败时保留缓存等待重试
+		ack := protocol.MetricsAck{ID: batch.ID, Success: err == nil}
+		if err != nil {
+			ack.Message = err.Error()
+		}
+		h.sendMetricsAck(agentID, ack)
+	}
+	return err
+}
+
+// sendMetricsAck 回复批量指标写入确认
+func (h *AgentHandler) sendMetricsAck(agentID string, ack protocol.MetricsAck) {
+	msgData, err := json.Marshal(protocol.OutboundMessage{
+		Type: protocol.MessageTypeMetricsAck,
+		Data: ack,
+	})
+	if err != nil {
+		h.logger.Error("failed to marshal metrics ack", zap.Error(err))
+		return
+	}
+	if err := h.wsManager.SendToClient(agentID, msgData); err != nil {
+		h.logger.Warn("failed to send metrics ack", zap.String("agentID", agentID), zap.Error(err))
+	}
 }
 
 func (h *AgentHandler) handleCommandResponseMessage(ctx context.Context, agentID string, data json.RawMessage) error {
@@ -232,11 +294,57 @@ func (h *AgentHandler) handleTamperProtectMessage(ctx context.Context, agentID s
 	return h.tamperService.HandleConfigResult(ctx, agentID, protectResp)
 }
 
+func (h *AgentHandler) handleAgentConfigResultMessage(ctx context.Context, agentID string, data json.RawMessage) error {
+	var resultData protocol.AgentConfigResult
+	if err := json.Unmarshal(data, &resultData); err != nil {
+		h.logger.Error("failed to unmarshal agent config result", zap.Error(err))
+		return err
+	}
+	return h.configProfile.HandleConfigResult(ctx, agentID, resultData)
+}
+
+func (h *AgentHandler) handleLogWatchConfigResultMessage(ctx context.Context, agentID string, data json.RawMessage) error {
+	var resultData protocol.LogWatchConfigResult
+	if err := json.Unmarshal(data, &resultData); err != nil {
+		h.logger.Error("failed to unmarshal log watch config result", zap.Error(err))
+		return err
+	}
+	return h.logWatch.HandleConfigResult(ctx, agentID, resultData)
+}
+
+func (h *AgentHandler) handleLogWatchEventMessage(ctx context.Context, agentID string, data json.RawMessage) error {
+	var eventData protocol.LogWatchEvent
+	if err := json.Unmarshal(data, &eventData); err != nil {
+		h.logger.Error("failed to unmarshal log watch event", zap.Error(err))
+		return err
+	}
+	return h.logWatch.HandleEvent(ctx, agentID, eventData)
+}
+
+func (h *AgentHandler) handleLogForwardConfigResultMessage(ctx context.Context, agentID string, data json.RawMessage) error {
+	var resultData protocol.LogForwardConfigResult
+	if err := json.Unmarshal(data, &resultData); err != nil {
+		h.logger.Error("failed to unmarshal log forward config result", zap.Error(err))
+		return err
+	}
+	return h.logForward.HandleConfigResult(ctx, agentID, resultData)
+}
+
+func (h *AgentHandler) handleLogEntriesMessage(ctx context.Context, agentID string, data json.RawMessage) error {
+	var batch protocol.LogEntryBatch
+	if err := json.Unmarshal(data, &batch); err != nil {
+		h.logger.Error("failed to unmarshal log entries", zap.Error(err))
+		return err
+	}
+	return h.logForward.HandleEntries(ctx, agentID, batch)
+}
+
 // sendRegisterSuccess 发送注册成功响应
 func (h *AgentHandler) sendRegisterSuccess(conn *websocket.Conn, agentID string) error {
 	resp := protocol.RegisterResponse{
-		AgentID: agentID,
-		Status:  "success",
+		AgentID:      agentID,
+		Status:       "success",
+		Capabilities: []string{protocol.CapabilityMetricsBatch, protocol.CapabilityMetricsAck},
 	}
 	return conn.WriteJSON(protocol.OutboundMessage{
 		Type: protocol.MessageTypeRegisterAck,
@@ -299,6 +407,51 @@ func (h *AgentHandler) sendSSHLoginConfig(conn *websocket.Conn, agentID string)
 	return conn.WriteMessage(websocket.TextMessage, msgData)
 }
 
+// sendAgentConfig 下发探针生效的配置模板，未匹配模板时下发空模板使探针恢复本地配置
+func (h *AgentHandler) sendAgentConfig(conn *websocket.Conn, agent *models.Agent) error {
+	data, err := h.configProfile.BuildConfigForAgent(context.Background(), agent)
+	if err != nil {
+		return err
+	}
+	msgData, err := json.Marshal(protocol.OutboundMessage{
+		Type: protocol.MessageTypeAgentConfig,
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, msgData)
+}
+
+// sendLogWatchConfig 下发探针应用的日志监控规则，没有规则时下发空规则使探针停止监控
+func (h *AgentHandler) sendLogWatchConfig(conn *websocket.Conn, agent *models.Agent) error {
+	config, err := h.logWatch.BuildConfigForAgent(context.Background(), agent)
+	if err != nil {
+		return err
+	}
+	msgData, err := json.Marshal(protocol.OutboundMessage{
+		Type: protocol.MessageTypeLogWatchConfig,
+		Data: config,
+	})
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, msgData)
+}
+
+// sendLogForwardConfig 下发系统日志转发配置
+func (h *AgentHandler) sendLogForwardConfig(conn *websocket.Conn, agent *models.Agent) error {
+	config := agent.LogForwardConfig.Data()
+	msgData, err := json.Marshal(protocol.OutboundMessage{
+		Type: protocol.MessageTypeLogForwardConfig,
+		Data: h.logForward.BuildConfig(&config),
+	})
+	if err != nil {
+		return err
+	}
+	return conn.WriteMessage(websocket.TextMessage, msgData)
+}
+
 func (h *AgentHandler) sendPublicIPConfig(conn *websocket.Conn, agentID string) error {
 	config, err := h.propertyService.GetPublicIPConfig(context.Background())
 	if err != nil {
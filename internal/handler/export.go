@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/export"
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+)
+
+// maxExportPoints 单个序列最多导出的数据点数，导出按窗口分批写出，该限制只用于约束单次导出的耗时
+const maxExportPoints = 1000000
+
+// defaultExportMetricTypes 未指定 types 时导出的指标类型
+var defaultExportMetricTypes = []string{"cpu", "memory", "disk", "network"}
+
+// parseExportStep 解析导出步长（秒），未指定时使用原始采集间隔，导出未经降采样的数据
+func parseExportStep(stepParam string, start, end int64, rawStep time.Duration) (time.Duration, error) {
+	step := rawStep
+	if stepParam != "" {
+		seconds, err := strconv.Atoi(stepParam)
+		if err != nil || seconds <= 0 {
+			return 0, fmt.Errorf("无效的 step，必须为正整数（秒）")
+		}
+		step = time.Duration(seconds) * time.Second
+	}
+	if (end-start)/step.Milliseconds() > maxExportPoints {
+		return 0, fmt.Errorf("导出数据点过多（单个序列最多 %d 个），请缩小时间范围或增大 step", maxExportPoints)
+	}
+	return step, nil
+}
+
+// parseExportMetricTypes 解析逗号分隔的指标类型列表，支持导出专用的 traffic 类型
+func parseExportMetricTypes(typesParam string) ([]string, error) {
+	if strings.TrimSpace(typesParam) == "" {
+		return defaultExportMetricTypes, nil
+	}
+	var metricTypes []string
+	seen := make(map[string]struct{})
+	for _, metricType := range strings.Split(typesParam, ",") {
+		metricType = strings.TrimSpace(metricType)
+		if metricType == "" {
+			continue
+		}
+		if _, ok := seen[metricType]; ok {
+			continue
+		}
+		// 自定义指标需要单独指定名称，不支持批量导出
+		if metricType != service.MetricTypeTraffic {
+			if _, ok := validMetricTypes[metricType]; !ok || metricType == "custom" {
+				return nil, fmt.Errorf("无效的指标类型: %s", metricType)
+			}
+		}
+		seen[metricType] = struct{}{}
+		metricTypes = append(metricTypes, metricType)
+	}
+	if len(metricTypes) == 0 {
+		return defaultExportMetricTypes, nil
+	}
+	return metricTypes, nil
+}
+
+// writeExport 以附件形式流式写出导出文件，写出第一个字节前出错时仍返回普通的错误响应
+func writeExport(c echo.Context, name string, start, end int64, format export.Format, source export.Source) error {
+	filename := fmt.Sprintf("%s-%s-%s.%s", name,
+		time.UnixMilli(start).Format("20060102150405"),
+		time.UnixMilli(end).Format("20060102150405"),
+		format.Extension())
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, format.ContentType())
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%s", filename))
+	err := export.Export(&exportResponseWriter{c.Response()}, format, source)
+	if err != nil && !c.Response().Committed {
+		header.Del(echo.HeaderContentDisposition)
+	}
+	return err
+}
+
+// exportResponseWriter 每批数据写出后刷新响应，响应不支持刷新时忽略
+type exportResponseWriter struct {
+	resp *echo.Response
+}
+
+func (w *exportResponseWriter) Write(p []byte) (int, error) {
+	return w.resp.Write(p)
+}
+
+func (w *exportResponseWriter) Flush() {
+	_ = http.NewResponseController(w.resp.Writer).Flush()
+}
+
+// parseExportRequest 解析导出的公共参数：时间范围、步长和格式，rawStep 为原始数据的采集间隔
+func parseExportRequest(c echo.Context, rawStep time.Duration) (start, end int64, step time.Duration, format export.Format, err error) {
+	start, end, err = parseTimeRangeOrStartEnd(c.QueryParam("range"), c.QueryParam("start"), c.QueryParam("end"))
+	if err != nil {
+		return 0, 0, 0, "", orz.NewError(400, err.Error())
+	}
+	step, err = parseExportStep(c.QueryParam("step"), start, end, rawStep)
+	if err != nil {
+		return 0, 0, 0, "", orz.NewError(400, err.Error())
+	}
+	format, err = export.ParseFormat(c.QueryParam("format"))
+	if err != nil {
+		return 0, 0, 0, "", orz.NewError(400, err.Error())
+	}
+	return start, end, step, format, nil
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/dushixiang/pika/internal/utils"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type FleetHandler struct {
+	logger       *zap.Logger
+	agentService *service.AgentService
+	fleetService *service.FleetService
+	diskForecast *service.DiskForecastService
+}
+
+func NewFleetHandler(logger *zap.Logger, agentService *service.AgentService, fleetService *service.FleetService, diskForecastService *service.DiskForecastService) *FleetHandler {
+	return &FleetHandler{
+		logger:       logger,
+		agentService: agentService,
+		fleetService: fleetService,
+		diskForecast: diskForecastService,
+	}
+}
+
+// GetOverview 获取全部探针及各标签分组的汇总
+// GET /api/admin/fleet/overview?range=1h
+func (h *FleetHandler) GetOverview(c echo.Context) error {
+	start, end, err := parseTimeRange(c.QueryParam("range"))
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	agents, err := h.agentService.ListByAuth(ctx, utils.IsAuthenticated(c))
+	if err != nil {
+		return err
+	}
+
+	overview, err := h.fleetService.GetOverview(ctx, agents, start, end)
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, overview)
+}
+
+// GetGroup 获取单个标签分组的汇总及组内探针统计
+// GET /api/admin/fleet/groups?tag=prod&range=1h
+func (h *FleetHandler) GetGroup(c echo.Context) error {
+	start, end, err := parseTimeRange(c.QueryParam("range"))
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+
+	ctx := c.Request().Context()
+	agents, err := h.agentService.ListByAuth(ctx, utils.IsAuthenticated(c))
+	if err != nil {
+		return err
+	}
+
+	detail, err := h.fleetService.GetGroup(ctx, agents, c.QueryParam("tag"), start, end)
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, detail)
+}
+
+// GetAtRiskDisks 获取预计在指定天数内写满的磁盘
+// GET /api/admin/fleet/at-risk-disks?days=30
+func (h *FleetHandler) GetAtRiskDisks(c echo.Context) error {
+	var days float64
+	if value := c.QueryParam("days"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed <= 0 {
+			return orz.NewError(400, "无效的天数")
+		}
+		days = parsed
+	}
+
+	ctx := c.Request().Context()
+	agents, err := h.agentService.ListByAuth(ctx, utils.IsAuthenticated(c))
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, h.diskForecast.ListAtRisk(agents, days))
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// LogForwardHandler 系统日志转发处理器
+type LogForwardHandler struct {
+	logger            *zap.Logger
+	logForwardService *service.LogForwardService
+}
+
+func NewLogForwardHandler(logger *zap.Logger, logForwardService *service.LogForwardService) *LogForwardHandler {
+	return &LogForwardHandler{
+		logger:            logger,
+		logForwardService: logForwardService,
+	}
+}
+
+// GetConfig 获取探针的系统日志转发配置
+// GET /api/admin/agents/:id/log-forward/config
+func (h *LogForwardHandler) GetConfig(c echo.Context) error {
+	config, err := h.logForwardService.GetConfig(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, config)
+}
+
+// UpdateConfig 更新探针的系统日志转发配置
+// POST /api/admin/agents/:id/log-forward/config
+func (h *LogForwardHandler) UpdateConfig(c echo.Context) error {
+	var req models.LogForwardConfigData
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+
+	if err := h.logForwardService.UpdateConfig(c.Request().Context(), c.Param("id"), &req); err != nil {
+		return err
+	}
+	return orz.Ok(c, orz.Map{})
+}
+
+// Search 检索系统日志，默认返回最近 15 分钟
+// GET /api/admin/logs/search?range=15m|start=&end=&agentIds=a,b&unit=nginx&priority=3&q=timeout&limit=200
+func (h *LogForwardHandler) Search(c echo.Context) error {
+	timeRange := c.QueryParam("range")
+	startParam := c.QueryParam("start")
+	endParam := c.QueryParam("end")
+	if timeRange == "" && startParam == "" && endParam == "" {
+		timeRange = "15m"
+	}
+	start, end, err := parseTimeRangeOrStartEnd(timeRange, startParam, endParam)
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+
+	req := service.LogSearchRequest{
+		Start:   start,
+		End:     end,
+		Unit:    c.QueryParam("unit"),
+		Keyword: c.QueryParam("q"),
+	}
+	for _, id := range strings.Split(c.QueryParam("agentIds"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			req.AgentIDs = append(req.AgentIDs, id)
+		}
+	}
+	if priorityParam := c.QueryParam("priority"); priorityParam != "" {
+		priority, err := strconv.Atoi(priorityParam)
+		if err != nil {
+			return orz.NewError(400, "无效的日志级别")
+		}
+		req.Priority = &priority
+	}
+	if limitParam := c.QueryParam("limit"); limitParam != "" {
+		if req.Limit, err = strconv.Atoi(limitParam); err != nil {
+			return orz.NewError(400, "无效的 limit")
+		}
+	}
+
+	result, err := h.logForwardService.Search(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, result)
+}
+
+// DeleteEntries 删除探针转发的所有系统日志
+// DELETE /api/admin/agents/:id/logs
+func (h *LogForwardHandler) DeleteEntries(c echo.Context) error {
+	if err := h.logForwardService.DeleteEntriesByAgentID(c.Request().Context(), c.Param("id")); err != nil {
+		h.logger.Error("删除系统日志失败", zap.Error(err))
+		return err
+	}
+	return orz.Ok(c, orz.Map{})
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type LogWatchHandler struct {
+	logger          *zap.Logger
+	logWatchService *service.LogWatchService
+}
+
+func NewLogWatchHandler(logger *zap.Logger, logWatchService *service.LogWatchService) *LogWatchHandler {
+	return &LogWatchHandler{
+		logger:          logger,
+		logWatchService: logWatchService,
+	}
+}
+
+// LogWatchRuleRequest 创建/更新日志监控规则请求
+type LogWatchRuleRequest struct {
+	Name        string   `json:"name" validate:"required"`
+	Description string   `json:"description"`
+	Enabled     bool     `json:"enabled"`
+	Path        string   `json:"path" validate:"required"`
+	Pattern     string   `json:"pattern" validate:"required"`
+	Exclude     string   `json:"exclude"`
+	Level       string   `json:"level"`
+	Window      int      `json:"window"`
+	SampleLines int      `json:"sampleLines"`
+	Notify      bool     `json:"notify"`
+	AgentIDs    []string `json:"agentIds"`
+	Tags        []string `json:"tags"`
+}
+
+func (req *LogWatchRuleRequest) apply(rule *models.LogWatchRule) {
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.Enabled = req.Enabled
+	rule.Path = req.Path
+	rule.Pattern = req.Pattern
+	rule.Exclude = req.Exclude
+	rule.Level = req.Level
+	rule.Window = req.Window
+	rule.SampleLines = req.SampleLines
+	rule.Notify = req.Notify
+	rule.AgentIDs = req.AgentIDs
+	rule.Tags = req.Tags
+}
+
+// Paging 日志监控规则分页查询
+func (h *LogWatchHandler) Paging(c echo.Context) error {
+	name := c.QueryParam("name")
+
+	pr := orz.GetPageRequest(c, "created_at", "name")
+
+	builder := orz.NewPageBuilder(h.logWatchService.RuleRepo).
+		PageRequest(pr).
+		Contains("name", name)
+
+	ctx := c.Request().Context()
+	page, err := builder.Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{
+		"items": page.Items,
+		"total": page.Total,
+	})
+}
+
+// Create 创建日志监控规则
+func (h *LogWatchHandler) Create(c echo.Context) error {
+	var req LogWatchRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	var rule models.LogWatchRule
+	req.apply(&rule)
+
+	ctx := c.Request().Context()
+	if err := h.logWatchService.Create(ctx, &rule); err != nil {
+		h.logger.Error("failed to create log watch rule", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, rule)
+}
+
+// Get 获取日志监控规则详情
+func (h *LogWatchHandler) Get(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	rule, err := h.logWatchService.RuleRepo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, rule)
+}
+
+// Update 更新日志监控规则
+func (h *LogWatchHandler) Update(c echo.Context) error {
+	id := c.Param("id")
+
+	var req LogWatchRuleRequest
+	if err := c.Bind(&req); err != nil {
+		return err
+	}
+	if err := c.Validate(&req); err != nil {
+		return err
+	}
+
+	ctx := c.Request().Context()
+	existing, err := h.logWatchService.RuleRepo.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	req.apply(&existing)
+
+	if err := h.logWatchService.Update(ctx, &existing); err != nil {
+		h.logger.Error("failed to update log watch rule", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// Delete 删除日志监控规则
+func (h *LogWatchHandler) Delete(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	if err := h.logWatchService.Delete(ctx, id); err != nil {
+		h.logger.Error("failed to delete log watch rule", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// ListEvents 查询日志匹配事件
+// GET /api/admin/log-watch/events?agentId=&ruleId=&level=
+func (h *LogWatchHandler) ListEvents(c echo.Context) error {
+	pr := orz.GetPageRequest(c, "lastAt", "createdAt")
+
+	builder := orz.NewPageBuilder(h.logWatchService.EventRepo.Repository).
+		PageRequest(pr).
+		Equal("agentId", c.QueryParam("agentId")).
+		Equal("ruleId", c.QueryParam("ruleId")).
+		Equal("level", c.QueryParam("level")).
+		Contains("path", c.QueryParam("path"))
+
+	ctx := c.Request().Context()
+	page, err := builder.Execute(ctx)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// DeleteEvents 删除探针的所有日志匹配事件
+// DELETE /api/admin/agents/:id/log-watch/events
+func (h *LogWatchHandler) DeleteEvents(c echo.Context) error {
+	agentID := c.Param("id")
+	ctx := c.Request().Context()
+
+	if err := h.logWatchService.DeleteEventsByAgentID(ctx, agentID); err != nil {
+		h.logger.Error("failed to delete log watch events", zap.Error(err))
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/dushixiang/pika/internal/utils"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type MetricQueryHandler struct {
+	logger        *zap.Logger
+	agentService  *service.AgentService
+	metricService *service.MetricService
+}
+
+func NewMetricQueryHandler(logger *zap.Logger, agentService *service.AgentService, metricService *service.MetricService) *MetricQueryHandler {
+	return &MetricQueryHandler{
+		logger:        logger,
+		agentService:  agentService,
+		metricService: metricService,
+	}
+}
+
+// Query 自定义 PromQL 即时查询
+// 管理接口可见全部探针，查询限制在已注册探针的序列内：已删除探针的残留数据、
+// 共用 VictoriaMetrics 时其他来源写入的序列以及不带 agent_id 的序列都不会返回
+// GET/POST /api/admin/metrics/query?query=xxx
+func (h *MetricQueryHandler) Query(c echo.Context) error {
+	var req service.PromQLQueryRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	agents, err := h.agentService.ListByAuth(ctx, utils.IsAuthenticated(c))
+	if err != nil {
+		return err
+	}
+
+	result, err := h.metricService.QueryPromQL(ctx, req.Query, agents)
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, result.Data)
+}
+
+// QueryRange 自定义 PromQL 范围查询
+// GET/POST /api/admin/metrics/query_range?query=xxx&start=&end=&step=
+func (h *MetricQueryHandler) QueryRange(c echo.Context) error {
+	var req service.PromQLQueryRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	ctx := c.Request().Context()
+	agents, err := h.agentService.ListByAuth(ctx, utils.IsAuthenticated(c))
+	if err != nil {
+		return err
+	}
+
+	result, err := h.metricService.QueryRangePromQL(ctx, &req, agents)
+	if err != nil {
+		return err
+	}
+	return orz.Ok(c, result.Data)
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
 	"github.com/dushixiang/pika/internal/service"
 	"github.com/dushixiang/pika/internal/utils"
 	"github.com/go-orz/orz"
@@ -13,14 +16,18 @@ type MonitorHandler struct {
 	monitorService *service.MonitorService
 	metricService  *service.MetricService
 	agentService   *service.AgentService
+	routeService   *service.RouteService
+	uptimeService  *service.UptimeService
 }
 
-func NewMonitorHandler(logger *zap.Logger, monitorService *service.MonitorService, metricService *service.MetricService, agentService *service.AgentService) *MonitorHandler {
+func NewMonitorHandler(logger *zap.Logger, monitorService *service.MonitorService, metricService *service.MetricService, agentService *service.AgentService, routeService *service.RouteService, uptimeService *service.UptimeService) *MonitorHandler {
 	return &MonitorHandler{
 		logger:         logger,
 		monitorService: monitorService,
 		metricService:  metricService,
 		agentService:   agentService,
+		routeService:   routeService,
+		uptimeService:  uptimeService,
 	}
 }
 
@@ -171,8 +178,12 @@ func (h *MonitorHandler) GetAgentStatsByID(c echo.Context) error {
 	}
 
 	stats := h.metricService.GetMonitorAgentStats(id)
+	isAuthenticated := utils.IsAuthenticated(c)
 	for i := range stats {
 		stats[i].Target = "" // 隐藏目标地址
+		if !isAuthenticated {
+			stats[i].Hops = nil // 路由追踪的逐跳 IP 仅登录可见
+		}
 	}
 	return orz.Ok(c, stats)
 }
@@ -209,3 +220,107 @@ func (h *MonitorHandler) GetHistoryByID(c echo.Context) error {
 
 	return orz.Ok(c, history)
 }
+
+// ExportHistory 导出监控任务历史数据
+// GET /api/admin/monitors/:id/history/export?format=csv|ndjson|parquet&start=&end=&step=
+func (h *MonitorHandler) ExportHistory(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	// 已停用的监控任务同样可以导出历史数据
+	monitor, err := h.monitorService.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	rawStep := time.Duration(monitor.Interval) * time.Second
+	if rawStep <= 0 {
+		rawStep = time.Minute
+	}
+
+	start, end, step, format, err := parseExportRequest(c, rawStep)
+	if err != nil {
+		return err
+	}
+	aggregation := normalizeAggregation(c.QueryParam("aggregation"))
+
+	return writeExport(c, "monitor-"+id, start, end, format, func(yield func([]metric.Series) error) error {
+		return h.metricService.ExportMonitorHistory(ctx, id, start, end, step, aggregation, yield)
+	})
+}
+
+// GetRouteSnapshots 获取路由追踪路径快照
+// GET /api/admin/monitors/:id/routes
+func (h *MonitorHandler) GetRouteSnapshots(c echo.Context) error {
+	id := c.Param("id")
+
+	pageReq := orz.GetPageRequest(c, "createdAt")
+	builder := orz.NewPageBuilder(h.routeService.SnapshotRepo.Repository).
+		PageRequest(pageReq).
+		Equal("monitorId", id).
+		Equal("agentId", c.QueryParam("agentId"))
+	if c.QueryParam("changed") == "true" {
+		builder.Equal("changed", true)
+	}
+
+	page, err := builder.Execute(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// GetUptimeByID 获取指定监控任务在 24h/7d/30d/90d 内的可用率（公开接口，已登录返回全部，未登录返回公开可见）
+func (h *MonitorHandler) GetUptimeByID(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+
+	// 验证监控任务访问权限
+	monitor, err := h.monitorService.GetMonitorByAuth(ctx, id, utils.IsAuthenticated(c))
+	if err != nil {
+		return err
+	}
+
+	uptime, err := h.uptimeService.GetUptime(ctx, monitor)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, uptime)
+}
+
+// GetIncidentsByID 获取指定监控任务的故障列表（公开接口，已登录返回全部，未登录返回公开可见）
+func (h *MonitorHandler) GetIncidentsByID(c echo.Context) error {
+	id := c.Param("id")
+	ctx := c.Request().Context()
+	isAuthenticated := utils.IsAuthenticated(c)
+
+	// 验证监控任务访问权限
+	monitor, err := h.monitorService.GetMonitorByAuth(ctx, id, isAuthenticated)
+	if err != nil {
+		return err
+	}
+
+	timeRange := c.QueryParam("range")
+	startParam := c.QueryParam("start")
+	endParam := c.QueryParam("end")
+
+	// 默认时间范围为 30 天
+	if timeRange == "" && startParam == "" && endParam == "" {
+		timeRange = "30d"
+	}
+
+	start, end, err := parseTimeRangeOrStartEnd(timeRange, startParam, endParam)
+	if err != nil {
+		return orz.NewError(400, err.Error())
+	}
+
+	// 错误信息可能包含目标地址，未登录时仅在允许公开目标时返回
+	showErrors := isAuthenticated || monitor.ShowTargetPublic
+	incidents, err := h.uptimeService.GetIncidents(ctx, monitor, start, end, showErrors)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, incidents)
+}
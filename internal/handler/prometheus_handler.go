@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/dushixiang/pika/internal/config"
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type PrometheusHandler struct {
+	logger        *zap.Logger
+	scrapeToken   string
+	metricService *service.MetricService
+}
+
+func NewPrometheusHandler(logger *zap.Logger, appConfig *config.AppConfig, metricService *service.MetricService) *PrometheusHandler {
+	var scrapeToken string
+	if appConfig.Prometheus != nil {
+		scrapeToken = appConfig.Prometheus.ScrapeToken
+	}
+	return &PrometheusHandler{
+		logger:        logger,
+		scrapeToken:   scrapeToken,
+		metricService: metricService,
+	}
+}
+
+// Metrics Prometheus 抓取接口，输出所有探针的最新指标
+// GET /api/prometheus/metrics
+// 使用单独配置的抓取令牌认证（Prometheus.ScrapeToken），只接受 Authorization: Bearer <token> 请求头，
+// 不使用探针注册的 API 密钥，也不接受查询参数以免令牌出现在访问日志中
+func (h *PrometheusHandler) Metrics(c echo.Context) error {
+	if h.scrapeToken == "" {
+		return orz.NewError(404, "未配置 Prometheus 抓取令牌")
+	}
+	auth := c.Request().Header.Get(echo.HeaderAuthorization)
+	token, ok := strings.CutPrefix(auth, "Bearer ")
+	if !ok || token == "" {
+		return orz.NewError(401, "缺少抓取令牌")
+	}
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.scrapeToken)) != 1 {
+		h.logger.Warn("Prometheus 抓取令牌无效", zap.String("ip", c.RealIP()))
+		return orz.NewError(401, "无效的抓取令牌")
+	}
+
+	var buf bytes.Buffer
+	if err := h.metricService.WriteOpenMetrics(c.Request().Context(), &buf); err != nil {
+		return err
+	}
+	return c.Blob(http.StatusOK, service.OpenMetricsContentType, buf.Bytes())
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"github.com/dushixiang/pika/internal/service"
+	"github.com/go-orz/orz"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+type StatusPageHandler struct {
+	logger            *zap.Logger
+	statusPageService *service.StatusPageService
+}
+
+func NewStatusPageHandler(logger *zap.Logger, statusPageService *service.StatusPageService) *StatusPageHandler {
+	return &StatusPageHandler{
+		logger:            logger,
+		statusPageService: statusPageService,
+	}
+}
+
+// Paging 状态页分页查询
+func (h *StatusPageHandler) Paging(c echo.Context) error {
+	pr := orz.GetPageRequest(c, "created_at", "slug")
+
+	builder := orz.NewPageBuilder(h.statusPageService.PageRepo).
+		PageRequest(pr).
+		Keyword([]string{"slug", "title"}, c.QueryParam("keyword"))
+
+	page, err := builder.Execute(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// Create 创建状态页
+func (h *StatusPageHandler) Create(c echo.Context) error {
+	var req service.StatusPageRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	page, err := h.statusPageService.CreatePage(c.Request().Context(), &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// Get 获取状态页配置
+func (h *StatusPageHandler) Get(c echo.Context) error {
+	page, err := h.statusPageService.PageRepo.FindById(c.Request().Context(), c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// Update 更新状态页
+func (h *StatusPageHandler) Update(c echo.Context) error {
+	var req service.StatusPageRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	page, err := h.statusPageService.UpdatePage(c.Request().Context(), c.Param("id"), &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// Delete 删除状态页
+func (h *StatusPageHandler) Delete(c echo.Context) error {
+	if err := h.statusPageService.DeletePage(c.Request().Context(), c.Param("id")); err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// ListIncidents 分页查询状态页的故障与维护公告
+func (h *StatusPageHandler) ListIncidents(c echo.Context) error {
+	pr := orz.GetPageRequest(c, "createdAt")
+
+	builder := orz.NewPageBuilder(h.statusPageService.IncidentRepo.Repository).
+		PageRequest(pr).
+		Equal("pageId", c.Param("id")).
+		Equal("kind", c.QueryParam("kind"))
+
+	page, err := builder.Execute(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// CreateIncident 发布故障或计划维护公告
+func (h *StatusPageHandler) CreateIncident(c echo.Context) error {
+	var req service.StatusPageIncidentRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	incident, err := h.statusPageService.CreateIncident(c.Request().Context(), c.Param("id"), &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, incident)
+}
+
+// GetIncident 获取公告详情
+func (h *StatusPageHandler) GetIncident(c echo.Context) error {
+	incident, err := h.statusPageService.GetIncident(c.Request().Context(), c.Param("id"), c.Param("incidentId"))
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, incident)
+}
+
+// AddIncidentUpdate 发布公告进展
+func (h *StatusPageHandler) AddIncidentUpdate(c echo.Context) error {
+	var req service.StatusPageIncidentUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	incident, err := h.statusPageService.AddIncidentUpdate(c.Request().Context(), c.Param("id"), c.Param("incidentId"), &req)
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, incident)
+}
+
+// DeleteIncident 删除公告
+func (h *StatusPageHandler) DeleteIncident(c echo.Context) error {
+	if err := h.statusPageService.DeleteIncident(c.Request().Context(), c.Param("id"), c.Param("incidentId")); err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// ListSubscribers 分页查询状态页订阅者
+func (h *StatusPageHandler) ListSubscribers(c echo.Context) error {
+	pr := orz.GetPageRequest(c, "createdAt")
+
+	builder := orz.NewPageBuilder(h.statusPageService.SubscriberRepo.Repository).
+		PageRequest(pr).
+		Equal("pageId", c.Param("id")).
+		Equal("type", c.QueryParam("type"))
+
+	page, err := builder.Execute(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// DeleteSubscriber 移除订阅者
+func (h *StatusPageHandler) DeleteSubscriber(c echo.Context) error {
+	if err := h.statusPageService.DeleteSubscriber(c.Request().Context(), c.Param("id"), c.Param("subscriberId")); err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// GetPublic 获取公开状态页（公开接口）
+// GET /api/status-pages/:slug
+func (h *StatusPageHandler) GetPublic(c echo.Context) error {
+	page, err := h.statusPageService.GetPublicPage(c.Request().Context(), c.Param("slug"))
+	if err != nil {
+		return err
+	}
+
+	return orz.Ok(c, page)
+}
+
+// Subscribe 订阅状态页通知（公开接口），邮件订阅需通过确认邮件中的链接确认后生效
+// POST /api/status-pages/:slug/subscribe
+func (h *StatusPageHandler) Subscribe(c echo.Context) error {
+	var req service.StatusPageSubscribeRequest
+	if err := c.Bind(&req); err != nil {
+		return orz.NewError(400, "请求参数错误")
+	}
+
+	if _, err := h.statusPageService.Subscribe(c.Request().Context(), c.Param("slug"), &req); err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// ConfirmSubscription 确认邮件订阅（公开接口，用于确认邮件中的链接）
+// GET /api/status-pages/:slug/confirm?token=xxx
+func (h *StatusPageHandler) ConfirmSubscription(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return orz.NewError(400, "缺少确认令牌")
+	}
+
+	if err := h.statusPageService.ConfirmSubscription(c.Request().Context(), c.Param("slug"), token); err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
+
+// Unsubscribe 退订状态页通知（公开接口，用于邮件中的退订链接）
+// GET /api/status-pages/:slug/unsubscribe?token=xxx
+func (h *StatusPageHandler) Unsubscribe(c echo.Context) error {
+	token := c.QueryParam("token")
+	if token == "" {
+		return orz.NewError(400, "缺少退订令牌")
+	}
+
+	if err := h.statusPageService.Unsubscribe(c.Request().Context(), c.Param("slug"), token); err != nil {
+		return err
+	}
+
+	return orz.Ok(c, orz.Map{})
+}
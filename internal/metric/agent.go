@@ -26,10 +26,21 @@ type LatestMetrics struct {
 	Memory            *protocol.MemoryData            `json:"memory,omitempty"`
 	Disk              *DiskSummary                    `json:"disk,omitempty"`
 	Network           *NetworkSummary                 `json:"network,omitempty"`
+	DiskIO            []*protocol.DiskIOData          `json:"diskIO,omitempty"`
 	NetworkInterfaces []protocol.NetworkData          `json:"networkInterfaces,omitempty"`
 	NetworkConnection *protocol.NetworkConnectionData `json:"networkConnection,omitempty"`
 	Host              *protocol.HostInfoData          `json:"host,omitempty"`
 	GPU               []protocol.GPUData              `json:"gpu,omitempty"`
 	Temp              []protocol.TemperatureData      `json:"temperature,omitempty"`
 	Monitors          []protocol.MonitorData          `json:"monitors,omitempty"`
+	Custom            []protocol.CustomMetricData     `json:"custom,omitempty"`
+	Processes         *protocol.ProcessMetricsData    `json:"processes,omitempty"`
+	Services          *protocol.ServiceStateData      `json:"services,omitempty"`
+	Kernel            *protocol.KernelData            `json:"kernel,omitempty"`
+	Hardware          *protocol.HardwareData          `json:"hardware,omitempty"`
+	Buffer            *protocol.MetricsBufferStats    `json:"buffer,omitempty"`
+	Health            *protocol.AgentHealth           `json:"health,omitempty"`
+
+	// Timestamps 各类型指标最新一条数据的采集时间（毫秒），用于识别离线缓存补发的旧数据
+	Timestamps map[string]int64 `json:"-"`
 }
@@ -0,0 +1,47 @@
+package metric
+
+// FleetGroupSummary 探针分组（按标签）汇总
+type FleetGroupSummary struct {
+	Tag             string   `json:"tag"`             // 分组标签，为空表示全部探针
+	AgentCount      int      `json:"agentCount"`      // 探针数量
+	OnlineCount     int      `json:"onlineCount"`     // 在线探针数量
+	CPUAvg          *float64 `json:"cpuAvg"`          // 时间范围内平均 CPU 使用率
+	CPUMax          *float64 `json:"cpuMax"`          // 时间范围内最高 CPU 使用率
+	CPUP95          *float64 `json:"cpuP95"`          // 组内各探针平均 CPU 使用率的 95 分位
+	MemoryAvg       *float64 `json:"memoryAvg"`       // 时间范围内平均内存使用率
+	NetworkSentRate uint64   `json:"networkSentRate"` // 当前总上传速率（字节/秒）
+	NetworkRecvRate uint64   `json:"networkRecvRate"` // 当前总下载速率（字节/秒）
+	TrafficSent     float64  `json:"trafficSent"`     // 时间范围内总上传流量（字节）
+	TrafficRecv     float64  `json:"trafficRecv"`     // 时间范围内总下载流量（字节）
+}
+
+// FleetAgentStats 分组内单个探针的统计
+type FleetAgentStats struct {
+	AgentID         string   `json:"agentId"`
+	Name            string   `json:"name"`
+	Tags            []string `json:"tags"`
+	Status          int      `json:"status"` // 0-离线, 1-在线
+	CPUAvg          *float64 `json:"cpuAvg"`
+	CPUMax          *float64 `json:"cpuMax"`
+	MemoryAvg       *float64 `json:"memoryAvg"`
+	NetworkSentRate uint64   `json:"networkSentRate"`
+	NetworkRecvRate uint64   `json:"networkRecvRate"`
+	TrafficSent     float64  `json:"trafficSent"`
+	TrafficRecv     float64  `json:"trafficRecv"`
+}
+
+// FleetOverview 全部探针及各分组的汇总
+type FleetOverview struct {
+	Start  int64               `json:"start"` // 统计开始时间（时间戳毫秒）
+	End    int64               `json:"end"`   // 统计结束时间（时间戳毫秒）
+	All    FleetGroupSummary   `json:"all"`
+	Groups []FleetGroupSummary `json:"groups"`
+}
+
+// FleetGroupDetail 单个分组的汇总及组内探针统计
+type FleetGroupDetail struct {
+	Start   int64             `json:"start"`
+	End     int64             `json:"end"`
+	Summary FleetGroupSummary `json:"summary"`
+	Agents  []FleetAgentStats `json:"agents"`
+}
@@ -0,0 +1,17 @@
+package metric
+
+// DiskForecast 单个挂载点的磁盘容量预测
+type DiskForecast struct {
+	AgentID           string   `json:"agentId"`
+	AgentName         string   `json:"agentName,omitempty"`
+	MountPoint        string   `json:"mountPoint"`
+	TotalBytes        float64  `json:"totalBytes"`        // 磁盘总容量
+	UsedBytes         float64  `json:"usedBytes"`         // 当前已用容量
+	UsagePercent      float64  `json:"usagePercent"`      // 当前使用率
+	GrowthBytesPerDay float64  `json:"growthBytesPerDay"` // 拟合的每日增长量，负数表示在减少
+	DaysUntilFull     *float64 `json:"daysUntilFull"`     // 预计写满的剩余天数，未增长时为空
+	FullAt            *int64   `json:"fullAt"`            // 预计写满的时间（时间戳毫秒），未增长时为空
+	R2                float64  `json:"r2"`                // 线性拟合的决定系数，越接近 1 趋势越可靠
+	Samples           int      `json:"samples"`           // 参与拟合的小时样本数
+	UpdatedAt         int64    `json:"updatedAt"`         // 预测时间（时间戳毫秒）
+}
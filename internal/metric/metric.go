@@ -19,6 +19,21 @@ type GetMetricsResponse struct {
 	Type    string   `json:"type"`
 	Range   string   `json:"range"`
 	Series  []Series `json:"series"`
+	Bands   []Band   `json:"bands,omitempty"` // 异常检测基线的预期范围，用于图表着色
+}
+
+// BandPoint 预期范围数据点
+type BandPoint struct {
+	Timestamp int64   `json:"timestamp"` // 毫秒时间戳
+	Expected  float64 `json:"expected"`  // 基线中位数
+	Lower     float64 `json:"lower"`     // 下界
+	Upper     float64 `json:"upper"`     // 上界
+}
+
+// Band 序列的预期范围，Name 与对应的 Series.Name 一致
+type Band struct {
+	Name string      `json:"name"`
+	Data []BandPoint `json:"data"`
 }
 
 // QueryDefinition 查询定义（用于构建多个查询）
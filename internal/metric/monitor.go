@@ -67,3 +67,45 @@ type MonitorDetailResponse struct {
 	Stats            *MonitorStatsResult    `json:"stats"`
 	Agents           []protocol.MonitorData `json:"agents"`
 }
+
+// MonitorUptime 监控任务可用率（SLA）
+type MonitorUptime struct {
+	MonitorID string               `json:"monitorId"`
+	Periods   []MonitorUptimeRange `json:"periods"` // 各统计周期的可用率
+}
+
+// MonitorUptimeRange 单个统计周期的可用率
+type MonitorUptimeRange struct {
+	Period string               `json:"period"`           // 统计周期：24h/7d/30d/90d
+	Uptime *float64             `json:"uptime"`           // 整体可用率(%)，无数据时为 null
+	Checks int64                `json:"checks"`           // 检测次数
+	Agents []AgentUptimeSummary `json:"agents,omitempty"` // 各探针的可用率
+}
+
+// AgentUptimeSummary 单个探针在统计周期内的可用率
+type AgentUptimeSummary struct {
+	AgentID   string  `json:"agentId"`
+	AgentName string  `json:"agentName"`
+	Uptime    float64 `json:"uptime"` // 可用率(%)
+	Checks    int64   `json:"checks"` // 检测次数
+}
+
+// MonitorIncidentSummary 监控故障（多个探针重叠的故障合并为一次）
+type MonitorIncidentSummary struct {
+	StartedAt int64                   `json:"startedAt"` // 开始时间(毫秒时间戳)
+	EndedAt   int64                   `json:"endedAt"`   // 恢复时间(毫秒时间戳)，0 表示尚未恢复
+	Duration  int64                   `json:"duration"`  // 持续时长(毫秒)
+	Ongoing   bool                    `json:"ongoing"`   // 是否仍在持续
+	Agents    []MonitorIncidentDetail `json:"agents"`    // 受影响的探针
+	Errors    []string                `json:"errors"`    // 去重后的错误信息
+}
+
+// MonitorIncidentDetail 单个探针上的故障详情
+type MonitorIncidentDetail struct {
+	AgentID   string `json:"agentId"`
+	AgentName string `json:"agentName"`
+	StartedAt int64  `json:"startedAt"`
+	EndedAt   int64  `json:"endedAt"`
+	Error     string `json:"error,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+}
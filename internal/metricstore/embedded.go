@@ -0,0 +1,336 @@
+package metricstore
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/vmclient"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// embeddedBatchSize 单次批量写入的样本数
+	embeddedBatchSize = 500
+	// embeddedQueryChunk 单次查询的序列数，避免 IN 条件过长
+	embeddedQueryChunk = 500
+)
+
+// EmbeddedStore 基于应用数据库（SQLite/PostgreSQL/MySQL）的内置时序存储，无需额外部署 VictoriaMetrics
+type EmbeddedStore struct {
+	logger    *zap.Logger
+	db        *gorm.DB
+	retention time.Duration
+
+	mu     sync.RWMutex
+	loaded bool
+	series map[string]*embeddedSeries // key: 标签哈希
+
+	// writeMu 写入时从解析序列到写入样本全程持有读锁，清理序列时持有写锁，
+	// 避免写入使用清理前解析到的序列 ID，写入指向已删除序列的样本
+	writeMu sync.RWMutex
+
+	tierMu sync.RWMutex
+	tiers  map[string]time.Duration // 指标名称后缀 -> 保留时长（降采样数据）
+}
+
+type embeddedSeries struct {
+	id     int64
+	labels map[string]string
+}
+
+// NewEmbeddedStore 创建内置存储，retention 为样本保留时长
+func NewEmbeddedStore(logger *zap.Logger, db *gorm.DB, retention time.Duration) *EmbeddedStore {
+	return &EmbeddedStore{
+		logger:    logger,
+		db:        db,
+		retention: retention,
+		series:    make(map[string]*embeddedSeries),
+		tiers:     make(map[string]time.Duration),
+	}
+}
+
+// SetTierRetention 为名称以 suffix 结尾的序列（降采样数据）设置独立的保留时长，其余序列使用默认保留时长
+func (s *EmbeddedStore) SetTierRetention(suffix string, retention time.Duration) {
+	s.tierMu.Lock()
+	defer s.tierMu.Unlock()
+	s.tiers[suffix] = retention
+}
+
+// Write 写入指标，同一序列同一时间戳的样本会被覆盖
+func (s *EmbeddedStore) Write(ctx context.Context, metrics []vmclient.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	s.writeMu.RLock()
+	defer s.writeMu.RUnlock()
+
+	if err := s.ensureLoaded(ctx); err != nil {
+		return err
+	}
+
+	samples := make([]models.MetricSample, 0, len(metrics))
+	for _, m := range metrics {
+		sr, err := s.resolveSeries(ctx, metricLabels(m))
+		if err != nil {
+			return err
+		}
+		for i, ts := range m.Timestamps {
+			if i >= len(m.Values) {
+				break
+			}
+			samples = append(samples, models.MetricSample{SeriesID: sr.id, Timestamp: ts, Value: m.Values[i]})
+		}
+	}
+
+	return s.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "series_id"}, {Name: "timestamp"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value"}),
+		}).
+		CreateInBatches(samples, embeddedBatchSize).Error
+}
+
+// QueryRange 范围查询
+func (s *EmbeddedStore) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (*vmclient.QueryResult, error) {
+	return queryRange(ctx, s, query, start, end, step)
+}
+
+// Query 即时查询
+func (s *EmbeddedStore) Query(ctx context.Context, query string) (*vmclient.QueryResult, error) {
+	return queryInstant(ctx, s, query, time.Now())
+}
+
+// GetLabelValues 获取指定 label 的所有值
+func (s *EmbeddedStore) GetLabelValues(ctx context.Context, labelName string, match []string) ([]string, error) {
+	selectors, err := parseMatchers(match)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	labelSets := make([]map[string]string, 0, len(s.series))
+	for _, sr := range s.series {
+		labelSets = append(labelSets, sr.labels)
+	}
+	s.mu.RUnlock()
+
+	return labelValues(labelSets, labelName, selectors), nil
+}
+
+// Run 定期清理过期样本及不再有样本的序列
+func (s *EmbeddedStore) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	s.cleanup(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.cleanup(ctx)
+		}
+	}
+}
+
+func (s *EmbeddedStore) cleanup(ctx context.Context) {
+	if s.retention <= 0 {
+		return
+	}
+	now := time.Now()
+	before := now.Add(-s.retention).UnixMilli()
+
+	s.tierMu.RLock()
+	tiers := make(map[string]time.Duration, len(s.tiers))
+	for suffix, retention := range s.tiers {
+		tiers[suffix] = retention
+	}
+	s.tierMu.RUnlock()
+
+	db := s.db.WithContext(ctx)
+	tierSeries := func(suffix string) *gorm.DB {
+		return db.Model(&models.MetricSeries{}).Select("id").Where("name LIKE ?", "%"+suffix)
+	}
+
+	// 原始数据使用默认保留时长，降采样数据使用各自的保留时长
+	query := db.Where("timestamp < ?", before)
+	for suffix := range tiers {
+		query = query.Where("series_id NOT IN (?)", tierSeries(suffix))
+	}
+	result := query.Delete(&models.MetricSample{})
+	if result.Error != nil {
+		s.logger.Error("清理过期指标样本失败", zap.Error(result.Error))
+		return
+	}
+	deleted := result.RowsAffected
+
+	for suffix, retention := range tiers {
+		result := db.Where("timestamp < ? AND series_id IN (?)", now.Add(-retention).UnixMilli(), tierSeries(suffix)).
+			Delete(&models.MetricSample{})
+		if result.Error != nil {
+			s.logger.Error("清理过期降采样样本失败", zap.String("suffix", suffix), zap.Error(result.Error))
+			continue
+		}
+		deleted += result.RowsAffected
+	}
+	if deleted == 0 {
+		return
+	}
+
+	// 删除已没有样本的序列，并重新加载序列缓存。等待进行中的写入完成，期间阻塞新的写入
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 只清理保留期之前创建的序列，避免误删刚创建、样本尚未写入的序列
+	err := db.Where("created_at < ? AND id NOT IN (?)", before, db.Model(&models.MetricSample{}).Distinct("series_id")).
+		Delete(&models.MetricSeries{}).Error
+	if err != nil {
+		s.logger.Error("清理过期指标序列失败", zap.Error(err))
+	}
+	s.series = make(map[string]*embeddedSeries)
+	s.loaded = false
+
+	s.logger.Info("清理过期指标样本完成", zap.Int64("deleted", deleted))
+}
+
+func (s *EmbeddedStore) selectSeries(ctx context.Context, sel *selectorExpr, start, end int64) ([]*series, error) {
+	if err := s.ensureLoaded(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	matched := make(map[int64]map[string]string)
+	for _, sr := range s.series {
+		if sel.matches(sr.labels) {
+			matched[sr.id] = sr.labels
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]int64, 0, len(matched))
+	for id := range matched {
+		ids = append(ids, id)
+	}
+
+	result := make(map[int64]*series)
+	for i := 0; i < len(ids); i += embeddedQueryChunk {
+		chunk := ids[i:min(i+embeddedQueryChunk, len(ids))]
+		var samples []models.MetricSample
+		err := s.db.WithContext(ctx).
+			Where("series_id IN ? AND timestamp > ? AND timestamp <= ?", chunk, start, end).
+			Order("series_id asc, timestamp asc").
+			Find(&samples).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range samples {
+			sr, ok := result[sample.SeriesID]
+			if !ok {
+				sr = &series{labels: matched[sample.SeriesID]}
+				result[sample.SeriesID] = sr
+			}
+			sr.timestamps = append(sr.timestamps, sample.Timestamp)
+			sr.values = append(sr.values, sample.Value)
+		}
+	}
+
+	out := make([]*series, 0, len(result))
+	for _, sr := range result {
+		out = append(out, sr)
+	}
+	return out, nil
+}
+
+// ensureLoaded 首次访问时加载全部序列到内存
+func (s *EmbeddedStore) ensureLoaded(ctx context.Context) error {
+	s.mu.RLock()
+	loaded := s.loaded
+	s.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded {
+		return nil
+	}
+
+	var items []models.MetricSeries
+	if err := s.db.WithContext(ctx).Find(&items).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		var labels map[string]string
+		if err := json.Unmarshal([]byte(item.Labels), &labels); err != nil {
+			s.logger.Warn("解析指标序列标签失败", zap.Int64("seriesId", item.ID), zap.Error(err))
+			continue
+		}
+		s.series[item.Hash] = &embeddedSeries{id: item.ID, labels: labels}
+	}
+	s.loaded = true
+	return nil
+}
+
+// resolveSeries 获取序列，不存在时创建
+func (s *EmbeddedStore) resolveSeries(ctx context.Context, labels map[string]string) (*embeddedSeries, error) {
+	hash := seriesHash(labels)
+
+	s.mu.RLock()
+	sr, ok := s.series[hash]
+	s.mu.RUnlock()
+	if ok {
+		return sr, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sr, ok := s.series[hash]; ok {
+		return sr, nil
+	}
+
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return nil, err
+	}
+	item := models.MetricSeries{
+		Hash:      hash,
+		Name:      labels["__name__"],
+		Labels:    string(data),
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	db := s.db.WithContext(ctx)
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&item).Error; err != nil {
+		return nil, err
+	}
+	if item.ID == 0 {
+		// 已被其他实例创建
+		if err := db.Where("hash = ?", hash).First(&item).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	sr = &embeddedSeries{id: item.ID, labels: labels}
+	s.series[hash] = sr
+	return sr, nil
+}
+
+func seriesHash(labels map[string]string) string {
+	sum := sha1.Sum([]byte(labelsKey(labels)))
+	return hex.EncodeToString(sum[:])
+}
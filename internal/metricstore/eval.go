@@ -0,0 +1,393 @@
+package metricstore
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/vmclient"
+)
+
+// defaultLookback 即时选择器向前查找最近样本的时长，与 Prometheus 默认值一致
+const defaultLookback = 5 * time.Minute
+
+// series 单条时间序列（样本按时间升序）
+type series struct {
+	labels     map[string]string
+	timestamps []int64 // 毫秒
+	values     []float64
+}
+
+// sample 某一时刻的计算结果
+type sample struct {
+	labels map[string]string
+	value  float64
+}
+
+// seriesSource 样本数据来源，由具体存储实现
+type seriesSource interface {
+	// selectSeries 返回匹配选择器且在 (start, end] 内有样本的序列
+	selectSeries(ctx context.Context, sel *selectorExpr, start, end int64) ([]*series, error)
+}
+
+// evaluator 在预先加载的样本上计算查询
+type evaluator struct {
+	data     map[*selectorExpr][]*series
+	lookback int64 // 毫秒
+	step     int64 // 毫秒，子查询未指定步长时使用
+}
+
+// queryRange 执行范围查询
+func queryRange(ctx context.Context, src seriesSource, query string, start, end time.Time, step time.Duration) (*vmclient.QueryResult, error) {
+	if step <= 0 {
+		step = vmclient.AutoStep(start, end)
+	}
+	root, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	stepMs := step.Milliseconds()
+	lookback := max(stepMs, defaultLookback.Milliseconds())
+	startMs := start.UnixMilli() / stepMs * stepMs
+	endMs := end.UnixMilli()
+
+	ev, err := newEvaluator(ctx, src, root, startMs, endMs, lookback, stepMs)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make(map[string]*vmclient.Result)
+	var keys []string
+	for t := startMs; t <= endMs; t += stepMs {
+		for _, s := range ev.eval(root, t) {
+			if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+				continue
+			}
+			key := labelsKey(s.labels)
+			r, ok := results[key]
+			if !ok {
+				r = &vmclient.Result{Metric: s.labels}
+				results[key] = r
+				keys = append(keys, key)
+			}
+			r.Values = append(r.Values, []interface{}{float64(t) / 1000, formatValue(s.value)})
+		}
+	}
+
+	sort.Strings(keys)
+	out := &vmclient.QueryResult{Status: "success", Data: vmclient.ResultData{ResultType: "matrix", Result: []vmclient.Result{}}}
+	for _, key := range keys {
+		out.Data.Result = append(out.Data.Result, *results[key])
+	}
+	return out, nil
+}
+
+// queryInstant 执行即时查询（计算当前时刻的最新值）
+func queryInstant(ctx context.Context, src seriesSource, query string, at time.Time) (*vmclient.QueryResult, error) {
+	root, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	t := at.UnixMilli()
+	lookback := defaultLookback.Milliseconds()
+	ev, err := newEvaluator(ctx, src, root, t, t, lookback, time.Minute.Milliseconds())
+	if err != nil {
+		return nil, err
+	}
+
+	samples := ev.eval(root, t)
+	sort.Slice(samples, func(i, j int) bool {
+		return labelsKey(samples[i].labels) < labelsKey(samples[j].labels)
+	})
+
+	out := &vmclient.QueryResult{Status: "success", Data: vmclient.ResultData{ResultType: "vector", Result: []vmclient.Result{}}}
+	for _, s := range samples {
+		if math.IsNaN(s.value) || math.IsInf(s.value, 0) {
+			continue
+		}
+		out.Data.Result = append(out.Data.Result, vmclient.Result{
+			Metric: s.labels,
+			Value:  []interface{}{float64(t) / 1000, formatValue(s.value)},
+		})
+	}
+	return out, nil
+}
+
+// newEvaluator 按查询涉及的最大回溯窗口一次性加载所需样本
+func newEvaluator(ctx context.Context, src seriesSource, root node, start, end, lookback, step int64) (*evaluator, error) {
+	ev := &evaluator{
+		data:     make(map[*selectorExpr][]*series),
+		lookback: lookback,
+		step:     step,
+	}
+
+	var load func(n node, back int64) error
+	load = func(n node, back int64) error {
+		switch e := n.(type) {
+		case *selectorExpr:
+			data, err := src.selectSeries(ctx, e, start-back, end)
+			if err != nil {
+				return err
+			}
+			ev.data[e] = data
+		case *aggregateExpr:
+			return load(e.expr, back)
+		case *rollupExpr:
+			inner := back + e.window.Milliseconds()
+			if e.subquery {
+				inner += lookback
+			} else if e.fn == "rate" || e.fn == "increase" {
+				// 额外加载窗口前的一个样本作为计数器基准
+				inner += lookback
+			}
+			return load(e.expr, inner)
+		}
+		return nil
+	}
+	if err := load(root, lookback); err != nil {
+		return nil, err
+	}
+	return ev, nil
+}
+
+func (ev *evaluator) eval(n node, t int64) []sample {
+	switch e := n.(type) {
+	case *selectorExpr:
+		return ev.evalSelector(e, t)
+	case *aggregateExpr:
+		return evalAggregate(e, ev.eval(e.expr, t))
+	case *rollupExpr:
+		return ev.evalRollup(e, t)
+	}
+	return nil
+}
+
+// evalSelector 取每条序列在回溯窗口内的最新样本
+func (ev *evaluator) evalSelector(sel *selectorExpr, t int64) []sample {
+	var out []sample
+	for _, s := range ev.data[sel] {
+		i := sort.Search(len(s.timestamps), func(i int) bool { return s.timestamps[i] > t }) - 1
+		if i < 0 || s.timestamps[i] <= t-ev.lookback {
+			continue
+		}
+		out = append(out, sample{labels: s.labels, value: s.values[i]})
+	}
+	return out
+}
+
+func (ev *evaluator) evalRollup(r *rollupExpr, t int64) []sample {
+	window := r.window.Milliseconds()
+	var out []sample
+
+	// 与 VictoriaMetrics 一致：子查询内部为普通选择器时直接基于原始样本计算
+	if sel, ok := r.expr.(*selectorExpr); ok {
+		for _, s := range ev.data[sel] {
+			lo := sort.Search(len(s.timestamps), func(i int) bool { return s.timestamps[i] > t-window })
+			hi := sort.Search(len(s.timestamps), func(i int) bool { return s.timestamps[i] > t })
+			if lo >= hi {
+				continue
+			}
+			prev := math.NaN()
+			if lo > 0 {
+				prev = s.values[lo-1]
+			}
+			out = append(out, sample{labels: dropName(s.labels), value: applyRollup(r.fn, s.values[lo:hi], prev, r.window)})
+		}
+		return out
+	}
+
+	// 子查询：按步长在窗口内多次计算内部表达式
+	step := r.subStep.Milliseconds()
+	if step <= 0 {
+		step = ev.step
+	}
+	grouped := make(map[string]*series)
+	var keys []string
+	first := (t-window)/step*step + step
+	for ts := first; ts <= t; ts += step {
+		for _, s := range ev.eval(r.expr, ts) {
+			key := labelsKey(s.labels)
+			g, ok := grouped[key]
+			if !ok {
+				g = &series{labels: s.labels}
+				grouped[key] = g
+				keys = append(keys, key)
+			}
+			g.values = append(g.values, s.value)
+		}
+	}
+	for _, key := range keys {
+		g := grouped[key]
+		out = append(out, sample{labels: dropName(g.labels), value: applyRollup(r.fn, g.values, math.NaN(), r.window)})
+	}
+	return out
+}
+
+// applyRollup 计算窗口内样本的区间函数，prev 为窗口前的最后一个样本（用于计数器函数）
+func applyRollup(fn string, values []float64, prev float64, window time.Duration) float64 {
+	switch fn {
+	case "avg_over_time":
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	case "min_over_time":
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Min(m, v)
+		}
+		return m
+	case "max_over_time":
+		m := values[0]
+		for _, v := range values[1:] {
+			m = math.Max(m, v)
+		}
+		return m
+	case "sum_over_time":
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum
+	case "count_over_time":
+		return float64(len(values))
+	case "last_over_time":
+		return values[len(values)-1]
+	case "increase":
+		return counterIncrease(values, prev)
+	case "rate":
+		return counterIncrease(values, prev) / window.Seconds()
+	}
+	return math.NaN()
+}
+
+// counterIncrease 计算计数器增量，计数器重置时从 0 重新累计
+func counterIncrease(values []float64, prev float64) float64 {
+	last := prev
+	var inc float64
+	for _, v := range values {
+		if !math.IsNaN(last) {
+			if v >= last {
+				inc += v - last
+			} else {
+				inc += v
+			}
+		}
+		last = v
+	}
+	return inc
+}
+
+// evalAggregate 按标签分组聚合
+func evalAggregate(agg *aggregateExpr, samples []sample) []sample {
+	type group struct {
+		labels map[string]string
+		values []float64
+	}
+	groups := make(map[string]*group)
+	var keys []string
+
+	for _, s := range samples {
+		labels := groupLabels(agg, s.labels)
+		key := labelsKey(labels)
+		g, ok := groups[key]
+		if !ok {
+			g = &group{labels: labels}
+			groups[key] = g
+			keys = append(keys, key)
+		}
+		g.values = append(g.values, s.value)
+	}
+
+	out := make([]sample, 0, len(keys))
+	for _, key := range keys {
+		g := groups[key]
+		var v float64
+		switch agg.op {
+		case "sum":
+			for _, x := range g.values {
+				v += x
+			}
+		case "avg":
+			for _, x := range g.values {
+				v += x
+			}
+			v /= float64(len(g.values))
+		case "min":
+			v = g.values[0]
+			for _, x := range g.values[1:] {
+				v = math.Min(v, x)
+			}
+		case "max":
+			v = g.values[0]
+			for _, x := range g.values[1:] {
+				v = math.Max(v, x)
+			}
+		case "count":
+			v = float64(len(g.values))
+		}
+		out = append(out, sample{labels: g.labels, value: v})
+	}
+	return out
+}
+
+func groupLabels(agg *aggregateExpr, labels map[string]string) map[string]string {
+	out := make(map[string]string)
+	if agg.without {
+		for k, v := range labels {
+			out[k] = v
+		}
+		delete(out, "__name__")
+		for _, l := range agg.labels {
+			delete(out, l)
+		}
+		return out
+	}
+	for _, l := range agg.labels {
+		if v, ok := labels[l]; ok {
+			out[l] = v
+		}
+	}
+	return out
+}
+
+func dropName(labels map[string]string) map[string]string {
+	if _, ok := labels["__name__"]; !ok {
+		return labels
+	}
+	out := make(map[string]string, len(labels)-1)
+	for k, v := range labels {
+		if k != "__name__" {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// labelsKey 生成标签集合的唯一标识
+func labelsKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(labels[k]))
+		sb.WriteByte(',')
+	}
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,138 @@
+package metricstore
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/vmclient"
+)
+
+// MemoryStore 纯内存时序存储，进程重启后数据丢失，适用于测试及无需保留历史的场景
+type MemoryStore struct {
+	mu        sync.RWMutex
+	series    map[string]*series // key: labelsKey
+	retention time.Duration
+}
+
+// NewMemoryStore 创建内存存储，retention 为 0 时不清理历史数据
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	return &MemoryStore{
+		series:    make(map[string]*series),
+		retention: retention,
+	}
+}
+
+// Write 写入指标，同一时间戳的样本会被覆盖
+func (s *MemoryStore) Write(ctx context.Context, metrics []vmclient.Metric) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var minTs int64
+	if s.retention > 0 {
+		minTs = time.Now().Add(-s.retention).UnixMilli()
+	}
+
+	for _, m := range metrics {
+		labels := metricLabels(m)
+		key := labelsKey(labels)
+		sr, ok := s.series[key]
+		if !ok {
+			sr = &series{labels: labels}
+			s.series[key] = sr
+		}
+		for i, ts := range m.Timestamps {
+			if i >= len(m.Values) {
+				break
+			}
+			insertSample(sr, ts, m.Values[i])
+		}
+		if minTs > 0 {
+			trimBefore(sr, minTs)
+		}
+	}
+	return nil
+}
+
+// QueryRange 范围查询
+func (s *MemoryStore) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (*vmclient.QueryResult, error) {
+	return queryRange(ctx, s, query, start, end, step)
+}
+
+// Query 即时查询
+func (s *MemoryStore) Query(ctx context.Context, query string) (*vmclient.QueryResult, error) {
+	return queryInstant(ctx, s, query, time.Now())
+}
+
+// GetLabelValues 获取指定 label 的所有值
+func (s *MemoryStore) GetLabelValues(ctx context.Context, labelName string, match []string) ([]string, error) {
+	selectors, err := parseMatchers(match)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	labelSets := make([]map[string]string, 0, len(s.series))
+	for _, sr := range s.series {
+		labelSets = append(labelSets, sr.labels)
+	}
+	s.mu.RUnlock()
+
+	return labelValues(labelSets, labelName, selectors), nil
+}
+
+func (s *MemoryStore) selectSeries(ctx context.Context, sel *selectorExpr, start, end int64) ([]*series, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var out []*series
+	for _, sr := range s.series {
+		if !sel.matches(sr.labels) {
+			continue
+		}
+		lo := sort.Search(len(sr.timestamps), func(i int) bool { return sr.timestamps[i] > start })
+		hi := sort.Search(len(sr.timestamps), func(i int) bool { return sr.timestamps[i] > end })
+		if lo >= hi {
+			continue
+		}
+		// 复制样本，避免查询期间被并发写入修改
+		out = append(out, &series{
+			labels:     sr.labels,
+			timestamps: append([]int64(nil), sr.timestamps[lo:hi]...),
+			values:     append([]float64(nil), sr.values[lo:hi]...),
+		})
+	}
+	return out, nil
+}
+
+// insertSample 按时间顺序插入样本
+func insertSample(sr *series, ts int64, value float64) {
+	n := len(sr.timestamps)
+	if n == 0 || sr.timestamps[n-1] < ts {
+		sr.timestamps = append(sr.timestamps, ts)
+		sr.values = append(sr.values, value)
+		return
+	}
+	i := sort.Search(n, func(i int) bool { return sr.timestamps[i] >= ts })
+	if i < n && sr.timestamps[i] == ts {
+		sr.values[i] = value
+		return
+	}
+	sr.timestamps = append(sr.timestamps, 0)
+	sr.values = append(sr.values, 0)
+	copy(sr.timestamps[i+1:], sr.timestamps[i:])
+	copy(sr.values[i+1:], sr.values[i:])
+	sr.timestamps[i] = ts
+	sr.values[i] = value
+}
+
+// trimBefore 删除指定时间之前的样本
+func trimBefore(sr *series, minTs int64) {
+	i := sort.Search(len(sr.timestamps), func(i int) bool { return sr.timestamps[i] >= minTs })
+	if i == 0 {
+		return
+	}
+	sr.timestamps = append(sr.timestamps[:0], sr.timestamps[i:]...)
+	sr.values = append(sr.values[:0], sr.values[i:]...)
+}
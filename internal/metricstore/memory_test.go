@@ -0,0 +1,146 @@
+package metricstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/vmclient"
+)
+
+func writeSamples(t *testing.T, store *MemoryStore, name string, labels map[string]string, start time.Time, values ...float64) {
+	t.Helper()
+	m := vmclient.Metric{Metric: map[string]string{"__name__": name}}
+	for k, v := range labels {
+		m.Metric[k] = v
+	}
+	for i, v := range values {
+		m.Timestamps = append(m.Timestamps, start.Add(time.Duration(i)*time.Minute).UnixMilli())
+		m.Values = append(m.Values, v)
+	}
+	if err := store.Write(context.Background(), []vmclient.Metric{m}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMemoryStoreQueryRange(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0)
+	start := time.Now().Truncate(time.Hour).Add(-time.Hour)
+
+	writeSamples(t, store, "pika_network_sent_bytes_rate", map[string]string{"agent_id": "a1", "interface": "eth0"}, start, 1, 2, 3)
+	writeSamples(t, store, "pika_network_sent_bytes_rate", map[string]string{"agent_id": "a1", "interface": "eth1"}, start, 10, 20, 30)
+	writeSamples(t, store, "pika_network_sent_bytes_rate", map[string]string{"agent_id": "a2", "interface": "eth0"}, start, 100, 200, 300)
+
+	result, err := store.QueryRange(ctx, `sum(pika_network_sent_bytes_rate{agent_id="a1"}) by (agent_id)`, start, start.Add(2*time.Minute), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data.Result) != 1 {
+		t.Fatalf("expected 1 series, got %d", len(result.Data.Result))
+	}
+	r := result.Data.Result[0]
+	if r.Metric["agent_id"] != "a1" || len(r.Metric) != 1 {
+		t.Fatalf("unexpected labels: %v", r.Metric)
+	}
+	want := []string{"11", "22", "33"}
+	if len(r.Values) != len(want) {
+		t.Fatalf("expected %d points, got %v", len(want), r.Values)
+	}
+	for i, w := range want {
+		if r.Values[i][1] != w {
+			t.Errorf("point %d = %v, want %s", i, r.Values[i][1], w)
+		}
+	}
+
+	// 子查询聚合
+	result, err = store.QueryRange(ctx, `max_over_time((pika_network_sent_bytes_rate{interface="eth0"})[120s:])`, start.Add(2*time.Minute), start.Add(2*time.Minute), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data.Result) != 2 {
+		t.Fatalf("expected 2 series, got %d", len(result.Data.Result))
+	}
+	for _, r := range result.Data.Result {
+		if _, ok := r.Metric["__name__"]; ok {
+			t.Errorf("rollup result should drop metric name: %v", r.Metric)
+		}
+	}
+}
+
+func TestMemoryStoreQuery(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore(0)
+	start := time.Now().Add(-5 * time.Minute)
+
+	writeSamples(t, store, "pika_monitor_status", map[string]string{"monitor_id": "m1", "agent_id": "a1"}, start, 1, 0, 1, 1)
+	writeSamples(t, store, "pika_monitor_status", map[string]string{"monitor_id": "m1", "agent_id": "a2"}, start, 1, 1)
+
+	up, err := store.Query(ctx, `sum by (agent_id) (sum_over_time(pika_monitor_status{monitor_id="m1"}[3600s]))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	total, err := store.Query(ctx, `sum by (agent_id) (count_over_time(pika_monitor_status{monitor_id="m1"}[3600s]))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(map[string][2]float64)
+	for _, r := range up.Data.Result {
+		v, _ := r.InstantValue()
+		g := got[r.Metric["agent_id"]]
+		g[0] = v
+		got[r.Metric["agent_id"]] = g
+	}
+	for _, r := range total.Data.Result {
+		v, _ := r.InstantValue()
+		g := got[r.Metric["agent_id"]]
+		g[1] = v
+		got[r.Metric["agent_id"]] = g
+	}
+	if got["a1"] != [2]float64{3, 4} || got["a2"] != [2]float64{2, 2} {
+		t.Fatalf("unexpected uptime counts: %v", got)
+	}
+}
+
+func TestMemoryStoreLabelValues(t *testing.T) {
+	store := NewMemoryStore(0)
+	start := time.Now()
+	writeSamples(t, store, "pika_network_sent_bytes_rate", map[string]string{"agent_id": "a1", "interface": "eth0"}, start, 1)
+	writeSamples(t, store, "pika_network_sent_bytes_rate", map[string]string{"agent_id": "a2", "interface": "wlan0"}, start, 1)
+
+	values, err := store.GetLabelValues(context.Background(), "interface", []string{`pika_network_sent_bytes_rate{agent_id="a1"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values[0] != "eth0" {
+		t.Fatalf("unexpected label values: %v", values)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	valid := []string{
+		`pika_cpu_usage_percent{agent_id="a1"}`,
+		`pika_disk_usage_percent{agent_id="a1",mount_point=""}`,
+		`sum(pika_network_recv_bytes_rate{agent_id="a1"}) by (agent_id)`,
+		`avg_over_time((pika_cpu_usage_percent{agent_id="a1"})[300s:])`,
+		`sum by (agent_id) (count_over_time(pika_monitor_status{monitor_id="m1",agent_id=~"a1|a2"}[24h]))`,
+		`rate(pika_requests_total[5m])`,
+	}
+	for _, q := range valid {
+		if _, err := parseQuery(q); err != nil {
+			t.Errorf("parseQuery(%q): %v", q, err)
+		}
+	}
+
+	invalid := []string{
+		`pika_cpu_usage_percent{agent_id="a1"`,
+		`histogram_quantile(0.9, pika_latency)`,
+		`rate(sum(pika_requests_total))`,
+	}
+	for _, q := range invalid {
+		if _, err := parseQuery(q); err == nil {
+			t.Errorf("parseQuery(%q) should fail", q)
+		}
+	}
+}
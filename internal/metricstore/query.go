@@ -0,0 +1,423 @@
+package metricstore
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 内置存储支持的 PromQL 子集：
+//   - 选择器：name{label="v", label!="v", label=~"re", label!~"re"}
+//   - 聚合：sum/avg/min/max/count，支持 by (...) / without (...)
+//   - 区间函数：avg/min/max/sum/count/last_over_time、rate、increase，参数为 selector[5m] 或子查询 (expr)[5m:]
+
+type node interface{}
+
+// selectorExpr 时间序列选择器
+type selectorExpr struct {
+	matchers []labelMatcher
+}
+
+// aggregateExpr 聚合表达式
+type aggregateExpr struct {
+	op      string
+	labels  []string
+	without bool
+	expr    node
+}
+
+// rollupExpr 区间函数表达式
+type rollupExpr struct {
+	fn       string
+	expr     node
+	window   time.Duration
+	subquery bool
+	subStep  time.Duration
+}
+
+// labelMatcher 标签匹配条件
+type labelMatcher struct {
+	name  string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (m labelMatcher) matches(labels map[string]string) bool {
+	v := labels[m.name]
+	switch m.op {
+	case "=":
+		return v == m.value
+	case "!=":
+		return v != m.value
+	case "=~":
+		return m.re.MatchString(v)
+	case "!~":
+		return !m.re.MatchString(v)
+	}
+	return false
+}
+
+func (s *selectorExpr) matches(labels map[string]string) bool {
+	for _, m := range s.matchers {
+		if !m.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+var aggregateOps = map[string]bool{"sum": true, "avg": true, "min": true, "max": true, "count": true}
+
+var rollupFuncs = map[string]bool{
+	"avg_over_time":   true,
+	"min_over_time":   true,
+	"max_over_time":   true,
+	"sum_over_time":   true,
+	"count_over_time": true,
+	"last_over_time":  true,
+	"rate":            true,
+	"increase":        true,
+}
+
+// parser PromQL 子集解析器
+type parser struct {
+	input string
+	pos   int
+}
+
+// parseQuery 解析查询语句
+func parseQuery(query string) (node, error) {
+	p := &parser{input: query}
+	n, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return n, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("unsupported query at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) readIdent() string {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) {
+		c := p.input[p.pos]
+		if c == '_' || c == ':' || unicode.IsLetter(rune(c)) || (p.pos > start && unicode.IsDigit(rune(c))) {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) parseExpr() (node, error) {
+	if p.peek() == '(' {
+		p.pos++
+		n, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return n, p.expect(')')
+	}
+	if p.peek() == '{' {
+		return p.parseSelector("")
+	}
+
+	ident := p.readIdent()
+	if ident == "" {
+		return nil, p.errorf("expected expression")
+	}
+
+	switch {
+	case aggregateOps[ident]:
+		return p.parseAggregate(ident)
+	case rollupFuncs[ident]:
+		return p.parseRollup(ident)
+	default:
+		return p.parseSelector(ident)
+	}
+}
+
+func (p *parser) parseAggregate(op string) (node, error) {
+	agg := &aggregateExpr{op: op}
+
+	// 支持 sum by (a) (expr) 与 sum(expr) by (a) 两种写法
+	if err := p.parseGrouping(agg); err != nil {
+		return nil, err
+	}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+	inner, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	agg.expr = inner
+	if err := p.parseGrouping(agg); err != nil {
+		return nil, err
+	}
+	return agg, nil
+}
+
+func (p *parser) parseGrouping(agg *aggregateExpr) error {
+	save := p.pos
+	keyword := p.readIdent()
+	if keyword != "by" && keyword != "without" {
+		p.pos = save
+		return nil
+	}
+	agg.without = keyword == "without"
+	if err := p.expect('('); err != nil {
+		return err
+	}
+	for p.peek() != ')' {
+		label := p.readIdent()
+		if label == "" {
+			return p.errorf("expected label name")
+		}
+		agg.labels = append(agg.labels, label)
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) parseRollup(fn string) (node, error) {
+	rollup := &rollupExpr{fn: fn}
+	if err := p.expect('('); err != nil {
+		return nil, err
+	}
+
+	if p.peek() == '(' {
+		// 子查询：(expr)[window:step]
+		p.pos++
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(')'); err != nil {
+			return nil, err
+		}
+		rollup.expr = inner
+		rollup.subquery = true
+	} else {
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := inner.(*selectorExpr); !ok {
+			return nil, p.errorf("%s requires a range vector", fn)
+		}
+		rollup.expr = inner
+	}
+
+	if err := p.expect('['); err != nil {
+		return nil, err
+	}
+	window, err := p.parseDuration()
+	if err != nil {
+		return nil, err
+	}
+	rollup.window = window
+	if p.peek() == ':' {
+		p.pos++
+		rollup.subquery = true
+		if p.peek() != ']' {
+			step, err := p.parseDuration()
+			if err != nil {
+				return nil, err
+			}
+			rollup.subStep = step
+		}
+	}
+	if err := p.expect(']'); err != nil {
+		return nil, err
+	}
+	if err := p.expect(')'); err != nil {
+		return nil, err
+	}
+	return rollup, nil
+}
+
+func (p *parser) parseDuration() (time.Duration, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && (unicode.IsDigit(rune(p.input[p.pos])) || unicode.IsLetter(rune(p.input[p.pos]))) {
+		p.pos++
+	}
+	d, err := parseDuration(p.input[start:p.pos])
+	if err != nil {
+		return 0, p.errorf("%v", err)
+	}
+	return d, nil
+}
+
+// parseDuration 解析 PromQL 时长，支持 ms/s/m/h/d/w 及其组合（如 1h30m）
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	units := map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+	}
+
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && unicode.IsDigit(rune(s[i])) {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, err := strconv.ParseInt(s[:i], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		s = s[i:]
+
+		j := 0
+		for j < len(s) && unicode.IsLetter(rune(s[j])) {
+			j++
+		}
+		unit, ok := units[s[:j]]
+		if !ok {
+			return 0, fmt.Errorf("invalid duration unit %q", s[:j])
+		}
+		total += time.Duration(n) * unit
+		s = s[j:]
+	}
+	return total, nil
+}
+
+func (p *parser) parseSelector(name string) (node, error) {
+	sel := &selectorExpr{}
+	if name != "" {
+		sel.matchers = append(sel.matchers, labelMatcher{name: "__name__", op: "=", value: name})
+	}
+	if p.peek() != '{' {
+		if name == "" {
+			return nil, p.errorf("empty selector")
+		}
+		return sel, nil
+	}
+	p.pos++
+
+	for p.peek() != '}' {
+		label := p.readIdent()
+		if label == "" {
+			return nil, p.errorf("expected label name")
+		}
+		op, err := p.parseMatchOp()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.parseString()
+		if err != nil {
+			return nil, err
+		}
+
+		m := labelMatcher{name: label, op: op, value: value}
+		if op == "=~" || op == "!~" {
+			re, err := regexp.Compile("^(?:" + value + ")$")
+			if err != nil {
+				return nil, p.errorf("invalid regexp %q: %v", value, err)
+			}
+			m.re = re
+		}
+		sel.matchers = append(sel.matchers, m)
+
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+	p.pos++
+
+	if len(sel.matchers) == 0 {
+		return nil, p.errorf("empty selector")
+	}
+	return sel, nil
+}
+
+func (p *parser) parseMatchOp() (string, error) {
+	p.skipSpace()
+	for _, op := range []string{"=~", "!~", "!=", "="} {
+		if strings.HasPrefix(p.input[p.pos:], op) {
+			p.pos += len(op)
+			return op, nil
+		}
+	}
+	return "", p.errorf("expected label matcher")
+}
+
+func (p *parser) parseString() (string, error) {
+	quote := p.peek()
+	if quote != '"' && quote != '\'' && quote != '`' {
+		return "", p.errorf("expected string")
+	}
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.input) && p.input[p.pos] != quote {
+		if p.input[p.pos] == '\\' && quote != '`' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		return "", p.errorf("unterminated string")
+	}
+	p.pos++
+
+	raw := p.input[start:p.pos]
+	if quote == '\'' {
+		raw = `"` + strings.ReplaceAll(raw[1:len(raw)-1], `"`, `\"`) + `"`
+	}
+	value, err := strconv.Unquote(raw)
+	if err != nil {
+		return "", p.errorf("invalid string %s", raw)
+	}
+	return value, nil
+}
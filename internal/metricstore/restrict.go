@@ -0,0 +1,258 @@
+package metricstore
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// promqlKeywords 不是指标名称的标识符
+var promqlKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "bool": true,
+	"by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true, "offset": true,
+	"inf": true, "nan": true, "keep_metric_names": true,
+}
+
+// groupingKeywords 后面跟标签列表的关键字，如 by (agent_id)
+var groupingKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true,
+	"group_left": true, "group_right": true,
+}
+
+// RestrictQuery 为 PromQL 中的每个序列选择器追加 label=~"v1|v2" 条件，用于限制可查询的序列范围
+// values 为空时追加一个不可能匹配的条件，查询结果为空；不带该标签的序列不会被匹配
+// 无法完整解析的选择器（如引号包裹的指标名、WITH 模板）返回错误，而不是原样放行
+func RestrictQuery(query string, label string, values []string) (string, error) {
+	escaped := make([]string, 0, len(values))
+	for _, v := range values {
+		escaped = append(escaped, regexp.QuoteMeta(v))
+	}
+	pattern := strings.Join(escaped, "|")
+	if pattern == "" {
+		pattern = "^$"
+	}
+	matcher := fmt.Sprintf(`%s=~%q`, label, pattern)
+
+	var out strings.Builder
+	n := len(query)
+	i := 0
+	// 读取标识符
+	readIdent := func(start int) int {
+		j := start
+		for j < n && isIdentChar(query[j], j == start) {
+			j++
+		}
+		return j
+	}
+	skipSpace := func(j int) int {
+		for j < n && (query[j] == ' ' || query[j] == '\t' || query[j] == '\n' || query[j] == '\r') {
+			j++
+		}
+		return j
+	}
+	// 复制到匹配的闭合括号（跳过字符串），返回闭合括号之后的位置
+	copyUntil := func(j int, closing byte) (int, error) {
+		for j < n {
+			c := query[j]
+			if c == '"' || c == '\'' || c == '`' {
+				end, err := skipString(query, j)
+				if err != nil {
+					return 0, err
+				}
+				out.WriteString(query[j:end])
+				j = end
+				continue
+			}
+			out.WriteByte(c)
+			j++
+			if c == closing {
+				return j, nil
+			}
+		}
+		return 0, fmt.Errorf("unclosed %q in query", closing)
+	}
+	// 解析 { 中的标签条件，为每个条件组插入限制条件
+	// VictoriaMetrics 支持 {a="x" or b="y"} 语法，每个 or 分支都需要追加条件，无法完整解析的选择器直接拒绝
+	writeSelector := func(j int) (int, error) {
+		groups := [][]string{nil}
+		k := j + 1
+		for {
+			k = skipSpace(k)
+			if k >= n {
+				return 0, fmt.Errorf("unclosed '{' in query")
+			}
+			if query[k] == '}' {
+				k++
+				break
+			}
+			end := readIdent(k)
+			if end == k {
+				return 0, fmt.Errorf("unsupported label matcher at position %d", k)
+			}
+			name := query[k:end]
+			next := skipSpace(end)
+			if strings.EqualFold(name, "or") && next < n && isIdentChar(query[next], true) {
+				if len(groups[len(groups)-1]) == 0 {
+					return 0, fmt.Errorf("empty label matcher group at position %d", k)
+				}
+				groups = append(groups, nil)
+				k = next
+				continue
+			}
+			op := ""
+			for _, candidate := range []string{"=~", "!~", "!=", "="} {
+				if strings.HasPrefix(query[next:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return 0, fmt.Errorf("unsupported label matcher at position %d", next)
+			}
+			valueStart := skipSpace(next + len(op))
+			if valueStart >= n || (query[valueStart] != '"' && query[valueStart] != '\'' && query[valueStart] != '`') {
+				return 0, fmt.Errorf("label matcher value must be a string at position %d", valueStart)
+			}
+			valueEnd, err := skipString(query, valueStart)
+			if err != nil {
+				return 0, err
+			}
+			groups[len(groups)-1] = append(groups[len(groups)-1], name+op+query[valueStart:valueEnd])
+
+			k = skipSpace(valueEnd)
+			switch {
+			case k < n && query[k] == ',':
+				k++
+			case k < n && query[k] == '}':
+			case strings.EqualFold(query[k:readIdent(k)], "or"):
+			default:
+				return 0, fmt.Errorf("unexpected character in selector at position %d", k)
+			}
+		}
+		if len(groups) > 1 && len(groups[len(groups)-1]) == 0 {
+			return 0, fmt.Errorf("empty label matcher group in query")
+		}
+
+		out.WriteByte('{')
+		for gi, group := range groups {
+			if gi > 0 {
+				out.WriteString(" or ")
+			}
+			out.WriteString(matcher)
+			for _, m := range group {
+				out.WriteByte(',')
+				out.WriteString(m)
+			}
+		}
+		out.WriteByte('}')
+		return k, nil
+	}
+
+	for i < n {
+		c := query[i]
+		switch {
+		case c == '#':
+			// 注释，跳到行尾
+			for i < n && query[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '\'' || c == '`':
+			end, err := skipString(query, i)
+			if err != nil {
+				return "", err
+			}
+			out.WriteString(query[i:end])
+			i = end
+		case c == '{':
+			end, err := writeSelector(i)
+			if err != nil {
+				return "", err
+			}
+			i = end
+		case c == '[':
+			end, err := copyUntil(i, ']')
+			if err != nil {
+				return "", err
+			}
+			i = end
+		case c >= '0' && c <= '9' || (c == '.' && i+1 < n && query[i+1] >= '0' && query[i+1] <= '9'):
+			// 数字或时长
+			j := i
+			for j < n && (isIdentChar(query[j], false) || query[j] == '.') {
+				j++
+			}
+			out.WriteString(query[i:j])
+			i = j
+		case isIdentChar(c, true):
+			j := readIdent(i)
+			ident := query[i:j]
+			out.WriteString(ident)
+			k := skipSpace(j)
+			lower := strings.ToLower(ident)
+			switch {
+			case lower == "with" && k < n && query[k] == '(':
+				// WITH 模板可以把选择器拆散定义，无法可靠地追加条件
+				return "", fmt.Errorf("WITH templates are not supported")
+			case groupingKeywords[lower] && k < n && query[k] == '(':
+				// 标签列表，原样复制
+				out.WriteString(query[j:k])
+				end, err := copyUntil(k, ')')
+				if err != nil {
+					return "", err
+				}
+				i = end
+			case promqlKeywords[lower]:
+				i = j
+			case k < n && query[k] == '(':
+				// 函数或聚合
+				i = j
+			case isGroupingClause(query, k, readIdent):
+				// 聚合操作符后跟 by/without，如 sum by (agent_id) (...)
+				i = j
+			case k < n && query[k] == '{':
+				out.WriteString(query[j:k])
+				end, err := writeSelector(k)
+				if err != nil {
+					return "", err
+				}
+				i = end
+			default:
+				// 仅有指标名称的选择器
+				out.WriteString("{" + matcher + "}")
+				i = j
+			}
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String(), nil
+}
+
+func isGroupingClause(query string, start int, readIdent func(int) int) bool {
+	next := strings.ToLower(query[start:readIdent(start)])
+	return next == "by" || next == "without"
+}
+
+func isIdentChar(c byte, first bool) bool {
+	if c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		return true
+	}
+	return !first && c >= '0' && c <= '9'
+}
+
+// skipString 返回字符串字面量结束后的位置
+func skipString(query string, start int) (int, error) {
+	quote := query[start]
+	for j := start + 1; j < len(query); j++ {
+		if query[j] == '\\' && quote != '`' {
+			j++
+			continue
+		}
+		if query[j] == quote {
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string in query")
+}
@@ -0,0 +1,63 @@
+package metricstore
+
+import "testing"
+
+func TestRestrictQuery(t *testing.T) {
+	const m = `agent_id=~"a1|a\\.2"`
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{`pika_cpu_usage_percent`, `pika_cpu_usage_percent{` + m + `}`},
+		{`pika_cpu_usage_percent{}`, `pika_cpu_usage_percent{` + m + `}`},
+		{`pika_disk_usage_percent{mount_point="/"}`, `pika_disk_usage_percent{` + m + `,mount_point="/"}`},
+		{`{__name__=~"pika_.*"}`, `{` + m + `,__name__=~"pika_.*"}`},
+		{
+			`sum by (agent_id) (rate(pika_network_sent_bytes_total[5m] offset 1h))`,
+			`sum by (agent_id) (rate(pika_network_sent_bytes_total{` + m + `}[5m] offset 1h))`,
+		},
+		{
+			`avg_over_time((pika_cpu_usage_percent > 0.5)[1h:5m]) / on(agent_id) group_left(name) pika_agent_up`,
+			`avg_over_time((pika_cpu_usage_percent{` + m + `} > 0.5)[1h:5m]) / on(agent_id) group_left(name) pika_agent_up{` + m + `}`,
+		},
+		{
+			`label_replace(up{job="x"}, "dst", "$1", "src", "(.*)") and NaN`,
+			`label_replace(up{` + m + `,job="x"}, "dst", "$1", "src", "(.*)") and NaN`,
+		},
+		{`topk(5, pika_memory_usage_percent) # comment`, `topk(5, pika_memory_usage_percent{` + m + `}) `},
+		// VictoriaMetrics 的 or 选择器，每个分支都要追加条件
+		{`up{a="x" or b="y"}`, `up{` + m + `,a="x" or ` + m + `,b="y"}`},
+		{`{__name__="up" or agent_id="other", job!~"x"}`, `{` + m + `,__name__="up" or ` + m + `,agent_id="other",job!~"x"}`},
+		{`up{ a = "x", }`, `up{` + m + `,a="x"}`},
+		{`up{or="x"}`, `up{` + m + `,or="x"}`},
+		// 注释与字符串中的括号不影响解析
+		{"up # {a=\"x\" or b=\"y\"}\n", "up{" + m + "} \n"},
+		{`label_replace(up, "dst", "}", "src", "{")`, `label_replace(up{` + m + `}, "dst", "}", "src", "{")`},
+	}
+	for _, tc := range cases {
+		got, err := RestrictQuery(tc.query, "agent_id", []string{"a1", "a.2"})
+		if err != nil {
+			t.Errorf("RestrictQuery(%q): %v", tc.query, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("RestrictQuery(%q)\n got %s\nwant %s", tc.query, got, tc.want)
+		}
+	}
+
+	// 无法完整解析的选择器必须拒绝，避免条件被绕过
+	for _, query := range []string{
+		`up{job="x"`,
+		`up{a="x" b="y"}`,
+		`up{a="x" or}`,
+		`up{or b="y"}`,
+		`up{"quoted.name", a="x"}`,
+		`up{a=x}`,
+		`up{a="x" # }`,
+		`WITH (f = up) f`,
+	} {
+		if got, err := RestrictQuery(query, "agent_id", nil); err == nil {
+			t.Errorf("RestrictQuery(%q) should fail, got %s", query, got)
+		}
+	}
+}
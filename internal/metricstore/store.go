@@ -0,0 +1,89 @@
+package metricstore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dushixiang/pika/internal/vmclient"
+)
+
+// MetricStore 时序数据存储
+// VictoriaMetrics 客户端与内置存储均实现该接口，查询语句使用 PromQL（内置存储仅支持 Pika 自身使用的子集）
+type MetricStore interface {
+	// Write 写入指标
+	Write(ctx context.Context, metrics []vmclient.Metric) error
+	// QueryRange 范围查询，step 为 0 时自动选择步长
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (*vmclient.QueryResult, error)
+	// Query 即时查询，返回每条序列的最新值
+	Query(ctx context.Context, query string) (*vmclient.QueryResult, error)
+	// GetLabelValues 获取匹配序列中指定标签的所有值
+	GetLabelValues(ctx context.Context, labelName string, match []string) ([]string, error)
+}
+
+// Runner 需要后台任务（定时落盘、过期清理）的存储实现
+type Runner interface {
+	Run(ctx context.Context)
+}
+
+// TieredRetention 支持按指标名称后缀设置保留时长的存储，用于降采样数据的分层保留
+type TieredRetention interface {
+	SetTierRetention(suffix string, retention time.Duration)
+}
+
+var _ MetricStore = (*vmclient.VMClient)(nil)
+
+// labelValues 从匹配的序列标签中收集指定标签的值
+func labelValues(labelSets []map[string]string, labelName string, match []*selectorExpr) []string {
+	seen := make(map[string]struct{})
+	values := make([]string, 0)
+	for _, labels := range labelSets {
+		if len(match) > 0 {
+			matched := false
+			for _, sel := range match {
+				if sel.matches(labels) {
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				continue
+			}
+		}
+		v, ok := labels[labelName]
+		if !ok || v == "" {
+			continue
+		}
+		if _, ok := seen[v]; !ok {
+			seen[v] = struct{}{}
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// parseMatchers 解析 GetLabelValues 的序列选择器
+func parseMatchers(match []string) ([]*selectorExpr, error) {
+	selectors := make([]*selectorExpr, 0, len(match))
+	for _, m := range match {
+		n, err := parseQuery(m)
+		if err != nil {
+			return nil, err
+		}
+		sel, ok := n.(*selectorExpr)
+		if !ok {
+			return nil, fmt.Errorf("match[] must be a series selector: %s", m)
+		}
+		selectors = append(selectors, sel)
+	}
+	return selectors, nil
+}
+
+// metricLabels 合并指标名称与标签
+func metricLabels(m vmclient.Metric) map[string]string {
+	labels := make(map[string]string, len(m.Metric))
+	for k, v := range m.Metric {
+		labels[k] = v
+	}
+	return labels
+}
@@ -4,6 +4,7 @@ import (
 	"net"
 	"strings"
 
+	"github.com/dushixiang/pika/internal/protocol"
 	"gorm.io/datatypes"
 )
 
@@ -36,6 +37,18 @@ type Agent struct {
 
 	// SSH登录监控配置
 	SSHLoginConfig datatypes.JSONType[SSHLoginConfigData] `json:"sshLoginConfig,omitempty"` // SSH登录监控配置
+
+	// 配置模板应用状态
+	ConfigProfileStatus datatypes.JSONType[ConfigProfileStatusData] `json:"configProfileStatus,omitempty"` // 配置模板应用状态
+
+	// 探针自身运行状态
+	Health datatypes.JSONType[protocol.AgentHealth] `json:"health,omitempty"` // 探针最近一次上报的运行状态
+
+	// 日志监控规则应用状态
+	LogWatchStatus datatypes.JSONType[LogWatchStatusData] `json:"logWatchStatus,omitempty"` // 日志监控规则应用状态
+
+	// 系统日志转发配置
+	LogForwardConfig datatypes.JSONType[LogForwardConfigData] `json:"logForwardConfig,omitempty"` // 系统日志转发配置
 }
 
 // TrafficStatsData 流量统计数据
@@ -62,6 +75,34 @@ type TamperProtectConfigData struct {
 	ApplyMessage string   `json:"applyMessage,omitempty"` // 应用结果消息
 }
 
+// ConfigProfileStatusData 配置模板应用状态数据
+type ConfigProfileStatusData struct {
+	ProfileID    string `json:"profileId,omitempty"`    // 下发的配置模板ID，为空表示使用探针本地配置
+	Version      string `json:"version,omitempty"`      // 下发的配置版本
+	ApplyStatus  string `json:"applyStatus,omitempty"`  // 配置应用状态: success/failed/pending
+	ApplyMessage string `json:"applyMessage,omitempty"` // 应用结果消息
+	AppliedAt    int64  `json:"appliedAt,omitempty"`    // 探针反馈时间（时间戳毫秒）
+}
+
+// LogWatchStatusData 日志监控规则应用状态数据
+type LogWatchStatusData struct {
+	Version      string   `json:"version,omitempty"`      // 下发的规则版本
+	ApplyStatus  string   `json:"applyStatus,omitempty"`  // 规则应用状态: success/failed/pending
+	ApplyMessage string   `json:"applyMessage,omitempty"` // 应用结果消息
+	Files        []string `json:"files,omitempty"`        // 探针当前跟踪的文件
+	AppliedAt    int64    `json:"appliedAt,omitempty"`    // 探针反馈时间（时间戳毫秒）
+}
+
+// LogForwardConfigData 系统日志转发配置数据
+type LogForwardConfigData struct {
+	Enabled      bool     `json:"enabled"`                // 是否启用
+	Units        []string `json:"units,omitempty"`        // 只转发这些 systemd 单元，为空表示全部
+	Priority     int      `json:"priority"`               // 转发的最低级别（0-emerg ... 7-debug），转发小于等于该值的日志
+	Source       string   `json:"source,omitempty"`       // 探针使用的日志来源: journald 或 syslog 文件路径
+	ApplyStatus  string   `json:"applyStatus,omitempty"`  // 配置应用状态: success/failed/pending
+	ApplyMessage string   `json:"applyMessage,omitempty"` // 应用结果消息
+}
+
 // SSHLoginConfigData SSH登录监控配置数据
 type SSHLoginConfigData struct {
 	Enabled      bool     `json:"enabled"`                // 是否启用
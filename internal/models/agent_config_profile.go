@@ -0,0 +1,40 @@
+package models
+
+import (
+	"slices"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"gorm.io/datatypes"
+)
+
+// AgentConfigProfile 探针配置模板，可分配给指定探针或按标签匹配，由服务端下发到探针并在运行时生效
+type AgentConfigProfile struct {
+	ID          string                                         `gorm:"primaryKey" json:"id"`                  // 模板ID (UUID)
+	Name        string                                         `gorm:"index" json:"name"`                     // 模板名称
+	Description string                                         `json:"description"`                           // 描述
+	Priority    int                                            `gorm:"default:0" json:"priority"`             // 优先级，探针匹配多个模板时使用优先级最高的
+	AgentIDs    datatypes.JSONSlice[string]                    `json:"agentIds"`                              // 直接分配的探针，优先于标签匹配
+	Tags        datatypes.JSONSlice[string]                    `json:"tags"`                                  // 按标签分配，探针包含任一标签即匹配
+	Config      datatypes.JSONType[protocol.RemoteAgentConfig] `json:"config"`                                // 配置内容
+	CreatedAt   int64                                          `json:"createdAt"`                             // 创建时间（时间戳毫秒）
+	UpdatedAt   int64                                          `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (AgentConfigProfile) TableName() string {
+	return "agent_config_profiles"
+}
+
+// AssignedTo 是否直接分配给指定探针
+func (p AgentConfigProfile) AssignedTo(agentID string) bool {
+	return slices.Contains(p.AgentIDs, agentID)
+}
+
+// MatchesTags 探针标签是否与模板标签有交集
+func (p AgentConfigProfile) MatchesTags(tags []string) bool {
+	for _, tag := range p.Tags {
+		if slices.Contains(tags, tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,16 @@
+package models
+
+// LogEntry 探针转发的系统日志（journald/syslog），按保留天数定期清理
+type LogEntry struct {
+	ID         int64  `gorm:"primaryKey;autoIncrement" json:"id"`                                                  // 记录ID
+	AgentID    string `gorm:"index:idx_log_entries_agent_ts,priority:1;not null" json:"agentId"`                   // 探针ID
+	Timestamp  int64  `gorm:"index:idx_log_entries_agent_ts,priority:2;index:idx_log_entries_ts" json:"timestamp"` // 日志时间（毫秒时间戳）
+	Priority   int    `json:"priority"`                                                                            // 级别（0-emerg ... 7-debug）
+	Unit       string `gorm:"index" json:"unit,omitempty"`                                                         // systemd 单元
+	Identifier string `json:"identifier,omitempty"`                                                                // 程序名
+	Message    string `json:"message"`                                                                             // 日志内容
+}
+
+func (LogEntry) TableName() string {
+	return "log_entries"
+}
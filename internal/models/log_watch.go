@@ -0,0 +1,65 @@
+package models
+
+import (
+	"slices"
+
+	"gorm.io/datatypes"
+)
+
+// LogWatchRule 日志监控规则，下发到匹配的探针，探针跟踪日志文件并上报匹配的行
+type LogWatchRule struct {
+	ID          string                      `gorm:"primaryKey" json:"id"`                  // 规则ID (UUID)
+	Name        string                      `gorm:"index" json:"name"`                     // 规则名称
+	Description string                      `json:"description"`                           // 描述
+	Enabled     bool                        `json:"enabled"`                               // 是否启用
+	Path        string                      `json:"path"`                                  // 日志文件路径，支持通配符
+	Pattern     string                      `json:"pattern"`                               // 匹配行的正则表达式
+	Exclude     string                      `json:"exclude"`                               // 排除行的正则表达式，可选
+	Level       string                      `json:"level"`                                 // 事件级别: info/warning/critical
+	Window      int                         `json:"window"`                                // 限流窗口（秒），同一文件每个窗口最多上报一次事件
+	SampleLines int                         `json:"sampleLines"`                           // 每个事件携带的样例行数
+	Notify      bool                        `json:"notify"`                                // 匹配时是否发送通知
+	AgentIDs    datatypes.JSONSlice[string] `json:"agentIds"`                              // 指定的探针，与标签均为空时应用到所有探针
+	Tags        datatypes.JSONSlice[string] `json:"tags"`                                  // 按标签匹配，探针包含任一标签即应用
+	CreatedAt   int64                       `json:"createdAt"`                             // 创建时间（时间戳毫秒）
+	UpdatedAt   int64                       `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (LogWatchRule) TableName() string {
+	return "log_watch_rules"
+}
+
+// AppliesTo 规则是否应用到指定探针
+func (r LogWatchRule) AppliesTo(agent *Agent) bool {
+	if len(r.AgentIDs) == 0 && len(r.Tags) == 0 {
+		return true
+	}
+	if slices.Contains(r.AgentIDs, agent.ID) {
+		return true
+	}
+	for _, tag := range r.Tags {
+		if slices.Contains(agent.Tags, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// LogWatchEvent 日志匹配事件，限流窗口内同一文件的匹配合并为一个事件
+type LogWatchEvent struct {
+	ID        string                      `gorm:"primaryKey" json:"id"`          // 事件ID (UUID)
+	AgentID   string                      `gorm:"index;not null" json:"agentId"` // 探针ID
+	RuleID    string                      `gorm:"index" json:"ruleId"`           // 规则ID
+	RuleName  string                      `json:"ruleName"`                      // 规则名称
+	Path      string                      `gorm:"index" json:"path"`             // 日志文件路径
+	Level     string                      `gorm:"index" json:"level"`            // 事件级别
+	Count     int                         `json:"count"`                         // 合并的匹配行数
+	Lines     datatypes.JSONSlice[string] `json:"lines"`                         // 样例行
+	FirstAt   int64                       `json:"firstAt"`                       // 第一条匹配时间（毫秒时间戳）
+	LastAt    int64                       `gorm:"index" json:"lastAt"`           // 最后一条匹配时间（毫秒时间戳）
+	CreatedAt int64                       `json:"createdAt"`                     // 记录创建时间（毫秒）
+}
+
+func (LogWatchEvent) TableName() string {
+	return "log_watch_events"
+}
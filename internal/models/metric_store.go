@@ -0,0 +1,25 @@
+package models
+
+// MetricSeries 内置时序存储的序列（未启用 VictoriaMetrics 时使用）
+type MetricSeries struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement" json:"id"` // 序列ID
+	Hash      string `gorm:"uniqueIndex" json:"hash"`            // 标签集合的哈希
+	Name      string `gorm:"index" json:"name"`                  // 指标名称
+	Labels    string `gorm:"type:text" json:"labels"`            // 标签集合（JSON）
+	CreatedAt int64  `json:"createdAt"`                          // 创建时间（时间戳毫秒）
+}
+
+func (MetricSeries) TableName() string {
+	return "metric_series"
+}
+
+// MetricSample 内置时序存储的样本
+type MetricSample struct {
+	SeriesID  int64   `gorm:"primaryKey;autoIncrement:false" json:"seriesId"`        // 序列ID
+	Timestamp int64   `gorm:"primaryKey;autoIncrement:false;index" json:"timestamp"` // 时间戳（毫秒）
+	Value     float64 `json:"value"`                                                 // 值
+}
+
+func (MetricSample) TableName() string {
+	return "metric_samples"
+}
@@ -0,0 +1,17 @@
+package models
+
+// MonitorIncident 监控故障记录：单个探针上一次 up→down→up 的完整过程
+type MonitorIncident struct {
+	ID        int64  `gorm:"primaryKey;autoIncrement" json:"id"` // 故障ID
+	MonitorID string `gorm:"index" json:"monitorId"`             // 监控任务ID
+	AgentID   string `gorm:"index" json:"agentId"`               // 探针ID
+	StartedAt int64  `gorm:"index" json:"startedAt"`             // 故障开始时间（时间戳毫秒）
+	EndedAt   int64  `gorm:"index" json:"endedAt"`               // 故障恢复时间（时间戳毫秒），0 表示尚未恢复
+	Duration  int64  `json:"duration"`                           // 故障持续时长（毫秒）
+	Error     string `json:"error"`                              // 首次失败的错误信息
+	LastError string `json:"lastError"`                          // 最近一次失败的错误信息
+}
+
+func (MonitorIncident) TableName() string {
+	return "monitor_incidents"
+}
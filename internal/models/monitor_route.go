@@ -0,0 +1,26 @@
+package models
+
+import (
+	"github.com/dushixiang/pika/internal/protocol"
+	"gorm.io/datatypes"
+)
+
+// MonitorRouteSnapshot 路由追踪路径快照
+type MonitorRouteSnapshot struct {
+	ID        int64                                       `gorm:"primaryKey;autoIncrement" json:"id"` // 快照ID
+	MonitorID string                                      `gorm:"index" json:"monitorId"`             // 监控任务ID
+	AgentID   string                                      `gorm:"index" json:"agentId"`               // 探针ID
+	Target    string                                      `json:"target"`                             // 追踪目标
+	Path      string                                      `json:"path"`                               // 逐跳 IP 路径，无响应的跳记为 *
+	ASPath    string                                      `json:"asPath"`                             // AS 路径（相邻重复的 AS 已合并）
+	HopCount  int                                         `json:"hopCount"`                           // 跳数
+	Reached   bool                                        `json:"reached"`                            // 是否到达目标
+	Changed   bool                                        `gorm:"index" json:"changed"`               // 与上一次快照相比 IP 路径是否变化
+	ASChanged bool                                        `json:"asChanged"`                          // 与上一次快照相比 AS 路径是否变化
+	Hops      datatypes.JSONSlice[protocol.TracerouteHop] `json:"hops"`                               // 逐跳统计
+	CreatedAt int64                                       `gorm:"index" json:"createdAt"`             // 创建时间（时间戳毫秒）
+}
+
+func (MonitorRouteSnapshot) TableName() string {
+	return "monitor_route_snapshots"
+}
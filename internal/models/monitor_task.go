@@ -7,22 +7,30 @@ import (
 
 // MonitorTask 描述一个服务监控任务
 type MonitorTask struct {
-	ID               string                                         `gorm:"primaryKey" json:"id"`                  // 任务 ID
-	Name             string                                         `gorm:"uniqueIndex" json:"name"`               // 任务名称
-	Type             string                                         `gorm:"index" json:"type"`                     // 监控类型 http/tcp
-	Target           string                                         `json:"target"`                                // 目标地址
-	Description      string                                         `json:"description"`                           // 描述信息
-	Enabled          bool                                           `json:"enabled"`                               // 是否启用
-	ShowTargetPublic bool                                           `json:"showTargetPublic"`                      // 在公开页面是否显示目标地址
-	Visibility       string                                         `gorm:"default:public" json:"visibility"`      // 可见性: public-匿名可见, private-登录可见
-	Interval         int                                            `json:"interval"`                              // 检测频率（秒），默认 60
-	AgentIds         datatypes.JSONSlice[string]                    `json:"agentIds"`                              // 指定的探针 ID 列表（JSON 数组）
-	AgentNames       []string                                       `gorm:"-" json:"agentNames"`                   // 指定的探针名称列表
-	HTTPConfig       datatypes.JSONType[protocol.HTTPMonitorConfig] `json:"httpConfig"`                            // HTTP 监控配置
-	TCPConfig        datatypes.JSONType[protocol.TCPMonitorConfig]  `json:"tcpConfig"`                             // TCP 监控配置
-	ICMPConfig       datatypes.JSONType[protocol.ICMPMonitorConfig] `json:"icmpConfig"`                            // ICMP 监控配置
-	CreatedAt        int64                                          `gorm:"autoCreateTime:milli" json:"createdAt"` // 创建时间
-	UpdatedAt        int64                                          `gorm:"autoUpdateTime:milli" json:"updatedAt"` // 更新时间
+	ID               string                                               `gorm:"primaryKey" json:"id"`                             // 任务 ID
+	Name             string                                               `gorm:"uniqueIndex" json:"name"`                          // 任务名称
+	Type             string                                               `gorm:"index" json:"type"`                                // 监控类型 http/tcp/icmp/udp/smtp/ssh/redis/mysql/postgresql/mqtt/traceroute
+	Target           string                                               `json:"target"`                                           // 目标地址
+	Description      string                                               `json:"description"`                                      // 描述信息
+	Enabled          bool                                                 `json:"enabled"`                                          // 是否启用
+	ShowTargetPublic bool                                                 `json:"showTargetPublic"`                                 // 在公开页面是否显示目标地址
+	Visibility       string                                               `gorm:"default:public" json:"visibility"`                 // 可见性: public-匿名可见, private-登录可见
+	Interval         int                                                  `json:"interval"`                                         // 检测频率（秒），默认 60
+	AgentIds         datatypes.JSONSlice[string]                          `json:"agentIds"`                                         // 指定的探针 ID 列表（JSON 数组）
+	AgentNames       []string                                             `gorm:"-" json:"agentNames"`                              // 指定的探针名称列表
+	HTTPConfig       datatypes.JSONType[protocol.HTTPMonitorConfig]       `json:"httpConfig"`                                       // HTTP 监控配置
+	TCPConfig        datatypes.JSONType[protocol.TCPMonitorConfig]        `json:"tcpConfig"`                                        // TCP 监控配置
+	ICMPConfig       datatypes.JSONType[protocol.ICMPMonitorConfig]       `json:"icmpConfig"`                                       // ICMP 监控配置
+	UDPConfig        datatypes.JSONType[protocol.UDPMonitorConfig]        `json:"udpConfig"`                                        // UDP 监控配置
+	SMTPConfig       datatypes.JSONType[protocol.SMTPMonitorConfig]       `json:"smtpConfig"`                                       // SMTP 监控配置
+	SSHConfig        datatypes.JSONType[protocol.SSHMonitorConfig]        `json:"sshConfig"`                                        // SSH 监控配置
+	RedisConfig      datatypes.JSONType[protocol.RedisMonitorConfig]      `json:"redisConfig"`                                      // Redis 监控配置
+	MySQLConfig      datatypes.JSONType[protocol.MySQLMonitorConfig]      `gorm:"column:mysql_config" json:"mysqlConfig"`           // MySQL 监控配置
+	PostgreSQLConfig datatypes.JSONType[protocol.PostgreSQLMonitorConfig] `gorm:"column:postgresql_config" json:"postgresqlConfig"` // PostgreSQL 监控配置
+	MQTTConfig       datatypes.JSONType[protocol.MQTTMonitorConfig]       `json:"mqttConfig"`                                       // MQTT 监控配置
+	TracerouteConfig datatypes.JSONType[protocol.TracerouteMonitorConfig] `json:"tracerouteConfig"`                                 // 路由追踪监控配置
+	CreatedAt        int64                                                `gorm:"autoCreateTime:milli" json:"createdAt"`            // 创建时间
+	UpdatedAt        int64                                                `gorm:"autoUpdateTime:milli" json:"updatedAt"`            // 更新时间
 }
 
 func (MonitorTask) TableName() string {
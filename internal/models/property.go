@@ -109,6 +109,16 @@ func (c *PublicIPConfig) IsIPv6Target(agentID string) bool {
 	return false
 }
 
+// MetricsConfig 指标配置
+type MetricsConfig struct {
+	// 写入时序数据时附带的探针元数据标签，便于直接用 PromQL 按标签聚合
+	// 注意：开启后修改探针名称或标签会产生新的时间序列
+	LabelTags bool `json:"labelTags"` // 附带探针标签（tags，逗号分隔）
+	LabelName bool `json:"labelName"` // 附带探针名称（agent_name）
+	LabelOS   bool `json:"labelOs"`   // 附带操作系统（os）
+	LabelArch bool `json:"labelArch"` // 附带架构（arch）
+}
+
 // AlertConfig 全局告警配置
 type AlertConfig struct {
 	Enabled       bool               `json:"enabled"`       // 是否启用全局告警
@@ -150,6 +160,68 @@ type AlertRules struct {
 	// 探针离线告警配置
 	AgentOfflineEnabled  bool `json:"agentOfflineEnabled"`  // 是否启用探针离线告警
 	AgentOfflineDuration int  `json:"agentOfflineDuration"` // 持续时间（秒）
+
+	// 监控丢包与抖动告警配置（ICMP、多次尝试的 TCP）
+	MonitorLossEnabled     bool    `json:"monitorLossEnabled"`     // 是否启用丢包率告警
+	MonitorLossThreshold   float64 `json:"monitorLossThreshold"`   // 丢包率阈值(0-100)
+	MonitorLossDuration    int     `json:"monitorLossDuration"`    // 持续时间（秒）
+	MonitorJitterEnabled   bool    `json:"monitorJitterEnabled"`   // 是否启用抖动告警
+	MonitorJitterThreshold float64 `json:"monitorJitterThreshold"` // 抖动阈值（毫秒）
+	MonitorJitterDuration  int     `json:"monitorJitterDuration"`  // 持续时间（秒）
+
+	// 路由追踪告警配置
+	RouteChangeEnabled bool    `json:"routeChangeEnabled"` // 是否启用 AS 路径变化告警
+	RouteLossEnabled   bool    `json:"routeLossEnabled"`   // 是否启用路由追踪末跳丢包告警
+	RouteLossThreshold float64 `json:"routeLossThreshold"` // 末跳（最后一个有响应的跳）丢包率阈值(0-100)
+	RouteLossDuration  int     `json:"routeLossDuration"`  // 持续时间（秒）
+
+	// 磁盘容量预测告警配置（按最近数天的已用容量拟合线性趋势）
+	DiskForecastEnabled bool    `json:"diskForecastEnabled"` // 是否启用磁盘写满预测告警
+	DiskForecastDays    float64 `json:"diskForecastDays"`    // 预计剩余天数阈值
+	DiskForecastWindow  int     `json:"diskForecastWindow"`  // 拟合趋势使用的历史天数，默认 7
+
+	// 异常检测告警配置（与按周内小时学习的基线比较）
+	AnomalyEnabled     bool     `json:"anomalyEnabled"`           // 是否启用异常检测告警
+	AnomalySensitivity float64  `json:"anomalySensitivity"`       // 偏离基线的 MAD 倍数，越小越灵敏，默认 3
+	AnomalyDuration    int      `json:"anomalyDuration"`          // 持续时间（秒）
+	AnomalyMetrics     []string `json:"anomalyMetrics,omitempty"` // 检测的指标: cpu, memory, network_upload, network_download, connections，为空时检测全部
+
+	// systemd 单元与容器告警配置
+	UnitFailedEnabled         bool `json:"unitFailedEnabled"`         // 是否启用 systemd 单元失败告警
+	UnitFailedDuration        int  `json:"unitFailedDuration"`        // 持续时间（秒）
+	ContainerRestartEnabled   bool `json:"containerRestartEnabled"`   // 是否启用容器反复重启告警
+	ContainerRestartThreshold int  `json:"containerRestartThreshold"` // 时间窗口内的重启次数阈值
+	ContainerRestartWindow    int  `json:"containerRestartWindow"`    // 时间窗口（秒）
+
+	// 硬件健康告警配置
+	RaidDegradedEnabled      bool    `json:"raidDegradedEnabled"`      // 是否启用 RAID 阵列降级告警（mdadm、ZFS）
+	DiskFailingEnabled       bool    `json:"diskFailingEnabled"`       // 是否启用磁盘故障预警（SMART）
+	DiskReallocatedThreshold int     `json:"diskReallocatedThreshold"` // 重映射扇区数阈值，默认 10
+	DiskWearThreshold        float64 `json:"diskWearThreshold"`        // SSD 已用寿命阈值(0-100)，默认 90
+
+	// 内核指标告警规则（CPU steal/iowait、PSI、文件句柄、conntrack、OOM）
+	KernelRules []KernelAlertRule `json:"kernelRules,omitempty"`
+
+	// 自定义指标告警规则
+	CustomRules []CustomMetricAlertRule `json:"customRules,omitempty"`
+}
+
+// KernelAlertRule 内核指标告警规则
+type KernelAlertRule struct {
+	Enabled   bool    `json:"enabled"`   // 是否启用
+	Metric    string  `json:"metric"`    // 指标: cpu_steal, cpu_iowait, cpu_softirq, psi_cpu, psi_memory, psi_io, fd_usage, conntrack_usage, oom_kills
+	Threshold float64 `json:"threshold"` // 阈值（百分比；oom_kills 为最近10分钟内的次数）
+	Duration  int     `json:"duration"`  // 持续时间（秒）
+}
+
+// CustomMetricAlertRule 自定义指标告警规则
+type CustomMetricAlertRule struct {
+	Enabled   bool              `json:"enabled"`          // 是否启用
+	Metric    string            `json:"metric"`           // 指标名称（不含 pika_custom_ 前缀）
+	Labels    map[string]string `json:"labels,omitempty"` // 标签过滤，为空时匹配所有序列
+	Operator  string            `json:"operator"`         // 比较方式: gt（不低于阈值触发，默认）, lt（不高于阈值触发）
+	Threshold float64           `json:"threshold"`        // 阈值
+	Duration  int               `json:"duration"`         // 持续时间（秒）
 }
 
 // AlertNotifications 告警通知开关
@@ -158,3 +230,9 @@ type AlertNotifications struct {
 	SSHLoginSuccessEnabled bool `json:"sshLoginSuccessEnabled"` // SSH 登录成功通知
 	TamperEventEnabled     bool `json:"tamperEventEnabled"`     // 防篡改事件通知
 }
+
+// RollupTierState 降采样层级的处理进度
+type RollupTierState struct {
+	First int64 `json:"first"` // 已生成数据的最早时间（毫秒）
+	Last  int64 `json:"last"`  // 已处理到的时间（毫秒）
+}
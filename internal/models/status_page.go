@@ -0,0 +1,85 @@
+package models
+
+import "gorm.io/datatypes"
+
+// StatusPage 公开状态页
+type StatusPage struct {
+	ID          string                               `gorm:"primaryKey" json:"id"`                  // 状态页ID (UUID)
+	Slug        string                               `gorm:"uniqueIndex" json:"slug"`               // 访问路径标识，如 /status/acme
+	Title       string                               `json:"title"`                                 // 页面标题，为空时使用系统名称
+	Description string                               `json:"description"`                           // 页面描述
+	LogoBase64  string                               `gorm:"type:text" json:"logoBase64"`           // 页面 logo，为空时使用系统 logo
+	Enabled     bool                                 `json:"enabled"`                               // 是否启用
+	Groups      datatypes.JSONSlice[StatusPageGroup] `json:"groups"`                                // 组件分组
+	CreatedAt   int64                                `json:"createdAt"`                             // 创建时间（时间戳毫秒）
+	UpdatedAt   int64                                `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+}
+
+func (StatusPage) TableName() string {
+	return "status_pages"
+}
+
+// StatusPageGroup 状态页组件分组
+type StatusPageGroup struct {
+	Name       string                `json:"name"`       // 分组名称
+	Components []StatusPageComponent `json:"components"` // 分组下的组件
+}
+
+// StatusPageComponent 状态页组件，映射到监控任务或探针
+type StatusPageComponent struct {
+	ID          string `json:"id"`          // 组件ID，用于关联故障与维护公告
+	Name        string `json:"name"`        // 对外展示的名称
+	Description string `json:"description"` // 描述
+	Type        string `json:"type"`        // 组件类型: monitor, agent
+	TargetID    string `json:"targetId"`    // 监控任务ID或探针ID
+}
+
+// StatusPageIncident 状态页故障或维护公告
+type StatusPageIncident struct {
+	ID             string                      `gorm:"primaryKey" json:"id"`                  // 公告ID (UUID)
+	PageID         string                      `gorm:"index" json:"pageId"`                   // 状态页ID
+	Kind           string                      `json:"kind"`                                  // 公告类型: incident-故障, maintenance-计划维护
+	Title          string                      `json:"title"`                                 // 标题
+	Status         string                      `json:"status"`                                // 当前状态，故障: investigating/identified/monitoring/resolved，维护: scheduled/in_progress/completed
+	Impact         string                      `json:"impact"`                                // 影响程度: none/minor/major/critical
+	ComponentIDs   datatypes.JSONSlice[string] `json:"componentIds"`                          // 受影响的组件ID
+	ScheduledStart int64                       `json:"scheduledStart"`                        // 计划维护开始时间（时间戳毫秒）
+	ScheduledEnd   int64                       `json:"scheduledEnd"`                          // 计划维护结束时间（时间戳毫秒）
+	ResolvedAt     int64                       `json:"resolvedAt"`                            // 解决/完成时间（时间戳毫秒），0 表示未结束
+	CreatedAt      int64                       `gorm:"index" json:"createdAt"`                // 创建时间（时间戳毫秒）
+	UpdatedAt      int64                       `json:"updatedAt" gorm:"autoUpdateTime:milli"` // 更新时间（时间戳毫秒）
+
+	Updates []StatusPageIncidentUpdate `gorm:"-" json:"updates"` // 进展更新，按时间倒序
+}
+
+func (StatusPageIncident) TableName() string {
+	return "status_page_incidents"
+}
+
+// StatusPageIncidentUpdate 故障或维护公告的进展更新
+type StatusPageIncidentUpdate struct {
+	ID         string `gorm:"primaryKey" json:"id"`     // 更新ID (UUID)
+	IncidentID string `gorm:"index" json:"incidentId"`  // 公告ID
+	Status     string `json:"status"`                   // 更新时的状态
+	Message    string `gorm:"type:text" json:"message"` // 更新内容
+	CreatedAt  int64  `json:"createdAt"`                // 创建时间（时间戳毫秒）
+}
+
+func (StatusPageIncidentUpdate) TableName() string {
+	return "status_page_incident_updates"
+}
+
+// StatusPageSubscriber 状态页订阅者
+type StatusPageSubscriber struct {
+	ID           string `gorm:"primaryKey" json:"id"`   // 订阅ID (UUID)
+	PageID       string `gorm:"index" json:"pageId"`    // 状态页ID
+	Type         string `json:"type"`                   // 订阅方式: email, webhook
+	Target       string `json:"target"`                 // 邮箱地址或 Webhook URL
+	Token        string `gorm:"uniqueIndex" json:"-"`   // 退订令牌
+	ConfirmToken string `gorm:"index" json:"-"`         // 邮件订阅的确认令牌，不为空表示尚未确认，未确认的订阅不发送通知
+	CreatedAt    int64  `gorm:"index" json:"createdAt"` // 创建时间（时间戳毫秒）
+}
+
+func (StatusPageSubscriber) TableName() string {
+	return "status_page_subscribers"
+}
@@ -22,11 +22,18 @@ type RegisterRequest struct {
 
 // RegisterResponse 注册响应
 type RegisterResponse struct {
-	AgentID string `json:"agentId"`
-	Status  string `json:"status"`
-	Message string `json:"message,omitempty"`
+	AgentID      string   `json:"agentId"`
+	Status       string   `json:"status"`
+	Message      string   `json:"message,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"` // 服务端支持的能力，旧版本服务端不返回
 }
 
+// 服务端能力
+const (
+	CapabilityMetricsBatch = "metrics_batch" // 支持 metrics_batch 消息
+	CapabilityMetricsAck   = "metrics_ack"   // 带 ID 的批量指标写入存储后回复 metrics_ack
+)
+
 // AgentInfo 探针信息
 type AgentInfo struct {
 	ID       string `json:"id"`       // 探针唯一标识（持久化）
@@ -43,6 +50,80 @@ type MetricsPayload struct {
 	Data      interface{} `json:"data"`
 	Timestamp int64       `json:"timestamp,omitempty"` // 客户端采集时间(毫秒)
 }
+
+// InputMetricsPayload 指标数据（接收端），Data 延迟到按类型解析
+type InputMetricsPayload struct {
+	Type      MetricType      `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp int64           `json:"timestamp,omitempty"`
+}
+
+// MetricsBatch 批量指标，包含一个采集周期的所有指标，补发缓存时可包含多个采集周期
+type MetricsBatch struct {
+	ID    uint64           `json:"id,omitempty"` // 需要服务端确认时设置，服务端写入存储后回复相同 ID 的 metrics_ack
+	Items []MetricsPayload `json:"items"`
+}
+
+// InputMetricsBatch 批量指标（接收端）
+type InputMetricsBatch struct {
+	ID    uint64                `json:"id,omitempty"`
+	Items []InputMetricsPayload `json:"items"`
+}
+
+// MetricsAck 批量指标写入确认
+type MetricsAck struct {
+	ID      uint64 `json:"id"`
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+// MetricsBufferStats 探针离线指标缓存状态
+type MetricsBufferStats struct {
+	Entries          uint64 `json:"entries"`          // 缓存的消息条数
+	SizeBytes        uint64 `json:"sizeBytes"`        // 缓存数据大小
+	OldestTimestamp  int64  `json:"oldestTimestamp"`  // 最早一条缓存的写入时间（毫秒），无缓存时为 0
+	DroppedTotal     uint64 `json:"droppedTotal"`     // 因超出大小或保留时间被丢弃的条数（探针启动以来）
+	DownsampledTotal uint64 `json:"downsampledTotal"` // 离线期间因降采样未缓存的采集次数（探针启动以来）
+}
+
+// AgentHealth 探针自身运行状态
+type AgentHealth struct {
+	Version       string            `json:"version"`       // 探针版本
+	StartedAt     int64             `json:"startedAt"`     // 探针启动时间（毫秒）
+	UptimeSeconds int64             `json:"uptimeSeconds"` // 运行时长（秒）
+	Goroutines    int               `json:"goroutines"`    // goroutine 数量
+	RSSBytes      uint64            `json:"rssBytes"`      // 常驻内存
+	HeapBytes     uint64            `json:"heapBytes"`     // Go 堆内存
+	Reconnects    uint64            `json:"reconnects"`    // 启动以来的重连次数
+	LastSendAt    int64             `json:"lastSendAt"`    // 最近一次成功发送指标的时间（毫秒），未发送过为 0
+	BufferEntries uint64            `json:"bufferEntries"` // 离线缓存中待补发的消息条数
+	Features      map[string]bool   `json:"features"`      // 功能启用状态: tamper、ssh_monitor、ddns、custom_metrics、buffer、config_profile
+	Collectors    []CollectorHealth `json:"collectors"`    // 各采集器的运行状态
+}
+
+// CollectorHealth 采集器运行状态
+type CollectorHealth struct {
+	Name        string  `json:"name"`                  // 采集器名称
+	DurationMs  float64 `json:"durationMs"`            // 最近一次采集耗时（毫秒）
+	Runs        uint64  `json:"runs"`                  // 启动以来的采集次数
+	Errors      uint64  `json:"errors"`                // 启动以来的失败次数
+	LastError   string  `json:"lastError,omitempty"`   // 最近一次失败的错误信息
+	LastErrorAt int64   `json:"lastErrorAt,omitempty"` // 最近一次失败的时间（毫秒）
+}
+
+// DiagnosticsBundle 探针诊断信息（diagnostics 指令结果）
+type DiagnosticsBundle struct {
+	GeneratedAt int64       `json:"generatedAt"` // 生成时间（毫秒）
+	OS          string      `json:"os"`          // 操作系统
+	Arch        string      `json:"arch"`        // 架构
+	GoVersion   string      `json:"goVersion"`   // Go 版本
+	Health      AgentHealth `json:"health"`      // 运行状态
+	Config      string      `json:"config"`      // 本地 agent.yaml 配置（YAML，密钥已遮蔽）
+	Logs        []string    `json:"logs"`        // 最近的日志（密钥已遮蔽）
+
+	ConfigProfile *AgentConfigData `json:"configProfile,omitempty"` // 生效的服务端配置模板
+}
+
 type MessageType string
 
 // 控制消息
@@ -56,6 +137,8 @@ const (
 	MessageTypeUninstall   MessageType = "uninstall"
 	// 指标消息
 	MessageTypeMetrics       MessageType = "metrics"
+	MessageTypeMetricsBatch  MessageType = "metrics_batch"
+	MessageTypeMetricsAck    MessageType = "metrics_ack"
 	MessageTypeMonitorConfig MessageType = "monitor_config"
 	// 防篡改消息
 	MessageTypeTamperProtect MessageType = "tamper_protect"
@@ -70,6 +153,17 @@ const (
 	MessageTypeSSHLoginConfig       MessageType = "ssh_login_config"
 	MessageTypeSSHLoginConfigResult MessageType = "ssh_login_config_result" // Agent 反馈配置应用结果
 	MessageTypeSSHLoginEvent        MessageType = "ssh_login_event"
+	// 探针配置模板消息
+	MessageTypeAgentConfig       MessageType = "agent_config"
+	MessageTypeAgentConfigResult MessageType = "agent_config_result" // Agent 反馈配置应用结果
+	// 日志监控消息
+	MessageTypeLogWatchConfig       MessageType = "log_watch_config"
+	MessageTypeLogWatchConfigResult MessageType = "log_watch_config_result" // Agent 反馈配置应用结果
+	MessageTypeLogWatchEvent        MessageType = "log_watch_event"
+	// 系统日志转发消息
+	MessageTypeLogForwardConfig       MessageType = "log_forward_config"
+	MessageTypeLogForwardConfigResult MessageType = "log_forward_config_result" // Agent 反馈配置应用结果
+	MessageTypeLogEntries             MessageType = "log_entries"
 )
 
 type MetricType string
@@ -86,8 +180,93 @@ const (
 	MetricTypeGPU               MetricType = "gpu"
 	MetricTypeTemperature       MetricType = "temperature"
 	MetricTypeMonitor           MetricType = "monitor"
+	MetricTypeCustom            MetricType = "custom"
+	MetricTypeExporter          MetricType = "exporter" // 探针抓取的 Prometheus exporter 指标，数据格式同 CustomMetricData
+	MetricTypeProcess           MetricType = "process"
+	MetricTypeService           MetricType = "service" // systemd 单元与容器状态
+	MetricTypeKernel            MetricType = "kernel"
+	MetricTypeHardware          MetricType = "hardware"     // 磁盘 SMART、RAID 阵列与内存 ECC 健康状态
+	MetricTypeAgentBuffer       MetricType = "agent_buffer" // 探针离线指标缓存状态
+	MetricTypeAgentHealth       MetricType = "agent_health" // 探针自身运行状态
+)
+
+// 自定义指标类型
+const (
+	CustomMetricKindGauge   = "gauge"
+	CustomMetricKindCounter = "counter"
 )
 
+// CustomMetricData 自定义指标数据（由探针本机脚本推送或 exec 插件采集）
+type CustomMetricData struct {
+	Name   string            `json:"name"`
+	Kind   string            `json:"kind,omitempty"` // gauge, counter
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// ProcessMetricsData 进程指标数据
+type ProcessMetricsData struct {
+	Top     []ProcessMetric        `json:"top,omitempty"`     // 按 CPU、内存、IO、文件描述符排名的进程（取并集）
+	Watched []WatchedProcessMetric `json:"watched,omitempty"` // 关注的进程
+}
+
+// ProcessMetric 单个进程的资源占用
+type ProcessMetric struct {
+	PID            int32   `json:"pid"`
+	Name           string  `json:"name"`
+	Cmdline        string  `json:"cmdline,omitempty"`
+	Username       string  `json:"username,omitempty"`
+	CPUPercent     float64 `json:"cpuPercent"`     // CPU 使用率（单核满载为 100%）
+	MemoryRSS      uint64  `json:"memoryRss"`      // 常驻内存(字节)
+	MemoryPercent  float64 `json:"memoryPercent"`  // 常驻内存占总内存的百分比
+	ReadBytesRate  uint64  `json:"readBytesRate"`  // 读取速率(字节/秒)
+	WriteBytesRate uint64  `json:"writeBytesRate"` // 写入速率(字节/秒)
+	NumFDs         int32   `json:"numFds"`         // 打开的文件描述符数量
+	NumThreads     int32   `json:"numThreads"`     // 线程数
+}
+
+// WatchedProcessMetric 关注进程的资源占用（匹配到的所有进程汇总）
+type WatchedProcessMetric struct {
+	Name       string  `json:"name"`       // 配置的名称
+	Count      int     `json:"count"`      // 匹配到的进程数，0 表示未运行
+	CPUPercent float64 `json:"cpuPercent"` // CPU 使用率（单核满载为 100%）
+	MemoryRSS  uint64  `json:"memoryRss"`  // 常驻内存(字节)
+	NumThreads int32   `json:"numThreads"` // 线程数
+	Restarts   uint64  `json:"restarts"`   // 探针启动以来检测到的重启次数
+}
+
+// ServiceStateData systemd 单元与容器状态数据
+type ServiceStateData struct {
+	Units      []SystemdUnitState `json:"units,omitempty"`
+	Containers []ContainerState   `json:"containers,omitempty"`
+}
+
+// SystemdUnitState systemd 单元状态
+type SystemdUnitState struct {
+	Name        string `json:"name"`
+	LoadState   string `json:"loadState"`   // loaded, not-found 等
+	ActiveState string `json:"activeState"` // active, inactive, failed, activating 等
+	SubState    string `json:"subState"`    // running, exited, dead 等
+	Result      string `json:"result"`      // success, exit-code, signal 等
+	Restarts    uint64 `json:"restarts"`    // systemd 自动重启次数（NRestarts）
+	ExitCode    int    `json:"exitCode"`    // 主进程最近一次退出码
+	MainPID     int32  `json:"mainPid"`
+}
+
+// ContainerState 容器状态
+type ContainerState struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Image       string  `json:"image"`
+	State       string  `json:"state"`            // running, exited, restarting, paused 等
+	Health      string  `json:"health,omitempty"` // healthy, unhealthy, starting
+	Restarts    uint64  `json:"restarts"`         // 容器重启次数
+	ExitCode    int     `json:"exitCode"`         // 最近一次退出码
+	CPUPercent  float64 `json:"cpuPercent"`       // CPU 使用率（单核满载为 100%）
+	MemoryUsage uint64  `json:"memoryUsage"`      // 内存占用(字节，不含页缓存)
+	MemoryLimit uint64  `json:"memoryLimit"`      // 内存限制(字节)
+}
+
 // CPUData CPU数据
 type CPUData struct {
 	// 静态信息(不常变化,但每次都发送)
@@ -95,8 +274,87 @@ type CPUData struct {
 	PhysicalCores int    `json:"physicalCores"`
 	ModelName     string `json:"modelName"`
 	// 动态信息
-	UsagePercent float64   `json:"usagePercent"`
-	PerCore      []float64 `json:"perCore,omitempty"`
+	UsagePercent float64       `json:"usagePercent"`
+	PerCore      []float64     `json:"perCore,omitempty"`
+	Times        *CPUTimesData `json:"times,omitempty"`
+}
+
+// CPUTimesData CPU 各类时间占比(百分比，iowait/irq/softirq/steal 仅 Linux 提供)
+type CPUTimesData struct {
+	User    float64 `json:"user"`    // 用户态（含 nice）
+	System  float64 `json:"system"`  // 内核态
+	IOWait  float64 `json:"iowait"`  // 等待 IO
+	IRQ     float64 `json:"irq"`     // 硬中断
+	SoftIRQ float64 `json:"softirq"` // 软中断
+	Steal   float64 `json:"steal"`   // 被宿主机其他虚拟机占用
+}
+
+// KernelData 内核统计数据（仅 Linux）
+type KernelData struct {
+	Pressure             *PressureData `json:"pressure,omitempty"`       // PSI 压力（Linux 4.20+）
+	ContextSwitchesRate  float64       `json:"contextSwitchesRate"`      // 每秒上下文切换次数
+	InterruptsRate       float64       `json:"interruptsRate"`           // 每秒中断次数
+	FileHandlesAllocated uint64        `json:"fileHandlesAllocated"`     // 已分配的文件句柄数
+	FileHandlesMax       uint64        `json:"fileHandlesMax"`           // 文件句柄上限
+	EntropyAvailable     uint64        `json:"entropyAvailable"`         // 可用熵（位）
+	ConntrackCount       uint64        `json:"conntrackCount,omitempty"` // conntrack 表当前条目数（未加载 nf_conntrack 时为空）
+	ConntrackMax         uint64        `json:"conntrackMax,omitempty"`   // conntrack 表上限
+	OOMKills             uint64        `json:"oomKills"`                 // 开机以来的 OOM kill 次数
+}
+
+// HardwareData 硬件健康数据（仅 Linux），缺少对应工具或设备时相应列表为空
+type HardwareData struct {
+	Disks  []SmartDiskData `json:"disks,omitempty"`  // smartctl 读取的磁盘 SMART 信息
+	Arrays []RaidArrayData `json:"arrays,omitempty"` // mdadm 软 RAID 与 ZFS 存储池
+	ECC    []EDACData      `json:"ecc,omitempty"`    // EDAC 内存控制器错误计数
+}
+
+// SmartDiskData 磁盘 SMART 健康信息，不支持的项为 0
+type SmartDiskData struct {
+	Device               string   `json:"device"`                // 设备路径，如 /dev/sda
+	Model                string   `json:"model"`                 // 型号
+	Serial               string   `json:"serial"`                // 序列号
+	Protocol             string   `json:"protocol"`              // ATA, NVMe, SCSI
+	Passed               bool     `json:"passed"`                // SMART 整体自检是否通过
+	Temperature          float64  `json:"temperature"`           // 当前温度(摄氏度)
+	PowerOnHours         uint64   `json:"powerOnHours"`          // 通电时间(小时)
+	ReallocatedSectors   uint64   `json:"reallocatedSectors"`    // 重映射扇区数（ATA 5，SCSI 为增长缺陷数）
+	PendingSectors       uint64   `json:"pendingSectors"`        // 待映射扇区数（ATA 197）
+	OfflineUncorrectable uint64   `json:"offlineUncorrectable"`  // 离线无法校正扇区数（ATA 198）
+	MediaErrors          uint64   `json:"mediaErrors"`           // 介质错误数（NVMe）
+	CriticalWarning      int      `json:"criticalWarning"`       // NVMe 严重警告位，非 0 表示存在问题
+	WearPercent          *float64 `json:"wearPercent,omitempty"` // SSD 已用寿命百分比，机械硬盘为空
+}
+
+// RaidArrayData 软 RAID 阵列或 ZFS 存储池状态
+type RaidArrayData struct {
+	Name          string  `json:"name"`                   // md0、tank 等
+	Type          string  `json:"type"`                   // mdadm, zfs
+	Level         string  `json:"level,omitempty"`        // raid1、raid5 等（仅 mdadm）
+	State         string  `json:"state"`                  // mdadm: active/inactive，zfs: ONLINE/DEGRADED/FAULTED 等
+	Degraded      bool    `json:"degraded"`               // 是否降级（有成员缺失、故障或存储池不健康）
+	Devices       int     `json:"devices"`                // 成员设备数（仅 mdadm）
+	ActiveDevices int     `json:"activeDevices"`          // 正常工作的成员设备数（仅 mdadm）
+	FailedDevices int     `json:"failedDevices"`          // 标记为故障的成员设备数（仅 mdadm）
+	SyncAction    string  `json:"syncAction,omitempty"`   // 正在进行的 recovery、resync、check 等
+	SyncProgress  float64 `json:"syncProgress,omitempty"` // 同步进度百分比
+}
+
+// EDACData 内存控制器 ECC 错误计数（开机以来累计）
+type EDACData struct {
+	Controller        string `json:"controller"`        // mc0 等
+	CorrectedErrors   uint64 `json:"correctedErrors"`   // 可纠正错误数
+	UncorrectedErrors uint64 `json:"uncorrectedErrors"` // 不可纠正错误数
+}
+
+// PressureData PSI 压力，取最近 10 秒的平均值(百分比)
+// some 表示至少有一个任务因资源不足而等待的时间占比，full 表示所有非空闲任务同时等待的时间占比
+type PressureData struct {
+	CPUSome    float64 `json:"cpuSome"`
+	MemorySome float64 `json:"memorySome"`
+	MemoryFull float64 `json:"memoryFull"`
+	IOSome     float64 `json:"ioSome"`
+	IOFull     float64 `json:"ioFull"`
 }
 
 // MemoryData 内存数据
@@ -314,6 +572,17 @@ type MonitorData struct {
 	// TLS 证书信息（仅用于 HTTPS）
 	CertExpiryTime int64 `json:"certExpiryTime,omitempty"` // 证书过期时间(毫秒时间戳)
 	CertDaysLeft   int   `json:"certDaysLeft,omitempty"`   // 证书剩余天数
+	// 延迟统计（ICMP 及多次尝试的 TCP）
+	PacketsSent int     `json:"packetsSent,omitempty"` // 发送次数
+	PacketsRecv int     `json:"packetsRecv,omitempty"` // 成功次数
+	PacketLoss  float64 `json:"packetLoss,omitempty"`  // 丢包率(0-100)
+	MinRtt      float64 `json:"minRtt,omitempty"`      // 最小 RTT（毫秒）
+	AvgRtt      float64 `json:"avgRtt,omitempty"`      // 平均 RTT（毫秒）
+	MaxRtt      float64 `json:"maxRtt,omitempty"`      // 最大 RTT（毫秒）
+	StdDevRtt   float64 `json:"stdDevRtt,omitempty"`   // RTT 标准差（毫秒）
+	Jitter      float64 `json:"jitter,omitempty"`      // 抖动：相邻 RTT 差值绝对值的平均（毫秒）
+	// 路由追踪信息（仅用于 traceroute）
+	Hops []TracerouteHop `json:"hops,omitempty"` // 逐跳统计
 }
 
 // TamperProtectConfig 防篡改保护配置（增量更新）
@@ -707,3 +976,108 @@ type SSHLoginEvent struct {
 	TTY       string `json:"tty,omitempty"`       // 终端
 	SessionID string `json:"sessionId,omitempty"` // 会话ID
 }
+
+// ==================== 探针配置模板相关数据结构 ====================
+
+// AgentConfigData 服务端下发的探针配置模板
+type AgentConfigData struct {
+	ProfileID string            `json:"profileId"` // 配置模板ID，为空表示未分配模板，探针恢复本地配置
+	Version   string            `json:"version"`   // 配置版本（内容摘要），用于核对应用结果
+	Config    RemoteAgentConfig `json:"config"`    // 配置内容
+}
+
+// RemoteAgentConfig 可由服务端管理的探针配置项，未设置的项沿用探针本地 agent.yaml
+type RemoteAgentConfig struct {
+	Interval          int                     `json:"interval,omitempty"`          // 数据采集间隔（秒），0 表示沿用本地配置
+	HeartbeatInterval int                     `json:"heartbeatInterval,omitempty"` // 心跳间隔（秒），0 表示沿用本地配置
+	NetworkInclude    []string                `json:"networkInclude"`              // 网卡白名单（正则表达式），为 null 表示沿用本地配置，空数组表示不使用白名单
+	NetworkExclude    []string                `json:"networkExclude"`              // 网卡黑名单（正则表达式），为 null 表示沿用本地配置，空数组表示使用默认排除规则
+	DiskInclude       []string                `json:"diskInclude"`                 // 磁盘挂载点白名单，为 null 表示沿用本地配置，空数组表示只采集系统主分区
+	AutoUpdate        *RemoteAutoUpdateConfig `json:"autoUpdate,omitempty"`        // 自动更新配置，为 null 表示沿用本地配置
+}
+
+// RemoteAutoUpdateConfig 自动更新配置
+type RemoteAutoUpdateConfig struct {
+	Enabled       bool   `json:"enabled"`       // 是否启用自动更新
+	CheckInterval string `json:"checkInterval"` // 检查更新间隔，如 10m
+}
+
+// AgentConfigResult 探针配置模板应用结果（Agent 反馈）
+type AgentConfigResult struct {
+	ProfileID string `json:"profileId"` // 配置模板ID
+	Version   string `json:"version"`   // 配置版本
+	Success   bool   `json:"success"`   // 配置应用是否成功
+	Message   string `json:"message"`   // 结果描述信息
+}
+
+// ==================== 日志监控相关数据结构 ====================
+
+// LogWatchConfig 服务端下发的日志监控规则，规则为空表示停止监控
+type LogWatchConfig struct {
+	Version string         `json:"version"` // 规则版本（内容摘要），用于核对应用结果
+	Rules   []LogWatchRule `json:"rules"`   // 生效的规则
+}
+
+// LogWatchRule 日志监控规则
+type LogWatchRule struct {
+	ID          string `json:"id"`                // 规则ID
+	Name        string `json:"name"`              // 规则名称
+	Path        string `json:"path"`              // 日志文件路径，支持通配符，如 /var/log/nginx/*.error.log
+	Pattern     string `json:"pattern"`           // 匹配行的正则表达式
+	Exclude     string `json:"exclude,omitempty"` // 排除行的正则表达式，可选
+	Level       string `json:"level"`             // 事件级别: info/warning/critical
+	Window      int    `json:"window"`            // 限流窗口（秒），同一文件每个窗口最多上报一次事件，窗口内的匹配合并计数
+	SampleLines int    `json:"sampleLines"`       // 每个事件携带的样例行数
+}
+
+// LogWatchConfigResult 日志监控规则应用结果（Agent 反馈）
+type LogWatchConfigResult struct {
+	Version string   `json:"version"`         // 规则版本
+	Success bool     `json:"success"`         // 规则应用是否成功
+	Message string   `json:"message"`         // 结果描述信息
+	Files   []string `json:"files,omitempty"` // 当前跟踪的文件
+}
+
+// LogWatchEvent 日志匹配事件
+type LogWatchEvent struct {
+	RuleID   string   `json:"ruleId"`   // 规则ID
+	RuleName string   `json:"ruleName"` // 规则名称
+	Path     string   `json:"path"`     // 日志文件路径
+	Level    string   `json:"level"`    // 事件级别
+	Count    int      `json:"count"`    // 本次事件合并的匹配行数
+	Lines    []string `json:"lines"`    // 样例行
+	FirstAt  int64    `json:"firstAt"`  // 第一条匹配时间（毫秒时间戳）
+	LastAt   int64    `json:"lastAt"`   // 最后一条匹配时间（毫秒时间戳）
+}
+
+// ==================== 系统日志转发相关数据结构 ====================
+
+// LogForwardConfig 系统日志转发配置
+type LogForwardConfig struct {
+	Enabled  bool     `json:"enabled"`  // 是否启用转发
+	Units    []string `json:"units"`    // 只转发这些 systemd 单元（syslog 下按程序名匹配），为空表示全部
+	Priority int      `json:"priority"` // 转发的最低级别（syslog priority，0-emerg ... 7-debug），转发小于等于该值的日志
+}
+
+// LogForwardConfigResult 系统日志转发配置应用结果（Agent 反馈）
+type LogForwardConfigResult struct {
+	Success bool   `json:"success"`          // 配置应用是否成功
+	Enabled bool   `json:"enabled"`          // 当前启用状态
+	Source  string `json:"source,omitempty"` // 日志来源: journald 或 syslog 文件路径
+	Message string `json:"message"`          // 结果描述信息
+}
+
+// LogEntry 系统日志条目
+type LogEntry struct {
+	Timestamp  int64  `json:"timestamp"`            // 日志时间（毫秒时间戳）
+	Priority   int    `json:"priority"`             // 级别（0-emerg ... 7-debug）
+	Unit       string `json:"unit,omitempty"`       // systemd 单元
+	Identifier string `json:"identifier,omitempty"` // 程序名（SYSLOG_IDENTIFIER）
+	Message    string `json:"message"`              // 日志内容
+}
+
+// LogEntryBatch 批量上报的系统日志
+type LogEntryBatch struct {
+	Entries []LogEntry `json:"entries"`           // 日志条目
+	Dropped int        `json:"dropped,omitempty"` // 上一批之后因队列已满丢弃的条目数
+}
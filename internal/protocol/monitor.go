@@ -8,12 +8,20 @@ type MonitorConfigPayload struct {
 
 // MonitorItem 监控项配置
 type MonitorItem struct {
-	ID         string             `json:"id"`
-	Type       string             `json:"type"`
-	Target     string             `json:"target"`
-	HTTPConfig *HTTPMonitorConfig `json:"httpConfig,omitempty"`
-	TCPConfig  *TCPMonitorConfig  `json:"tcpConfig,omitempty"`
-	ICMPConfig *ICMPMonitorConfig `json:"icmpConfig,omitempty"`
+	ID               string                   `json:"id"`
+	Type             string                   `json:"type"`
+	Target           string                   `json:"target"`
+	HTTPConfig       *HTTPMonitorConfig       `json:"httpConfig,omitempty"`
+	TCPConfig        *TCPMonitorConfig        `json:"tcpConfig,omitempty"`
+	ICMPConfig       *ICMPMonitorConfig       `json:"icmpConfig,omitempty"`
+	UDPConfig        *UDPMonitorConfig        `json:"udpConfig,omitempty"`
+	SMTPConfig       *SMTPMonitorConfig       `json:"smtpConfig,omitempty"`
+	SSHConfig        *SSHMonitorConfig        `json:"sshConfig,omitempty"`
+	RedisConfig      *RedisMonitorConfig      `json:"redisConfig,omitempty"`
+	MySQLConfig      *MySQLMonitorConfig      `json:"mysqlConfig,omitempty"`
+	PostgreSQLConfig *PostgreSQLMonitorConfig `json:"postgresqlConfig,omitempty"`
+	MQTTConfig       *MQTTMonitorConfig       `json:"mqttConfig,omitempty"`
+	TracerouteConfig *TracerouteMonitorConfig `json:"tracerouteConfig,omitempty"`
 }
 
 // HTTPMonitorConfig HTTP 监控配置
@@ -29,6 +37,7 @@ type HTTPMonitorConfig struct {
 // TCPMonitorConfig TCP 监控配置
 type TCPMonitorConfig struct {
 	Timeout int `json:"timeout"`
+	Count   int `json:"count,omitempty"` // 连接尝试次数，大于 1 时统计丢包率与延迟分布
 }
 
 // ICMPMonitorConfig ICMP 监控配置
@@ -36,3 +45,81 @@ type ICMPMonitorConfig struct {
 	Timeout int `json:"timeout"` // 超时时间（秒）
 	Count   int `json:"count"`   // Ping 次数
 }
+
+// UDPMonitorConfig UDP 监控配置
+type UDPMonitorConfig struct {
+	Timeout         int    `json:"timeout"`                   // 超时时间（秒）
+	Payload         string `json:"payload,omitempty"`         // 发送内容
+	PayloadHex      bool   `json:"payloadHex,omitempty"`      // 发送内容是否为十六进制编码
+	ExpectedContent string `json:"expectedContent,omitempty"` // 期望响应中包含的内容，为空时只要有响应即视为正常
+}
+
+// SMTPMonitorConfig SMTP 监控配置
+type SMTPMonitorConfig struct {
+	Timeout  int    `json:"timeout"`            // 超时时间（秒）
+	HeloName string `json:"heloName,omitempty"` // EHLO 使用的主机名，默认 localhost
+	StartTLS bool   `json:"startTLS,omitempty"` // 是否要求服务端支持 STARTTLS
+}
+
+// SSHMonitorConfig SSH 监控配置
+type SSHMonitorConfig struct {
+	Timeout        int    `json:"timeout"`                  // 超时时间（秒）
+	ExpectedBanner string `json:"expectedBanner,omitempty"` // 期望 banner 中包含的内容（如 OpenSSH_9）
+}
+
+// RedisMonitorConfig Redis 监控配置
+type RedisMonitorConfig struct {
+	Timeout  int    `json:"timeout"`            // 超时时间（秒）
+	Username string `json:"username,omitempty"` // ACL 用户名（Redis 6+）
+	Password string `json:"password,omitempty"` // 密码
+}
+
+// MySQLMonitorConfig MySQL 监控配置
+type MySQLMonitorConfig struct {
+	Timeout  int    `json:"timeout"`            // 超时时间（秒）
+	Username string `json:"username,omitempty"` // 用户名，为空时只检查握手包
+	Password string `json:"password,omitempty"` // 密码
+	Database string `json:"database,omitempty"` // 数据库
+	Query    string `json:"query,omitempty"`    // 登录后执行的查询语句，如 SELECT 1
+}
+
+// PostgreSQLMonitorConfig PostgreSQL 监控配置
+type PostgreSQLMonitorConfig struct {
+	Timeout  int    `json:"timeout"`            // 超时时间（秒）
+	Username string `json:"username,omitempty"` // 用户名，为空时只检查协议握手
+	Password string `json:"password,omitempty"` // 密码
+	Database string `json:"database,omitempty"` // 数据库
+	SSLMode  string `json:"sslMode,omitempty"`  // SSL 模式: disable/require/verify-full，默认 prefer
+	Query    string `json:"query,omitempty"`    // 登录后执行的查询语句，如 SELECT 1
+}
+
+// MQTTMonitorConfig MQTT 监控配置
+type MQTTMonitorConfig struct {
+	Timeout  int    `json:"timeout"`            // 超时时间（秒）
+	ClientID string `json:"clientId,omitempty"` // 客户端 ID，默认自动生成
+	Username string `json:"username,omitempty"` // 用户名
+	Password string `json:"password,omitempty"` // 密码
+}
+
+// TracerouteMonitorConfig 路由追踪（MTR）监控配置
+type TracerouteMonitorConfig struct {
+	Timeout int `json:"timeout"` // 每轮探测等待响应的时间（秒）
+	MaxHops int `json:"maxHops"` // 最大跳数，默认 30
+	Count   int `json:"count"`   // 每跳探测次数，默认 3
+}
+
+// TracerouteHop 路由追踪单跳统计
+type TracerouteHop struct {
+	TTL       int     `json:"ttl"`                // 跳数
+	IP        string  `json:"ip,omitempty"`       // 响应的路由器 IP，无响应时为空
+	Sent      int     `json:"sent"`               // 发送的探测包数
+	Recv      int     `json:"recv"`               // 收到的响应数
+	Loss      float64 `json:"loss"`               // 丢包率(0-100)
+	MinRtt    float64 `json:"minRtt"`             // 最小 RTT（毫秒）
+	AvgRtt    float64 `json:"avgRtt"`             // 平均 RTT（毫秒）
+	MaxRtt    float64 `json:"maxRtt"`             // 最大 RTT（毫秒）
+	StdDevRtt float64 `json:"stdDevRtt"`          // RTT 标准差（毫秒）
+	ASN       uint    `json:"asn,omitempty"`      // 自治系统号（服务端补充）
+	ASOrg     string  `json:"asOrg,omitempty"`    // 自治系统组织（服务端补充）
+	Location  string  `json:"location,omitempty"` // IP 归属地（服务端补充）
+}
@@ -0,0 +1,119 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dushixiang/pika/internal/vmclient"
+)
+
+// Client Prometheus remote-write 客户端
+type Client struct {
+	name        string
+	url         string
+	username    string
+	password    string
+	bearerToken string
+	headers     map[string]string
+	httpClient  *http.Client
+}
+
+// Options 客户端配置
+type Options struct {
+	Name        string
+	URL         string
+	Username    string
+	Password    string
+	BearerToken string
+	Headers     map[string]string
+	Timeout     time.Duration
+}
+
+// RecoverableError 可重试的错误（网络错误、429、5xx）
+type RecoverableError struct {
+	err error
+}
+
+func (e *RecoverableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *RecoverableError) Unwrap() error {
+	return e.err
+}
+
+// IsRecoverable 判断错误是否可重试
+func IsRecoverable(err error) bool {
+	var re *RecoverableError
+	return errors.As(err, &re)
+}
+
+// NewClient 创建 remote-write 客户端
+func NewClient(opts Options) *Client {
+	if opts.Timeout == 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	return &Client{
+		name:        opts.Name,
+		url:         opts.URL,
+		username:    opts.Username,
+		password:    opts.Password,
+		bearerToken: opts.BearerToken,
+		headers:     opts.Headers,
+		httpClient: &http.Client{
+			Timeout: opts.Timeout,
+		},
+	}
+}
+
+// Name 目标名称
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Write 发送一批指标
+func (c *Client) Write(ctx context.Context, metrics []vmclient.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(Encode(metrics)))
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("User-Agent", "pika-remote-write")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	} else if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return &RecoverableError{err: fmt.Errorf("remote write failed: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("remote write failed: status=%d, body=%s", resp.StatusCode, string(body))
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5 {
+		return &RecoverableError{err: err}
+	}
+	return err
+}
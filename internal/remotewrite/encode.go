@@ -0,0 +1,70 @@
+package remotewrite
+
+import (
+	"encoding/binary"
+	"math"
+	"sort"
+
+	"github.com/dushixiang/pika/internal/vmclient"
+	"github.com/golang/snappy"
+)
+
+// Prometheus remote-write 协议（prometheus.WriteRequest）字段编号
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// Encode 将指标编码为 snappy 压缩的 protobuf WriteRequest
+func Encode(metrics []vmclient.Metric) []byte {
+	var req []byte
+	for _, m := range metrics {
+		req = appendBytes(req, 1, encodeTimeSeries(m))
+	}
+	return snappy.Encode(nil, req)
+}
+
+func encodeTimeSeries(m vmclient.Metric) []byte {
+	// 协议要求标签按名称排序
+	names := make([]string, 0, len(m.Metric))
+	for name := range m.Metric {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var ts []byte
+	for _, name := range names {
+		var label []byte
+		label = appendBytes(label, 1, []byte(name))
+		label = appendBytes(label, 2, []byte(m.Metric[name]))
+		ts = appendBytes(ts, 1, label)
+	}
+	for i, value := range m.Values {
+		if i >= len(m.Timestamps) {
+			break
+		}
+		var sample []byte
+		sample = appendTag(sample, 1, wireFixed64)
+		sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(value))
+		sample = appendTag(sample, 2, wireVarint)
+		sample = binary.AppendUvarint(sample, uint64(m.Timestamps[i]))
+		ts = appendBytes(ts, 2, sample)
+	}
+	return ts
+}
+
+func appendTag(b []byte, field int, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+func appendBytes(b []byte, field int, data []byte) []byte {
+	b = appendTag(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
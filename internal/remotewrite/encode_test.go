@@ -0,0 +1,64 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/vmclient"
+	"github.com/golang/snappy"
+)
+
+func TestEncode(t *testing.T) {
+	metrics := []vmclient.Metric{{
+		Metric:     map[string]string{"__name__": "up", "a": "b"},
+		Values:     []float64{1},
+		Timestamps: []int64{1000},
+	}}
+
+	data, err := snappy.Decode(nil, Encode(metrics))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{
+		0x0a, 0x26, // timeseries
+		0x0a, 0x0e, 0x0a, 0x08, '_', '_', 'n', 'a', 'm', 'e', '_', '_', 0x12, 0x02, 'u', 'p', // label __name__=up
+		0x0a, 0x06, 0x0a, 0x01, 'a', 0x12, 0x01, 'b', // label a=b
+		0x12, 0x0c, 0x09, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, 0x10, 0xe8, 0x07, // sample value=1 timestamp=1000
+	}
+	if !bytes.Equal(data, want) {
+		t.Fatalf("unexpected encoding:\n got % x\nwant % x", data, want)
+	}
+}
+
+func TestClientWriteRecoverable(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	client := NewClient(Options{Name: "test", URL: server.URL, BearerToken: "token"})
+	metrics := []vmclient.Metric{{Metric: map[string]string{"__name__": "up"}, Values: []float64{1}, Timestamps: []int64{1}}}
+
+	if err := client.Write(context.Background(), metrics); !IsRecoverable(err) {
+		t.Fatalf("5xx should be recoverable, got %v", err)
+	}
+
+	status = http.StatusBadRequest
+	if err := client.Write(context.Background(), metrics); err == nil || IsRecoverable(err) {
+		t.Fatalf("4xx should not be recoverable, got %v", err)
+	}
+
+	status = http.StatusNoContent
+	if err := client.Write(context.Background(), metrics); err != nil {
+		t.Fatal(err)
+	}
+}
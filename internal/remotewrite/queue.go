@@ -0,0 +1,173 @@
+package remotewrite
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/vmclient"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBatchSize     = 2000
+	defaultMaxBuffer     = 100000
+	defaultFlushInterval = 5 * time.Second
+	minBackoff           = time.Second
+	maxBackoff           = time.Minute
+)
+
+// Queue 带缓冲与重试的发送队列，远端不可用时缓存数据，超出容量时丢弃最旧的数据
+type Queue struct {
+	logger    *zap.Logger
+	client    *Client
+	batchSize int
+	maxBuffer int
+
+	mu      sync.Mutex
+	pending []vmclient.Metric
+	dropped int64
+	notify  chan struct{}
+}
+
+// NewQueue 创建发送队列，batchSize/maxBuffer 为 0 时使用默认值
+func NewQueue(logger *zap.Logger, client *Client, batchSize, maxBuffer int) *Queue {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if maxBuffer <= 0 {
+		maxBuffer = defaultMaxBuffer
+	}
+	return &Queue{
+		logger:    logger,
+		client:    client,
+		batchSize: batchSize,
+		maxBuffer: maxBuffer,
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// Enqueue 加入待发送队列
+func (q *Queue) Enqueue(metrics []vmclient.Metric) {
+	if len(metrics) == 0 {
+		return
+	}
+
+	q.mu.Lock()
+	q.pending = append(q.pending, metrics...)
+	if overflow := len(q.pending) - q.maxBuffer; overflow > 0 {
+		q.pending = append(q.pending[:0], q.pending[overflow:]...)
+		q.dropped += int64(overflow)
+	}
+	full := len(q.pending) >= q.batchSize
+	q.mu.Unlock()
+
+	if full {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Run 定时或达到批量大小时发送，失败时按指数退避重试
+func (q *Queue) Run(ctx context.Context) {
+	ticker := time.NewTicker(defaultFlushInterval)
+	defer ticker.Stop()
+
+	backoff := time.Duration(0)
+	for {
+		if backoff > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+		} else {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-q.notify:
+			}
+		}
+
+		backoff = q.flush(ctx, backoff)
+	}
+}
+
+// flush 发送缓冲区中的全部数据，返回下一次重试前需要等待的时长
+func (q *Queue) flush(ctx context.Context, backoff time.Duration) time.Duration {
+	q.reportDropped()
+
+	for {
+		batch := q.take()
+		if len(batch) == 0 {
+			return 0
+		}
+
+		err := q.client.Write(ctx, batch)
+		if err == nil {
+			backoff = 0
+			continue
+		}
+		if !IsRecoverable(err) {
+			q.logger.Error("remote write 发送失败，丢弃该批数据",
+				zap.String("target", q.client.Name()),
+				zap.Int("series", len(batch)),
+				zap.Error(err))
+			continue
+		}
+
+		q.requeue(batch)
+		if backoff == 0 {
+			backoff = minBackoff
+		} else {
+			backoff = min(backoff*2, maxBackoff)
+		}
+		q.logger.Warn("remote write 发送失败，稍后重试",
+			zap.String("target", q.client.Name()),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+		return backoff
+	}
+}
+
+func (q *Queue) take() []vmclient.Metric {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := min(len(q.pending), q.batchSize)
+	if n == 0 {
+		return nil
+	}
+	batch := make([]vmclient.Metric, n)
+	copy(batch, q.pending[:n])
+	q.pending = append(q.pending[:0], q.pending[n:]...)
+	return batch
+}
+
+// requeue 将发送失败的数据放回队首
+func (q *Queue) requeue(batch []vmclient.Metric) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending = append(batch, q.pending...)
+	if overflow := len(q.pending) - q.maxBuffer; overflow > 0 {
+		q.pending = q.pending[overflow:]
+		q.dropped += int64(overflow)
+	}
+}
+
+func (q *Queue) reportDropped() {
+	q.mu.Lock()
+	dropped := q.dropped
+	q.dropped = 0
+	q.mu.Unlock()
+
+	if dropped > 0 {
+		q.logger.Warn("remote write 缓冲区已满，丢弃最旧的数据",
+			zap.String("target", q.client.Name()),
+			zap.Int64("dropped", dropped))
+	}
+}
@@ -0,0 +1,30 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+type AgentConfigProfileRepo struct {
+	orz.Repository[models.AgentConfigProfile, string]
+	db *gorm.DB
+}
+
+func NewAgentConfigProfileRepo(db *gorm.DB) *AgentConfigProfileRepo {
+	return &AgentConfigProfileRepo{
+		Repository: orz.NewRepository[models.AgentConfigProfile, string](db),
+		db:         db,
+	}
+}
+
+// ListByPriority 按优先级从高到低列出所有配置模板
+func (r *AgentConfigProfileRepo) ListByPriority(ctx context.Context) ([]models.AgentConfigProfile, error) {
+	var profiles []models.AgentConfigProfile
+	err := r.db.WithContext(ctx).
+		Order("priority DESC, created_at ASC").
+		Find(&profiles).Error
+	return profiles, err
+}
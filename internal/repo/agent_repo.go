@@ -111,11 +111,11 @@ func (r *AgentRepo) GetLatestAuditResultByType(ctx context.Context, agentID stri
 	return &audit, nil
 }
 
-// ListAuditResults 获取审计结果列表
+// ListAuditResults 获取审计结果列表（仅 VPS 审计，诊断信息等其他类型不在列表中）
 func (r *AgentRepo) ListAuditResults(ctx context.Context, agentID string) ([]models.AuditResult, error) {
 	var audits []models.AuditResult
 	err := r.db.WithContext(ctx).
-		Where("agent_id = ?", agentID).
+		Where("agent_id = ? AND type = ?", agentID, "vps_audit").
 		Order("created_at DESC").
 		Limit(50).
 		Find(&audits).Error
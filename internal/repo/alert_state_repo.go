@@ -46,6 +46,15 @@ func (r *AlertStateRepo) DeleteAlertStatesByConfigID(ctx context.Context, config
 	return r.db.WithContext(ctx).Where("config_id = ?", configID).Delete(&models.AlertState{}).Error
 }
 
+// FindFiringStates 获取探针指定类型中正在告警的状态
+func (r *AlertStateRepo) FindFiringStates(ctx context.Context, agentID, alertType string) ([]models.AlertState, error) {
+	var states []models.AlertState
+	err := r.db.WithContext(ctx).
+		Where("agent_id = ? and alert_type = ? and is_firing = ?", agentID, alertType, true).
+		Find(&states).Error
+	return states, err
+}
+
 // LoadAllStates 加载所有告警状态
 func (r *AlertStateRepo) LoadAllStates(ctx context.Context) ([]models.AlertState, error) {
 	var states []models.AlertState
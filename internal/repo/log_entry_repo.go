@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+// LogEntryRepo 系统日志数据访问层
+type LogEntryRepo struct {
+	orz.Repository[models.LogEntry, int64]
+}
+
+func NewLogEntryRepo(db *gorm.DB) *LogEntryRepo {
+	return &LogEntryRepo{
+		Repository: orz.NewRepository[models.LogEntry, int64](db),
+	}
+}
+
+// LogEntryQuery 系统日志查询条件
+type LogEntryQuery struct {
+	AgentIDs    []string // 探针ID，为空表示全部探针
+	Start       int64    // 开始时间（毫秒时间戳）
+	End         int64    // 结束时间（毫秒时间戳）
+	Unit        string   // systemd 单元或程序名
+	MaxPriority int      // 最低级别，只返回小于等于该值的日志
+	Keyword     string   // 日志内容关键字（不区分大小写）
+	Limit       int      // 最多返回的条数
+}
+
+// CreateEntries 批量写入日志
+func (r *LogEntryRepo) CreateEntries(ctx context.Context, entries []models.LogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.GetDB(ctx).CreateInBatches(entries, 100).Error
+}
+
+// Search 按条件查询日志，按时间倒序返回
+func (r *LogEntryRepo) Search(ctx context.Context, query LogEntryQuery) ([]models.LogEntry, error) {
+	db := r.GetDB(ctx).
+		Where("timestamp >= ? AND timestamp <= ?", query.Start, query.End).
+		Where("priority <= ?", query.MaxPriority)
+	if len(query.AgentIDs) > 0 {
+		db = db.Where("agent_id IN ?", query.AgentIDs)
+	}
+	if query.Unit != "" {
+		// 单元名可省略 .service 后缀；syslog 来源没有单元，按程序名匹配
+		db = db.Where("unit = ? OR unit = ? OR identifier = ?",
+			query.Unit, query.Unit+".service", strings.TrimSuffix(query.Unit, ".service"))
+	}
+	if query.Keyword != "" {
+		// 关键字中的 % 与 _ 按普通字符匹配
+		db = db.Where(`LOWER(message) LIKE ? ESCAPE '\'`, "%"+escapeLike(strings.ToLower(query.Keyword))+"%")
+	}
+
+	var entries []models.LogEntry
+	err := db.Order("timestamp DESC, id DESC").Limit(query.Limit).Find(&entries).Error
+	return entries, err
+}
+
+// likeEscaper 转义 LIKE 的通配符，配合 ESCAPE '\' 使用
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// DeleteBefore 删除指定时间之前的日志
+func (r *LogEntryRepo) DeleteBefore(ctx context.Context, before int64) error {
+	return r.GetDB(ctx).Where("timestamp < ?", before).Delete(&models.LogEntry{}).Error
+}
+
+// DeleteByAgentID 删除探针的所有日志
+func (r *LogEntryRepo) DeleteByAgentID(ctx context.Context, agentID string) error {
+	return r.GetDB(ctx).Where("agent_id = ?", agentID).Delete(&models.LogEntry{}).Error
+}
@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+// LogWatchRuleRepo 日志监控规则数据访问层
+type LogWatchRuleRepo struct {
+	orz.Repository[models.LogWatchRule, string]
+	db *gorm.DB
+}
+
+func NewLogWatchRuleRepo(db *gorm.DB) *LogWatchRuleRepo {
+	return &LogWatchRuleRepo{
+		Repository: orz.NewRepository[models.LogWatchRule, string](db),
+		db:         db,
+	}
+}
+
+// ListEnabled 列出所有启用的规则
+func (r *LogWatchRuleRepo) ListEnabled(ctx context.Context) ([]models.LogWatchRule, error) {
+	var rules []models.LogWatchRule
+	err := r.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Order("created_at ASC").
+		Find(&rules).Error
+	return rules, err
+}
+
+// LogWatchEventRepo 日志匹配事件数据访问层
+type LogWatchEventRepo struct {
+	orz.Repository[models.LogWatchEvent, string]
+}
+
+func NewLogWatchEventRepo(db *gorm.DB) *LogWatchEventRepo {
+	return &LogWatchEventRepo{
+		Repository: orz.NewRepository[models.LogWatchEvent, string](db),
+	}
+}
+
+// DeleteEventsByAgentID 删除探针的所有日志匹配事件
+func (r *LogWatchEventRepo) DeleteEventsByAgentID(ctx context.Context, agentID string) error {
+	return r.GetDB(ctx).Where("agent_id = ?", agentID).Delete(&models.LogWatchEvent{}).Error
+}
@@ -0,0 +1,60 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+// MonitorIncidentRepo 监控故障记录数据访问层
+type MonitorIncidentRepo struct {
+	orz.Repository[models.MonitorIncident, int64]
+}
+
+func NewMonitorIncidentRepo(db *gorm.DB) *MonitorIncidentRepo {
+	return &MonitorIncidentRepo{
+		Repository: orz.NewRepository[models.MonitorIncident, int64](db),
+	}
+}
+
+// FindOngoing 获取所有尚未恢复的故障
+func (r *MonitorIncidentRepo) FindOngoing(ctx context.Context) ([]models.MonitorIncident, error) {
+	var incidents []models.MonitorIncident
+	err := r.GetDB(ctx).Where("ended_at = 0").Find(&incidents).Error
+	return incidents, err
+}
+
+// FindByMonitorIDAndRange 获取监控任务在时间范围内发生过的故障（包括范围开始前发生但仍在持续的故障）
+func (r *MonitorIncidentRepo) FindByMonitorIDAndRange(ctx context.Context, monitorID string, start, end int64) ([]models.MonitorIncident, error) {
+	var incidents []models.MonitorIncident
+	err := r.GetDB(ctx).
+		Where("monitor_id = ? AND started_at <= ? AND (ended_at = 0 OR ended_at >= ?)", monitorID, end, start).
+		Order("started_at asc").
+		Find(&incidents).Error
+	return incidents, err
+}
+
+// UpdateError 更新故障最近一次的错误信息
+func (r *MonitorIncidentRepo) UpdateError(ctx context.Context, id int64, lastError string) error {
+	return r.GetDB(ctx).Model(&models.MonitorIncident{}).Where("id = ?", id).Update("last_error", lastError).Error
+}
+
+// Close 标记故障已恢复
+func (r *MonitorIncidentRepo) Close(ctx context.Context, id int64, endedAt, duration int64) error {
+	return r.GetDB(ctx).Model(&models.MonitorIncident{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"ended_at": endedAt,
+		"duration": duration,
+	}).Error
+}
+
+// DeleteByMonitorID 删除监控任务的所有故障记录
+func (r *MonitorIncidentRepo) DeleteByMonitorID(ctx context.Context, monitorID string) error {
+	return r.GetDB(ctx).Where("monitor_id = ?", monitorID).Delete(&models.MonitorIncident{}).Error
+}
+
+// DeleteBefore 删除指定时间之前已恢复的故障
+func (r *MonitorIncidentRepo) DeleteBefore(ctx context.Context, before int64) error {
+	return r.GetDB(ctx).Where("ended_at > 0 AND ended_at < ?", before).Delete(&models.MonitorIncident{}).Error
+}
@@ -0,0 +1,43 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+// MonitorRouteSnapshotRepo 路由快照数据访问层
+type MonitorRouteSnapshotRepo struct {
+	orz.Repository[models.MonitorRouteSnapshot, int64]
+}
+
+func NewMonitorRouteSnapshotRepo(db *gorm.DB) *MonitorRouteSnapshotRepo {
+	return &MonitorRouteSnapshotRepo{
+		Repository: orz.NewRepository[models.MonitorRouteSnapshot, int64](db),
+	}
+}
+
+// FindLatest 获取监控任务在指定探针上的最新快照
+func (r *MonitorRouteSnapshotRepo) FindLatest(ctx context.Context, monitorID, agentID string) (*models.MonitorRouteSnapshot, error) {
+	var snapshot models.MonitorRouteSnapshot
+	err := r.GetDB(ctx).
+		Where("monitor_id = ? AND agent_id = ?", monitorID, agentID).
+		Order("created_at desc").
+		First(&snapshot).Error
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// DeleteByMonitorID 删除监控任务的所有快照
+func (r *MonitorRouteSnapshotRepo) DeleteByMonitorID(ctx context.Context, monitorID string) error {
+	return r.GetDB(ctx).Where("monitor_id = ?", monitorID).Delete(&models.MonitorRouteSnapshot{}).Error
+}
+
+// DeleteBefore 删除指定时间之前的快照
+func (r *MonitorRouteSnapshotRepo) DeleteBefore(ctx context.Context, before int64) error {
+	return r.GetDB(ctx).Where("created_at < ?", before).Delete(&models.MonitorRouteSnapshot{}).Error
+}
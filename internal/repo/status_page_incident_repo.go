@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+// StatusPageIncidentRepo 状态页故障与维护公告数据访问层
+type StatusPageIncidentRepo struct {
+	orz.Repository[models.StatusPageIncident, string]
+}
+
+func NewStatusPageIncidentRepo(db *gorm.DB) *StatusPageIncidentRepo {
+	return &StatusPageIncidentRepo{
+		Repository: orz.NewRepository[models.StatusPageIncident, string](db),
+	}
+}
+
+// FindVisible 获取状态页需要展示的公告：未结束的公告以及指定时间之后结束的公告
+func (r *StatusPageIncidentRepo) FindVisible(ctx context.Context, pageID string, resolvedAfter int64) ([]models.StatusPageIncident, error) {
+	var incidents []models.StatusPageIncident
+	err := r.GetDB(ctx).
+		Where("page_id = ? AND (resolved_at = 0 OR resolved_at >= ?)", pageID, resolvedAfter).
+		Order("created_at desc").
+		Find(&incidents).Error
+	return incidents, err
+}
+
+// FindByPageAndID 获取状态页下的指定公告
+func (r *StatusPageIncidentRepo) FindByPageAndID(ctx context.Context, pageID, id string) (*models.StatusPageIncident, error) {
+	var incident models.StatusPageIncident
+	err := r.GetDB(ctx).Where("page_id = ? AND id = ?", pageID, id).First(&incident).Error
+	if err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// DeleteByPageID 删除状态页的所有公告
+func (r *StatusPageIncidentRepo) DeleteByPageID(ctx context.Context, pageID string) error {
+	return r.GetDB(ctx).Where("page_id = ?", pageID).Delete(&models.StatusPageIncident{}).Error
+}
+
+// StatusPageIncidentUpdateRepo 公告进展更新数据访问层
+type StatusPageIncidentUpdateRepo struct {
+	orz.Repository[models.StatusPageIncidentUpdate, string]
+}
+
+func NewStatusPageIncidentUpdateRepo(db *gorm.DB) *StatusPageIncidentUpdateRepo {
+	return &StatusPageIncidentUpdateRepo{
+		Repository: orz.NewRepository[models.StatusPageIncidentUpdate, string](db),
+	}
+}
+
+// FindByIncidentIDs 获取多个公告的进展更新，按时间倒序
+func (r *StatusPageIncidentUpdateRepo) FindByIncidentIDs(ctx context.Context, incidentIDs []string) ([]models.StatusPageIncidentUpdate, error) {
+	var updates []models.StatusPageIncidentUpdate
+	if len(incidentIDs) == 0 {
+		return updates, nil
+	}
+	err := r.GetDB(ctx).
+		Where("incident_id IN ?", incidentIDs).
+		Order("created_at desc").
+		Find(&updates).Error
+	return updates, err
+}
+
+// DeleteByIncidentID 删除公告的所有进展更新
+func (r *StatusPageIncidentUpdateRepo) DeleteByIncidentID(ctx context.Context, incidentID string) error {
+	return r.GetDB(ctx).Where("incident_id = ?", incidentID).Delete(&models.StatusPageIncidentUpdate{}).Error
+}
+
+// DeleteByPageID 删除状态页所有公告的进展更新
+func (r *StatusPageIncidentUpdateRepo) DeleteByPageID(ctx context.Context, pageID string) error {
+	return r.GetDB(ctx).
+		Where("incident_id IN (?)", r.GetDB(ctx).Model(&models.StatusPageIncident{}).Select("id").Where("page_id = ?", pageID)).
+		Delete(&models.StatusPageIncidentUpdate{}).Error
+}
@@ -0,0 +1,38 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+// StatusPageRepo 状态页数据访问层
+type StatusPageRepo struct {
+	orz.Repository[models.StatusPage, string]
+}
+
+func NewStatusPageRepo(db *gorm.DB) *StatusPageRepo {
+	return &StatusPageRepo{
+		Repository: orz.NewRepository[models.StatusPage, string](db),
+	}
+}
+
+// FindBySlug 根据访问路径标识查询状态页
+func (r *StatusPageRepo) FindBySlug(ctx context.Context, slug string) (*models.StatusPage, error) {
+	var page models.StatusPage
+	if err := r.GetDB(ctx).Where("slug = ?", slug).First(&page).Error; err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// ExistsBySlug 检查访问路径标识是否已被其他状态页使用
+func (r *StatusPageRepo) ExistsBySlug(ctx context.Context, slug, excludeID string) (bool, error) {
+	var count int64
+	err := r.GetDB(ctx).Model(&models.StatusPage{}).
+		Where("slug = ? AND id <> ?", slug, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/go-orz/orz"
+	"gorm.io/gorm"
+)
+
+// StatusPageSubscriberRepo 状态页订阅者数据访问层
+type StatusPageSubscriberRepo struct {
+	orz.Repository[models.StatusPageSubscriber, string]
+}
+
+func NewStatusPageSubscriberRepo(db *gorm.DB) *StatusPageSubscriberRepo {
+	return &StatusPageSubscriberRepo{
+		Repository: orz.NewRepository[models.StatusPageSubscriber, string](db),
+	}
+}
+
+// FindConfirmedByPageID 获取状态页已确认的订阅者
+func (r *StatusPageSubscriberRepo) FindConfirmedByPageID(ctx context.Context, pageID string) ([]models.StatusPageSubscriber, error) {
+	var subscribers []models.StatusPageSubscriber
+	err := r.GetDB(ctx).Where("page_id = ? AND confirm_token = ?", pageID, "").Find(&subscribers).Error
+	return subscribers, err
+}
+
+// FindByTarget 查询状态页上指定方式与地址的订阅
+func (r *StatusPageSubscriberRepo) FindByTarget(ctx context.Context, pageID, subscribeType, target string) (*models.StatusPageSubscriber, error) {
+	var subscriber models.StatusPageSubscriber
+	err := r.GetDB(ctx).
+		Where("page_id = ? AND type = ? AND target = ?", pageID, subscribeType, target).
+		First(&subscriber).Error
+	if err != nil {
+		return nil, err
+	}
+	return &subscriber, nil
+}
+
+// CountByPageID 统计状态页的订阅者数量
+func (r *StatusPageSubscriberRepo) CountByPageID(ctx context.Context, pageID string) (int64, error) {
+	var count int64
+	err := r.GetDB(ctx).Model(&models.StatusPageSubscriber{}).Where("page_id = ?", pageID).Count(&count).Error
+	return count, err
+}
+
+// ConfirmByToken 根据确认令牌确认订阅
+func (r *StatusPageSubscriberRepo) ConfirmByToken(ctx context.Context, pageID, token string) (int64, error) {
+	result := r.GetDB(ctx).Model(&models.StatusPageSubscriber{}).
+		Where("page_id = ? AND confirm_token = ?", pageID, token).
+		Update("confirm_token", "")
+	return result.RowsAffected, result.Error
+}
+
+// DeleteByPageAndID 删除状态页下的指定订阅者
+func (r *StatusPageSubscriberRepo) DeleteByPageAndID(ctx context.Context, pageID, id string) (int64, error) {
+	result := r.GetDB(ctx).Where("page_id = ? AND id = ?", pageID, id).Delete(&models.StatusPageSubscriber{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteByToken 根据退订令牌删除订阅
+func (r *StatusPageSubscriberRepo) DeleteByToken(ctx context.Context, pageID, token string) (int64, error) {
+	result := r.GetDB(ctx).Where("page_id = ? AND token = ?", pageID, token).Delete(&models.StatusPageSubscriber{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteByPageID 删除状态页的所有订阅者
+func (r *StatusPageSubscriberRepo) DeleteByPageID(ctx context.Context, pageID string) error {
+	return r.GetDB(ctx).Where("page_id = ?", pageID).Delete(&models.StatusPageSubscriber{}).Error
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/dushixiang/pika/internal/websocket"
+
+	"github.com/go-orz/orz"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// AgentConfigProfileService 探针配置模板服务：管理模板、计算探针生效的模板并通过 WebSocket 下发
+type AgentConfigProfileService struct {
+	logger      *zap.Logger
+	ProfileRepo *repo.AgentConfigProfileRepo // 导出用于 handler 的 PageBuilder
+	agentRepo   *repo.AgentRepo
+	wsManager   *websocket.Manager
+}
+
+func NewAgentConfigProfileService(logger *zap.Logger, db *gorm.DB, wsManager *websocket.Manager) *AgentConfigProfileService {
+	return &AgentConfigProfileService{
+		logger:      logger,
+		ProfileRepo: repo.NewAgentConfigProfileRepo(db),
+		agentRepo:   repo.NewAgentRepo(db),
+		wsManager:   wsManager,
+	}
+}
+
+// EffectiveAgentConfig 探针当前生效的配置模板及应用状态
+type EffectiveAgentConfig struct {
+	Profile *models.AgentConfigProfile     `json:"profile"` // 匹配的配置模板，为空表示使用探针本地配置
+	Version string                         `json:"version"` // 应下发的配置版本
+	Status  models.ConfigProfileStatusData `json:"status"`  // 探针反馈的应用状态
+}
+
+// ValidateConfig 校验配置模板内容，与探针端的校验规则保持一致
+func (s *AgentConfigProfileService) ValidateConfig(config *protocol.RemoteAgentConfig) error {
+	if config.Interval < 0 || config.Interval > 3600 {
+		return orz.NewError(400, "采集间隔范围为 1-3600 秒，0 表示沿用探针本地配置")
+	}
+	if config.HeartbeatInterval < 0 || config.HeartbeatInterval > 3600 {
+		return orz.NewError(400, "心跳间隔范围为 1-3600 秒，0 表示沿用探针本地配置")
+	}
+	for _, pattern := range append(append([]string{}, config.NetworkInclude...), config.NetworkExclude...) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return orz.NewError(400, fmt.Sprintf("网卡过滤规则 '%s' 无效: %v", pattern, err))
+		}
+	}
+	if config.AutoUpdate != nil && config.AutoUpdate.Enabled {
+		duration, err := time.ParseDuration(config.AutoUpdate.CheckInterval)
+		if err != nil || duration < time.Minute {
+			return orz.NewError(400, "更新检查间隔格式错误，如 10m、1h，且不能小于 1 分钟")
+		}
+	}
+	return nil
+}
+
+// Create 创建配置模板并下发到匹配的在线探针
+func (s *AgentConfigProfileService) Create(ctx context.Context, profile *models.AgentConfigProfile) error {
+	config := profile.Config.Data()
+	if err := s.ValidateConfig(&config); err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	profile.ID = uuid.New().String()
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+	if err := s.ProfileRepo.Create(ctx, profile); err != nil {
+		return err
+	}
+
+	go s.SyncOnlineAgents(context.Background())
+	return nil
+}
+
+// Update 更新配置模板并下发到受影响的在线探针
+func (s *AgentConfigProfileService) Update(ctx context.Context, profile *models.AgentConfigProfile) error {
+	config := profile.Config.Data()
+	if err := s.ValidateConfig(&config); err != nil {
+		return err
+	}
+
+	profile.UpdatedAt = time.Now().UnixMilli()
+	if err := s.ProfileRepo.Save(ctx, profile); err != nil {
+		return err
+	}
+
+	go s.SyncOnlineAgents(context.Background())
+	return nil
+}
+
+// Delete 删除配置模板，原先使用该模板的在线探针改用其他匹配模板或恢复本地配置
+func (s *AgentConfigProfileService) Delete(ctx context.Context, id string) error {
+	if err := s.ProfileRepo.DeleteById(ctx, id); err != nil {
+		return err
+	}
+
+	go s.SyncOnlineAgents(context.Background())
+	return nil
+}
+
+// Resolve 计算探针生效的配置模板：直接分配优先于标签匹配，同类中取优先级最高的
+func (s *AgentConfigProfileService) Resolve(ctx context.Context, agent *models.Agent) (*models.AgentConfigProfile, error) {
+	profiles, err := s.ProfileRepo.ListByPriority(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resolveAgentConfigProfile(profiles, agent), nil
+}
+
+// resolveAgentConfigProfile 从按优先级排序的模板中选出探针生效的模板
+func resolveAgentConfigProfile(profiles []models.AgentConfigProfile, agent *models.Agent) *models.AgentConfigProfile {
+	for i := range profiles {
+		if profiles[i].AssignedTo(agent.ID) {
+			return &profiles[i]
+		}
+	}
+	for i := range profiles {
+		if profiles[i].MatchesTags(agent.Tags) {
+			return &profiles[i]
+		}
+	}
+	return nil
+}
+
+// buildAgentConfigData 构建下发的配置消息，版本为模板ID与配置内容的摘要
+func buildAgentConfigData(profile *models.AgentConfigProfile) protocol.AgentConfigData {
+	if profile == nil {
+		return protocol.AgentConfigData{}
+	}
+
+	config := profile.Config.Data()
+	content, _ := json.Marshal(config)
+	sum := sha256.Sum256(append([]byte(profile.ID+":"), content...))
+	return protocol.AgentConfigData{
+		ProfileID: profile.ID,
+		Version:   hex.EncodeToString(sum[:8]),
+		Config:    config,
+	}
+}
+
+// GetEffective 获取探针当前生效的配置模板及应用状态
+func (s *AgentConfigProfileService) GetEffective(ctx context.Context, agentID string) (*EffectiveAgentConfig, error) {
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.Resolve(ctx, &agent)
+	if err != nil {
+		return nil, err
+	}
+	return &EffectiveAgentConfig{
+		Profile: profile,
+		Version: buildAgentConfigData(profile).Version,
+		Status:  agent.ConfigProfileStatus.Data(),
+	}, nil
+}
+
+// BuildConfigForAgent 构建探针应使用的配置消息，配置变化时将应用状态标记为 pending
+// 探针每次连接都会收到配置消息，已应用相同版本的探针会直接确认
+func (s *AgentConfigProfileService) BuildConfigForAgent(ctx context.Context, agent *models.Agent) (*protocol.AgentConfigData, error) {
+	profile, err := s.Resolve(ctx, agent)
+	if err != nil {
+		return nil, err
+	}
+	data := buildAgentConfigData(profile)
+
+	status := agent.ConfigProfileStatus.Data()
+	if status.ProfileID != data.ProfileID || status.Version != data.Version {
+		status = models.ConfigProfileStatusData{
+			ProfileID:   data.ProfileID,
+			Version:     data.Version,
+			ApplyStatus: "pending",
+		}
+		if err := s.updateStatus(ctx, agent.ID, status); err != nil {
+			return nil, err
+		}
+	}
+	return &data, nil
+}
+
+// SyncAgents 重新计算指定在线探针的配置模板，配置变化时下发
+func (s *AgentConfigProfileService) SyncAgents(ctx context.Context, agentIDs []string) {
+	agents, err := s.agentRepo.ListByIDs(ctx, agentIDs)
+	if err != nil {
+		s.logger.Error("查询探针失败", zap.Error(err))
+		return
+	}
+	profiles, err := s.ProfileRepo.ListByPriority(ctx)
+	if err != nil {
+		s.logger.Error("查询配置模板失败", zap.Error(err))
+		return
+	}
+
+	for i := range agents {
+		agent := &agents[i]
+		if _, online := s.wsManager.GetClient(agent.ID); !online {
+			continue
+		}
+
+		data := buildAgentConfigData(resolveAgentConfigProfile(profiles, agent))
+		status := agent.ConfigProfileStatus.Data()
+		if status.ProfileID == data.ProfileID && status.Version == data.Version && status.ApplyStatus != "failed" {
+			continue
+		}
+
+		if err := s.updateStatus(ctx, agent.ID, models.ConfigProfileStatusData{
+			ProfileID:   data.ProfileID,
+			Version:     data.Version,
+			ApplyStatus: "pending",
+		}); err != nil {
+			s.logger.Error("更新配置模板状态失败", zap.String("agentId", agent.ID), zap.Error(err))
+			continue
+		}
+		if err := s.sendConfig(agent.ID, data); err != nil {
+			s.logger.Warn("下发配置模板到探针失败", zap.String("agentId", agent.ID), zap.Error(err))
+			continue
+		}
+		s.logger.Info("已下发配置模板到探针",
+			zap.String("agentId", agent.ID),
+			zap.String("profileId", data.ProfileID),
+			zap.String("version", data.Version))
+	}
+}
+
+// SyncOnlineAgents 重新计算所有在线探针的配置模板，模板增删改后调用
+func (s *AgentConfigProfileService) SyncOnlineAgents(ctx context.Context) {
+	s.SyncAgents(ctx, s.wsManager.GetAllClients())
+}
+
+// sendConfig 通过 WebSocket 下发配置模板
+func (s *AgentConfigProfileService) sendConfig(agentID string, data protocol.AgentConfigData) error {
+	msgBytes, err := json.Marshal(protocol.OutboundMessage{
+		Type: protocol.MessageTypeAgentConfig,
+		Data: data,
+	})
+	if err != nil {
+		return err
+	}
+	return s.wsManager.SendToClient(agentID, msgBytes)
+}
+
+// HandleConfigResult 处理探针上报的配置模板应用结果
+func (s *AgentConfigProfileService) HandleConfigResult(ctx context.Context, agentID string, result protocol.AgentConfigResult) error {
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("获取探针失败: %w", err)
+	}
+
+	// 忽略已被新配置取代的旧结果
+	status := agent.ConfigProfileStatus.Data()
+	if status.ProfileID != result.ProfileID || status.Version != result.Version {
+		return nil
+	}
+
+	status.ApplyStatus = "success"
+	if !result.Success {
+		status.ApplyStatus = "failed"
+	}
+	status.ApplyMessage = result.Message
+	status.AppliedAt = time.Now().UnixMilli()
+	return s.updateStatus(ctx, agentID, status)
+}
+
+func (s *AgentConfigProfileService) updateStatus(ctx context.Context, agentID string, status models.ConfigProfileStatusData) error {
+	var agentForUpdate = models.Agent{
+		ID:                  agentID,
+		ConfigProfileStatus: datatypes.NewJSONType(status),
+	}
+	return s.agentRepo.UpdateById(ctx, &agentForUpdate)
+}
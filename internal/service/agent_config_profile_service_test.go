@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"gorm.io/datatypes"
+)
+
+func TestResolveAgentConfigProfile(t *testing.T) {
+	// 已按优先级从高到低排序
+	profiles := []models.AgentConfigProfile{
+		{ID: "db", Priority: 10, Tags: []string{"db"}},
+		{ID: "prod", Priority: 5, Tags: []string{"prod"}},
+		{ID: "pinned", Priority: 0, AgentIDs: []string{"agent-3"}},
+	}
+
+	cases := []struct {
+		agent models.Agent
+		want  string
+	}{
+		{models.Agent{ID: "agent-1", Tags: []string{"prod", "db"}}, "db"},
+		{models.Agent{ID: "agent-2", Tags: []string{"prod"}}, "prod"},
+		{models.Agent{ID: "agent-3", Tags: []string{"db"}}, "pinned"}, // 直接分配优先于标签匹配
+		{models.Agent{ID: "agent-4", Tags: []string{"dev"}}, ""},
+	}
+	for _, tc := range cases {
+		var got string
+		if profile := resolveAgentConfigProfile(profiles, &tc.agent); profile != nil {
+			got = profile.ID
+		}
+		if got != tc.want {
+			t.Errorf("agent %s: got profile %q, want %q", tc.agent.ID, got, tc.want)
+		}
+	}
+}
+
+func TestBuildAgentConfigDataVersion(t *testing.T) {
+	profile := &models.AgentConfigProfile{
+		ID:     "p1",
+		Config: datatypes.NewJSONType(protocol.RemoteAgentConfig{Interval: 10}),
+	}
+	v1 := buildAgentConfigData(profile).Version
+	if v1 == "" || buildAgentConfigData(profile).Version != v1 {
+		t.Fatalf("version should be stable, got %q", v1)
+	}
+
+	profile.Config = datatypes.NewJSONType(protocol.RemoteAgentConfig{Interval: 10, DiskInclude: []string{"/"}})
+	if buildAgentConfigData(profile).Version == v1 {
+		t.Fatal("version should change with config content")
+	}
+	if data := buildAgentConfigData(nil); data.ProfileID != "" || data.Version != "" {
+		t.Fatalf("unexpected data for nil profile: %+v", data)
+	}
+}
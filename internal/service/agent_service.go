@@ -22,6 +22,8 @@ type AgentService struct {
 	AgentRepo         *repo.AgentRepo
 	TamperEventRepo   *repo.TamperEventRepo
 	SSHLoginEventRepo *repo.SSHLoginEventRepo
+	LogWatchEventRepo *repo.LogWatchEventRepo
+	LogEntryRepo      *repo.LogEntryRepo
 	apiKeyService     *ApiKeyService
 	metricService     *MetricService
 	geoipService      *GeoIPService
@@ -34,6 +36,8 @@ func NewAgentService(logger *zap.Logger, db *gorm.DB, apiKeyService *ApiKeyServi
 		AgentRepo:         repo.NewAgentRepo(db),
 		TamperEventRepo:   repo.NewTamperEventRepo(db),
 		SSHLoginEventRepo: repo.NewSSHLoginEventRepo(db),
+		LogWatchEventRepo: repo.NewLogWatchEventRepo(db),
+		LogEntryRepo:      repo.NewLogEntryRepo(db),
 		apiKeyService:     apiKeyService,
 		metricService:     metricService,
 		geoipService:      geoipService,
@@ -177,6 +181,8 @@ func (s *AgentService) HandleCommandResponse(ctx context.Context, agentID string
 	switch resp.Type {
 	case "vps_audit":
 		return s.handleVPSAuditResponse(ctx, agentID, resp)
+	case "diagnostics":
+		return s.handleDiagnosticsResponse(ctx, agentID, resp)
 	default:
 		s.logger.Warn("unknown command type", zap.String("type", resp.Type))
 		return nil
@@ -288,6 +294,57 @@ func (s *AgentService) enrichLoginRecordsWithLocation(result *protocol.VPSAuditR
 	}
 }
 
+// handleDiagnosticsResponse 处理诊断信息响应，与审计结果共用存储
+func (s *AgentService) handleDiagnosticsResponse(ctx context.Context, agentID string, resp *protocol.CommandResponse) error {
+	if resp.Status == "error" {
+		s.logger.Error("collect diagnostics failed",
+			zap.String("agentID", agentID),
+			zap.String("error", resp.Error))
+		return nil
+	}
+	if resp.Status != "success" {
+		return nil
+	}
+
+	var bundle protocol.DiagnosticsBundle
+	if err := json.Unmarshal([]byte(resp.Result), &bundle); err != nil {
+		s.logger.Error("failed to parse diagnostics bundle", zap.Error(err))
+		return err
+	}
+
+	record := &models.AuditResult{
+		AgentID:   agentID,
+		Type:      "diagnostics",
+		Result:    resp.Result,
+		StartTime: bundle.GeneratedAt,
+		EndTime:   bundle.GeneratedAt,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	if err := s.AgentRepo.SaveAuditResult(ctx, record); err != nil {
+		return err
+	}
+
+	s.logger.Info("诊断信息保存成功", zap.String("agentId", agentID), zap.Int64("id", record.ID))
+	return nil
+}
+
+// GetDiagnostics 获取最新的诊断信息
+func (s *AgentService) GetDiagnostics(ctx context.Context, agentID string) (*protocol.DiagnosticsBundle, error) {
+	record, err := s.AgentRepo.GetLatestAuditResultByType(ctx, agentID, "diagnostics")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var bundle protocol.DiagnosticsBundle
+	if err := json.Unmarshal([]byte(record.Result), &bundle); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
 // GetAuditResult 获取最新的审计结果(原始数据)
 func (s *AgentService) GetAuditResult(ctx context.Context, agentID string) (*protocol.VPSAuditResult, error) {
 	record, err := s.AgentRepo.GetLatestAuditResultByType(ctx, agentID, "vps_audit")
@@ -383,7 +440,19 @@ func (s *AgentService) DeleteAgent(ctx context.Context, agentID string) error {
 			return err
 		}
 
-		// 4. 最后删除探针本身
+		// 4. 删除探针的日志匹配事件数据
+		if err := s.LogWatchEventRepo.DeleteEventsByAgentID(ctx, agentID); err != nil {
+			s.logger.Error("删除探针日志匹配事件失败", zap.String("agentId", agentID), zap.Error(err))
+			return err
+		}
+
+		// 5. 删除探针转发的系统日志
+		if err := s.LogEntryRepo.DeleteByAgentID(ctx, agentID); err != nil {
+			s.logger.Error("删除探针系统日志失败", zap.String("agentId", agentID), zap.Error(err))
+			return err
+		}
+
+		// 6. 最后删除探针本身
 		if err := s.AgentRepo.DeleteById(ctx, agentID); err != nil {
 			s.logger.Error("删除探针失败", zap.String("agentId", agentID), zap.Error(err))
 			return err
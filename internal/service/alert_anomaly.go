@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/models"
+	"go.uber.org/zap"
+)
+
+// CheckAnomalies 检查探针指标是否偏离学习到的基线
+func (s *AlertService) CheckAnomalies(ctx context.Context, agentID string, latest *metric.LatestMetrics) error {
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	rules := alertConfig.Rules
+	if !alertConfig.Enabled || !rules.AnomalyEnabled || latest == nil {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now()
+	sensitivity := anomalySensitivity(rules.AnomalySensitivity)
+	for _, m := range anomalyMetrics {
+		if len(rules.AnomalyMetrics) > 0 && !slices.Contains(rules.AnomalyMetrics, m.Key) {
+			continue
+		}
+		value, ok := anomalyCurrentValue(m.Key, latest)
+		if !ok {
+			continue
+		}
+		// 历史数据不足以学习基线时不做判断
+		band, ok := s.metricService.anomalyBand(ctx, agentID, m, now, sensitivity)
+		if !ok {
+			continue
+		}
+		s.checkAnomalyAlert(ctx, alertConfig, &agent, m, value, band, now.UnixMilli())
+	}
+
+	return nil
+}
+
+// checkAnomalyAlert 检查单个指标的异常状态，持续偏离预期范围指定时间后触发
+func (s *AlertService) checkAnomalyAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, m anomalyMetric, value float64, band metric.BandPoint, now int64) {
+	stateKey := fmt.Sprintf("%s:global:anomaly:%s", agent.ID, m.Key)
+	duration := config.Rules.AnomalyDuration
+
+	breached := value > band.Upper || value < band.Lower
+	// 阈值记录被突破的边界
+	threshold := band.Upper
+	if value < band.Lower {
+		threshold = band.Lower
+	}
+	state, shouldFire, shouldResolve := s.updateAlertState(ctx, agent, stateKey, "anomaly",
+		breached, value, threshold, duration, now)
+
+	if shouldFire {
+		s.fireAnomalyAlert(ctx, agent, m, band, state, now)
+	}
+
+	if shouldResolve {
+		s.resolveAlert(ctx, config, agent, state)
+	}
+}
+
+// fireAnomalyAlert 触发异常检测告警
+func (s *AlertService) fireAnomalyAlert(ctx context.Context, agent *models.Agent, m anomalyMetric, band metric.BandPoint, state *models.AlertState, now int64) {
+	s.logger.Info("触发异常检测告警",
+		zap.String("agentId", agent.ID),
+		zap.String("metric", m.Key),
+		zap.Float64("value", state.Value),
+		zap.Float64("lower", band.Lower),
+		zap.Float64("upper", band.Upper),
+	)
+
+	direction := "高于"
+	if state.Value < band.Lower {
+		direction = "低于"
+	}
+
+	// 偏离程度：超出边界的距离占预期范围半宽的百分比
+	level := "info"
+	if halfWidth := (band.Upper - band.Lower) / 2; halfWidth > 0 {
+		deviation := (state.Value - state.Threshold) / halfWidth * 100
+		if deviation < 0 {
+			deviation = -deviation
+		}
+		level = s.calculateLevel(deviation, 0)
+	}
+
+	record := &models.AlertRecord{
+		AgentID:   agent.ID,
+		AgentName: agent.Name,
+		AlertType: state.AlertType,
+		Message: fmt.Sprintf("%s持续%d秒%s基线预期范围，当前值%s，预期%s（范围 %s ~ %s）",
+			m.Label, state.Duration, direction, m.formatValue(state.Value),
+			m.formatValue(band.Expected), m.formatValue(band.Lower), m.formatValue(band.Upper)),
+		Threshold:   state.Threshold,
+		ActualValue: state.Value,
+		Level:       level,
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建异常检测告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	go s.sendAlertNotification(record, agent)
+}
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"go.uber.org/zap"
+)
+
+// CheckCustomMetrics 检查探针自定义指标告警
+func (s *AlertService) CheckCustomMetrics(ctx context.Context, agentID string, custom []protocol.CustomMetricData) error {
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	if !alertConfig.Enabled || len(alertConfig.Rules.CustomRules) == 0 || len(custom) == 0 {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	for _, rule := range alertConfig.Rules.CustomRules {
+		if !rule.Enabled {
+			continue
+		}
+		// 探针未上报该指标时保持原状态，不视为恢复
+		value, ok := evaluateCustomMetricRule(rule, custom)
+		if !ok {
+			continue
+		}
+		s.checkCustomMetricAlert(ctx, alertConfig, &agent, rule, value, now)
+	}
+
+	return nil
+}
+
+// evaluateCustomMetricRule 计算规则匹配的所有序列中最接近触发条件的值（gt 取最大值，lt 取最小值）
+func evaluateCustomMetricRule(rule models.CustomMetricAlertRule, custom []protocol.CustomMetricData) (float64, bool) {
+	name := customMetricName(rule.Metric)
+	if name == "" {
+		return 0, false
+	}
+
+	var result float64
+	var found bool
+	for _, data := range custom {
+		if customMetricName(data.Name) != name || math.IsNaN(data.Value) {
+			continue
+		}
+		// 规则使用与时序存储一致的标签名，如 queue.name 写作 queue_name
+		labels := customMetricLabels(data.Labels)
+		matched := true
+		for k, v := range rule.Labels {
+			if labels[k] != v {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		switch {
+		case !found:
+			result = data.Value
+		case rule.Operator == "lt":
+			result = math.Min(result, data.Value)
+		default:
+			result = math.Max(result, data.Value)
+		}
+		found = true
+	}
+	return result, found
+}
+
+// customMetricRuleKey 生成规则的唯一标识，用于告警状态
+func customMetricRuleKey(rule models.CustomMetricAlertRule) string {
+	operator := rule.Operator
+	if operator != "lt" {
+		operator = "gt"
+	}
+	parts := make([]string, 0, len(rule.Labels))
+	for k, v := range rule.Labels {
+		parts = append(parts, k+"="+v)
+	}
+	sort.Strings(parts)
+	return fmt.Sprintf("%s:%s:%s", customMetricName(rule.Metric), operator, strings.Join(parts, ","))
+}
+
+// checkCustomMetricAlert 检查单条自定义指标告警规则，持续满足触发条件指定时间后触发
+func (s *AlertService) checkCustomMetricAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, rule models.CustomMetricAlertRule, value float64, now int64) {
+	stateKey := fmt.Sprintf("%s:global:custom:%s", agent.ID, customMetricRuleKey(rule))
+
+	breached := value >= rule.Threshold
+	if rule.Operator == "lt" {
+		breached = value <= rule.Threshold
+	}
+	state, shouldFire, shouldResolve := s.updateAlertState(ctx, agent, stateKey, "custom",
+		breached, value, rule.Threshold, rule.Duration, now)
+
+	if shouldFire {
+		s.fireCustomMetricAlert(ctx, agent, rule, state, now)
+	}
+
+	if shouldResolve {
+		s.resolveAlert(ctx, config, agent, state)
+	}
+}
+
+// fireCustomMetricAlert 触发自定义指标告警
+func (s *AlertService) fireCustomMetricAlert(ctx context.Context, agent *models.Agent, rule models.CustomMetricAlertRule, state *models.AlertState, now int64) {
+	s.logger.Info("触发自定义指标告警",
+		zap.String("agentId", agent.ID),
+		zap.String("metric", rule.Metric),
+		zap.Float64("value", state.Value),
+		zap.Float64("threshold", state.Threshold),
+	)
+
+	comparison := "超过"
+	if rule.Operator == "lt" {
+		comparison = "低于"
+	}
+
+	record := &models.AlertRecord{
+		AgentID:   agent.ID,
+		AgentName: agent.Name,
+		AlertType: state.AlertType,
+		Message: fmt.Sprintf("自定义指标 %s 持续%d秒%s%.2f，当前值%.2f",
+			rule.Metric, rule.Duration, comparison, rule.Threshold, state.Value),
+		Threshold:   state.Threshold,
+		ActualValue: state.Value,
+		Level:       s.calculateLevel(math.Abs(state.Value-state.Threshold)+state.Threshold, state.Threshold),
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建自定义指标告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	go s.sendAlertNotification(record, agent)
+}
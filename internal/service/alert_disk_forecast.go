@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/models"
+	"go.uber.org/zap"
+)
+
+// diskForecastMinR2 触发预测告警所需的最低拟合度，避免波动较大的磁盘误报
+const diskForecastMinR2 = 0.5
+
+// CheckDiskForecasts 检查磁盘写满预测告警
+func (s *AlertService) CheckDiskForecasts(ctx context.Context, config *models.AlertConfig, forecasts map[string][]metric.DiskForecast) {
+	if !config.Enabled || !config.Rules.DiskForecastEnabled {
+		return
+	}
+
+	now := time.Now().UnixMilli()
+	for agentID, items := range forecasts {
+		agent, err := s.agentRepo.FindById(ctx, agentID)
+		if err != nil {
+			// 探针已删除
+			continue
+		}
+		for i := range items {
+			s.checkDiskForecastAlert(ctx, config, &agent, &items[i], now)
+		}
+	}
+}
+
+// checkDiskForecastAlert 检查单个挂载点的预测告警，预测结果已是多日趋势，满足条件即触发
+func (s *AlertService) checkDiskForecastAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, forecast *metric.DiskForecast, now int64) {
+	stateKey := fmt.Sprintf("%s:global:disk_forecast:%s", agent.ID, forecast.MountPoint)
+	threshold := config.Rules.DiskForecastDays
+
+	breached := forecast.DaysUntilFull != nil && *forecast.DaysUntilFull <= threshold && forecast.R2 >= diskForecastMinR2
+	value := float64(-1)
+	if forecast.DaysUntilFull != nil {
+		value = *forecast.DaysUntilFull
+	}
+	state, shouldFire, shouldResolve := s.updateAlertState(ctx, agent, stateKey, "disk_forecast",
+		breached, value, threshold, 0, now)
+
+	if shouldResolve {
+		s.resolveAlert(ctx, config, agent, state)
+		return
+	}
+	if !shouldFire {
+		return
+	}
+
+	s.logger.Info("触发磁盘容量预测告警",
+		zap.String("agentId", agent.ID),
+		zap.String("mountPoint", forecast.MountPoint),
+		zap.Float64("daysUntilFull", state.Value),
+		zap.Float64("threshold", threshold),
+	)
+
+	record := &models.AlertRecord{
+		AgentID:   agent.ID,
+		AgentName: agent.Name,
+		AlertType: "disk_forecast",
+		Message: fmt.Sprintf("挂载点 %s 预计%.1f天后写满（当前使用率%.2f%%，每日增长%s），低于阈值%.0f天",
+			forecast.MountPoint, state.Value, forecast.UsagePercent, formatBytes(uint64(forecast.GrowthBytesPerDay)), threshold),
+		Threshold:   threshold,
+		ActualValue: state.Value,
+		Level:       s.calculateCertLevel(state.Value),
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建磁盘容量预测告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	go s.sendAlertNotification(record, agent)
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultDiskReallocatedThreshold 默认的重映射扇区数告警阈值
+	defaultDiskReallocatedThreshold = 10
+	// defaultDiskWearThreshold 默认的 SSD 已用寿命告警阈值（百分比）
+	defaultDiskWearThreshold = 90
+)
+
+// CheckHardwareHealth 检查 RAID 阵列降级与磁盘故障预警
+func (s *AlertService) CheckHardwareHealth(ctx context.Context, agentID string, hardware *protocol.HardwareData) error {
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	rules := alertConfig.Rules
+	if !alertConfig.Enabled || hardware == nil || (!rules.RaidDegradedEnabled && !rules.DiskFailingEnabled) {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	if rules.RaidDegradedEnabled {
+		checked := make(map[string]struct{}, len(hardware.Arrays))
+		for _, array := range hardware.Arrays {
+			stateKey := fmt.Sprintf("%s:global:raid_degraded:%s:%s", agent.ID, array.Type, array.Name)
+			checked[stateKey] = struct{}{}
+			s.checkRaidDegradedAlert(ctx, alertConfig, &agent, stateKey, array, now)
+		}
+		s.resolveMissingStates(ctx, alertConfig, &agent, "raid_degraded", checked)
+	}
+
+	if rules.DiskFailingEnabled {
+		checked := make(map[string]struct{}, len(hardware.Disks))
+		for _, disk := range hardware.Disks {
+			// 设备名可能随重启变化，优先使用序列号区分磁盘
+			id := disk.Serial
+			if id == "" {
+				id = disk.Device
+			}
+			stateKey := fmt.Sprintf("%s:global:disk_failing:%s", agent.ID, id)
+			checked[stateKey] = struct{}{}
+			s.checkDiskFailingAlert(ctx, alertConfig, &agent, stateKey, disk, now)
+		}
+		s.resolveMissingStates(ctx, alertConfig, &agent, "disk_failing", checked)
+	}
+
+	return nil
+}
+
+// checkRaidDegradedAlert 检查单个阵列，降级后立即触发
+func (s *AlertService) checkRaidDegradedAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, stateKey string, array protocol.RaidArrayData, now int64) {
+	state, shouldFire, shouldResolve := s.updateAlertState(ctx, agent, stateKey, "raid_degraded",
+		array.Degraded, float64(array.FailedDevices), 0, 0, now)
+
+	if shouldResolve {
+		s.resolveAlert(ctx, config, agent, state)
+		return
+	}
+	if !shouldFire {
+		return
+	}
+
+	var message string
+	if array.Type == "zfs" {
+		message = fmt.Sprintf("ZFS 存储池 %s 状态为 %s", array.Name, array.State)
+	} else {
+		message = fmt.Sprintf("软 RAID 阵列 %s（%s）已降级：状态 %s，正常设备 %d/%d，故障设备 %d",
+			array.Name, array.Level, array.State, array.ActiveDevices, array.Devices, array.FailedDevices)
+		if array.SyncAction != "" {
+			message += fmt.Sprintf("，正在 %s（%.1f%%）", array.SyncAction, array.SyncProgress)
+		}
+	}
+	s.fireServiceAlert(ctx, agent, state, message, "critical", now)
+}
+
+// checkDiskFailingAlert 检查单块磁盘，SMART 指标出现故障征兆后立即触发
+func (s *AlertService) checkDiskFailingAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, stateKey string, disk protocol.SmartDiskData, now int64) {
+	reasons, critical := diskFailingReasons(disk, config.Rules)
+	state, shouldFire, shouldResolve := s.updateAlertState(ctx, agent, stateKey, "disk_failing",
+		len(reasons) > 0, float64(disk.ReallocatedSectors), 0, 0, now)
+
+	if shouldResolve {
+		s.resolveAlert(ctx, config, agent, state)
+		return
+	}
+	if !shouldFire {
+		return
+	}
+
+	level := "warning"
+	if critical {
+		level = "critical"
+	}
+	message := fmt.Sprintf("磁盘 %s（%s，序列号 %s）存在故障征兆：%s",
+		disk.Device, disk.Model, disk.Serial, strings.Join(reasons, "，"))
+	s.fireServiceAlert(ctx, agent, state, message, level, now)
+}
+
+// diskFailingReasons 根据 SMART 信息判断磁盘的故障征兆，SMART 自检失败、存在待映射或无法校正的扇区、
+// NVMe 严重警告视为严重，重映射扇区与寿命超过阈值视为警告
+func diskFailingReasons(disk protocol.SmartDiskData, rules models.AlertRules) ([]string, bool) {
+	reallocatedThreshold := uint64(rules.DiskReallocatedThreshold)
+	if reallocatedThreshold == 0 {
+		reallocatedThreshold = defaultDiskReallocatedThreshold
+	}
+	wearThreshold := rules.DiskWearThreshold
+	if wearThreshold <= 0 {
+		wearThreshold = defaultDiskWearThreshold
+	}
+
+	var reasons []string
+	critical := false
+	if !disk.Passed {
+		reasons = append(reasons, "SMART 自检未通过")
+		critical = true
+	}
+	if disk.PendingSectors > 0 {
+		reasons = append(reasons, fmt.Sprintf("待映射扇区 %d", disk.PendingSectors))
+		critical = true
+	}
+	if disk.OfflineUncorrectable > 0 {
+		reasons = append(reasons, fmt.Sprintf("无法校正扇区 %d", disk.OfflineUncorrectable))
+		critical = true
+	}
+	if disk.CriticalWarning != 0 {
+		reasons = append(reasons, fmt.Sprintf("NVMe 严重警告 0x%02x", disk.CriticalWarning))
+		critical = true
+	}
+	if disk.ReallocatedSectors >= reallocatedThreshold {
+		reasons = append(reasons, fmt.Sprintf("重映射扇区 %d", disk.ReallocatedSectors))
+	}
+	if disk.WearPercent != nil && *disk.WearPercent >= wearThreshold {
+		reasons = append(reasons, fmt.Sprintf("寿命已用 %.0f%%", *disk.WearPercent))
+	}
+	return reasons, critical
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"go.uber.org/zap"
+)
+
+// oomKillWindowSeconds OOM 次数告警统计的时间窗口
+const oomKillWindowSeconds = 600
+
+// kernelAlertMetric 可告警的内核指标
+type kernelAlertMetric struct {
+	Name  string
+	Unit  string
+	Value func(latest *metric.LatestMetrics) (float64, bool)
+}
+
+// kernelAlertMetrics 支持告警的内核指标，oom_kills 需查询时间窗口内的增量，单独处理
+var kernelAlertMetrics = map[string]kernelAlertMetric{
+	"cpu_steal": {Name: "CPU steal", Unit: "%", Value: func(latest *metric.LatestMetrics) (float64, bool) {
+		if latest.CPU == nil || latest.CPU.Times == nil {
+			return 0, false
+		}
+		return latest.CPU.Times.Steal, true
+	}},
+	"cpu_iowait": {Name: "CPU iowait", Unit: "%", Value: func(latest *metric.LatestMetrics) (float64, bool) {
+		if latest.CPU == nil || latest.CPU.Times == nil {
+			return 0, false
+		}
+		return latest.CPU.Times.IOWait, true
+	}},
+	"cpu_softirq": {Name: "CPU softirq", Unit: "%", Value: func(latest *metric.LatestMetrics) (float64, bool) {
+		if latest.CPU == nil || latest.CPU.Times == nil {
+			return 0, false
+		}
+		return latest.CPU.Times.SoftIRQ, true
+	}},
+	"psi_cpu": {Name: "CPU 压力(PSI)", Unit: "%", Value: func(latest *metric.LatestMetrics) (float64, bool) {
+		if latest.Kernel == nil || latest.Kernel.Pressure == nil {
+			return 0, false
+		}
+		return latest.Kernel.Pressure.CPUSome, true
+	}},
+	"psi_memory": {Name: "内存压力(PSI)", Unit: "%", Value: func(latest *metric.LatestMetrics) (float64, bool) {
+		if latest.Kernel == nil || latest.Kernel.Pressure == nil {
+			return 0, false
+		}
+		return latest.Kernel.Pressure.MemorySome, true
+	}},
+	"psi_io": {Name: "IO 压力(PSI)", Unit: "%", Value: func(latest *metric.LatestMetrics) (float64, bool) {
+		if latest.Kernel == nil || latest.Kernel.Pressure == nil {
+			return 0, false
+		}
+		return latest.Kernel.Pressure.IOSome, true
+	}},
+	"fd_usage": {Name: "文件句柄使用率", Unit: "%", Value: func(latest *metric.LatestMetrics) (float64, bool) {
+		if latest.Kernel == nil || latest.Kernel.FileHandlesMax == 0 {
+			return 0, false
+		}
+		return float64(latest.Kernel.FileHandlesAllocated) / float64(latest.Kernel.FileHandlesMax) * 100, true
+	}},
+	"conntrack_usage": {Name: "conntrack 表使用率", Unit: "%", Value: func(latest *metric.LatestMetrics) (float64, bool) {
+		if latest.Kernel == nil || latest.Kernel.ConntrackMax == 0 {
+			return 0, false
+		}
+		return float64(latest.Kernel.ConntrackCount) / float64(latest.Kernel.ConntrackMax) * 100, true
+	}},
+	"oom_kills": {Name: "OOM Kill", Unit: "次"},
+}
+
+// CheckKernelMetrics 检查 CPU steal、PSI、文件句柄、conntrack 与 OOM 等内核指标告警
+func (s *AlertService) CheckKernelMetrics(ctx context.Context, agentID string, latest *metric.LatestMetrics) error {
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	if !alertConfig.Enabled || len(alertConfig.Rules.KernelRules) == 0 || latest == nil {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	checked := make(map[string]struct{}, len(alertConfig.Rules.KernelRules))
+	for _, rule := range alertConfig.Rules.KernelRules {
+		m, ok := kernelAlertMetrics[rule.Metric]
+		if !rule.Enabled || !ok {
+			continue
+		}
+
+		var value float64
+		if rule.Metric == "oom_kills" {
+			value, ok = s.oomKillIncrease(ctx, agent.ID)
+		} else {
+			value, ok = m.Value(latest)
+		}
+		// 探针未上报该指标（非 Linux 或内核不支持）时不做判断
+		if !ok {
+			continue
+		}
+
+		stateKey := fmt.Sprintf("%s:global:kernel:%s", agent.ID, rule.Metric)
+		checked[stateKey] = struct{}{}
+		state, shouldFire, shouldResolve := s.updateAlertState(ctx, &agent, stateKey, "kernel",
+			value >= rule.Threshold, value, rule.Threshold, rule.Duration, now)
+
+		if shouldResolve {
+			s.resolveAlert(ctx, alertConfig, &agent, state)
+			continue
+		}
+		if !shouldFire {
+			continue
+		}
+
+		var message string
+		if rule.Metric == "oom_kills" {
+			message = fmt.Sprintf("最近%d分钟内发生%.0f次 OOM Kill", oomKillWindowSeconds/60, value)
+		} else {
+			message = fmt.Sprintf("%s %.2f%s 已持续%d秒超过阈值 %.2f%s", m.Name, value, m.Unit, rule.Duration, rule.Threshold, m.Unit)
+		}
+		s.fireServiceAlert(ctx, &agent, state, message, s.calculateLevel(value, rule.Threshold), now)
+	}
+
+	// 规则被禁用或指标不再上报时恢复告警
+	s.resolveMissingStates(ctx, alertConfig, &agent, "kernel", checked)
+	return nil
+}
+
+// oomKillIncrease 查询时间窗口内的 OOM Kill 次数
+func (s *AlertService) oomKillIncrease(ctx context.Context, agentID string) (float64, bool) {
+	query := fmt.Sprintf(`increase(pika_kernel_oom_kills_total{agent_id="%s"}[%ds])`, escapeLabelValue(agentID), oomKillWindowSeconds)
+	result, err := s.metricService.metricStore.Query(ctx, query)
+	if err != nil {
+		s.logger.Error("查询 OOM Kill 次数失败", zap.String("agentId", agentID), zap.Error(err))
+		return 0, false
+	}
+	for _, r := range result.Data.Result {
+		if value, ok := r.InstantValue(); ok {
+			return value, true
+		}
+	}
+	return 0, false
+}
@@ -20,18 +20,20 @@ type AlertService struct {
 	AlertStateRepo  *repo.AlertStateRepo
 	agentRepo       *repo.AgentRepo
 	monitorService  *MonitorService
+	metricService   *MetricService
 	propertyService *PropertyService
 	notifier        *Notifier
 	logger          *zap.Logger
 }
 
-func NewAlertService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, monitorService *MonitorService, notifier *Notifier) *AlertService {
+func NewAlertService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, monitorService *MonitorService, metricService *MetricService, notifier *Notifier) *AlertService {
 	return &AlertService{
 		Service:         orz.NewService(db),
 		AlertRecordRepo: repo.NewAlertRecordRepo(db),
 		AlertStateRepo:  repo.NewAlertStateRepo(db),
 		agentRepo:       repo.NewAgentRepo(db),
 		monitorService:  monitorService,
+		metricService:   metricService,
 		propertyService: propertyService,
 		notifier:        notifier,
 		logger:          logger,
@@ -159,6 +161,48 @@ func (s *AlertService) checkAlert(ctx context.Context, config *models.AlertConfi
 	}
 }
 
+// updateAlertState 加载并更新告警状态，满足条件持续指定时间后返回 shouldFire，条件消失时返回 shouldResolve
+func (s *AlertService) updateAlertState(ctx context.Context, agent *models.Agent, stateKey, alertType string, breached bool, value, threshold float64, duration int, now int64) (*models.AlertState, bool, bool) {
+	var shouldFire, shouldResolve bool
+
+	state, err := s.AlertStateRepo.GetAlertState(ctx, stateKey)
+	if err != nil {
+		state = &models.AlertState{
+			ID:        stateKey,
+			AgentID:   agent.ID,
+			AlertType: alertType,
+		}
+	}
+	state.AgentID = agent.ID
+	state.AlertType = alertType
+	state.Threshold = threshold
+	state.Duration = duration
+	state.Value = value
+	state.LastCheckTime = now
+
+	if breached {
+		if state.StartTime == 0 {
+			state.StartTime = now
+		}
+
+		elapsedSeconds := (now - state.StartTime) / 1000
+		if elapsedSeconds >= int64(duration) && !state.IsFiring {
+			shouldFire = true
+			state.IsFiring = true
+		}
+	} else {
+		if state.IsFiring {
+			shouldResolve = true
+		}
+		state.StartTime = 0
+	}
+
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+	return state, shouldFire, shouldResolve
+}
+
 // fireAlert 触发告警
 func (s *AlertService) fireAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, state *models.AlertState) {
 	s.logger.Info("触发告警",
@@ -268,6 +312,12 @@ func (s *AlertService) buildAlertMessage(state *models.AlertState) string {
 		return fmt.Sprintf("HTTPS证书剩余天数%.0f天，低于阈值%.0f天", state.Value, state.Threshold)
 	case "service":
 		return fmt.Sprintf("服务持续离线%d秒", state.Duration)
+	case "route_loss":
+		alertTypeName = "路由追踪末跳丢包率"
+	case "monitor_loss":
+		alertTypeName = "监控项丢包率"
+	case "monitor_jitter":
+		return fmt.Sprintf("监控项抖动持续%d秒超过%.2fms，当前值%.2fms", state.Duration, state.Threshold, state.Value)
 	default:
 		alertTypeName = state.AlertType
 	}
@@ -366,6 +416,20 @@ func (s *AlertService) CheckMonitorAlerts(ctx context.Context) error {
 		}
 	}
 
+	// 检查路由追踪丢包告警
+	if alertConfig.Rules.RouteLossEnabled {
+		if err := s.checkRouteLossAlerts(ctx, alertConfig, now); err != nil {
+			s.logger.Error("检查路由丢包告警失败", zap.Error(err))
+		}
+	}
+
+	// 检查丢包率与抖动告警
+	if alertConfig.Rules.MonitorLossEnabled || alertConfig.Rules.MonitorJitterEnabled {
+		if err := s.checkMonitorLatencyAlerts(ctx, alertConfig, now); err != nil {
+			s.logger.Error("检查丢包与抖动告警失败", zap.Error(err))
+		}
+	}
+
 	// 检查探针离线告警
 	if alertConfig.Rules.AgentOfflineEnabled {
 		if err := s.checkAgentOfflineAlerts(ctx, alertConfig, now); err != nil {
@@ -682,6 +746,183 @@ func (s *AlertService) resolveServiceDownAlert(ctx context.Context, config *mode
 	}
 }
 
+// checkRouteLossAlerts 检查路由追踪末跳丢包告警
+func (s *AlertService) checkRouteLossAlerts(ctx context.Context, config *models.AlertConfig, now int64) error {
+	monitors, err := s.monitorService.GetLatestMonitorMetricsByType(ctx, "traceroute")
+	if err != nil {
+		return err
+	}
+
+	for _, monitor := range monitors {
+		if len(monitor.Hops) == 0 {
+			continue
+		}
+
+		agent, err := s.agentRepo.FindById(ctx, monitor.AgentId)
+		if err != nil {
+			s.logger.Error("获取探针信息失败", zap.String("agentId", monitor.AgentId), zap.Error(err))
+			continue
+		}
+
+		loss, ttl := lastHopLoss(monitor.Hops)
+		rule := monitorThresholdRule{
+			alertType: "route_loss",
+			value:     loss,
+			threshold: config.Rules.RouteLossThreshold,
+			duration:  config.Rules.RouteLossDuration,
+			message: fmt.Sprintf("路由追踪 %s 末跳（第%d跳）丢包率持续%d秒超过%.2f%%，当前值%.2f%%",
+				monitor.Target, ttl, config.Rules.RouteLossDuration, config.Rules.RouteLossThreshold, loss),
+		}
+		s.checkMonitorThreshold(ctx, config, &agent, &monitor, rule, now)
+	}
+
+	return nil
+}
+
+// checkMonitorLatencyAlerts 检查监控项丢包率与抖动告警（ICMP、多次尝试的 TCP）
+func (s *AlertService) checkMonitorLatencyAlerts(ctx context.Context, config *models.AlertConfig, now int64) error {
+	monitors, err := s.monitorService.GetAllLatestMonitorMetrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, monitor := range monitors {
+		if monitor.PacketsSent == 0 {
+			continue
+		}
+
+		agent, err := s.agentRepo.FindById(ctx, monitor.AgentId)
+		if err != nil {
+			s.logger.Error("获取探针信息失败", zap.String("agentId", monitor.AgentId), zap.Error(err))
+			continue
+		}
+
+		if config.Rules.MonitorLossEnabled {
+			rule := monitorThresholdRule{
+				alertType: "monitor_loss",
+				value:     monitor.PacketLoss,
+				threshold: config.Rules.MonitorLossThreshold,
+				duration:  config.Rules.MonitorLossDuration,
+				message: fmt.Sprintf("监控项 %s 丢包率持续%d秒超过%.2f%%，当前值%.2f%%",
+					monitor.Target, config.Rules.MonitorLossDuration, config.Rules.MonitorLossThreshold, monitor.PacketLoss),
+			}
+			s.checkMonitorThreshold(ctx, config, &agent, &monitor, rule, now)
+		}
+
+		// 抖动至少需要两次成功的探测
+		if config.Rules.MonitorJitterEnabled && monitor.PacketsRecv > 1 {
+			rule := monitorThresholdRule{
+				alertType: "monitor_jitter",
+				value:     monitor.Jitter,
+				threshold: config.Rules.MonitorJitterThreshold,
+				duration:  config.Rules.MonitorJitterDuration,
+				message: fmt.Sprintf("监控项 %s 抖动持续%d秒超过%.2fms，当前值%.2fms",
+					monitor.Target, config.Rules.MonitorJitterDuration, config.Rules.MonitorJitterThreshold, monitor.Jitter),
+			}
+			s.checkMonitorThreshold(ctx, config, &agent, &monitor, rule, now)
+		}
+	}
+
+	return nil
+}
+
+// monitorThresholdRule 监控项维度的阈值告警规则
+type monitorThresholdRule struct {
+	alertType string
+	value     float64
+	threshold float64
+	duration  int
+	message   string
+}
+
+// checkMonitorThreshold 检查监控项维度的阈值告警，持续超过阈值指定时间后触发
+func (s *AlertService) checkMonitorThreshold(ctx context.Context, config *models.AlertConfig, agent *models.Agent, monitor *protocol.MonitorData, rule monitorThresholdRule, now int64) {
+	stateKey := fmt.Sprintf("%s:global:%s:%s", agent.ID, rule.alertType, monitor.MonitorId)
+
+	var shouldFire, shouldResolve bool
+
+	state, err := s.AlertStateRepo.GetAlertState(ctx, stateKey)
+	if err != nil {
+		state = &models.AlertState{
+			ID:        stateKey,
+			AgentID:   agent.ID,
+			AlertType: rule.alertType,
+		}
+	}
+	state.AgentID = agent.ID
+	state.AlertType = rule.alertType
+	state.Threshold = rule.threshold
+	state.Duration = rule.duration
+	state.Value = rule.value
+	state.LastCheckTime = now
+
+	if rule.value >= rule.threshold {
+		if state.StartTime == 0 {
+			state.StartTime = monitor.CheckedAt
+		}
+
+		elapsedSeconds := (now - state.StartTime) / 1000
+		if elapsedSeconds >= int64(rule.duration) && !state.IsFiring {
+			shouldFire = true
+			state.IsFiring = true
+		}
+	} else {
+		if state.IsFiring {
+			shouldResolve = true
+		}
+		state.StartTime = 0
+	}
+
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	if shouldFire {
+		s.fireMonitorThresholdAlert(ctx, agent, monitor, state, rule.message, now)
+	}
+
+	if shouldResolve {
+		s.resolveAlert(ctx, config, agent, state)
+	}
+}
+
+// fireMonitorThresholdAlert 触发监控项维度的阈值告警
+func (s *AlertService) fireMonitorThresholdAlert(ctx context.Context, agent *models.Agent, monitor *protocol.MonitorData, state *models.AlertState, message string, now int64) {
+	s.logger.Info("触发监控告警",
+		zap.String("agentId", agent.ID),
+		zap.String("monitorId", monitor.MonitorId),
+		zap.String("target", monitor.Target),
+		zap.String("alertType", state.AlertType),
+		zap.Float64("value", state.Value),
+		zap.Float64("threshold", state.Threshold),
+	)
+
+	record := &models.AlertRecord{
+		AgentID:     agent.ID,
+		AgentName:   agent.Name,
+		AlertType:   state.AlertType,
+		Message:     message,
+		Threshold:   state.Threshold,
+		ActualValue: state.Value,
+		Level:       s.calculateLevel(state.Value, state.Threshold),
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建监控告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	go s.sendAlertNotification(record, agent)
+}
+
 // checkAgentOfflineAlerts 检查探针离线告警
 func (s *AlertService) checkAgentOfflineAlerts(ctx context.Context, config *models.AlertConfig, now int64) error {
 	// 获取所有探针
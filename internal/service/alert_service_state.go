@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"go.uber.org/zap"
+)
+
+// CheckServiceStates 检查 systemd 单元失败与容器反复重启告警
+func (s *AlertService) CheckServiceStates(ctx context.Context, agentID string, services *protocol.ServiceStateData) error {
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+		return err
+	}
+
+	rules := alertConfig.Rules
+	if !alertConfig.Enabled || services == nil || (!rules.UnitFailedEnabled && !rules.ContainerRestartEnabled) {
+		return nil
+	}
+
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.Error(err))
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	if rules.UnitFailedEnabled {
+		checked := make(map[string]struct{}, len(services.Units))
+		for _, unit := range services.Units {
+			stateKey := fmt.Sprintf("%s:global:unit_failed:%s", agent.ID, unit.Name)
+			checked[stateKey] = struct{}{}
+			s.checkUnitFailedAlert(ctx, alertConfig, &agent, stateKey, unit, now)
+		}
+		s.resolveMissingStates(ctx, alertConfig, &agent, "unit_failed", checked)
+	}
+
+	if rules.ContainerRestartEnabled {
+		restarts := s.containerRestartIncrease(ctx, agent.ID, rules.ContainerRestartWindow)
+		checked := make(map[string]struct{}, len(services.Containers))
+		for _, container := range services.Containers {
+			stateKey := fmt.Sprintf("%s:global:container_restart:%s", agent.ID, container.Name)
+			checked[stateKey] = struct{}{}
+			s.checkContainerRestartAlert(ctx, alertConfig, &agent, stateKey, container, restarts[container.Name], now)
+		}
+		s.resolveMissingStates(ctx, alertConfig, &agent, "container_restart", checked)
+	}
+
+	return nil
+}
+
+// containerRestartIncrease 查询时间窗口内各容器的重启次数，容器重建导致的计数归零由 increase 处理
+func (s *AlertService) containerRestartIncrease(ctx context.Context, agentID string, window int) map[string]float64 {
+	if window <= 0 {
+		window = 600
+	}
+	query := fmt.Sprintf(`increase(pika_container_restarts_total{agent_id="%s"}[%ds])`, escapeLabelValue(agentID), window)
+	result, err := s.metricService.metricStore.Query(ctx, query)
+	if err != nil {
+		s.logger.Error("查询容器重启次数失败", zap.String("agentId", agentID), zap.Error(err))
+		return nil
+	}
+
+	restarts := make(map[string]float64, len(result.Data.Result))
+	for _, r := range result.Data.Result {
+		if value, ok := r.InstantValue(); ok {
+			restarts[r.Metric["container"]] = value
+		}
+	}
+	return restarts
+}
+
+// checkUnitFailedAlert 检查单个 systemd 单元，持续处于 failed 状态指定时间后触发
+func (s *AlertService) checkUnitFailedAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, stateKey string, unit protocol.SystemdUnitState, now int64) {
+	duration := config.Rules.UnitFailedDuration
+	state, shouldFire, shouldResolve := s.updateAlertState(ctx, agent, stateKey, "unit_failed",
+		unit.ActiveState == "failed", float64(unit.ExitCode), 0, duration, now)
+
+	if shouldResolve {
+		s.resolveAlert(ctx, config, agent, state)
+		return
+	}
+	if !shouldFire {
+		return
+	}
+
+	message := fmt.Sprintf("systemd 单元 %s 处于 failed 状态已持续%d秒（结果 %s，退出码 %d，已自动重启%d次）",
+		unit.Name, duration, unit.Result, unit.ExitCode, unit.Restarts)
+	s.fireServiceAlert(ctx, agent, state, message, "critical", now)
+}
+
+// checkContainerRestartAlert 检查单个容器，时间窗口内重启次数达到阈值或处于 restarting 状态时触发
+func (s *AlertService) checkContainerRestartAlert(ctx context.Context, config *models.AlertConfig, agent *models.Agent, stateKey string, container protocol.ContainerState, restarts float64, now int64) {
+	threshold := float64(config.Rules.ContainerRestartThreshold)
+	if threshold <= 0 {
+		threshold = 3
+	}
+	breached := restarts >= threshold || container.State == "restarting"
+	state, shouldFire, shouldResolve := s.updateAlertState(ctx, agent, stateKey, "container_restart",
+		breached, restarts, threshold, 0, now)
+
+	if shouldResolve {
+		s.resolveAlert(ctx, config, agent, state)
+		return
+	}
+	if !shouldFire {
+		return
+	}
+
+	level := "warning"
+	if restarts >= threshold*2 {
+		level = "critical"
+	}
+	message := fmt.Sprintf("容器 %s 最近%d分钟内重启%.0f次（当前状态 %s，退出码 %d，镜像 %s）",
+		container.Name, config.Rules.ContainerRestartWindow/60, restarts, container.State, container.ExitCode, container.Image)
+	s.fireServiceAlert(ctx, agent, state, message, level, now)
+}
+
+// resolveMissingStates 恢复已不再上报的单元或容器（已从配置移除或容器已删除）的告警
+func (s *AlertService) resolveMissingStates(ctx context.Context, config *models.AlertConfig, agent *models.Agent, alertType string, checked map[string]struct{}) {
+	states, err := s.AlertStateRepo.FindFiringStates(ctx, agent.ID, alertType)
+	if err != nil {
+		s.logger.Error("获取告警状态失败", zap.Error(err))
+		return
+	}
+	for i := range states {
+		if _, ok := checked[states[i].ID]; ok {
+			continue
+		}
+		states[i].StartTime = 0
+		s.resolveAlert(ctx, config, agent, &states[i])
+	}
+}
+
+// fireServiceAlert 触发 systemd 单元或容器告警
+func (s *AlertService) fireServiceAlert(ctx context.Context, agent *models.Agent, state *models.AlertState, message, level string, now int64) {
+	s.logger.Info("触发服务状态告警",
+		zap.String("agentId", agent.ID),
+		zap.String("alertType", state.AlertType),
+		zap.String("target", strings.TrimPrefix(state.ID, agent.ID+":global:"+state.AlertType+":")),
+		zap.Float64("value", state.Value),
+	)
+
+	record := &models.AlertRecord{
+		AgentID:     agent.ID,
+		AgentName:   agent.Name,
+		AlertType:   state.AlertType,
+		Message:     message,
+		Threshold:   state.Threshold,
+		ActualValue: state.Value,
+		Level:       level,
+		Status:      "firing",
+		FiredAt:     now,
+		CreatedAt:   now,
+	}
+
+	if err := s.AlertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+		s.logger.Error("创建服务状态告警记录失败", zap.Error(err))
+		return
+	}
+
+	state.LastRecordID = record.ID
+	if err := s.AlertStateRepo.SaveAlertState(ctx, state); err != nil {
+		s.logger.Error("保存告警状态失败", zap.Error(err))
+	}
+
+	go s.sendAlertNotification(record, agent)
+}
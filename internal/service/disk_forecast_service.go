@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/metricstore"
+	"github.com/dushixiang/pika/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	// diskForecastInterval 重新计算预测的间隔
+	diskForecastInterval = time.Hour
+	// defaultDiskForecastWindow 默认拟合趋势使用的历史天数
+	defaultDiskForecastWindow = 7
+	// diskForecastMinSamples 拟合所需的最少小时样本数
+	diskForecastMinSamples = 12
+	// defaultAtRiskDays 风险磁盘列表默认的剩余天数上限
+	defaultAtRiskDays = 30
+)
+
+// DiskForecastService 磁盘容量预测服务，定期按挂载点拟合已用容量的线性趋势并预测写满时间
+type DiskForecastService struct {
+	logger          *zap.Logger
+	metricStore     metricstore.MetricStore
+	propertyService *PropertyService
+	alertService    *AlertService
+
+	mu        sync.RWMutex
+	forecasts map[string][]metric.DiskForecast // key: 探针 ID
+}
+
+// NewDiskForecastService 创建磁盘容量预测服务
+func NewDiskForecastService(logger *zap.Logger, metricStore metricstore.MetricStore, propertyService *PropertyService, alertService *AlertService) *DiskForecastService {
+	return &DiskForecastService{
+		logger:          logger,
+		metricStore:     metricStore,
+		propertyService: propertyService,
+		alertService:    alertService,
+		forecasts:       make(map[string][]metric.DiskForecast),
+	}
+}
+
+// Run 定期刷新预测结果并检查告警
+func (s *DiskForecastService) Run(ctx context.Context) {
+	ticker := time.NewTicker(diskForecastInterval)
+	defer ticker.Stop()
+
+	s.refresh(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refresh(ctx)
+		}
+	}
+}
+
+// refresh 查询所有探针最近数天的磁盘用量并重新计算预测
+func (s *DiskForecastService) refresh(ctx context.Context) {
+	window := defaultDiskForecastWindow
+	alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil {
+		s.logger.Error("获取全局告警配置失败", zap.Error(err))
+	} else if alertConfig.Rules.DiskForecastWindow > 0 {
+		window = alertConfig.Rules.DiskForecastWindow
+	}
+
+	now := time.Now()
+	start := now.Add(-time.Duration(window) * 24 * time.Hour)
+	// 按 agent_id、mount_point 聚合，探针元数据标签在窗口内变化时不会拆分为多条序列
+	used, err := s.metricStore.QueryRange(ctx, `max by (agent_id, mount_point) (avg_over_time(pika_disk_used_bytes[3600s]))`, start, now, time.Hour)
+	if err != nil {
+		s.logger.Error("查询磁盘用量失败", zap.Error(err))
+		return
+	}
+	total, err := s.metricStore.QueryRange(ctx, `max by (agent_id, mount_point) (last_over_time(pika_disk_total_bytes[3600s]))`, start, now, time.Hour)
+	if err != nil {
+		s.logger.Error("查询磁盘容量失败", zap.Error(err))
+		return
+	}
+
+	// 磁盘容量取最新值
+	totals := make(map[string]float64)
+	for _, m := range queryResultToMetrics(total, "") {
+		if n := len(m.Values); n > 0 {
+			totals[m.Metric["agent_id"]+"\x00"+m.Metric["mount_point"]] = m.Values[n-1]
+		}
+	}
+
+	forecasts := make(map[string][]metric.DiskForecast)
+	for _, m := range queryResultToMetrics(used, "") {
+		agentID, mountPoint := m.Metric["agent_id"], m.Metric["mount_point"]
+		totalBytes, ok := totals[agentID+"\x00"+mountPoint]
+		if agentID == "" || mountPoint == "" || !ok || totalBytes <= 0 {
+			continue
+		}
+		forecast, ok := fitDiskForecast(m.Timestamps, m.Values, totalBytes, now)
+		if !ok {
+			continue
+		}
+		forecast.AgentID = agentID
+		forecast.MountPoint = mountPoint
+		forecasts[agentID] = append(forecasts[agentID], forecast)
+	}
+	for _, items := range forecasts {
+		sort.Slice(items, func(i, j int) bool { return items[i].MountPoint < items[j].MountPoint })
+	}
+
+	s.mu.Lock()
+	s.forecasts = forecasts
+	s.mu.Unlock()
+
+	if alertConfig != nil {
+		s.alertService.CheckDiskForecasts(ctx, alertConfig, forecasts)
+	}
+}
+
+// fitDiskForecast 对小时平均已用容量做最小二乘线性拟合，按当前用量和增长斜率计算写满时间
+func fitDiskForecast(timestamps []int64, values []float64, totalBytes float64, now time.Time) (metric.DiskForecast, bool) {
+	n := len(values)
+	if n < diskForecastMinSamples || len(timestamps) != n {
+		return metric.DiskForecast{}, false
+	}
+
+	// x 以天为单位，相对第一个样本
+	var sumX, sumY float64
+	xs := make([]float64, n)
+	for i := range values {
+		xs[i] = float64(timestamps[i]-timestamps[0]) / float64(24*time.Hour/time.Millisecond)
+		sumX += xs[i]
+		sumY += values[i]
+	}
+	meanX, meanY := sumX/float64(n), sumY/float64(n)
+
+	var sxx, sxy, syy float64
+	for i := range values {
+		dx, dy := xs[i]-meanX, values[i]-meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	// 样本时间跨度过短无法拟合
+	if sxx == 0 || xs[n-1] < 0.5 {
+		return metric.DiskForecast{}, false
+	}
+
+	slope := sxy / sxx
+	r2 := 1.0
+	if syy > 0 {
+		r2 = sxy * sxy / (sxx * syy)
+	}
+
+	usedBytes := values[n-1]
+	forecast := metric.DiskForecast{
+		TotalBytes:        totalBytes,
+		UsedBytes:         usedBytes,
+		UsagePercent:      usedBytes / totalBytes * 100,
+		GrowthBytesPerDay: slope,
+		R2:                r2,
+		Samples:           n,
+		UpdatedAt:         now.UnixMilli(),
+	}
+	if slope > 0 {
+		days := math.Max((totalBytes-usedBytes)/slope, 0)
+		fullAt := now.Add(time.Duration(days * float64(24*time.Hour))).UnixMilli()
+		forecast.DaysUntilFull = &days
+		forecast.FullAt = &fullAt
+	}
+	return forecast, true
+}
+
+// GetAgentForecasts 获取探针各挂载点的预测结果
+func (s *DiskForecastService) GetAgentForecasts(agentID string) []metric.DiskForecast {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	items := s.forecasts[agentID]
+	result := make([]metric.DiskForecast, len(items))
+	copy(result, items)
+	return result
+}
+
+// ListAtRisk 列出预计在 days 天内写满的磁盘，按剩余天数升序排列
+func (s *DiskForecastService) ListAtRisk(agents []models.Agent, days float64) []metric.DiskForecast {
+	if days <= 0 {
+		days = defaultAtRiskDays
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]metric.DiskForecast, 0)
+	for _, agent := range agents {
+		for _, forecast := range s.forecasts[agent.ID] {
+			if forecast.DaysUntilFull == nil || *forecast.DaysUntilFull > days {
+				continue
+			}
+			forecast.AgentName = agent.Name
+			result = append(result, forecast)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return *result[i].DaysUntilFull < *result[j].DaysUntilFull
+	})
+	return result
+}
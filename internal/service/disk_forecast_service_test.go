@@ -0,0 +1,42 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitDiskForecast(t *testing.T) {
+	now := time.Now()
+	const gb = 1 << 30
+
+	// 7 天内每天增长 10GB，总容量 100GB，当前已用 80GB
+	var timestamps []int64
+	var values []float64
+	for h := 0; h <= 7*24; h++ {
+		ts := now.Add(-time.Duration(7*24-h) * time.Hour)
+		timestamps = append(timestamps, ts.UnixMilli())
+		values = append(values, 10*gb+float64(h)*10*gb/24)
+	}
+	forecast, ok := fitDiskForecast(timestamps, values, 100*gb, now)
+	if !ok || forecast.DaysUntilFull == nil {
+		t.Fatalf("expected forecast, got %+v", forecast)
+	}
+	if math.Abs(*forecast.DaysUntilFull-2) > 0.01 || math.Abs(forecast.GrowthBytesPerDay-10*gb) > 1 || forecast.R2 < 0.99 {
+		t.Fatalf("unexpected forecast: days=%v %+v", *forecast.DaysUntilFull, forecast)
+	}
+
+	// 用量不变时不预测写满时间
+	flat := make([]float64, len(values))
+	for i := range flat {
+		flat[i] = 50 * gb
+	}
+	forecast, ok = fitDiskForecast(timestamps, flat, 100*gb, now)
+	if !ok || forecast.DaysUntilFull != nil {
+		t.Fatalf("flat usage should not have days until full: %+v", forecast)
+	}
+
+	if _, ok := fitDiskForecast(timestamps[:5], values[:5], 100*gb, now); ok {
+		t.Fatalf("too few samples should not be fitted")
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/metricstore"
+	"github.com/dushixiang/pika/internal/models"
+	"go.uber.org/zap"
+)
+
+// FleetService 按探针标签分组的汇总统计
+// 分组关系取自 agents 表，时序查询只按 agent_id 过滤，无需在时序数据中附带标签
+type FleetService struct {
+	logger        *zap.Logger
+	metricService *MetricService
+	metricStore   metricstore.MetricStore
+}
+
+func NewFleetService(logger *zap.Logger, metricService *MetricService, metricStore metricstore.MetricStore) *FleetService {
+	return &FleetService{
+		logger:        logger,
+		metricService: metricService,
+		metricStore:   metricStore,
+	}
+}
+
+// GetOverview 获取全部探针及每个标签分组的汇总
+func (s *FleetService) GetOverview(ctx context.Context, agents []models.Agent, start, end int64) (*metric.FleetOverview, error) {
+	stats, err := s.collectAgentStats(ctx, agents, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]*metric.FleetAgentStats)
+	for _, st := range stats {
+		for _, tag := range uniqueTags(st.Tags) {
+			groups[tag] = append(groups[tag], st)
+		}
+	}
+	tags := make([]string, 0, len(groups))
+	for tag := range groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	overview := &metric.FleetOverview{
+		Start:  start,
+		End:    end,
+		All:    summarizeGroup("", stats),
+		Groups: make([]metric.FleetGroupSummary, 0, len(tags)),
+	}
+	for _, tag := range tags {
+		overview.Groups = append(overview.Groups, summarizeGroup(tag, groups[tag]))
+	}
+	return overview, nil
+}
+
+// GetGroup 获取单个标签分组的汇总及组内探针统计，tag 为空时返回全部探针
+func (s *FleetService) GetGroup(ctx context.Context, agents []models.Agent, tag string, start, end int64) (*metric.FleetGroupDetail, error) {
+	var members []models.Agent
+	for _, agent := range agents {
+		if tag == "" || containsTag(agent.Tags, tag) {
+			members = append(members, agent)
+		}
+	}
+
+	stats, err := s.collectAgentStats(ctx, members, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &metric.FleetGroupDetail{
+		Start:   start,
+		End:     end,
+		Summary: summarizeGroup(tag, stats),
+		Agents:  make([]metric.FleetAgentStats, 0, len(stats)),
+	}
+	for _, st := range stats {
+		detail.Agents = append(detail.Agents, *st)
+	}
+	return detail, nil
+}
+
+// collectAgentStats 查询每个探针在时间范围内的 CPU、内存与流量统计，当前速率取自最新指标缓存
+func (s *FleetService) collectAgentStats(ctx context.Context, agents []models.Agent, start, end int64) ([]*metric.FleetAgentStats, error) {
+	stats := make([]*metric.FleetAgentStats, 0, len(agents))
+	byID := make(map[string]*metric.FleetAgentStats, len(agents))
+	for _, agent := range agents {
+		st := &metric.FleetAgentStats{
+			AgentID: agent.ID,
+			Name:    agent.Name,
+			Tags:    uniqueTags(agent.Tags),
+			Status:  agent.Status,
+		}
+		if latest, ok := s.metricService.GetLatestMetrics(agent.ID); ok && latest.Network != nil {
+			st.NetworkSentRate = latest.Network.TotalBytesSentRate
+			st.NetworkRecvRate = latest.Network.TotalBytesRecvRate
+		}
+		stats = append(stats, st)
+		byID[agent.ID] = st
+	}
+	if len(agents) == 0 {
+		return stats, nil
+	}
+
+	// 统计窗口以当前时间为终点
+	window := fmt.Sprintf("%ds", max((end-start)/1000, 60))
+	queries := []struct {
+		query string
+		apply func(st *metric.FleetAgentStats, v float64)
+	}{
+		{fmt.Sprintf(`avg_over_time(pika_cpu_usage_percent[%s])`, window), func(st *metric.FleetAgentStats, v float64) { st.CPUAvg = &v }},
+		{fmt.Sprintf(`max_over_time(pika_cpu_usage_percent[%s])`, window), func(st *metric.FleetAgentStats, v float64) { st.CPUMax = &v }},
+		{fmt.Sprintf(`avg_over_time(pika_memory_usage_percent[%s])`, window), func(st *metric.FleetAgentStats, v float64) { st.MemoryAvg = &v }},
+		{fmt.Sprintf(`sum by (agent_id) (increase(pika_network_sent_bytes_total[%s]))`, window), func(st *metric.FleetAgentStats, v float64) { st.TrafficSent = v }},
+		{fmt.Sprintf(`sum by (agent_id) (increase(pika_network_recv_bytes_total[%s]))`, window), func(st *metric.FleetAgentStats, v float64) { st.TrafficRecv = v }},
+	}
+
+	for _, q := range queries {
+		restricted, err := restrictToAgents(q.query, agents)
+		if err != nil {
+			return nil, err
+		}
+		result, err := s.metricStore.Query(ctx, restricted)
+		if err != nil {
+			s.logger.Error("查询分组统计失败", zap.String("query", q.query), zap.Error(err))
+			return nil, err
+		}
+		for _, r := range result.Data.Result {
+			st, ok := byID[r.Metric["agent_id"]]
+			if !ok {
+				continue
+			}
+			if v, ok := r.InstantValue(); ok && !math.IsNaN(v) {
+				q.apply(st, v)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// summarizeGroup 汇总分组内探针的统计
+func summarizeGroup(tag string, stats []*metric.FleetAgentStats) metric.FleetGroupSummary {
+	summary := metric.FleetGroupSummary{Tag: tag, AgentCount: len(stats)}
+
+	var cpuAvgs, memAvgs []float64
+	for _, st := range stats {
+		if st.Status == 1 {
+			summary.OnlineCount++
+		}
+		summary.NetworkSentRate += st.NetworkSentRate
+		summary.NetworkRecvRate += st.NetworkRecvRate
+		summary.TrafficSent += st.TrafficSent
+		summary.TrafficRecv += st.TrafficRecv
+		if st.CPUAvg != nil {
+			cpuAvgs = append(cpuAvgs, *st.CPUAvg)
+		}
+		if st.CPUMax != nil && (summary.CPUMax == nil || *st.CPUMax > *summary.CPUMax) {
+			v := *st.CPUMax
+			summary.CPUMax = &v
+		}
+		if st.MemoryAvg != nil {
+			memAvgs = append(memAvgs, *st.MemoryAvg)
+		}
+	}
+
+	summary.CPUAvg = average(cpuAvgs)
+	summary.CPUP95 = percentile(cpuAvgs, 95)
+	summary.MemoryAvg = average(memAvgs)
+	return summary
+}
+
+func average(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	avg := sum / float64(len(values))
+	return &avg
+}
+
+// percentile 最近秩法计算百分位
+func percentile(values []float64, p float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	v := sorted[max(rank-1, 0)]
+	return &v
+}
+
+func uniqueTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag == "" {
+			continue
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		out = append(out, tag)
+	}
+	return out
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dushixiang/pika/internal/metric"
+)
+
+func TestSummarizeGroup(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	stats := []*metric.FleetAgentStats{
+		{Status: 1, CPUAvg: f(10), CPUMax: f(40), MemoryAvg: f(50), NetworkSentRate: 100, TrafficSent: 1000},
+		{Status: 1, CPUAvg: f(30), CPUMax: f(90), MemoryAvg: f(70), NetworkSentRate: 200, TrafficSent: 2000},
+		{Status: 0},
+	}
+
+	summary := summarizeGroup("prod", stats)
+	if summary.AgentCount != 3 || summary.OnlineCount != 2 {
+		t.Fatalf("unexpected counts: %+v", summary)
+	}
+	if *summary.CPUAvg != 20 || *summary.CPUMax != 90 || *summary.CPUP95 != 30 || *summary.MemoryAvg != 60 {
+		t.Fatalf("unexpected cpu/memory: avg=%v max=%v p95=%v mem=%v", *summary.CPUAvg, *summary.CPUMax, *summary.CPUP95, *summary.MemoryAvg)
+	}
+	if summary.NetworkSentRate != 300 || summary.TrafficSent != 3000 {
+		t.Fatalf("unexpected traffic: %+v", summary)
+	}
+
+	empty := summarizeGroup("", nil)
+	if empty.CPUAvg != nil || empty.CPUP95 != nil {
+		t.Fatal("empty group should have no cpu stats")
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := make([]float64, 0, 100)
+	for i := 100; i >= 1; i-- {
+		values = append(values, float64(i))
+	}
+	if got := *percentile(values, 95); got != 95 {
+		t.Fatalf("p95 = %v, want 95", got)
+	}
+	if got := *percentile([]float64{7}, 95); got != 7 {
+		t.Fatalf("p95 = %v, want 7", got)
+	}
+}
@@ -14,6 +14,7 @@ type GeoIPService struct {
 	logger *zap.Logger
 	config *config.GeoIPConfig
 	db     *geoip2.Reader
+	asnDB  *geoip2.Reader
 	mu     sync.RWMutex
 }
 
@@ -34,6 +35,18 @@ func NewGeoIPService(logger *zap.Logger, appCfg *config.AppConfig) (*GeoIPServic
 			return s, nil
 		}
 		logger.Info("GeoIP service initialized successfully", zap.String("dbPath", cfg.DBPath))
+
+		// ASN 数据库可选，加载失败不影响归属地查询
+		if cfg.ASNDBPath != "" {
+			asnDB, err := geoip2.Open(cfg.ASNDBPath)
+			if err != nil {
+				logger.Warn("failed to load ASN database",
+					zap.String("path", cfg.ASNDBPath),
+					zap.Error(err))
+			} else {
+				s.asnDB = asnDB
+			}
+		}
 	} else {
 		logger.Info("GeoIP service is disabled")
 	}
@@ -130,11 +143,38 @@ func (s *GeoIPService) LookupIP(ip string) string {
 	return location
 }
 
+// LookupASN 查询 IP 所属的自治系统
+func (s *GeoIPService) LookupASN(ip string) (uint, string) {
+	if s.config == nil || !s.config.Enabled || s.asnDB == nil {
+		return 0, ""
+	}
+
+	parsedIP := net.ParseIP(ip)
+	if parsedIP == nil || isPrivateIP(ip) {
+		return 0, ""
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	record, err := s.asnDB.ASN(parsedIP)
+	if err != nil {
+		s.logger.Debug("failed to lookup ASN",
+			zap.String("ip", ip),
+			zap.Error(err))
+		return 0, ""
+	}
+	return record.AutonomousSystemNumber, record.AutonomousSystemOrganization
+}
+
 // Close 关闭数据库连接
 func (s *GeoIPService) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.asnDB != nil {
+		_ = s.asnDB.Close()
+	}
 	if s.db != nil {
 		return s.db.Close()
 	}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/config"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/dushixiang/pika/internal/websocket"
+
+	"github.com/go-orz/orz"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// defaultLogRetentionDays 系统日志默认保留天数
+	defaultLogRetentionDays = 7
+	// defaultLogSearchRange 未指定时间范围时查询最近 15 分钟
+	defaultLogSearchRange = 15 * time.Minute
+	defaultLogSearchLimit = 200
+	maxLogSearchLimit     = 1000
+	// maxLogForwardUnits 每个探针最多指定的单元数
+	maxLogForwardUnits = 50
+	// maxLogEntriesPerBatch 单批最多保存的日志条数，与探针端的批次大小保持一致
+	maxLogEntriesPerBatch = 500
+	// maxLogMessageBytes 单条日志最多保存的字节数，与探针端的截断长度保持一致
+	maxLogMessageBytes = 4 * 1024
+)
+
+// LogForwardService 系统日志转发服务：管理探针的转发配置、保存上报的日志并提供检索
+type LogForwardService struct {
+	logger       *zap.Logger
+	LogEntryRepo *repo.LogEntryRepo
+	agentRepo    *repo.AgentRepo
+	wsManager    *websocket.Manager
+	retention    time.Duration
+}
+
+func NewLogForwardService(logger *zap.Logger, db *gorm.DB, appConfig *config.AppConfig, wsManager *websocket.Manager) *LogForwardService {
+	retentionDays := defaultLogRetentionDays
+	if appConfig.LogForward != nil && appConfig.LogForward.RetentionDays > 0 {
+		retentionDays = appConfig.LogForward.RetentionDays
+	}
+	return &LogForwardService{
+		logger:       logger,
+		LogEntryRepo: repo.NewLogEntryRepo(db),
+		agentRepo:    repo.NewAgentRepo(db),
+		wsManager:    wsManager,
+		retention:    time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+// === 配置管理 ===
+
+// GetConfig 获取探针的转发配置
+func (s *LogForwardService) GetConfig(ctx context.Context, agentID string) (*models.LogForwardConfigData, error) {
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		return nil, err
+	}
+	config := agent.LogForwardConfig.Data()
+	return &config, nil
+}
+
+// UpdateConfig 更新转发配置并下发到探针
+func (s *LogForwardService) UpdateConfig(ctx context.Context, agentID string, req *models.LogForwardConfigData) error {
+	if req.Priority < 0 || req.Priority > 7 {
+		return orz.NewError(400, "日志级别范围为 0-7")
+	}
+
+	units := make([]string, 0, len(req.Units))
+	for _, unit := range req.Units {
+		unit = strings.TrimSpace(unit)
+		if unit == "" || slices.Contains(units, unit) {
+			continue
+		}
+		if strings.ContainsAny(unit, " \t\r\n") {
+			return orz.NewError(400, fmt.Sprintf("单元名称 '%s' 无效", unit))
+		}
+		units = append(units, unit)
+	}
+	if len(units) > maxLogForwardUnits {
+		return orz.NewError(400, fmt.Sprintf("最多指定 %d 个单元", maxLogForwardUnits))
+	}
+
+	config := models.LogForwardConfigData{
+		Enabled:     req.Enabled,
+		Units:       units,
+		Priority:    req.Priority,
+		ApplyStatus: "pending",
+	}
+	var agentForUpdate = models.Agent{
+		ID:               agentID,
+		LogForwardConfig: datatypes.NewJSONType(config),
+	}
+	if err := s.agentRepo.UpdateById(ctx, &agentForUpdate); err != nil {
+		return err
+	}
+
+	go func() {
+		if err := s.sendConfigToAgent(agentID, &config); err != nil {
+			s.logger.Error("下发系统日志转发配置到 Agent 失败", zap.String("agentId", agentID), zap.Error(err))
+		}
+	}()
+	return nil
+}
+
+// BuildConfig 生成下发到探针的转发配置
+func (s *LogForwardService) BuildConfig(config *models.LogForwardConfigData) protocol.LogForwardConfig {
+	return protocol.LogForwardConfig{
+		Enabled:  config.Enabled,
+		Units:    config.Units,
+		Priority: config.Priority,
+	}
+}
+
+// sendConfigToAgent 下发配置到 Agent
+func (s *LogForwardService) sendConfigToAgent(agentID string, config *models.LogForwardConfigData) error {
+	msgBytes, err := json.Marshal(protocol.OutboundMessage{
+		Type: protocol.MessageTypeLogForwardConfig,
+		Data: s.BuildConfig(config),
+	})
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+	return s.wsManager.SendToClient(agentID, msgBytes)
+}
+
+// HandleConfigResult 处理 Agent 上报的配置应用结果
+func (s *LogForwardService) HandleConfigResult(ctx context.Context, agentID string, result protocol.LogForwardConfigResult) error {
+	config, err := s.GetConfig(ctx, agentID)
+	if err != nil {
+		return fmt.Errorf("获取配置失败: %w", err)
+	}
+
+	config.ApplyStatus = "success"
+	if !result.Success {
+		config.ApplyStatus = "failed"
+	}
+	config.ApplyMessage = result.Message
+	config.Source = result.Source
+
+	var agentForUpdate = models.Agent{
+		ID:               agentID,
+		LogForwardConfig: datatypes.NewJSONType(*config),
+	}
+	return s.agentRepo.UpdateById(ctx, &agentForUpdate)
+}
+
+// === 日志处理 ===
+
+// HandleEntries 保存探针上报的一批日志
+func (s *LogForwardService) HandleEntries(ctx context.Context, agentID string, batch protocol.LogEntryBatch) error {
+	if batch.Dropped > 0 {
+		s.logger.Warn("探针上报队列已满，部分系统日志被丢弃",
+			zap.String("agentId", agentID), zap.Int("dropped", batch.Dropped))
+	}
+
+	entries := batch.Entries
+	if len(entries) > maxLogEntriesPerBatch {
+		entries = entries[:maxLogEntriesPerBatch]
+	}
+	records := make([]models.LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		message := entry.Message
+		if len(message) > maxLogMessageBytes {
+			message = strings.ToValidUTF8(message[:maxLogMessageBytes], "")
+		}
+		records = append(records, models.LogEntry{
+			AgentID:    agentID,
+			Timestamp:  entry.Timestamp,
+			Priority:   entry.Priority,
+			Unit:       entry.Unit,
+			Identifier: entry.Identifier,
+			Message:    message,
+		})
+	}
+	return s.LogEntryRepo.CreateEntries(ctx, records)
+}
+
+// LogSearchRequest 系统日志检索条件
+type LogSearchRequest struct {
+	AgentIDs []string // 探针ID，为空表示全部探针
+	Start    int64    // 开始时间（毫秒时间戳），为 0 表示结束时间前 15 分钟
+	End      int64    // 结束时间（毫秒时间戳），为 0 表示当前时间
+	Unit     string   // systemd 单元或程序名
+	Priority *int     // 最低级别，只返回小于等于该值的日志，为空表示全部
+	Keyword  string   // 日志内容关键字
+	Limit    int      // 最多返回的条数，默认 200，最多 1000
+}
+
+// LogSearchResult 系统日志检索结果
+type LogSearchResult struct {
+	Items   []models.LogEntry `json:"items"`   // 按时间倒序排列的日志
+	Start   int64             `json:"start"`   // 实际查询的开始时间
+	End     int64             `json:"end"`     // 实际查询的结束时间
+	HasMore bool              `json:"hasMore"` // 是否还有更早的日志未返回，可缩小时间范围或以最后一条的时间作为结束时间继续查询
+}
+
+// Search 检索系统日志
+func (s *LogForwardService) Search(ctx context.Context, req LogSearchRequest) (*LogSearchResult, error) {
+	query := repo.LogEntryQuery{
+		AgentIDs:    req.AgentIDs,
+		Start:       req.Start,
+		End:         req.End,
+		Unit:        strings.TrimSpace(req.Unit),
+		MaxPriority: 7,
+		Keyword:     strings.TrimSpace(req.Keyword),
+		Limit:       req.Limit,
+	}
+	if query.End <= 0 {
+		query.End = time.Now().UnixMilli()
+	}
+	if query.Start <= 0 {
+		query.Start = query.End - defaultLogSearchRange.Milliseconds()
+	}
+	if query.Start > query.End {
+		return nil, orz.NewError(400, "开始时间不能晚于结束时间")
+	}
+	if req.Priority != nil {
+		if *req.Priority < 0 || *req.Priority > 7 {
+			return nil, orz.NewError(400, "日志级别范围为 0-7")
+		}
+		query.MaxPriority = *req.Priority
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultLogSearchLimit
+	}
+	query.Limit = min(query.Limit, maxLogSearchLimit)
+
+	// 多查一条用于判断是否还有更多日志
+	limit := query.Limit
+	query.Limit++
+	entries, err := s.LogEntryRepo.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LogSearchResult{Items: entries, Start: query.Start, End: query.End}
+	if len(entries) > limit {
+		result.Items = entries[:limit]
+		result.HasMore = true
+	}
+	return result, nil
+}
+
+// DeleteEntriesByAgentID 删除探针的所有系统日志
+func (s *LogForwardService) DeleteEntriesByAgentID(ctx context.Context, agentID string) error {
+	return s.LogEntryRepo.DeleteByAgentID(ctx, agentID)
+}
+
+// Run 定期清理过期的系统日志
+func (s *LogForwardService) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			before := time.Now().Add(-s.retention).UnixMilli()
+			if err := s.LogEntryRepo.DeleteBefore(ctx, before); err != nil {
+				s.logger.Error("清理过期系统日志失败", zap.Error(err))
+			}
+		}
+	}
+}
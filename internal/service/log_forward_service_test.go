@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/models"
+)
+
+func TestLogForwardRejectsInvalidConfig(t *testing.T) {
+	s := &LogForwardService{}
+	cases := []models.LogForwardConfigData{
+		{Enabled: true, Priority: 8},
+		{Enabled: true, Priority: -1},
+		{Enabled: true, Priority: 3, Units: []string{"nginx", "bad unit"}},
+	}
+	for i, req := range cases {
+		if err := s.UpdateConfig(context.Background(), "agent-1", &req); err == nil {
+			t.Errorf("case %d: expected error", i)
+		}
+	}
+
+	priority := 9
+	if _, err := s.Search(context.Background(), LogSearchRequest{Priority: &priority}); err == nil {
+		t.Error("expected invalid priority error")
+	}
+	if _, err := s.Search(context.Background(), LogSearchRequest{Start: 2000, End: 1000}); err == nil {
+		t.Error("expected invalid time range error")
+	}
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/dushixiang/pika/internal/websocket"
+
+	"github.com/go-orz/orz"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// logWatchNotifyLineLimit 通知中每条样例行的最大长度
+const logWatchNotifyLineLimit = 300
+
+// LogWatchService 日志监控服务：管理规则、下发到探针、保存匹配事件并发送通知
+type LogWatchService struct {
+	logger          *zap.Logger
+	RuleRepo        *repo.LogWatchRuleRepo  // 导出用于 handler 的 PageBuilder
+	EventRepo       *repo.LogWatchEventRepo // 导出用于 handler 的 PageBuilder
+	agentRepo       *repo.AgentRepo
+	wsManager       *websocket.Manager
+	notificationSvc *NotificationService
+}
+
+func NewLogWatchService(logger *zap.Logger, db *gorm.DB, wsManager *websocket.Manager, notificationSvc *NotificationService) *LogWatchService {
+	return &LogWatchService{
+		logger:          logger,
+		RuleRepo:        repo.NewLogWatchRuleRepo(db),
+		EventRepo:       repo.NewLogWatchEventRepo(db),
+		agentRepo:       repo.NewAgentRepo(db),
+		wsManager:       wsManager,
+		notificationSvc: notificationSvc,
+	}
+}
+
+// === 规则管理 ===
+
+// ValidateRule 校验规则内容，与探针端的校验规则保持一致
+func (s *LogWatchService) ValidateRule(rule *models.LogWatchRule) error {
+	if rule.Path == "" {
+		return orz.NewError(400, "日志路径不能为空")
+	}
+	if !filepath.IsAbs(rule.Path) {
+		return orz.NewError(400, "日志路径必须为绝对路径")
+	}
+	if _, err := filepath.Match(rule.Path, ""); err != nil {
+		return orz.NewError(400, fmt.Sprintf("日志路径 '%s' 无效: %v", rule.Path, err))
+	}
+	if rule.Pattern == "" {
+		return orz.NewError(400, "匹配规则不能为空")
+	}
+	if _, err := regexp.Compile(rule.Pattern); err != nil {
+		return orz.NewError(400, fmt.Sprintf("匹配规则 '%s' 无效: %v", rule.Pattern, err))
+	}
+	if rule.Exclude != "" {
+		if _, err := regexp.Compile(rule.Exclude); err != nil {
+			return orz.NewError(400, fmt.Sprintf("排除规则 '%s' 无效: %v", rule.Exclude, err))
+		}
+	}
+	switch rule.Level {
+	case "":
+		rule.Level = "warning"
+	case "info", "warning", "critical":
+	default:
+		return orz.NewError(400, "事件级别只能为 info、warning 或 critical")
+	}
+	if rule.Window < 0 || rule.Window > 86400 {
+		return orz.NewError(400, "限流窗口范围为 1-86400 秒，0 表示默认 60 秒")
+	}
+	if rule.SampleLines < 0 || rule.SampleLines > 20 {
+		return orz.NewError(400, "样例行数范围为 1-20，0 表示默认 5 行")
+	}
+	return nil
+}
+
+// Create 创建规则并下发到匹配的在线探针
+func (s *LogWatchService) Create(ctx context.Context, rule *models.LogWatchRule) error {
+	if err := s.ValidateRule(rule); err != nil {
+		return err
+	}
+
+	now := time.Now().UnixMilli()
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := s.RuleRepo.Create(ctx, rule); err != nil {
+		return err
+	}
+
+	go s.SyncOnlineAgents(context.Background())
+	return nil
+}
+
+// Update 更新规则并下发到受影响的在线探针
+func (s *LogWatchService) Update(ctx context.Context, rule *models.LogWatchRule) error {
+	if err := s.ValidateRule(rule); err != nil {
+		return err
+	}
+
+	rule.UpdatedAt = time.Now().UnixMilli()
+	if err := s.RuleRepo.Save(ctx, rule); err != nil {
+		return err
+	}
+
+	go s.SyncOnlineAgents(context.Background())
+	return nil
+}
+
+// Delete 删除规则，已应用该规则的在线探针停止跟踪对应文件
+func (s *LogWatchService) Delete(ctx context.Context, id string) error {
+	if err := s.RuleRepo.DeleteById(ctx, id); err != nil {
+		return err
+	}
+
+	go s.SyncOnlineAgents(context.Background())
+	return nil
+}
+
+// === 规则下发 ===
+
+// buildLogWatchConfig 构建探针应用的规则，版本为规则内容的摘要，没有规则时版本为空
+func buildLogWatchConfig(rules []models.LogWatchRule, agent *models.Agent) protocol.LogWatchConfig {
+	config := protocol.LogWatchConfig{Rules: []protocol.LogWatchRule{}}
+	for _, rule := range rules {
+		if !rule.Enabled || !rule.AppliesTo(agent) {
+			continue
+		}
+		config.Rules = append(config.Rules, protocol.LogWatchRule{
+			ID:          rule.ID,
+			Name:        rule.Name,
+			Path:        rule.Path,
+			Pattern:     rule.Pattern,
+			Exclude:     rule.Exclude,
+			Level:       rule.Level,
+			Window:      rule.Window,
+			SampleLines: rule.SampleLines,
+		})
+	}
+	if len(config.Rules) == 0 {
+		return config
+	}
+
+	content, _ := json.Marshal(config.Rules)
+	sum := sha256.Sum256(content)
+	config.Version = hex.EncodeToString(sum[:8])
+	return config
+}
+
+// BuildConfigForAgent 构建探针应用的规则，规则变化时将应用状态标记为 pending
+func (s *LogWatchService) BuildConfigForAgent(ctx context.Context, agent *models.Agent) (*protocol.LogWatchConfig, error) {
+	rules, err := s.RuleRepo.ListEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := buildLogWatchConfig(rules, agent)
+
+	if status := agent.LogWatchStatus.Data(); status.Version != config.Version {
+		if err := s.updateStatus(ctx, agent.ID, models.LogWatchStatusData{
+			Version:     config.Version,
+			ApplyStatus: "pending",
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return &config, nil
+}
+
+// SyncAgents 重新计算指定在线探针的规则，规则变化时下发
+func (s *LogWatchService) SyncAgents(ctx context.Context, agentIDs []string) {
+	agents, err := s.agentRepo.ListByIDs(ctx, agentIDs)
+	if err != nil {
+		s.logger.Error("查询探针失败", zap.Error(err))
+		return
+	}
+	rules, err := s.RuleRepo.ListEnabled(ctx)
+	if err != nil {
+		s.logger.Error("查询日志监控规则失败", zap.Error(err))
+		return
+	}
+
+	for i := range agents {
+		agent := &agents[i]
+		if _, online := s.wsManager.GetClient(agent.ID); !online {
+			continue
+		}
+
+		config := buildLogWatchConfig(rules, agent)
+		status := agent.LogWatchStatus.Data()
+		if status.Version == config.Version && status.ApplyStatus != "failed" {
+			continue
+		}
+
+		if err := s.updateStatus(ctx, agent.ID, models.LogWatchStatusData{
+			Version:     config.Version,
+			ApplyStatus: "pending",
+		}); err != nil {
+			s.logger.Error("更新日志监控状态失败", zap.String("agentId", agent.ID), zap.Error(err))
+			continue
+		}
+		if err := s.sendConfig(agent.ID, config); err != nil {
+			s.logger.Warn("下发日志监控规则到探针失败", zap.String("agentId", agent.ID), zap.Error(err))
+			continue
+		}
+		s.logger.Info("已下发日志监控规则到探针",
+			zap.String("agentId", agent.ID),
+			zap.Int("rules", len(config.Rules)),
+			zap.String("version", config.Version))
+	}
+}
+
+// SyncOnlineAgents 重新计算所有在线探针的规则，规则增删改后调用
+func (s *LogWatchService) SyncOnlineAgents(ctx context.Context) {
+	s.SyncAgents(ctx, s.wsManager.GetAllClients())
+}
+
+// sendConfig 通过 WebSocket 下发规则
+func (s *LogWatchService) sendConfig(agentID string, config protocol.LogWatchConfig) error {
+	msgBytes, err := json.Marshal(protocol.OutboundMessage{
+		Type: protocol.MessageTypeLogWatchConfig,
+		Data: config,
+	})
+	if err != nil {
+		return err
+	}
+	return s.wsManager.SendToClient(agentID, msgBytes)
+}
+
+// HandleConfigResult 处理探针上报的规则应用结果
+func (s *LogWatchService) HandleConfigResult(ctx context.Context, agentID string, result protocol.LogWatchConfigResult) error {
+	agent, err := s.agentRepo.FindById(ctx, agentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return fmt.Errorf("获取探针失败: %w", err)
+	}
+
+	// 忽略已被新规则取代的旧结果
+	status := agent.LogWatchStatus.Data()
+	if status.Version != result.Version {
+		return nil
+	}
+
+	status.ApplyStatus = "success"
+	if !result.Success {
+		status.ApplyStatus = "failed"
+	}
+	status.ApplyMessage = result.Message
+	status.Files = result.Files
+	status.AppliedAt = time.Now().UnixMilli()
+	return s.updateStatus(ctx, agentID, status)
+}
+
+func (s *LogWatchService) updateStatus(ctx context.Context, agentID string, status models.LogWatchStatusData) error {
+	var agentForUpdate = models.Agent{
+		ID:             agentID,
+		LogWatchStatus: datatypes.NewJSONType(status),
+	}
+	return s.agentRepo.UpdateById(ctx, &agentForUpdate)
+}
+
+// === 事件处理 ===
+
+// HandleEvent 保存探针上报的日志匹配事件，规则开启通知时发送通知
+func (s *LogWatchService) HandleEvent(ctx context.Context, agentID string, eventData protocol.LogWatchEvent) error {
+	event := &models.LogWatchEvent{
+		ID:        uuid.NewString(),
+		AgentID:   agentID,
+		RuleID:    eventData.RuleID,
+		RuleName:  eventData.RuleName,
+		Path:      eventData.Path,
+		Level:     eventData.Level,
+		Count:     eventData.Count,
+		Lines:     eventData.Lines,
+		FirstAt:   eventData.FirstAt,
+		LastAt:    eventData.LastAt,
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	if err := s.EventRepo.Create(ctx, event); err != nil {
+		s.logger.Error("保存日志匹配事件失败", zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("日志匹配事件已记录",
+		zap.String("agentId", agentID),
+		zap.String("rule", eventData.RuleName),
+		zap.String("path", eventData.Path),
+		zap.Int("count", eventData.Count))
+
+	// 规则已删除或关闭通知时只记录事件
+	rule, exists, err := s.RuleRepo.FindByIdExists(ctx, eventData.RuleID)
+	if err != nil {
+		return err
+	}
+	if !exists || !rule.Notify {
+		return nil
+	}
+	s.sendEventNotification(agentID, event)
+	return nil
+}
+
+func (s *LogWatchService) sendEventNotification(agentID string, event *models.LogWatchEvent) {
+	if s.notificationSvc == nil {
+		return
+	}
+
+	agent, err := s.agentRepo.FindById(context.Background(), agentID)
+	if err != nil {
+		s.logger.Error("获取探针信息失败", zap.String("agentId", agentID), zap.Error(err))
+		return
+	}
+
+	firedAt := event.LastAt
+	if firedAt == 0 {
+		firedAt = event.CreatedAt
+	}
+
+	record := &models.AlertRecord{
+		AgentID:     agentID,
+		AgentName:   agent.Name,
+		AlertType:   "log_match",
+		Message:     buildLogWatchMessage(event),
+		Threshold:   0,
+		ActualValue: float64(event.Count),
+		Level:       event.Level,
+		Status:      "notice",
+		FiredAt:     firedAt,
+		CreatedAt:   firedAt,
+	}
+
+	go func(record *models.AlertRecord, agent *models.Agent) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := s.notificationSvc.SendAlertNotification(ctx, NotificationTypeLogMatch, record, agent); err != nil {
+			s.logger.Error("发送日志匹配通知失败",
+				zap.String("agentId", agentID),
+				zap.Error(err),
+			)
+		}
+	}(record, &agent)
+}
+
+// buildLogWatchMessage 构建通知内容，样例行过长时截断
+func buildLogWatchMessage(event *models.LogWatchEvent) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "日志匹配：规则 %s，文件 %s，匹配 %d 行", event.RuleName, event.Path, event.Count)
+	for _, line := range event.Lines {
+		if runes := []rune(line); len(runes) > logWatchNotifyLineLimit {
+			line = string(runes[:logWatchNotifyLineLimit]) + "..."
+		}
+		sb.WriteString("\n")
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
+
+// DeleteEventsByAgentID 删除探针的所有日志匹配事件
+func (s *LogWatchService) DeleteEventsByAgentID(ctx context.Context, agentID string) error {
+	return s.EventRepo.DeleteEventsByAgentID(ctx, agentID)
+}
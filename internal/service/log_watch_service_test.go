@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dushixiang/pika/internal/models"
+)
+
+func TestBuildLogWatchConfig(t *testing.T) {
+	rules := []models.LogWatchRule{
+		{ID: "all", Enabled: true, Path: "/var/log/kern.log", Pattern: "Out of memory"},
+		{ID: "web", Enabled: true, Path: "/var/log/nginx/error.log", Pattern: `\[error\]`, Tags: []string{"web"}},
+		{ID: "pinned", Enabled: true, Path: "/var/log/app.log", Pattern: "panic", AgentIDs: []string{"agent-2"}},
+		{ID: "disabled", Enabled: false, Path: "/var/log/syslog", Pattern: "error"},
+	}
+
+	web := buildLogWatchConfig(rules, &models.Agent{ID: "agent-1", Tags: []string{"web"}})
+	if len(web.Rules) != 2 || web.Rules[0].ID != "all" || web.Rules[1].ID != "web" {
+		t.Fatalf("unexpected rules: %+v", web.Rules)
+	}
+	pinned := buildLogWatchConfig(rules, &models.Agent{ID: "agent-2"})
+	if len(pinned.Rules) != 2 || pinned.Rules[1].ID != "pinned" {
+		t.Fatalf("unexpected rules: %+v", pinned.Rules)
+	}
+	if web.Version == "" || web.Version == pinned.Version {
+		t.Fatalf("versions should differ: %q %q", web.Version, pinned.Version)
+	}
+
+	// 规则内容变化时版本变化
+	rules[1].Pattern = `\[crit\]`
+	if changed := buildLogWatchConfig(rules, &models.Agent{ID: "agent-1", Tags: []string{"web"}}); changed.Version == web.Version {
+		t.Fatal("version should change with rule content")
+	}
+
+	// 没有规则时版本为空，探针停止监控
+	if empty := buildLogWatchConfig(rules[3:], &models.Agent{ID: "agent-1"}); empty.Version != "" || len(empty.Rules) != 0 {
+		t.Fatalf("unexpected empty config: %+v", empty)
+	}
+}
+
+func TestLogWatchValidateRule(t *testing.T) {
+	s := &LogWatchService{}
+	cases := []struct {
+		rule  models.LogWatchRule
+		valid bool
+	}{
+		{models.LogWatchRule{Path: "/var/log/nginx/*.log", Pattern: "error"}, true},
+		{models.LogWatchRule{Path: "var/log/app.log", Pattern: "error"}, false},
+		{models.LogWatchRule{Path: "/var/log/[.log", Pattern: "error"}, false},
+		{models.LogWatchRule{Path: "/var/log/app.log", Pattern: "("}, false},
+		{models.LogWatchRule{Path: "/var/log/app.log", Pattern: "error", Level: "fatal"}, false},
+		{models.LogWatchRule{Path: "/var/log/app.log", Pattern: "error", SampleLines: 50}, false},
+	}
+	for i, tc := range cases {
+		err := s.ValidateRule(&tc.rule)
+		if (err == nil) != tc.valid {
+			t.Errorf("case %d: valid=%v, err=%v", i, tc.valid, err)
+		}
+	}
+}
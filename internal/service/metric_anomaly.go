@@ -0,0 +1,276 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+)
+
+const (
+	// anomalyHistory 学习基线使用的历史数据时长
+	anomalyHistory = 28 * 24 * time.Hour
+	// anomalyBaselineTTL 基线缓存时长
+	anomalyBaselineTTL = 6 * time.Hour
+	// anomalyEmptyBaselineTTL 历史数据不足时的基线缓存时长
+	anomalyEmptyBaselineTTL = time.Hour
+	// anomalyMinSamples 每个时间桶（含相邻小时）计算基线所需的最少样本数
+	anomalyMinSamples = 6
+	// defaultAnomalySensitivity 默认偏离阈值（MAD 倍数）
+	defaultAnomalySensitivity = 3.0
+	// madScale MAD 换算为正态分布标准差的系数
+	madScale = 1.4826
+)
+
+// anomalyMetric 参与异常检测的指标
+type anomalyMetric struct {
+	Key        string  // 指标标识，用于告警配置与告警状态
+	Label      string  // 告警消息中的指标名称
+	MetricType string  // 对应 GetMetrics 的指标类型
+	Series     string  // 对应 GetMetrics 的序列名称
+	Query      string  // PromQL 模板，%s 为探针 ID
+	MinSigma   float64 // 标准差估计的下限，避免平稳序列的预期范围过窄
+	Max        float64 // 取值上限，0 表示不限制
+	Bytes      bool    // 是否为字节速率
+}
+
+var anomalyMetrics = []anomalyMetric{
+	{Key: "cpu", Label: "CPU使用率", MetricType: "cpu", Series: "usage", Query: `pika_cpu_usage_percent{agent_id="%s"}`, MinSigma: 2, Max: 100},
+	{Key: "memory", Label: "内存使用率", MetricType: "memory", Series: "usage", Query: `pika_memory_usage_percent{agent_id="%s"}`, MinSigma: 1, Max: 100},
+	{Key: "network_upload", Label: "上行速率", MetricType: "network", Series: "upload", Query: `sum(pika_network_sent_bytes_rate{agent_id="%s"})`, MinSigma: 16 * 1024, Bytes: true},
+	{Key: "network_download", Label: "下行速率", MetricType: "network", Series: "download", Query: `sum(pika_network_recv_bytes_rate{agent_id="%s"})`, MinSigma: 16 * 1024, Bytes: true},
+	{Key: "connections", Label: "TCP连接数", MetricType: "network_connection", Series: "established", Query: `pika_network_conn_established{agent_id="%s"}`, MinSigma: 5},
+}
+
+// formatValue 格式化指标值，用于告警消息
+func (m anomalyMetric) formatValue(v float64) string {
+	switch {
+	case m.Bytes:
+		return formatBytes(uint64(math.Max(v, 0))) + "/s"
+	case m.Max == 100:
+		return fmt.Sprintf("%.2f%%", v)
+	default:
+		return fmt.Sprintf("%.0f", v)
+	}
+}
+
+// baselineBucket 单个时间桶的基线（中位数与绝对中位差）
+type baselineBucket struct {
+	median float64
+	mad    float64
+	ok     bool
+}
+
+// anomalyBaseline 按周内小时（168 个桶）学习的季节性基线，数据不足一周时回退到按天内小时（24 个桶）
+type anomalyBaseline struct {
+	week [168]baselineBucket
+	day  [24]baselineBucket
+}
+
+// hourOfWeek 返回时间所在的周内小时
+func hourOfWeek(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// learnBaseline 从小时均值学习基线，每个桶合并相邻小时的样本以提高稳定性
+func learnBaseline(points []metric.DataPoint) *anomalyBaseline {
+	var weekValues [168][]float64
+	var dayValues [24][]float64
+	for _, p := range points {
+		if math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		// 小时均值的时间戳为窗口结束时间，归入窗口所在的小时
+		t := time.UnixMilli(p.Timestamp).Add(-30 * time.Minute)
+		weekValues[hourOfWeek(t)] = append(weekValues[hourOfWeek(t)], p.Value)
+		dayValues[t.Hour()] = append(dayValues[t.Hour()], p.Value)
+	}
+
+	b := &anomalyBaseline{}
+	for h := range b.week {
+		b.week[h] = newBaselineBucket(weekValues[:], h)
+	}
+	for h := range b.day {
+		b.day[h] = newBaselineBucket(dayValues[:], h)
+	}
+	return b
+}
+
+// newBaselineBucket 合并第 h 个桶及其相邻桶的样本计算中位数与 MAD
+func newBaselineBucket(buckets [][]float64, h int) baselineBucket {
+	n := len(buckets)
+	var values []float64
+	for _, i := range []int{(h + n - 1) % n, h, (h + 1) % n} {
+		values = append(values, buckets[i]...)
+	}
+	if len(values) < anomalyMinSamples {
+		return baselineBucket{}
+	}
+
+	median := medianOf(values)
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - median)
+	}
+	return baselineBucket{median: median, mad: medianOf(deviations), ok: true}
+}
+
+// medianOf 计算中位数（会对切片排序）
+func medianOf(values []float64) float64 {
+	slices.Sort(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// valid 基线是否至少有一个可用的时间桶
+func (b *anomalyBaseline) valid() bool {
+	for _, bucket := range b.day {
+		if bucket.ok {
+			return true
+		}
+	}
+	return false
+}
+
+// band 计算指定时间的预期范围：中位数 ± 敏感度 × max(1.4826 × MAD, 最小半宽, 5% 中位数)
+func (b *anomalyBaseline) band(m anomalyMetric, t time.Time, sensitivity float64) (metric.BandPoint, bool) {
+	bucket := b.week[hourOfWeek(t)]
+	if !bucket.ok {
+		bucket = b.day[t.Hour()]
+	}
+	if !bucket.ok {
+		return metric.BandPoint{}, false
+	}
+
+	sigma := math.Max(madScale*bucket.mad, math.Max(m.MinSigma, 0.05*math.Abs(bucket.median)))
+	point := metric.BandPoint{
+		Timestamp: t.UnixMilli(),
+		Expected:  bucket.median,
+		Lower:     math.Max(bucket.median-sensitivity*sigma, 0),
+		Upper:     bucket.median + sensitivity*sigma,
+	}
+	if m.Max > 0 {
+		point.Upper = math.Min(point.Upper, m.Max)
+	}
+	return point, true
+}
+
+// getAnomalyBaseline 获取探针指标的基线，缓存过期后从时序存储重新学习
+func (s *MetricService) getAnomalyBaseline(ctx context.Context, agentID string, m anomalyMetric) *anomalyBaseline {
+	key := agentID + ":" + m.Key
+	if baseline, ok := s.anomalyBaselineCache.Get(key); ok {
+		return baseline
+	}
+
+	end := time.Now().Truncate(time.Hour)
+	start := end.Add(-anomalyHistory)
+	queries := []metric.QueryDefinition{{
+		Name:  m.Key,
+		Query: fmt.Sprintf(`avg_over_time((%s)[3600s:])`, fmt.Sprintf(m.Query, agentID)),
+	}}
+
+	var points []metric.DataPoint
+	for _, series := range s.querySeries(ctx, queries, start.UnixMilli(), end.UnixMilli(), time.Hour) {
+		points = append(points, series.Data...)
+	}
+
+	baseline := learnBaseline(points)
+	ttl := anomalyBaselineTTL
+	if !baseline.valid() {
+		ttl = anomalyEmptyBaselineTTL
+	}
+	s.anomalyBaselineCache.Set(key, baseline, ttl)
+	return baseline
+}
+
+// anomalySettings 读取异常检测配置，返回是否启用及敏感度
+func (s *MetricService) anomalySettings(ctx context.Context) (bool, float64) {
+	config, err := s.propertyService.GetAlertConfig(ctx)
+	if err != nil || !config.Rules.AnomalyEnabled {
+		return false, 0
+	}
+	return true, anomalySensitivity(config.Rules.AnomalySensitivity)
+}
+
+// anomalySensitivity 返回有效的敏感度，未配置时使用默认值
+func anomalySensitivity(value float64) float64 {
+	if value <= 0 {
+		return defaultAnomalySensitivity
+	}
+	return value
+}
+
+// anomalyBand 计算探针指标在指定时间的预期范围
+func (s *MetricService) anomalyBand(ctx context.Context, agentID string, m anomalyMetric, t time.Time, sensitivity float64) (metric.BandPoint, bool) {
+	return s.getAnomalyBaseline(ctx, agentID, m).band(m, t, sensitivity)
+}
+
+// buildAnomalyBands 为支持异常检测的图表序列生成预期范围，仅在启用异常检测时返回
+func (s *MetricService) buildAnomalyBands(ctx context.Context, agentID, metricType, interfaceName string, series []metric.Series) []metric.Band {
+	// 指定网卡的流量与基线（所有网卡汇总）不可比
+	if metricType == "network" && interfaceName != "" && interfaceName != "all" {
+		return nil
+	}
+	enabled, sensitivity := s.anomalySettings(ctx)
+	if !enabled {
+		return nil
+	}
+
+	var bands []metric.Band
+	for _, m := range anomalyMetrics {
+		if m.MetricType != metricType {
+			continue
+		}
+		for _, item := range series {
+			if item.Name != m.Series {
+				continue
+			}
+			baseline := s.getAnomalyBaseline(ctx, agentID, m)
+			if !baseline.valid() {
+				break
+			}
+			band := metric.Band{Name: item.Name}
+			for _, p := range item.Data {
+				if point, ok := baseline.band(m, time.UnixMilli(p.Timestamp), sensitivity); ok {
+					band.Data = append(band.Data, point)
+				}
+			}
+			bands = append(bands, band)
+			break
+		}
+	}
+	return bands
+}
+
+// anomalyCurrentValue 从最新指标中提取参与异常检测的当前值
+func anomalyCurrentValue(key string, latest *metric.LatestMetrics) (float64, bool) {
+	switch key {
+	case "cpu":
+		if latest.CPU != nil {
+			return latest.CPU.UsagePercent, true
+		}
+	case "memory":
+		if latest.Memory != nil {
+			return latest.Memory.UsagePercent, true
+		}
+	case "network_upload":
+		if latest.Network != nil {
+			return float64(latest.Network.TotalBytesSentRate), true
+		}
+	case "network_download":
+		if latest.Network != nil {
+			return float64(latest.Network.TotalBytesRecvRate), true
+		}
+	case "connections":
+		if latest.NetworkConnection != nil {
+			return float64(latest.NetworkConnection.Established), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+)
+
+func TestAnomalyBaseline(t *testing.T) {
+	cpu := anomalyMetrics[0]
+	// 三周的小时均值：工作时间 60%，其余时间 10%
+	start := time.Date(2026, 9, 7, 0, 0, 0, 0, time.Local)
+	var points []metric.DataPoint
+	for h := 0; h < 21*24; h++ {
+		ts := start.Add(time.Duration(h+1) * time.Hour)
+		value := 10.0
+		if hour := start.Add(time.Duration(h) * time.Hour).Hour(); hour >= 9 && hour < 18 {
+			value = 60 + float64(h%3)
+		}
+		points = append(points, metric.DataPoint{Timestamp: ts.UnixMilli(), Value: value})
+	}
+
+	baseline := learnBaseline(points)
+	if !baseline.valid() {
+		t.Fatalf("baseline should be valid")
+	}
+
+	night, ok := baseline.band(cpu, time.Date(2026, 9, 28, 3, 15, 0, 0, time.Local), 3)
+	if !ok || night.Expected != 10 || night.Lower != 4 || night.Upper != 16 {
+		t.Fatalf("unexpected night band: %+v", night)
+	}
+	noon, ok := baseline.band(cpu, time.Date(2026, 9, 28, 12, 15, 0, 0, time.Local), 3)
+	if !ok || noon.Expected != 61 || noon.Lower > 55 || noon.Upper < 67 {
+		t.Fatalf("unexpected noon band: %+v", noon)
+	}
+	// 夜间 70% 为异常，白天不是
+	if 70 <= night.Upper || 70 > noon.Upper {
+		t.Fatalf("unexpected anomaly classification: night=%+v noon=%+v", night, noon)
+	}
+
+	if learnBaseline(points[:4]).valid() {
+		t.Fatalf("baseline with too few samples should be invalid")
+	}
+}
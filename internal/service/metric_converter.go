@@ -2,6 +2,8 @@ package service
 
 import (
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/vmclient"
@@ -17,6 +19,16 @@ func (s *MetricService) convertToMetrics(agentID string, metricType string, data
 		metrics = append(metrics, createMetric("pika_cpu_usage_percent", agentID, nil, cpuData.UsagePercent, timestamp))
 		metrics = append(metrics, createMetric("pika_cpu_cores_logical", agentID, nil, float64(cpuData.LogicalCores), timestamp))
 		metrics = append(metrics, createMetric("pika_cpu_cores_physical", agentID, nil, float64(cpuData.PhysicalCores), timestamp))
+		if times := cpuData.Times; times != nil {
+			metrics = append(metrics,
+				createMetric("pika_cpu_user_percent", agentID, nil, times.User, timestamp),
+				createMetric("pika_cpu_system_percent", agentID, nil, times.System, timestamp),
+				createMetric("pika_cpu_iowait_percent", agentID, nil, times.IOWait, timestamp),
+				createMetric("pika_cpu_irq_percent", agentID, nil, times.IRQ, timestamp),
+				createMetric("pika_cpu_softirq_percent", agentID, nil, times.SoftIRQ, timestamp),
+				createMetric("pika_cpu_steal_percent", agentID, nil, times.Steal, timestamp),
+			)
+		}
 
 	case protocol.MetricTypeMemory:
 		memData := data.(*protocol.MemoryData)
@@ -102,6 +114,215 @@ func (s *MetricService) convertToMetrics(agentID string, metricType string, data
 				"target":       monitorData.Target,
 			}
 			metrics = append(metrics, createMetric("pika_monitor_response_time_ms", agentID, labels, float64(monitorData.ResponseTime), timestamp))
+
+			// 检测状态（1=up, 0=down），用于计算可用率
+			if monitorData.Status == "up" || monitorData.Status == "down" {
+				var status float64
+				if monitorData.Status == "up" {
+					status = 1
+				}
+				metrics = append(metrics, createMetric("pika_monitor_status", agentID, labels, status, timestamp))
+			}
+
+			// 多次探测的延迟统计（ICMP、多次尝试的 TCP）
+			if monitorData.PacketsSent > 0 {
+				metrics = append(metrics, createMetric("pika_monitor_packet_loss_percent", agentID, labels, monitorData.PacketLoss, timestamp))
+				if monitorData.PacketsRecv > 0 {
+					metrics = append(metrics,
+						createMetric("pika_monitor_rtt_min_ms", agentID, labels, monitorData.MinRtt, timestamp),
+						createMetric("pika_monitor_rtt_avg_ms", agentID, labels, monitorData.AvgRtt, timestamp),
+						createMetric("pika_monitor_rtt_max_ms", agentID, labels, monitorData.MaxRtt, timestamp),
+						createMetric("pika_monitor_rtt_stddev_ms", agentID, labels, monitorData.StdDevRtt, timestamp),
+						createMetric("pika_monitor_jitter_ms", agentID, labels, monitorData.Jitter, timestamp),
+					)
+				}
+			}
+		}
+
+	case protocol.MetricTypeCustom:
+		customDataList := data.([]protocol.CustomMetricData)
+		for _, customData := range customDataList {
+			// NaN/Inf 无法编码为 JSON，直接丢弃
+			if math.IsNaN(customData.Value) || math.IsInf(customData.Value, 0) {
+				continue
+			}
+			name := customMetricName(customData.Name)
+			if name == "" {
+				continue
+			}
+			metrics = append(metrics, createMetric(name, agentID, customMetricLabels(customData.Labels), customData.Value, timestamp))
+		}
+
+	case protocol.MetricTypeProcess:
+		processData := data.(*protocol.ProcessMetricsData)
+		// 排名进程按名称汇总，不带 pid 标签，避免短生命周期进程不断产生新的时间序列
+		for _, p := range sumProcessesByName(processData.Top) {
+			labels := map[string]string{"process": p.Name}
+			metrics = append(metrics,
+				createMetric("pika_process_cpu_percent", agentID, labels, p.CPUPercent, timestamp),
+				createMetric("pika_process_memory_rss_bytes", agentID, labels, float64(p.MemoryRSS), timestamp),
+				createMetric("pika_process_io_read_bytes_rate", agentID, labels, float64(p.ReadBytesRate), timestamp),
+				createMetric("pika_process_io_write_bytes_rate", agentID, labels, float64(p.WriteBytesRate), timestamp),
+				createMetric("pika_process_open_fds", agentID, labels, float64(p.NumFDs), timestamp),
+				createMetric("pika_process_threads", agentID, labels, float64(p.NumThreads), timestamp),
+			)
+		}
+		// 关注进程同样只按名称区分，重启后仍为同一条时间序列
+		for _, w := range processData.Watched {
+			labels := map[string]string{"process": w.Name}
+			metrics = append(metrics,
+				createMetric("pika_process_watch_count", agentID, labels, float64(w.Count), timestamp),
+				createMetric("pika_process_watch_cpu_percent", agentID, labels, w.CPUPercent, timestamp),
+				createMetric("pika_process_watch_memory_rss_bytes", agentID, labels, float64(w.MemoryRSS), timestamp),
+				createMetric("pika_process_watch_threads", agentID, labels, float64(w.NumThreads), timestamp),
+				createMetric("pika_process_watch_restarts_total", agentID, labels, float64(w.Restarts), timestamp),
+			)
+		}
+
+	case protocol.MetricTypeAgentBuffer:
+		bufferStats := data.(*protocol.MetricsBufferStats)
+		metrics = append(metrics,
+			createMetric("pika_agent_buffer_entries", agentID, nil, float64(bufferStats.Entries), timestamp),
+			createMetric("pika_agent_buffer_size_bytes", agentID, nil, float64(bufferStats.SizeBytes), timestamp),
+			createMetric("pika_agent_buffer_dropped_total", agentID, nil, float64(bufferStats.DroppedTotal), timestamp),
+			createMetric("pika_agent_buffer_downsampled_total", agentID, nil, float64(bufferStats.DownsampledTotal), timestamp),
+		)
+		if bufferStats.OldestTimestamp > 0 {
+			metrics = append(metrics, createMetric("pika_agent_buffer_oldest_age_seconds", agentID, nil,
+				float64(timestamp-bufferStats.OldestTimestamp)/1000, timestamp))
+		}
+
+	case protocol.MetricTypeAgentHealth:
+		health := data.(*protocol.AgentHealth)
+		metrics = append(metrics,
+			createMetric("pika_agent_uptime_seconds", agentID, nil, float64(health.UptimeSeconds), timestamp),
+			createMetric("pika_agent_goroutines", agentID, nil, float64(health.Goroutines), timestamp),
+			createMetric("pika_agent_rss_bytes", agentID, nil, float64(health.RSSBytes), timestamp),
+			createMetric("pika_agent_heap_bytes", agentID, nil, float64(health.HeapBytes), timestamp),
+			createMetric("pika_agent_reconnects_total", agentID, nil, float64(health.Reconnects), timestamp),
+		)
+		for _, c := range health.Collectors {
+			labels := map[string]string{"collector": c.Name}
+			metrics = append(metrics,
+				createMetric("pika_agent_collect_duration_seconds", agentID, labels, c.DurationMs/1000, timestamp),
+				createMetric("pika_agent_collector_errors_total", agentID, labels, float64(c.Errors), timestamp),
+			)
+		}
+
+	case protocol.MetricTypeKernel:
+		kernelData := data.(*protocol.KernelData)
+		if p := kernelData.Pressure; p != nil {
+			metrics = append(metrics,
+				createMetric("pika_pressure_cpu_some_percent", agentID, nil, p.CPUSome, timestamp),
+				createMetric("pika_pressure_memory_some_percent", agentID, nil, p.MemorySome, timestamp),
+				createMetric("pika_pressure_memory_full_percent", agentID, nil, p.MemoryFull, timestamp),
+				createMetric("pika_pressure_io_some_percent", agentID, nil, p.IOSome, timestamp),
+				createMetric("pika_pressure_io_full_percent", agentID, nil, p.IOFull, timestamp),
+			)
+		}
+		metrics = append(metrics,
+			createMetric("pika_kernel_context_switches_rate", agentID, nil, kernelData.ContextSwitchesRate, timestamp),
+			createMetric("pika_kernel_interrupts_rate", agentID, nil, kernelData.InterruptsRate, timestamp),
+			createMetric("pika_kernel_file_handles_allocated", agentID, nil, float64(kernelData.FileHandlesAllocated), timestamp),
+			createMetric("pika_kernel_file_handles_max", agentID, nil, float64(kernelData.FileHandlesMax), timestamp),
+			createMetric("pika_kernel_entropy_available_bits", agentID, nil, float64(kernelData.EntropyAvailable), timestamp),
+			createMetric("pika_kernel_oom_kills_total", agentID, nil, float64(kernelData.OOMKills), timestamp),
+		)
+		// 使用率在写入时计算，便于查询与告警
+		if kernelData.FileHandlesMax > 0 {
+			metrics = append(metrics, createMetric("pika_kernel_file_handles_usage_percent", agentID, nil,
+				float64(kernelData.FileHandlesAllocated)/float64(kernelData.FileHandlesMax)*100, timestamp))
+		}
+		if kernelData.ConntrackMax > 0 {
+			metrics = append(metrics,
+				createMetric("pika_kernel_conntrack_entries", agentID, nil, float64(kernelData.ConntrackCount), timestamp),
+				createMetric("pika_kernel_conntrack_max", agentID, nil, float64(kernelData.ConntrackMax), timestamp),
+				createMetric("pika_kernel_conntrack_usage_percent", agentID, nil,
+					float64(kernelData.ConntrackCount)/float64(kernelData.ConntrackMax)*100, timestamp),
+			)
+		}
+
+	case protocol.MetricTypeService:
+		serviceData := data.(*protocol.ServiceStateData)
+		for _, unit := range serviceData.Units {
+			labels := map[string]string{"unit": unit.Name}
+			metrics = append(metrics,
+				createMetric("pika_systemd_unit_active", agentID, labels, boolToFloat(unit.ActiveState == "active"), timestamp),
+				createMetric("pika_systemd_unit_failed", agentID, labels, boolToFloat(unit.ActiveState == "failed"), timestamp),
+				createMetric("pika_systemd_unit_restarts_total", agentID, labels, float64(unit.Restarts), timestamp),
+				createMetric("pika_systemd_unit_exit_code", agentID, labels, float64(unit.ExitCode), timestamp),
+			)
+		}
+		for _, container := range serviceData.Containers {
+			labels := map[string]string{"container": container.Name}
+			metrics = append(metrics,
+				createMetric("pika_container_running", agentID, labels, boolToFloat(container.State == "running"), timestamp),
+				createMetric("pika_container_restarts_total", agentID, labels, float64(container.Restarts), timestamp),
+				createMetric("pika_container_exit_code", agentID, labels, float64(container.ExitCode), timestamp),
+			)
+			if container.State == "running" {
+				metrics = append(metrics,
+					createMetric("pika_container_cpu_percent", agentID, labels, container.CPUPercent, timestamp),
+					createMetric("pika_container_memory_usage_bytes", agentID, labels, float64(container.MemoryUsage), timestamp),
+					createMetric("pika_container_memory_limit_bytes", agentID, labels, float64(container.MemoryLimit), timestamp),
+				)
+			}
+		}
+
+	case protocol.MetricTypeHardware:
+		hardwareData := data.(*protocol.HardwareData)
+		for _, disk := range hardwareData.Disks {
+			labels := map[string]string{"device": disk.Device, "model": disk.Model}
+			metrics = append(metrics,
+				createMetric("pika_smart_healthy", agentID, labels, boolToFloat(disk.Passed), timestamp),
+				createMetric("pika_smart_temperature_celsius", agentID, labels, disk.Temperature, timestamp),
+				createMetric("pika_smart_power_on_hours", agentID, labels, float64(disk.PowerOnHours), timestamp),
+				createMetric("pika_smart_reallocated_sectors", agentID, labels, float64(disk.ReallocatedSectors), timestamp),
+				createMetric("pika_smart_pending_sectors", agentID, labels, float64(disk.PendingSectors), timestamp),
+				createMetric("pika_smart_offline_uncorrectable", agentID, labels, float64(disk.OfflineUncorrectable), timestamp),
+				createMetric("pika_smart_media_errors", agentID, labels, float64(disk.MediaErrors), timestamp),
+			)
+			if disk.WearPercent != nil {
+				metrics = append(metrics, createMetric("pika_smart_wear_percent", agentID, labels, *disk.WearPercent, timestamp))
+			}
+		}
+		for _, array := range hardwareData.Arrays {
+			labels := map[string]string{"array": array.Name, "type": array.Type}
+			if array.Level != "" {
+				labels["level"] = array.Level
+			}
+			metrics = append(metrics,
+				createMetric("pika_raid_degraded", agentID, labels, boolToFloat(array.Degraded), timestamp),
+				createMetric("pika_raid_sync_progress_percent", agentID, labels, array.SyncProgress, timestamp),
+			)
+			if array.Type == "mdadm" {
+				metrics = append(metrics,
+					createMetric("pika_raid_devices", agentID, labels, float64(array.Devices), timestamp),
+					createMetric("pika_raid_active_devices", agentID, labels, float64(array.ActiveDevices), timestamp),
+					createMetric("pika_raid_failed_devices", agentID, labels, float64(array.FailedDevices), timestamp),
+				)
+			}
+		}
+		for _, ecc := range hardwareData.ECC {
+			labels := map[string]string{"controller": ecc.Controller}
+			metrics = append(metrics,
+				createMetric("pika_edac_corrected_errors_total", agentID, labels, float64(ecc.CorrectedErrors), timestamp),
+				createMetric("pika_edac_uncorrected_errors_total", agentID, labels, float64(ecc.UncorrectedErrors), timestamp),
+			)
+		}
+
+	case protocol.MetricTypeExporter:
+		exporterDataList := data.([]protocol.CustomMetricData)
+		for _, exporterData := range exporterDataList {
+			if math.IsNaN(exporterData.Value) || math.IsInf(exporterData.Value, 0) {
+				continue
+			}
+			// 保留原始指标名，但不允许覆盖 Pika 自身的指标
+			name := sanitizeMetricName(exporterData.Name)
+			if name == "" || strings.HasPrefix(name, "pika_") {
+				continue
+			}
+			metrics = append(metrics, createMetric(name, agentID, customMetricLabels(exporterData.Labels), exporterData.Value, timestamp))
 		}
 	}
 
@@ -128,3 +349,33 @@ func createMetric(metricName, agentID string, extraLabels map[string]string, val
 		Timestamps: []int64{timestamp},
 	}
 }
+
+// boolToFloat 将布尔状态转换为 1/0
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// sumProcessesByName 将同名进程的资源占用相加，按首次出现的顺序返回
+func sumProcessesByName(processes []protocol.ProcessMetric) []protocol.ProcessMetric {
+	index := make(map[string]int, len(processes))
+	var result []protocol.ProcessMetric
+	for _, p := range processes {
+		i, ok := index[p.Name]
+		if !ok {
+			i = len(result)
+			index[p.Name] = i
+			result = append(result, protocol.ProcessMetric{Name: p.Name})
+		}
+		sum := &result[i]
+		sum.CPUPercent += p.CPUPercent
+		sum.MemoryRSS += p.MemoryRSS
+		sum.ReadBytesRate += p.ReadBytesRate
+		sum.WriteBytesRate += p.WriteBytesRate
+		sum.NumFDs += p.NumFDs
+		sum.NumThreads += p.NumThreads
+	}
+	return result
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func TestConvertProcessMetricsSumsByName(t *testing.T) {
+	s := &MetricService{}
+	data := &protocol.ProcessMetricsData{Top: []protocol.ProcessMetric{
+		{PID: 10, Name: "nginx", CPUPercent: 1.5, NumThreads: 2},
+		{PID: 11, Name: "postgres", CPUPercent: 3},
+		{PID: 12, Name: "nginx", CPUPercent: 2.5, NumThreads: 4},
+	}}
+
+	cpu := make(map[string]float64)
+	for _, m := range s.convertToMetrics("a1", string(protocol.MetricTypeProcess), data, 1000) {
+		if _, ok := m.Metric["pid"]; ok {
+			t.Fatalf("unexpected pid label: %v", m.Metric)
+		}
+		if m.Metric["__name__"] == "pika_process_cpu_percent" {
+			cpu[m.Metric["process"]] = m.Values[0]
+		}
+	}
+	if len(cpu) != 2 || cpu["nginx"] != 4 || cpu["postgres"] != 3 {
+		t.Fatalf("unexpected cpu series: %v", cpu)
+	}
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/vmclient"
+	"go.uber.org/zap"
+)
+
+// CustomMetricPrefix 自定义指标在时序存储中的名称前缀
+const CustomMetricPrefix = "pika_custom_"
+
+// maxCustomMetricsPerPayload 单次上报的自定义指标数量上限，避免异常脚本写入过多序列
+const maxCustomMetricsPerPayload = 1000
+
+// maxProcessesPerPayload 单次上报的进程数量上限
+const maxProcessesPerPayload = 200
+
+// maxServicesPerPayload 单次上报的 systemd 单元或容器数量上限
+const maxServicesPerPayload = 500
+
+// maxHardwareItemsPerPayload 单次上报的磁盘、阵列或内存控制器数量上限
+const maxHardwareItemsPerPayload = 200
+
+// maxExporterMetricsPerPayload 单次上报的 exporter 指标数量上限
+const maxExporterMetricsPerPayload = 50000
+
+// maxMetricsBatchItems 单个批量消息包含的指标项数量上限
+const maxMetricsBatchItems = 2000
+
+// customMetricName 规范化自定义指标名称并加上 pika_custom_ 前缀，名称无效时返回空字符串
+func customMetricName(name string) string {
+	name = sanitizeMetricName(strings.TrimPrefix(name, CustomMetricPrefix))
+	if name == "" {
+		return ""
+	}
+	return CustomMetricPrefix + name
+}
+
+// sanitizeMetricName 将非法字符替换为下划线，使其符合 Prometheus 指标命名规范
+func sanitizeMetricName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// customMetricLabels 规范化自定义指标标签，丢弃保留标签（agent_id 及 __ 开头的内部标签）
+func customMetricLabels(labels map[string]string) map[string]string {
+	if len(labels) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(labels))
+	for k, v := range labels {
+		k = strings.ReplaceAll(sanitizeMetricName(k), ":", "_")
+		if k == "" || k == "agent_id" || strings.HasPrefix(k, "__") {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
+// GetCustomMetrics 获取探针指定自定义指标的时序数据，每个标签组合一条序列
+func (s *MetricService) GetCustomMetrics(ctx context.Context, agentID, name string, start, end int64, aggregation string) (*metric.GetMetricsResponse, error) {
+	metricName := customMetricName(name)
+	if metricName == "" {
+		return nil, fmt.Errorf("invalid custom metric name: %s", name)
+	}
+
+	step := vmclient.AutoStep(time.UnixMilli(start), time.UnixMilli(end))
+	query := fmt.Sprintf(`%s{agent_id="%s"}`, metricName, agentID)
+	if aggregation != "" {
+		query = wrapAggregationQuery(query, aggregation, step)
+	}
+	queries := []metric.QueryDefinition{{
+		Name:  strings.TrimPrefix(metricName, CustomMetricPrefix),
+		Query: query,
+	}}
+
+	return &metric.GetMetricsResponse{
+		AgentID: agentID,
+		Type:    "custom",
+		Range:   fmt.Sprintf("%d-%d", start, end),
+		Series:  s.querySeries(ctx, queries, start, end, step),
+	}, nil
+}
+
+// GetAvailableCustomMetrics 获取探针已上报的自定义指标名称（不含前缀）
+func (s *MetricService) GetAvailableCustomMetrics(ctx context.Context, agentID string) ([]string, error) {
+	match := []string{fmt.Sprintf(`{__name__=~"%s.+",agent_id="%s"}`, CustomMetricPrefix, agentID)}
+	names, err := s.metricStore.GetLabelValues(ctx, "__name__", match)
+	if err != nil {
+		s.logger.Error("查询自定义指标列表失败",
+			zap.String("agentID", agentID),
+			zap.Error(err))
+		return []string{}, nil // 返回空列表而不是错误
+	}
+
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if strings.HasPrefix(name, CustomMetricPrefix) {
+			result = append(result, strings.TrimPrefix(name, CustomMetricPrefix))
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
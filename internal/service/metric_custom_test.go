@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func TestCustomMetricName(t *testing.T) {
+	cases := map[string]string{
+		"queue.depth":             "pika_custom_queue_depth",
+		"pika_custom_orders":      "pika_custom_orders",
+		"9lives":                  "pika_custom__9lives",
+		"http-requests:rate5m":    "pika_custom_http_requests:rate5m",
+		"  ":                      "",
+		"pika_custom_":            "",
+		"ok_name_with_digits_123": "pika_custom_ok_name_with_digits_123",
+	}
+	for in, want := range cases {
+		if got := customMetricName(in); got != want {
+			t.Errorf("customMetricName(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	labels := customMetricLabels(map[string]string{"agent_id": "x", "__name__": "y", "queue.name": "email"})
+	if len(labels) != 1 || labels["queue_name"] != "email" {
+		t.Fatalf("unexpected labels: %v", labels)
+	}
+}
+
+func TestEvaluateCustomMetricRule(t *testing.T) {
+	custom := []protocol.CustomMetricData{
+		{Name: "queue_depth", Labels: map[string]string{"queue": "email"}, Value: 10},
+		{Name: "queue_depth", Labels: map[string]string{"queue": "sms"}, Value: 30},
+		{Name: "orders", Value: 5},
+		{Name: "consumer_lag", Labels: map[string]string{"queue.name": "email"}, Value: 7},
+	}
+
+	value, ok := evaluateCustomMetricRule(models.CustomMetricAlertRule{Metric: "queue_depth"}, custom)
+	if !ok || value != 30 {
+		t.Fatalf("expected max 30, got %v %v", value, ok)
+	}
+
+	value, ok = evaluateCustomMetricRule(models.CustomMetricAlertRule{Metric: "queue_depth", Operator: "lt"}, custom)
+	if !ok || value != 10 {
+		t.Fatalf("expected min 10, got %v %v", value, ok)
+	}
+
+	value, ok = evaluateCustomMetricRule(models.CustomMetricAlertRule{Metric: "pika_custom_queue_depth", Labels: map[string]string{"queue": "email"}}, custom)
+	if !ok || value != 10 {
+		t.Fatalf("expected 10, got %v %v", value, ok)
+	}
+
+	// 标签名与图表中展示的一致
+	value, ok = evaluateCustomMetricRule(models.CustomMetricAlertRule{Metric: "consumer_lag", Labels: map[string]string{"queue_name": "email"}}, custom)
+	if !ok || value != 7 {
+		t.Fatalf("expected 7, got %v %v", value, ok)
+	}
+
+	if _, ok = evaluateCustomMetricRule(models.CustomMetricAlertRule{Metric: "missing"}, custom); ok {
+		t.Fatalf("expected no match")
+	}
+}
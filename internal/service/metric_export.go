@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+)
+
+// MetricTypeTraffic 导出专用的指标类型：每个步长内的上行/下行流量（字节）
+const MetricTypeTraffic = "traffic"
+
+// exportBatchPoints 导出时单次查询的最大步长数，长时间范围按窗口分批查询并逐批写出
+const exportBatchPoints = 10000
+
+// ExportAgentMetrics 按指定步长分批查询探针多个指标类型的时序数据用于导出，每个时间窗口调用一次 yield，
+// 序列名称加上指标类型前缀（如 cpu.usage、network.upload），并去掉固定的 agent_id 标签
+func (s *MetricService) ExportAgentMetrics(ctx context.Context, agentID string, metricTypes []string, start, end int64, step time.Duration, interfaceName, aggregation string, yield func([]metric.Series) error) error {
+	return forEachExportWindow(start, end, step, func(windowStart, windowEnd int64) error {
+		var series []metric.Series
+		for _, metricType := range metricTypes {
+			var items []metric.Series
+			if metricType == MetricTypeTraffic {
+				items = s.querySeries(ctx, buildTrafficQueries(agentID, interfaceName, step), windowStart, windowEnd, step)
+			} else {
+				resp, err := s.getMetrics(ctx, agentID, metricType, windowStart, windowEnd, step, interfaceName, aggregation)
+				if err != nil {
+					return err
+				}
+				items = resp.Series
+			}
+
+			for _, item := range items {
+				item.Name = metricType + "." + item.Name
+				delete(item.Labels, "agent_id")
+				series = append(series, item)
+			}
+		}
+		return yield(series)
+	})
+}
+
+// ExportMonitorHistory 按指定步长分批查询监控任务各探针的历史数据用于导出，每个时间窗口调用一次 yield
+func (s *MetricService) ExportMonitorHistory(ctx context.Context, monitorID string, start, end int64, step time.Duration, aggregation string, yield func([]metric.Series) error) error {
+	return forEachExportWindow(start, end, step, func(windowStart, windowEnd int64) error {
+		resp, err := s.getMonitorHistory(ctx, monitorID, windowStart, windowEnd, step, aggregation)
+		if err != nil {
+			return err
+		}
+		return yield(resp.Series)
+	})
+}
+
+// forEachExportWindow 将 [start, end] 切分为最多 exportBatchPoints 个步长的窗口，
+// 窗口起点与 start 按步长对齐且互不重叠
+func forEachExportWindow(start, end int64, step time.Duration, fn func(windowStart, windowEnd int64) error) error {
+	stepMs := step.Milliseconds()
+	if stepMs <= 0 {
+		return fmt.Errorf("invalid export step: %s", step)
+	}
+	span := stepMs * (exportBatchPoints - 1)
+	for windowStart := start; windowStart <= end; windowStart += span + stepMs {
+		if err := fn(windowStart, min(windowStart+span, end)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildTrafficQueries 构造流量导出查询，按步长统计累计流量计数器的增量
+func buildTrafficQueries(agentID, interfaceName string, step time.Duration) []metric.QueryDefinition {
+	matcher := fmt.Sprintf(`agent_id="%s"`, agentID)
+	var labels map[string]string
+	if interfaceName != "" && interfaceName != "all" {
+		matcher += fmt.Sprintf(`,interface="%s"`, escapeLabelValue(interfaceName))
+		labels = map[string]string{"interface": interfaceName}
+	}
+	window := fmt.Sprintf("%ds", int(step.Seconds()))
+	return []metric.QueryDefinition{
+		{
+			Name:   "upload_bytes",
+			Query:  fmt.Sprintf(`sum(increase(pika_network_sent_bytes_total{%s}[%s]))`, matcher, window),
+			Labels: labels,
+		},
+		{
+			Name:   "download_bytes",
+			Query:  fmt.Sprintf(`sum(increase(pika_network_recv_bytes_total{%s}[%s]))`, matcher, window),
+			Labels: labels,
+		},
+	}
+}
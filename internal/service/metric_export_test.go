@@ -0,0 +1,30 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForEachExportWindow(t *testing.T) {
+	var windows [][2]int64
+	step := 5 * time.Second
+	end := int64(exportBatchPoints*2) * step.Milliseconds()
+	err := forEachExportWindow(0, end, step, func(windowStart, windowEnd int64) error {
+		windows = append(windows, [2]int64{windowStart, windowEnd})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 每个窗口最多 exportBatchPoints 个点，窗口之间相差一个步长，最后剩余一个点
+	span := int64(exportBatchPoints-1) * step.Milliseconds()
+	want := [][2]int64{{0, span}, {span + 5000, 2*span + 5000}, {end, end}}
+	if len(windows) != len(want) {
+		t.Fatalf("unexpected windows: %v", windows)
+	}
+	for i := range want {
+		if windows[i] != want[i] {
+			t.Fatalf("window %d: got %v, want %v", i, windows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/vmclient"
+)
+
+// OpenMetricsContentType OpenMetrics 文本格式的 Content-Type
+const OpenMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// WriteOpenMetrics 以 OpenMetrics 文本格式输出所有探针缓存的最新指标，供 Prometheus 抓取
+func (s *MetricService) WriteOpenMetrics(ctx context.Context, w io.Writer) error {
+	agents, err := s.agentRepo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	var metrics []vmclient.Metric
+	for _, agent := range agents {
+		agentLabels := exposedAgentLabels(&agent)
+
+		status := float64(0)
+		if agent.Status == 1 {
+			status = 1
+		}
+		metrics = append(metrics, createMetric("pika_agent_up", agent.ID, agentLabels, status, 0))
+
+		latest, ok := s.latestCache.Get(agent.ID)
+		if !ok {
+			continue
+		}
+		for _, m := range s.convertLatestMetrics(agent.ID, latest) {
+			for k, v := range agentLabels {
+				m.Metric[k] = v
+			}
+			metrics = append(metrics, m)
+		}
+	}
+
+	return writeOpenMetrics(w, metrics)
+}
+
+// convertLatestMetrics 将缓存的最新指标转换为与写入时序存储一致的指标
+func (s *MetricService) convertLatestMetrics(agentID string, latest *metric.LatestMetrics) []vmclient.Metric {
+	var metrics []vmclient.Metric
+	if latest.CPU != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeCPU), latest.CPU, 0)...)
+	}
+	if latest.Memory != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeMemory), latest.Memory, 0)...)
+	}
+	if latest.Disk != nil {
+		// 缓存中只有汇总数据，对应时序存储中 mount_point 为空的序列
+		summary := []protocol.DiskData{{
+			UsagePercent: latest.Disk.UsagePercent,
+			Total:        latest.Disk.Total,
+			Used:         latest.Disk.Used,
+			Free:         latest.Disk.Free,
+		}}
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeDisk), summary, 0)...)
+	}
+	if len(latest.DiskIO) > 0 {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeDiskIO), latest.DiskIO, 0)...)
+	}
+	if len(latest.NetworkInterfaces) > 0 {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeNetwork), latest.NetworkInterfaces, 0)...)
+	}
+	if latest.NetworkConnection != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeNetworkConnection), latest.NetworkConnection, 0)...)
+	}
+	if len(latest.GPU) > 0 {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeGPU), latest.GPU, 0)...)
+	}
+	if len(latest.Temp) > 0 {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeTemperature), latest.Temp, 0)...)
+	}
+	if len(latest.Monitors) > 0 {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeMonitor), latest.Monitors, 0)...)
+	}
+	if len(latest.Custom) > 0 {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeCustom), latest.Custom, 0)...)
+	}
+	if latest.Processes != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeProcess), latest.Processes, 0)...)
+	}
+	if latest.Services != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeService), latest.Services, 0)...)
+	}
+	if latest.Kernel != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeKernel), latest.Kernel, 0)...)
+	}
+	if latest.Hardware != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeHardware), latest.Hardware, 0)...)
+	}
+	if latest.Buffer != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeAgentBuffer), latest.Buffer, 0)...)
+	}
+	if latest.Health != nil {
+		metrics = append(metrics, s.convertToMetrics(agentID, string(protocol.MetricTypeAgentHealth), latest.Health, 0)...)
+	}
+	return metrics
+}
+
+// exposedAgentLabels 抓取接口附加的探针标签，tags 为逗号分隔的标签列表
+func exposedAgentLabels(agent *models.Agent) map[string]string {
+	tags := make([]string, 0, len(agent.Tags))
+	for _, tag := range agent.Tags {
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+
+	return map[string]string{
+		"agent_name": agent.Name,
+		"tags":       strings.Join(tags, ","),
+	}
+}
+
+// writeOpenMetrics 按指标名称分组输出，同名指标必须连续
+func writeOpenMetrics(w io.Writer, metrics []vmclient.Metric) error {
+	type line struct {
+		labels string
+		value  float64
+	}
+	families := make(map[string][]line)
+	for _, m := range metrics {
+		if len(m.Values) == 0 {
+			continue
+		}
+		name := m.Metric["__name__"]
+		families[name] = append(families[name], line{labels: formatLabels(m.Metric), value: m.Values[len(m.Values)-1]})
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		fmt.Fprintf(bw, "# TYPE %s gauge\n", name)
+		lines := families[name]
+		sort.Slice(lines, func(i, j int) bool { return lines[i].labels < lines[j].labels })
+		for _, l := range lines {
+			fmt.Fprintf(bw, "%s%s %s\n", name, l.labels, formatSampleValue(l.value))
+		}
+	}
+	fmt.Fprintf(bw, "# EOF\n")
+	return bw.Flush()
+}
+
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k, v := range labels {
+		if k == "__name__" || v == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabelValue(labels[k]))
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueReplacer.Replace(v)
+}
+
+func formatSampleValue(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/vmclient"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	metrics := []vmclient.Metric{
+		createMetric("pika_cpu_usage_percent", "a2", map[string]string{"agent_name": "web-2"}, 12.5, 0),
+		createMetric("pika_agent_up", "a1", map[string]string{"agent_name": `db "1"`, "tags": "prod,db"}, 1, 0),
+		createMetric("pika_cpu_usage_percent", "a1", map[string]string{"agent_name": "db", "tags": ""}, 3, 0),
+	}
+
+	var buf bytes.Buffer
+	if err := writeOpenMetrics(&buf, metrics); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# TYPE pika_agent_up gauge
+pika_agent_up{agent_id="a1",agent_name="db \"1\"",tags="prod,db"} 1
+# TYPE pika_cpu_usage_percent gauge
+pika_cpu_usage_percent{agent_id="a1",agent_name="db"} 3
+pika_cpu_usage_percent{agent_id="a2",agent_name="web-2"} 12.5
+# EOF
+`
+	if buf.String() != want {
+		t.Fatalf("unexpected output:\n%s", buf.String())
+	}
+}
+
+func TestConvertLatestMetrics(t *testing.T) {
+	s := &MetricService{}
+	latest := &metric.LatestMetrics{
+		DiskIO:    []*protocol.DiskIOData{{Device: "sda", ReadBytesRate: 1024}},
+		Processes: &protocol.ProcessMetricsData{Top: []protocol.ProcessMetric{{Name: "nginx", CPUPercent: 5}}},
+		Services:  &protocol.ServiceStateData{Units: []protocol.SystemdUnitState{{Name: "nginx.service", ActiveState: "active"}}},
+		Kernel:    &protocol.KernelData{FileHandlesMax: 100},
+		Hardware:  &protocol.HardwareData{Disks: []protocol.SmartDiskData{{Device: "/dev/sda", Passed: true}}},
+		Buffer:    &protocol.MetricsBufferStats{Entries: 3},
+		Health:    &protocol.AgentHealth{UptimeSeconds: 60},
+	}
+
+	names := make(map[string]bool)
+	for _, m := range s.convertLatestMetrics("a1", latest) {
+		names[m.Metric["__name__"]] = true
+	}
+	for _, name := range []string{
+		"pika_disk_read_bytes_rate",
+		"pika_process_cpu_percent",
+		"pika_systemd_unit_active",
+		"pika_kernel_file_handles_max",
+		"pika_smart_healthy",
+		"pika_agent_buffer_entries",
+		"pika_agent_uptime_seconds",
+	} {
+		if !names[name] {
+			t.Errorf("missing %s", name)
+		}
+	}
+}
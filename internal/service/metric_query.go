@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metricstore"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/vmclient"
+	"github.com/go-orz/orz"
+	"go.uber.org/zap"
+)
+
+// maxQueryPoints 范围查询单条序列最多返回的点数，与 Prometheus 限制一致
+const maxQueryPoints = 11000
+
+// PromQLQueryRequest 自定义 PromQL 查询请求
+type PromQLQueryRequest struct {
+	Query string `json:"query" query:"query" form:"query"`
+	Start int64  `json:"start" query:"start" form:"start"` // 开始时间（时间戳毫秒），仅范围查询
+	End   int64  `json:"end" query:"end" form:"end"`       // 结束时间（时间戳毫秒），仅范围查询
+	Step  int64  `json:"step" query:"step" form:"step"`    // 步长（秒），为 0 时自动选择
+}
+
+// QueryPromQL 执行即时查询，查询范围限制在 agents 内，结果附带探针名称与标签
+func (s *MetricService) QueryPromQL(ctx context.Context, query string, agents []models.Agent) (*vmclient.QueryResult, error) {
+	restricted, err := restrictToAgents(query, agents)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.metricStore.Query(ctx, restricted)
+	if err != nil {
+		s.logger.Warn("自定义 PromQL 查询失败", zap.String("query", query), zap.Error(err))
+		return nil, orz.NewError(400, "查询失败: "+err.Error())
+	}
+	enrichAgentLabels(result, agents)
+	return result, nil
+}
+
+// QueryRangePromQL 执行范围查询，查询范围限制在 agents 内，结果附带探针名称与标签
+func (s *MetricService) QueryRangePromQL(ctx context.Context, req *PromQLQueryRequest, agents []models.Agent) (*vmclient.QueryResult, error) {
+	if req.Start <= 0 || req.End <= 0 || req.Start >= req.End {
+		return nil, orz.NewError(400, "start 必须小于 end")
+	}
+	start, end := time.UnixMilli(req.Start), time.UnixMilli(req.End)
+
+	step := time.Duration(req.Step) * time.Second
+	if step <= 0 {
+		step = vmclient.AutoStep(start, end)
+	}
+	if end.Sub(start)/step > maxQueryPoints {
+		return nil, orz.NewError(400, "查询点数过多，请增大 step 或缩小时间范围")
+	}
+
+	restricted, err := restrictToAgents(req.Query, agents)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.metricStore.QueryRange(ctx, restricted, start, end, step)
+	if err != nil {
+		s.logger.Warn("自定义 PromQL 范围查询失败", zap.String("query", req.Query), zap.Error(err))
+		return nil, orz.NewError(400, "查询失败: "+err.Error())
+	}
+	enrichAgentLabels(result, agents)
+	return result, nil
+}
+
+// restrictToAgents 为查询中的每个序列选择器追加 agent_id 条件
+func restrictToAgents(query string, agents []models.Agent) (string, error) {
+	if strings.TrimSpace(query) == "" {
+		return "", orz.NewError(400, "查询语句不能为空")
+	}
+
+	ids := make([]string, 0, len(agents))
+	for _, agent := range agents {
+		ids = append(ids, agent.ID)
+	}
+	sort.Strings(ids)
+
+	restricted, err := metricstore.RestrictQuery(query, "agent_id", ids)
+	if err != nil {
+		return "", orz.NewError(400, "查询语句错误: "+err.Error())
+	}
+	return restricted, nil
+}
+
+// enrichAgentLabels 为包含 agent_id 的序列补充 agent_name 与 tags 标签
+func enrichAgentLabels(result *vmclient.QueryResult, agents []models.Agent) {
+	agentMap := make(map[string]*models.Agent, len(agents))
+	for i := range agents {
+		agentMap[agents[i].ID] = &agents[i]
+	}
+
+	for i := range result.Data.Result {
+		labels := result.Data.Result[i].Metric
+		agent, ok := agentMap[labels["agent_id"]]
+		if !ok {
+			continue
+		}
+		for k, v := range exposedAgentLabels(agent) {
+			labels[k] = v
+		}
+	}
+}
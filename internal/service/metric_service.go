@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/metricstore"
+	"github.com/dushixiang/pika/internal/models"
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/internal/repo"
 	"github.com/dushixiang/pika/internal/vmclient"
@@ -16,6 +19,7 @@ import (
 
 	"github.com/go-orz/cache"
 	"go.uber.org/zap"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -26,29 +30,84 @@ type MetricService struct {
 	monitorRepo     *repo.MonitorRepo
 	propertyService *PropertyService
 	trafficService  *TrafficService // 流量统计服务
-	vmClient        *vmclient.VMClient
+	routeService    *RouteService   // 路由追踪服务
+	uptimeService   *UptimeService  // 可用率服务
+	metricStore     metricstore.MetricStore
+	remoteWrite     *RemoteWriteService // Prometheus remote-write 转发
+	rollupService   *RollupService      // 降采样服务
 
 	latestCache cache.Cache[string, *metric.LatestMetrics] // Agent 最新指标缓存
 
 	monitorLatestCache cache.Cache[string, *metric.LatestMonitorMetrics] // 监控最新指标缓存
+
+	agentLabelsCache cache.Cache[string, map[string]string] // 探针元数据标签缓存
+
+	anomalyBaselineCache cache.Cache[string, *anomalyBaseline] // 异常检测基线缓存
 }
 
 // NewMetricService 创建指标服务
-func NewMetricService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, trafficService *TrafficService, vmClient *vmclient.VMClient) *MetricService {
+func NewMetricService(logger *zap.Logger, db *gorm.DB, propertyService *PropertyService, trafficService *TrafficService, routeService *RouteService, uptimeService *UptimeService, remoteWrite *RemoteWriteService, rollupService *RollupService, metricStore metricstore.MetricStore) *MetricService {
 	return &MetricService{
 		logger:             logger,
 		agentRepo:          repo.NewAgentRepo(db),
 		monitorRepo:        repo.NewMonitorRepo(db),
 		propertyService:    propertyService,
 		trafficService:     trafficService,
-		vmClient:           vmClient,
+		routeService:       routeService,
+		uptimeService:      uptimeService,
+		metricStore:        metricStore,
+		remoteWrite:        remoteWrite,
+		rollupService:      rollupService,
 		latestCache:        cache.New[string, *metric.LatestMetrics](time.Minute),
 		monitorLatestCache: cache.New[string, *metric.LatestMonitorMetrics](5 * time.Minute), // 监控数据缓存 5 分钟
+		agentLabelsCache:   cache.New[string, map[string]string](time.Minute),
+
+		anomalyBaselineCache: cache.New[string, *anomalyBaseline](time.Hour),
 	}
 }
 
 // HandleMetricData 处理指标数据
 func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, metricType string, data json.RawMessage, timestamp int64) error {
+	metrics, err := s.parseMetricData(ctx, agentID, metricType, data, timestamp)
+	if err != nil {
+		return err
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+	return s.writeMetrics(ctx, agentID, metrics)
+}
+
+// HandleMetricBatch 处理批量指标数据，一个批次可包含多个采集周期的多种指标，解析后一次性写入时序存储
+// 单项解析失败只跳过该项，不影响同批次的其他指标
+func (s *MetricService) HandleMetricBatch(ctx context.Context, agentID string, items []protocol.InputMetricsPayload) error {
+	if len(items) > maxMetricsBatchItems {
+		s.logger.Warn("批量指标数量超过上限，超出部分将被丢弃",
+			zap.String("agentId", agentID),
+			zap.Int("count", len(items)))
+		items = items[:maxMetricsBatchItems]
+	}
+
+	var metrics []vmclient.Metric
+	for _, item := range items {
+		itemMetrics, err := s.parseMetricData(ctx, agentID, string(item.Type), item.Data, item.Timestamp)
+		if err != nil {
+			s.logger.Warn("解析批量指标失败，已跳过",
+				zap.String("agentId", agentID),
+				zap.String("type", string(item.Type)),
+				zap.Error(err))
+			continue
+		}
+		metrics = append(metrics, itemMetrics...)
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+	return s.writeMetrics(ctx, agentID, metrics)
+}
+
+// parseMetricData 解析单项指标数据，更新最新指标缓存并转换为时序数据
+func (s *MetricService) parseMetricData(ctx context.Context, agentID string, metricType string, data json.RawMessage, timestamp int64) ([]vmclient.Metric, error) {
 	if timestamp == 0 {
 		timestamp = time.Now().UnixMilli()
 	}
@@ -56,34 +115,44 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 	// 更新内存缓存
 	latestMetrics, ok := s.latestCache.Get(agentID)
 	if !ok {
-		latestMetrics = &metric.LatestMetrics{}
+		latestMetrics = &metric.LatestMetrics{Timestamps: make(map[string]int64)}
 		s.latestCache.Set(agentID, latestMetrics, time.Hour)
 	}
+	// 离线缓存补发的旧数据只写入时序存储，不覆盖最新缓存，也不触发流量统计、故障记录等副作用
+	stale := timestamp < latestMetrics.Timestamps[metricType]
+	if !stale {
+		latestMetrics.Timestamps[metricType] = timestamp
+	}
 
-	// 解析数据并写入 VictoriaMetrics
+	// 解析数据并写入时序存储
 	switch protocol.MetricType(metricType) {
 	case protocol.MetricTypeCPU:
 		var cpuData protocol.CPUData
 		if err := json.Unmarshal(data, &cpuData); err != nil {
-			return err
+			return nil, err
 		}
-		latestMetrics.CPU = &cpuData
-		metrics := s.convertToMetrics(agentID, metricType, &cpuData, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		if !stale {
+			latestMetrics.CPU = &cpuData
+		}
+		return s.convertToMetrics(agentID, metricType, &cpuData, timestamp), nil
 
 	case protocol.MetricTypeMemory:
 		var memData protocol.MemoryData
 		if err := json.Unmarshal(data, &memData); err != nil {
-			return err
+			return nil, err
+		}
+		if !stale {
+			latestMetrics.Memory = &memData
 		}
-		latestMetrics.Memory = &memData
-		metrics := s.convertToMetrics(agentID, metricType, &memData, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		return s.convertToMetrics(agentID, metricType, &memData, timestamp), nil
 
 	case protocol.MetricTypeDisk:
 		var diskDataList []protocol.DiskData
 		if err := json.Unmarshal(data, &diskDataList); err != nil {
-			return err
+			return nil, err
+		}
+		if stale {
+			return s.convertToMetrics(agentID, metricType, diskDataList, timestamp), nil
 		}
 		// 计算汇总数据用于缓存
 		var totalTotal, totalUsed, totalFree uint64
@@ -103,13 +172,15 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 			Used:         totalUsed,
 			Free:         totalFree,
 		}
-		metrics := s.convertToMetrics(agentID, metricType, diskDataList, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		return s.convertToMetrics(agentID, metricType, diskDataList, timestamp), nil
 
 	case protocol.MetricTypeNetwork:
 		var networkDataList []protocol.NetworkData
 		if err := json.Unmarshal(data, &networkDataList); err != nil {
-			return err
+			return nil, err
+		}
+		if stale {
+			return s.convertToMetrics(agentID, metricType, networkDataList, timestamp), nil
 		}
 		// 计算汇总数据用于缓存
 		var totalSentRate, totalRecvRate uint64
@@ -134,82 +205,283 @@ func (s *MetricService) HandleMetricData(ctx context.Context, agentID string, me
 				zap.String("agentId", agentID),
 				zap.Error(err))
 		}
-		metrics := s.convertToMetrics(agentID, metricType, networkDataList, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		return s.convertToMetrics(agentID, metricType, networkDataList, timestamp), nil
 
 	case protocol.MetricTypeNetworkConnection:
 		var connData protocol.NetworkConnectionData
 		if err := json.Unmarshal(data, &connData); err != nil {
-			return err
+			return nil, err
+		}
+		if !stale {
+			latestMetrics.NetworkConnection = &connData
 		}
-		latestMetrics.NetworkConnection = &connData
-		metrics := s.convertToMetrics(agentID, metricType, &connData, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		return s.convertToMetrics(agentID, metricType, &connData, timestamp), nil
 
 	case protocol.MetricTypeDiskIO:
 		var diskIODataList []*protocol.DiskIOData
 		if err := json.Unmarshal(data, &diskIODataList); err != nil {
-			return err
+			return nil, err
+		}
+		if !stale {
+			latestMetrics.DiskIO = diskIODataList
 		}
-		metrics := s.convertToMetrics(agentID, metricType, diskIODataList, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		return s.convertToMetrics(agentID, metricType, diskIODataList, timestamp), nil
 
 	case protocol.MetricTypeHost:
 		var hostData protocol.HostInfoData
 		if err := json.Unmarshal(data, &hostData); err != nil {
-			return err
+			return nil, err
 		}
-		latestMetrics.Host = &hostData
-		return nil
+		if !stale {
+			latestMetrics.Host = &hostData
+		}
+		return nil, nil
 
 	case protocol.MetricTypeGPU:
 		var gpuDataList []protocol.GPUData
 		if err := json.Unmarshal(data, &gpuDataList); err != nil {
-			return err
+			return nil, err
 		}
 		// 更新缓存
-		latestMetrics.GPU = gpuDataList
-		metrics := s.convertToMetrics(agentID, metricType, gpuDataList, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		if !stale {
+			latestMetrics.GPU = gpuDataList
+		}
+		return s.convertToMetrics(agentID, metricType, gpuDataList, timestamp), nil
 
 	case protocol.MetricTypeTemperature:
 		var tempDataList []protocol.TemperatureData
 		if err := json.Unmarshal(data, &tempDataList); err != nil {
-			return err
+			return nil, err
 		}
 		// 更新缓存
-		latestMetrics.Temp = tempDataList
-		metrics := s.convertToMetrics(agentID, metricType, tempDataList, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		if !stale {
+			latestMetrics.Temp = tempDataList
+		}
+		return s.convertToMetrics(agentID, metricType, tempDataList, timestamp), nil
 
 	case protocol.MetricTypeMonitor:
 		var monitorDataList []protocol.MonitorData
 		if err := json.Unmarshal(data, &monitorDataList); err != nil {
-			return err
+			return nil, err
 		}
 		for i := range monitorDataList {
 			monitorDataList[i].AgentId = agentID // 关联探针ID
 		}
+		if stale {
+			return s.convertToMetrics(agentID, metricType, monitorDataList, timestamp), nil
+		}
+		for i := range monitorDataList {
+			// 路由追踪：补充 ASN/归属地并记录路径快照
+			if monitorDataList[i].Type == "traceroute" {
+				s.routeService.HandleTracerouteResult(ctx, agentID, &monitorDataList[i])
+			}
+			// 根据状态变化记录故障
+			s.uptimeService.HandleMonitorResult(ctx, agentID, &monitorDataList[i], timestamp)
+		}
 		// 更新缓存
 		latestMetrics.Monitors = monitorDataList
 		for _, monitorData := range monitorDataList {
 			s.updateMonitorCache(agentID, &monitorData, timestamp)
 		}
 
-		metrics := s.convertToMetrics(agentID, metricType, monitorDataList, timestamp)
-		return s.vmClient.Write(ctx, metrics)
+		return s.convertToMetrics(agentID, metricType, monitorDataList, timestamp), nil
+
+	case protocol.MetricTypeCustom:
+		var customDataList []protocol.CustomMetricData
+		if err := json.Unmarshal(data, &customDataList); err != nil {
+			return nil, err
+		}
+		if len(customDataList) > maxCustomMetricsPerPayload {
+			s.logger.Warn("自定义指标数量超过上限，超出部分将被丢弃",
+				zap.String("agentId", agentID),
+				zap.Int("count", len(customDataList)))
+			customDataList = customDataList[:maxCustomMetricsPerPayload]
+		}
+		// 更新缓存（供告警使用）
+		if !stale {
+			latestMetrics.Custom = customDataList
+		}
+		return s.convertToMetrics(agentID, metricType, customDataList, timestamp), nil
+
+	case protocol.MetricTypeProcess:
+		var processData protocol.ProcessMetricsData
+		if err := json.Unmarshal(data, &processData); err != nil {
+			return nil, err
+		}
+		if len(processData.Top) > maxProcessesPerPayload {
+			processData.Top = processData.Top[:maxProcessesPerPayload]
+		}
+		if len(processData.Watched) > maxProcessesPerPayload {
+			processData.Watched = processData.Watched[:maxProcessesPerPayload]
+		}
+		// 更新缓存
+		if !stale {
+			latestMetrics.Processes = &processData
+		}
+		return s.convertToMetrics(agentID, metricType, &processData, timestamp), nil
+
+	case protocol.MetricTypeAgentBuffer:
+		var bufferStats protocol.MetricsBufferStats
+		if err := json.Unmarshal(data, &bufferStats); err != nil {
+			return nil, err
+		}
+		if !stale {
+			latestMetrics.Buffer = &bufferStats
+		}
+		return s.convertToMetrics(agentID, metricType, &bufferStats, timestamp), nil
+
+	case protocol.MetricTypeAgentHealth:
+		var health protocol.AgentHealth
+		if err := json.Unmarshal(data, &health); err != nil {
+			return nil, err
+		}
+		if stale {
+			return s.convertToMetrics(agentID, metricType, &health, timestamp), nil
+		}
+		latestMetrics.Health = &health
+		// 运行状态随探针详情展示，保存到探针信息中
+		if err := s.agentRepo.UpdateById(ctx, &models.Agent{
+			ID:     agentID,
+			Health: datatypes.NewJSONType(health),
+		}); err != nil {
+			s.logger.Warn("保存探针运行状态失败", zap.String("agentId", agentID), zap.Error(err))
+		}
+		return s.convertToMetrics(agentID, metricType, &health, timestamp), nil
+
+	case protocol.MetricTypeKernel:
+		var kernelData protocol.KernelData
+		if err := json.Unmarshal(data, &kernelData); err != nil {
+			return nil, err
+		}
+		// 更新缓存（供告警使用）
+		if !stale {
+			latestMetrics.Kernel = &kernelData
+		}
+		return s.convertToMetrics(agentID, metricType, &kernelData, timestamp), nil
+
+	case protocol.MetricTypeService:
+		var serviceData protocol.ServiceStateData
+		if err := json.Unmarshal(data, &serviceData); err != nil {
+			return nil, err
+		}
+		if len(serviceData.Units) > maxServicesPerPayload {
+			serviceData.Units = serviceData.Units[:maxServicesPerPayload]
+		}
+		if len(serviceData.Containers) > maxServicesPerPayload {
+			serviceData.Containers = serviceData.Containers[:maxServicesPerPayload]
+		}
+		// 更新缓存（供告警使用）
+		if !stale {
+			latestMetrics.Services = &serviceData
+		}
+		return s.convertToMetrics(agentID, metricType, &serviceData, timestamp), nil
+
+	case protocol.MetricTypeHardware:
+		var hardwareData protocol.HardwareData
+		if err := json.Unmarshal(data, &hardwareData); err != nil {
+			return nil, err
+		}
+		if len(hardwareData.Disks) > maxHardwareItemsPerPayload {
+			hardwareData.Disks = hardwareData.Disks[:maxHardwareItemsPerPayload]
+		}
+		if len(hardwareData.Arrays) > maxHardwareItemsPerPayload {
+			hardwareData.Arrays = hardwareData.Arrays[:maxHardwareItemsPerPayload]
+		}
+		if len(hardwareData.ECC) > maxHardwareItemsPerPayload {
+			hardwareData.ECC = hardwareData.ECC[:maxHardwareItemsPerPayload]
+		}
+		// 更新缓存（供告警使用）
+		if !stale {
+			latestMetrics.Hardware = &hardwareData
+		}
+		return s.convertToMetrics(agentID, metricType, &hardwareData, timestamp), nil
+
+	case protocol.MetricTypeExporter:
+		var exporterDataList []protocol.CustomMetricData
+		if err := json.Unmarshal(data, &exporterDataList); err != nil {
+			return nil, err
+		}
+		if len(exporterDataList) > maxExporterMetricsPerPayload {
+			s.logger.Warn("exporter 指标数量超过上限，超出部分将被丢弃",
+				zap.String("agentId", agentID),
+				zap.Int("count", len(exporterDataList)))
+			exporterDataList = exporterDataList[:maxExporterMetricsPerPayload]
+		}
+		return s.convertToMetrics(agentID, metricType, exporterDataList, timestamp), nil
 
 	default:
 		s.logger.Warn("unknown cpiMetric type", zap.String("type", metricType))
-		return nil
+		return nil, nil
+	}
+}
+
+// writeMetrics 附加探针元数据标签后写入时序存储，并转发到 remote-write 目标
+func (s *MetricService) writeMetrics(ctx context.Context, agentID string, metrics []vmclient.Metric) error {
+	if labels := s.getAgentMetricLabels(ctx, agentID); len(labels) > 0 {
+		for _, m := range metrics {
+			for k, v := range labels {
+				m.Metric[k] = v
+			}
+		}
+	}
+	s.remoteWrite.Enqueue(metrics)
+	return s.metricStore.Write(ctx, metrics)
+}
+
+// agentMetadataLabels 根据指标配置附加到时序数据的探针元数据标签
+var agentMetadataLabels = []string{"tags", "agent_name", "os", "arch"}
+
+// getAgentMetricLabels 根据指标配置获取需要附加到时序数据的探针元数据标签，结果缓存 1 分钟
+func (s *MetricService) getAgentMetricLabels(ctx context.Context, agentID string) map[string]string {
+	if labels, ok := s.agentLabelsCache.Get(agentID); ok {
+		return labels
+	}
+
+	labels := make(map[string]string)
+	config, err := s.propertyService.GetMetricsConfig(ctx)
+	if err != nil {
+		s.logger.Warn("获取指标配置失败", zap.Error(err))
+		return labels
+	}
+	if config.LabelTags || config.LabelName || config.LabelOS || config.LabelArch {
+		agent, err := s.agentRepo.FindById(ctx, agentID)
+		if err != nil {
+			// 探针不存在时不缓存，避免新注册的探针长时间缺少标签
+			return labels
+		}
+		exposed := exposedAgentLabels(&agent)
+		if config.LabelTags && exposed["tags"] != "" {
+			labels["tags"] = exposed["tags"]
+		}
+		if config.LabelName && agent.Name != "" {
+			labels["agent_name"] = agent.Name
+		}
+		if config.LabelOS && agent.OS != "" {
+			labels["os"] = agent.OS
+		}
+		if config.LabelArch && agent.Arch != "" {
+			labels["arch"] = agent.Arch
+		}
 	}
+
+	s.agentLabelsCache.Set(agentID, labels, time.Minute)
+	return labels
 }
 
-// GetMetrics 获取聚合指标数据（从 VictoriaMetrics 查询）
+// GetMetrics 获取聚合指标数据（从时序存储查询）
 // 返回统一的 GetMetricsResponse 格式
 func (s *MetricService) GetMetrics(ctx context.Context, agentID, metricType string, start, end int64, interfaceName string, aggregation string) (*metric.GetMetricsResponse, error) {
 	step := vmclient.AutoStep(time.UnixMilli(start), time.UnixMilli(end))
+	resp, err := s.getMetrics(ctx, agentID, metricType, start, end, step, interfaceName, aggregation)
+	if err != nil {
+		return nil, err
+	}
+	resp.Bands = s.buildAnomalyBands(ctx, agentID, metricType, interfaceName, resp.Series)
+	return resp, nil
+}
 
+// getMetrics 按指定步长获取聚合指标数据
+func (s *MetricService) getMetrics(ctx context.Context, agentID, metricType string, start, end int64, step time.Duration, interfaceName string, aggregation string) (*metric.GetMetricsResponse, error) {
 	// 构造 PromQL 查询（返回多个查询以支持多系列）
 	queries := s.buildPromQLQueries(agentID, metricType, interfaceName, aggregation, step)
 	if len(queries) == 0 {
@@ -217,25 +489,7 @@ func (s *MetricService) GetMetrics(ctx context.Context, agentID, metricType stri
 	}
 
 	// 执行查询并转换结果
-	// step 设为 0，让 VictoriaMetrics 自动选择合适的步长
-	var series []metric.Series
-
-	for _, q := range queries {
-		result, err := s.vmClient.QueryRange(ctx, q.Query,
-			time.UnixMilli(start),
-			time.UnixMilli(end),
-			step)
-		if err != nil {
-			s.logger.Error("查询 VictoriaMetrics 失败",
-				zap.String("query", q.Query),
-				zap.Error(err))
-			continue // 跳过失败的查询，继续处理其他查询
-		}
-
-		// 转换查询结果为 MetricSeries
-		convertedSeries := s.convertQueryResultToSeries(result, q.Name, q.Labels)
-		series = append(series, convertedSeries...)
-	}
+	series := s.querySeries(ctx, queries, start, end, step)
 
 	// 如果是监控类型，添加监控任务名称到标签中
 	if metricType == "monitor" && len(series) > 0 {
@@ -284,6 +538,96 @@ func (s *MetricService) GetMetrics(ctx context.Context, agentID, metricType stri
 	}, nil
 }
 
+// querySeries 执行范围查询并转换为图表序列，失败的查询会被跳过
+// 查询范围较长时优先读取降采样数据，降采样尚未覆盖的末尾部分仍从原始数据读取
+func (s *MetricService) querySeries(ctx context.Context, queries []metric.QueryDefinition, start, end int64, step time.Duration) []metric.Series {
+	var series []metric.Series
+
+	tier := s.rollupService.SelectTier(start, step)
+	for _, q := range queries {
+		if tier != nil {
+			if rollupQuery, ok := RewriteQuery(q.Query, tier); ok {
+				series = append(series, s.queryRollupSeries(ctx, q, rollupQuery, tier, start, end, step)...)
+				continue
+			}
+		}
+		series = append(series, s.queryRangeSeries(ctx, s.metricStore, q, q.Query, start, end, step)...)
+	}
+
+	return series
+}
+
+// queryRollupSeries 从降采样层级查询，并拼接尚未降采样的原始数据
+func (s *MetricService) queryRollupSeries(ctx context.Context, q metric.QueryDefinition, rollupQuery string, tier *RollupTier, start, end int64, step time.Duration) []metric.Series {
+	last := s.rollupService.TierLast(tier)
+	if last >= end {
+		return s.queryRangeSeries(ctx, s.rollupService.Store(), q, rollupQuery, start, end, step)
+	}
+
+	series := s.queryRangeSeries(ctx, s.rollupService.Store(), q, rollupQuery, start, last, step)
+	// 原始数据从降采样进度之后的第一个步长点开始，保持时间点对齐
+	stepMs := step.Milliseconds()
+	tailStart := start + ((last-start)/stepMs+1)*stepMs
+	if tailStart > end {
+		return series
+	}
+	tail := s.queryRangeSeries(ctx, s.metricStore, q, q.Query, tailStart, end, step)
+	return mergeSeries(series, tail)
+}
+
+// queryRangeSeries 执行单个范围查询并转换为图表序列
+func (s *MetricService) queryRangeSeries(ctx context.Context, store metricstore.MetricStore, q metric.QueryDefinition, query string, start, end int64, step time.Duration) []metric.Series {
+	result, err := store.QueryRange(ctx, query,
+		time.UnixMilli(start),
+		time.UnixMilli(end),
+		step)
+	if err != nil {
+		s.logger.Error("查询时序存储失败",
+			zap.String("query", query),
+			zap.Error(err))
+		return nil // 跳过失败的查询，继续处理其他查询
+	}
+
+	// 转换查询结果为 MetricSeries
+	return s.convertQueryResultToSeries(result, q.Name, q.Labels)
+}
+
+// mergeSeries 按名称与标签合并两组序列，tail 中的数据点追加在 head 之后
+func mergeSeries(head, tail []metric.Series) []metric.Series {
+	index := make(map[string]int, len(head))
+	for i, item := range head {
+		index[seriesIdentity(item)] = i
+	}
+	for _, item := range tail {
+		if i, ok := index[seriesIdentity(item)]; ok {
+			head[i].Data = append(head[i].Data, item.Data...)
+			continue
+		}
+		index[seriesIdentity(item)] = len(head)
+		head = append(head, item)
+	}
+	return head
+}
+
+// seriesIdentity 生成序列唯一标识（名称 + 排序后的标签）
+func seriesIdentity(item metric.Series) string {
+	keys := make([]string, 0, len(item.Labels))
+	for k := range item.Labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	b.WriteString(item.Name)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(item.Labels[k])
+	}
+	return b.String()
+}
+
 // CleanMonitorCache 清理监控任务缓存中不再关联的探针数据
 func (s *MetricService) CleanMonitorCache(ctx context.Context, monitorID string) error {
 	// 从缓存读取监控数据
@@ -343,11 +687,11 @@ func (s *MetricService) GetLatestMetrics(agentID string) (*metric.LatestMetrics,
 	return metrics, ok
 }
 
-// GetAvailableNetworkInterfaces 获取探针的可用网卡列表（从 VictoriaMetrics 查询）
+// GetAvailableNetworkInterfaces 获取探针的可用网卡列表（从时序存储查询）
 func (s *MetricService) GetAvailableNetworkInterfaces(ctx context.Context, agentID string) ([]string, error) {
 	// 查询 interface label 的所有值，排除空字符串（汇总数据）
 	match := []string{fmt.Sprintf(`pika_network_sent_bytes_rate{agent_id="%s"}`, agentID)}
-	allInterfaces, err := s.vmClient.GetLabelValues(ctx, "interface", match)
+	allInterfaces, err := s.metricStore.GetLabelValues(ctx, "interface", match)
 	if err != nil {
 		s.logger.Error("查询网卡列表失败",
 			zap.String("agentID", agentID),
@@ -455,6 +799,121 @@ func (s *MetricService) buildPromQLQueries(agentID, metricType string, interface
 			Query: fmt.Sprintf(`pika_temperature_celsius{agent_id="%s"}`, agentID),
 		}}
 
+	case "cpu_times":
+		// CPU 时间分布
+		queries = []metric.QueryDefinition{
+			{Name: "user", Query: fmt.Sprintf(`pika_cpu_user_percent{agent_id="%s"}`, agentID)},
+			{Name: "system", Query: fmt.Sprintf(`pika_cpu_system_percent{agent_id="%s"}`, agentID)},
+			{Name: "iowait", Query: fmt.Sprintf(`pika_cpu_iowait_percent{agent_id="%s"}`, agentID)},
+			{Name: "irq", Query: fmt.Sprintf(`pika_cpu_irq_percent{agent_id="%s"}`, agentID)},
+			{Name: "softirq", Query: fmt.Sprintf(`pika_cpu_softirq_percent{agent_id="%s"}`, agentID)},
+			{Name: "steal", Query: fmt.Sprintf(`pika_cpu_steal_percent{agent_id="%s"}`, agentID)},
+		}
+
+	case "pressure":
+		// PSI 压力
+		queries = []metric.QueryDefinition{
+			{Name: "cpu_some", Query: fmt.Sprintf(`pika_pressure_cpu_some_percent{agent_id="%s"}`, agentID)},
+			{Name: "memory_some", Query: fmt.Sprintf(`pika_pressure_memory_some_percent{agent_id="%s"}`, agentID)},
+			{Name: "memory_full", Query: fmt.Sprintf(`pika_pressure_memory_full_percent{agent_id="%s"}`, agentID)},
+			{Name: "io_some", Query: fmt.Sprintf(`pika_pressure_io_some_percent{agent_id="%s"}`, agentID)},
+			{Name: "io_full", Query: fmt.Sprintf(`pika_pressure_io_full_percent{agent_id="%s"}`, agentID)},
+		}
+
+	case "kernel":
+		// 内核统计
+		queries = []metric.QueryDefinition{
+			{Name: "context_switches", Query: fmt.Sprintf(`pika_kernel_context_switches_rate{agent_id="%s"}`, agentID)},
+			{Name: "interrupts", Query: fmt.Sprintf(`pika_kernel_interrupts_rate{agent_id="%s"}`, agentID)},
+			{Name: "file_handles_usage", Query: fmt.Sprintf(`pika_kernel_file_handles_usage_percent{agent_id="%s"}`, agentID)},
+			{Name: "conntrack_usage", Query: fmt.Sprintf(`pika_kernel_conntrack_usage_percent{agent_id="%s"}`, agentID)},
+			{Name: "entropy", Query: fmt.Sprintf(`pika_kernel_entropy_available_bits{agent_id="%s"}`, agentID)},
+			{Name: "oom_kills", Query: fmt.Sprintf(`pika_kernel_oom_kills_total{agent_id="%s"}`, agentID)},
+		}
+
+	case "smart":
+		// 磁盘 SMART：按 device 分组
+		queries = []metric.QueryDefinition{
+			{Name: "healthy", Query: fmt.Sprintf(`pika_smart_healthy{agent_id="%s"}`, agentID)},
+			{Name: "temperature", Query: fmt.Sprintf(`pika_smart_temperature_celsius{agent_id="%s"}`, agentID)},
+			{Name: "reallocated", Query: fmt.Sprintf(`pika_smart_reallocated_sectors{agent_id="%s"}`, agentID)},
+			{Name: "pending", Query: fmt.Sprintf(`pika_smart_pending_sectors{agent_id="%s"}`, agentID)},
+			{Name: "media_errors", Query: fmt.Sprintf(`pika_smart_media_errors{agent_id="%s"}`, agentID)},
+			{Name: "wear", Query: fmt.Sprintf(`pika_smart_wear_percent{agent_id="%s"}`, agentID)},
+		}
+
+	case "raid":
+		// 软 RAID 与 ZFS 存储池：按 array 分组
+		queries = []metric.QueryDefinition{
+			{Name: "degraded", Query: fmt.Sprintf(`pika_raid_degraded{agent_id="%s"}`, agentID)},
+			{Name: "active_devices", Query: fmt.Sprintf(`pika_raid_active_devices{agent_id="%s"}`, agentID)},
+			{Name: "failed_devices", Query: fmt.Sprintf(`pika_raid_failed_devices{agent_id="%s"}`, agentID)},
+			{Name: "sync_progress", Query: fmt.Sprintf(`pika_raid_sync_progress_percent{agent_id="%s"}`, agentID)},
+		}
+
+	case "ecc":
+		// 内存 ECC 错误：按 controller 分组
+		queries = []metric.QueryDefinition{
+			{Name: "corrected", Query: fmt.Sprintf(`pika_edac_corrected_errors_total{agent_id="%s"}`, agentID)},
+			{Name: "uncorrected", Query: fmt.Sprintf(`pika_edac_uncorrected_errors_total{agent_id="%s"}`, agentID)},
+		}
+
+	case "agent_buffer":
+		// 探针离线缓存
+		queries = []metric.QueryDefinition{
+			{Name: "entries", Query: fmt.Sprintf(`pika_agent_buffer_entries{agent_id="%s"}`, agentID)},
+			{Name: "size", Query: fmt.Sprintf(`pika_agent_buffer_size_bytes{agent_id="%s"}`, agentID)},
+			{Name: "dropped", Query: fmt.Sprintf(`pika_agent_buffer_dropped_total{agent_id="%s"}`, agentID)},
+			{Name: "downsampled", Query: fmt.Sprintf(`pika_agent_buffer_downsampled_total{agent_id="%s"}`, agentID)},
+		}
+
+	case "agent_health":
+		// 探针自身运行状态，采集耗时与错误按 collector 分组
+		queries = []metric.QueryDefinition{
+			{Name: "goroutines", Query: fmt.Sprintf(`pika_agent_goroutines{agent_id="%s"}`, agentID)},
+			{Name: "rss", Query: fmt.Sprintf(`pika_agent_rss_bytes{agent_id="%s"}`, agentID)},
+			{Name: "heap", Query: fmt.Sprintf(`pika_agent_heap_bytes{agent_id="%s"}`, agentID)},
+			{Name: "reconnects", Query: fmt.Sprintf(`pika_agent_reconnects_total{agent_id="%s"}`, agentID)},
+			{Name: "collect_duration", Query: fmt.Sprintf(`pika_agent_collect_duration_seconds{agent_id="%s"}`, agentID)},
+			{Name: "collector_errors", Query: fmt.Sprintf(`pika_agent_collector_errors_total{agent_id="%s"}`, agentID)},
+		}
+
+	case "process":
+		// 进程：排名靠前的进程，按 process 分组（同名进程已汇总）
+		queries = []metric.QueryDefinition{
+			{Name: "cpu", Query: fmt.Sprintf(`pika_process_cpu_percent{agent_id="%s"}`, agentID)},
+			{Name: "memory", Query: fmt.Sprintf(`pika_process_memory_rss_bytes{agent_id="%s"}`, agentID)},
+			{Name: "io_read", Query: fmt.Sprintf(`pika_process_io_read_bytes_rate{agent_id="%s"}`, agentID)},
+			{Name: "io_write", Query: fmt.Sprintf(`pika_process_io_write_bytes_rate{agent_id="%s"}`, agentID)},
+			{Name: "fds", Query: fmt.Sprintf(`pika_process_open_fds{agent_id="%s"}`, agentID)},
+		}
+
+	case "process_watch":
+		// 关注进程：按 process 分组
+		queries = []metric.QueryDefinition{
+			{Name: "count", Query: fmt.Sprintf(`pika_process_watch_count{agent_id="%s"}`, agentID)},
+			{Name: "cpu", Query: fmt.Sprintf(`pika_process_watch_cpu_percent{agent_id="%s"}`, agentID)},
+			{Name: "memory", Query: fmt.Sprintf(`pika_process_watch_memory_rss_bytes{agent_id="%s"}`, agentID)},
+			{Name: "threads", Query: fmt.Sprintf(`pika_process_watch_threads{agent_id="%s"}`, agentID)},
+			{Name: "restarts", Query: fmt.Sprintf(`pika_process_watch_restarts_total{agent_id="%s"}`, agentID)},
+		}
+
+	case "systemd":
+		// systemd 单元：按 unit 分组
+		queries = []metric.QueryDefinition{
+			{Name: "active", Query: fmt.Sprintf(`pika_systemd_unit_active{agent_id="%s"}`, agentID)},
+			{Name: "restarts", Query: fmt.Sprintf(`pika_systemd_unit_restarts_total{agent_id="%s"}`, agentID)},
+		}
+
+	case "container":
+		// 容器：按 container 分组
+		queries = []metric.QueryDefinition{
+			{Name: "running", Query: fmt.Sprintf(`pika_container_running{agent_id="%s"}`, agentID)},
+			{Name: "cpu", Query: fmt.Sprintf(`pika_container_cpu_percent{agent_id="%s"}`, agentID)},
+			{Name: "memory", Query: fmt.Sprintf(`pika_container_memory_usage_bytes{agent_id="%s"}`, agentID)},
+			{Name: "restarts", Query: fmt.Sprintf(`pika_container_restarts_total{agent_id="%s"}`, agentID)},
+		}
+
 	case "monitor":
 		// 监控：响应时间（该探针参与的所有监控任务）
 		queries = []metric.QueryDefinition{{
@@ -493,7 +952,7 @@ func wrapAggregationQuery(query, aggregation string, step time.Duration) string
 	}
 }
 
-// convertQueryResultToSeries 将 VictoriaMetrics 查询结果转换为 MetricSeries
+// convertQueryResultToSeries 将时序存储查询结果转换为 MetricSeries
 func (s *MetricService) convertQueryResultToSeries(result *vmclient.QueryResult, seriesName string, extraLabels map[string]string) []metric.Series {
 	if result == nil || len(result.Data.Result) == 0 {
 		return nil
@@ -552,6 +1011,10 @@ func (s *MetricService) convertQueryResultToSeries(result *vmclient.QueryResult,
 
 		// 移除 target 标签（避免数据泄露）
 		delete(labels, "target")
+		// 移除附加的探针元数据标签，保持接口返回结构不变
+		for _, k := range agentMetadataLabels {
+			delete(labels, k)
+		}
 
 		allSeries = append(allSeries, metric.Series{
 			Name:   finalName,
@@ -567,6 +1030,12 @@ func (s *MetricService) convertQueryResultToSeries(result *vmclient.QueryResult,
 func (s *MetricService) buildMonitorPromQLQueries(monitorID string, aggregation string, step time.Duration) []metric.QueryDefinition {
 	var queries = []metric.QueryDefinition{
 		{Name: "response_time", Query: fmt.Sprintf(`pika_monitor_response_time_ms{monitor_id="%s"}`, monitorID)},
+		{Name: "packet_loss", Query: fmt.Sprintf(`pika_monitor_packet_loss_percent{monitor_id="%s"}`, monitorID)},
+		{Name: "rtt_min", Query: fmt.Sprintf(`pika_monitor_rtt_min_ms{monitor_id="%s"}`, monitorID)},
+		{Name: "rtt_avg", Query: fmt.Sprintf(`pika_monitor_rtt_avg_ms{monitor_id="%s"}`, monitorID)},
+		{Name: "rtt_max", Query: fmt.Sprintf(`pika_monitor_rtt_max_ms{monitor_id="%s"}`, monitorID)},
+		{Name: "rtt_stddev", Query: fmt.Sprintf(`pika_monitor_rtt_stddev_ms{monitor_id="%s"}`, monitorID)},
+		{Name: "jitter", Query: fmt.Sprintf(`pika_monitor_jitter_ms{monitor_id="%s"}`, monitorID)},
 	}
 	if aggregation != "" {
 		for i := range queries {
@@ -578,6 +1047,12 @@ func (s *MetricService) buildMonitorPromQLQueries(monitorID string, aggregation
 
 // GetMonitorHistory 获取监控任务的历史趋势数据
 func (s *MetricService) GetMonitorHistory(ctx context.Context, monitorID string, start, end int64, aggregation string) (*metric.GetMetricsResponse, error) {
+	step := vmclient.AutoStep(time.UnixMilli(start), time.UnixMilli(end))
+	return s.getMonitorHistory(ctx, monitorID, start, end, step, aggregation)
+}
+
+// getMonitorHistory 按指定步长获取监控任务的历史时序数据
+func (s *MetricService) getMonitorHistory(ctx context.Context, monitorID string, start, end int64, step time.Duration, aggregation string) (*metric.GetMetricsResponse, error) {
 	// 查询监控任务配置
 	monitorTask, err := s.monitorRepo.FindById(ctx, monitorID)
 	if err != nil {
@@ -585,25 +1060,9 @@ func (s *MetricService) GetMonitorHistory(ctx context.Context, monitorID string,
 		return nil, err
 	}
 
-	step := vmclient.AutoStep(time.UnixMilli(start), time.UnixMilli(end))
 	queries := s.buildMonitorPromQLQueries(monitorID, aggregation, step)
 
-	var series []metric.Series
-	for _, q := range queries {
-		result, err := s.vmClient.QueryRange(
-			ctx,
-			q.Query,
-			time.UnixMilli(start),
-			time.UnixMilli(end),
-			step,
-		)
-		if err != nil {
-			s.logger.Warn("查询历史趋势失败", zap.String("query", q.Name), zap.Error(err))
-			continue
-		}
-		convertedSeries := s.convertQueryResultToSeries(result, q.Name, q.Labels)
-		series = append(series, convertedSeries...)
-	}
+	series := s.querySeries(ctx, queries, start, end, step)
 
 	// 过滤掉已取消关联的 agent 数据（仅在有过滤条件时）
 	agentIdSet := make(map[string]struct{})
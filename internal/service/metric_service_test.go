@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/metricstore"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/vmclient"
+	"github.com/go-orz/cache"
+	"go.uber.org/zap"
+)
+
+// recordingStore 记录每次写入的指标
+type recordingStore struct {
+	metricstore.MetricStore
+	writes [][]vmclient.Metric
+}
+
+func (r *recordingStore) Write(_ context.Context, metrics []vmclient.Metric) error {
+	r.writes = append(r.writes, metrics)
+	return nil
+}
+
+func newTestMetricService(store metricstore.MetricStore) *MetricService {
+	s := &MetricService{
+		logger:           zap.NewNop(),
+		metricStore:      store,
+		remoteWrite:      &RemoteWriteService{},
+		latestCache:      cache.New[string, *metric.LatestMetrics](time.Minute),
+		agentLabelsCache: cache.New[string, map[string]string](time.Minute),
+	}
+	// 预置空标签，避免读取指标配置
+	s.agentLabelsCache.Set("a1", map[string]string{}, time.Hour)
+	return s
+}
+
+func cpuItem(t *testing.T, usage float64, timestamp int64) protocol.InputMetricsPayload {
+	data, err := json.Marshal(protocol.CPUData{UsagePercent: usage})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return protocol.InputMetricsPayload{Type: protocol.MetricTypeCPU, Data: data, Timestamp: timestamp}
+}
+
+func TestHandleMetricBatchStaleItems(t *testing.T) {
+	store := &recordingStore{}
+	s := newTestMetricService(store)
+
+	// 补发的旧数据晚于实时数据到达
+	if err := s.HandleMetricBatch(context.Background(), "a1", []protocol.InputMetricsPayload{cpuItem(t, 50, 2000)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.HandleMetricBatch(context.Background(), "a1", []protocol.InputMetricsPayload{cpuItem(t, 10, 1000)}); err != nil {
+		t.Fatal(err)
+	}
+
+	latest, _ := s.latestCache.Get("a1")
+	if latest.CPU.UsagePercent != 50 {
+		t.Fatalf("stale item overwrote latest cache: %v", latest.CPU.UsagePercent)
+	}
+	if len(store.writes) != 2 {
+		t.Fatalf("expected stale item to be written, got %d writes", len(store.writes))
+	}
+}
+
+func TestHandleMetricBatch(t *testing.T) {
+	store := &recordingStore{}
+	s := newTestMetricService(store)
+
+	items := []protocol.InputMetricsPayload{
+		cpuItem(t, 10, 1000),
+		{Type: protocol.MetricTypeMemory, Data: json.RawMessage(`{"usagePercent":`), Timestamp: 1000},
+		cpuItem(t, 20, 2000),
+	}
+	if err := s.HandleMetricBatch(context.Background(), "a1", items); err != nil {
+		t.Fatal(err)
+	}
+
+	// 解析失败的项被跳过，其余指标一次性写入
+	if len(store.writes) != 1 {
+		t.Fatalf("expected a single write, got %d", len(store.writes))
+	}
+	perItem := len(s.convertToMetrics("a1", string(protocol.MetricTypeCPU), &protocol.CPUData{}, 0))
+	if len(store.writes[0]) != 2*perItem {
+		t.Fatalf("expected %d metrics, got %d", 2*perItem, len(store.writes[0]))
+	}
+	latest, _ := s.latestCache.Get("a1")
+	if latest.CPU.UsagePercent != 20 || latest.Memory != nil {
+		t.Fatalf("unexpected latest metrics: %+v", latest)
+	}
+}
+
+func TestHandleMetricBatchItemLimit(t *testing.T) {
+	store := &recordingStore{}
+	s := newTestMetricService(store)
+
+	items := make([]protocol.InputMetricsPayload, maxMetricsBatchItems+10)
+	for i := range items {
+		items[i] = cpuItem(t, float64(i), int64(i+1))
+	}
+	if err := s.HandleMetricBatch(context.Background(), "a1", items); err != nil {
+		t.Fatal(err)
+	}
+
+	// 超出上限的项被丢弃
+	perItem := len(s.convertToMetrics("a1", string(protocol.MetricTypeCPU), &protocol.CPUData{}, 0))
+	if len(store.writes) != 1 || len(store.writes[0]) != maxMetricsBatchItems*perItem {
+		t.Fatalf("unexpected writes: %d", len(store.writes))
+	}
+	latest, _ := s.latestCache.Get("a1")
+	if latest.CPU.UsagePercent != float64(maxMetricsBatchItems-1) {
+		t.Fatalf("unexpected latest cpu: %v", latest.CPU.UsagePercent)
+	}
+}
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/dushixiang/pika/internal/metric"
@@ -24,6 +28,8 @@ type MonitorService struct {
 	*repo.MonitorRepo
 	*orz.Service
 	agentRepo     *repo.AgentRepo
+	routeRepo     *repo.MonitorRouteSnapshotRepo
+	uptimeService *UptimeService
 	metricService *MetricService
 	wsManager     *ws.Manager
 
@@ -38,12 +44,14 @@ type MonitorScheduler interface {
 	RemoveTask(monitorID string)
 }
 
-func NewMonitorService(logger *zap.Logger, db *gorm.DB, metricService *MetricService, wsManager *ws.Manager) *MonitorService {
+func NewMonitorService(logger *zap.Logger, db *gorm.DB, metricService *MetricService, uptimeService *UptimeService, wsManager *ws.Manager) *MonitorService {
 	return &MonitorService{
 		logger:        logger,
 		Service:       orz.NewService(db),
 		MonitorRepo:   repo.NewMonitorRepo(db),
 		agentRepo:     repo.NewAgentRepo(db),
+		routeRepo:     repo.NewMonitorRouteSnapshotRepo(db),
+		uptimeService: uptimeService,
 		metricService: metricService,
 		wsManager:     wsManager,
 	}
@@ -55,21 +63,108 @@ func (s *MonitorService) SetScheduler(scheduler MonitorScheduler) {
 }
 
 type MonitorTaskRequest struct {
-	Name             string                     `json:"name"`
-	Type             string                     `json:"type"`
-	Target           string                     `json:"target"`
-	Description      string                     `json:"description"`
-	Enabled          bool                       `json:"enabled,omitempty"`
-	ShowTargetPublic bool                       `json:"showTargetPublic,omitempty"` // 在公开页面是否显示目标地址
-	Visibility       string                     `json:"visibility,omitempty"`       // 可见性: public-匿名可见, private-登录可见
-	Interval         int                        `json:"interval"`                   // 检测频率（秒）
-	HTTPConfig       protocol.HTTPMonitorConfig `json:"httpConfig,omitempty"`
-	TCPConfig        protocol.TCPMonitorConfig  `json:"tcpConfig,omitempty"`
-	ICMPConfig       protocol.ICMPMonitorConfig `json:"icmpConfig,omitempty"`
-	AgentIds         []string                   `json:"agentIds,omitempty"`
+	Name             string                           `json:"name"`
+	Type             string                           `json:"type"`
+	Target           string                           `json:"target"`
+	Description      string                           `json:"description"`
+	Enabled          bool                             `json:"enabled,omitempty"`
+	ShowTargetPublic bool                             `json:"showTargetPublic,omitempty"` // 在公开页面是否显示目标地址
+	Visibility       string                           `json:"visibility,omitempty"`       // 可见性: public-匿名可见, private-登录可见
+	Interval         int                              `json:"interval"`                   // 检测频率（秒）
+	HTTPConfig       protocol.HTTPMonitorConfig       `json:"httpConfig,omitempty"`
+	TCPConfig        protocol.TCPMonitorConfig        `json:"tcpConfig,omitempty"`
+	ICMPConfig       protocol.ICMPMonitorConfig       `json:"icmpConfig,omitempty"`
+	UDPConfig        protocol.UDPMonitorConfig        `json:"udpConfig,omitempty"`
+	SMTPConfig       protocol.SMTPMonitorConfig       `json:"smtpConfig,omitempty"`
+	SSHConfig        protocol.SSHMonitorConfig        `json:"sshConfig,omitempty"`
+	RedisConfig      protocol.RedisMonitorConfig      `json:"redisConfig,omitempty"`
+	MySQLConfig      protocol.MySQLMonitorConfig      `json:"mysqlConfig,omitempty"`
+	PostgreSQLConfig protocol.PostgreSQLMonitorConfig `json:"postgresqlConfig,omitempty"`
+	MQTTConfig       protocol.MQTTMonitorConfig       `json:"mqttConfig,omitempty"`
+	TracerouteConfig protocol.TracerouteMonitorConfig `json:"tracerouteConfig,omitempty"`
+	AgentIds         []string                         `json:"agentIds,omitempty"`
+}
+
+// 需要 host:port 形式目标地址的监控类型
+var hostPortMonitorTypes = map[string]struct{}{
+	"tcp": {}, "udp": {}, "smtp": {}, "ssh": {}, "redis": {}, "mysql": {}, "postgresql": {}, "mqtt": {},
+}
+
+// validateMonitorRequest 校验监控任务请求
+func validateMonitorRequest(req *MonitorTaskRequest) error {
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+	target := strings.TrimSpace(req.Target)
+	if strings.TrimSpace(req.Name) == "" {
+		return orz.NewError(400, "监控名称不能为空")
+	}
+	if target == "" {
+		return orz.NewError(400, "监控目标不能为空")
+	}
+
+	switch req.Type {
+	case "http", "https":
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return orz.NewError(400, "HTTP 监控目标必须是合法的 http(s) 地址")
+		}
+	case "icmp", "ping", "traceroute":
+		if strings.ContainsAny(target, "/: ") && net.ParseIP(target) == nil {
+			return orz.NewError(400, fmt.Sprintf("%s 监控目标必须是主机名或 IP 地址", strings.ToUpper(req.Type)))
+		}
+	default:
+		if _, ok := hostPortMonitorTypes[req.Type]; !ok {
+			return orz.NewError(400, fmt.Sprintf("不支持的监控类型: %s", req.Type))
+		}
+		host, port, err := net.SplitHostPort(target)
+		if err != nil || host == "" || port == "" {
+			return orz.NewError(400, fmt.Sprintf("%s 监控目标必须是 host:port 格式", strings.ToUpper(req.Type)))
+		}
+		if p, err := strconv.Atoi(port); err != nil || p <= 0 || p > 65535 {
+			return orz.NewError(400, "端口号必须在 1-65535 之间")
+		}
+	}
+
+	switch req.Type {
+	case "tcp":
+		if req.TCPConfig.Count < 0 || req.TCPConfig.Count > 20 {
+			return orz.NewError(400, "TCP 连接尝试次数必须在 1-20 之间")
+		}
+	case "udp":
+		if req.UDPConfig.PayloadHex {
+			if _, err := hex.DecodeString(strings.ReplaceAll(req.UDPConfig.Payload, " ", "")); err != nil {
+				return orz.NewError(400, "UDP 发送内容不是合法的十六进制字符串")
+			}
+		}
+	case "traceroute":
+		if req.TracerouteConfig.MaxHops < 0 || req.TracerouteConfig.MaxHops > 64 {
+			return orz.NewError(400, "路由追踪最大跳数必须在 1-64 之间")
+		}
+		if req.TracerouteConfig.Count < 0 || req.TracerouteConfig.Count > 10 {
+			return orz.NewError(400, "路由追踪每跳探测次数必须在 1-10 之间")
+		}
+	case "mysql":
+		if req.MySQLConfig.Query != "" && req.MySQLConfig.Username == "" {
+			return orz.NewError(400, "MySQL 执行查询时必须提供用户名")
+		}
+	case "postgresql":
+		if req.PostgreSQLConfig.Query != "" && req.PostgreSQLConfig.Username == "" {
+			return orz.NewError(400, "PostgreSQL 执行查询时必须提供用户名")
+		}
+		switch req.PostgreSQLConfig.SSLMode {
+		case "", "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			return orz.NewError(400, fmt.Sprintf("不支持的 PostgreSQL SSL 模式: %s", req.PostgreSQLConfig.SSLMode))
+		}
+	}
+
+	return nil
 }
 
 func (s *MonitorService) CreateMonitor(ctx context.Context, req *MonitorTaskRequest) (*models.MonitorTask, error) {
+	if err := validateMonitorRequest(req); err != nil {
+		return nil, err
+	}
+
 	// 设置默认检测频率
 	interval := req.Interval
 	if interval <= 0 {
@@ -96,6 +191,14 @@ func (s *MonitorService) CreateMonitor(ctx context.Context, req *MonitorTaskRequ
 		HTTPConfig:       datatypes.NewJSONType(req.HTTPConfig),
 		TCPConfig:        datatypes.NewJSONType(req.TCPConfig),
 		ICMPConfig:       datatypes.NewJSONType(req.ICMPConfig),
+		UDPConfig:        datatypes.NewJSONType(req.UDPConfig),
+		SMTPConfig:       datatypes.NewJSONType(req.SMTPConfig),
+		SSHConfig:        datatypes.NewJSONType(req.SSHConfig),
+		RedisConfig:      datatypes.NewJSONType(req.RedisConfig),
+		MySQLConfig:      datatypes.NewJSONType(req.MySQLConfig),
+		PostgreSQLConfig: datatypes.NewJSONType(req.PostgreSQLConfig),
+		MQTTConfig:       datatypes.NewJSONType(req.MQTTConfig),
+		TracerouteConfig: datatypes.NewJSONType(req.TracerouteConfig),
 		CreatedAt:        0,
 		UpdatedAt:        0,
 	}
@@ -117,6 +220,10 @@ func (s *MonitorService) CreateMonitor(ctx context.Context, req *MonitorTaskRequ
 }
 
 func (s *MonitorService) UpdateMonitor(ctx context.Context, id string, req *MonitorTaskRequest) (*models.MonitorTask, error) {
+	if err := validateMonitorRequest(req); err != nil {
+		return nil, err
+	}
+
 	task, err := s.MonitorRepo.FindById(ctx, id)
 	if err != nil {
 		return nil, err
@@ -145,6 +252,14 @@ func (s *MonitorService) UpdateMonitor(ctx context.Context, id string, req *Moni
 	task.HTTPConfig = datatypes.NewJSONType(req.HTTPConfig)
 	task.TCPConfig = datatypes.NewJSONType(req.TCPConfig)
 	task.ICMPConfig = datatypes.NewJSONType(req.ICMPConfig)
+	task.UDPConfig = datatypes.NewJSONType(req.UDPConfig)
+	task.SMTPConfig = datatypes.NewJSONType(req.SMTPConfig)
+	task.SSHConfig = datatypes.NewJSONType(req.SSHConfig)
+	task.RedisConfig = datatypes.NewJSONType(req.RedisConfig)
+	task.MySQLConfig = datatypes.NewJSONType(req.MySQLConfig)
+	task.PostgreSQLConfig = datatypes.NewJSONType(req.PostgreSQLConfig)
+	task.MQTTConfig = datatypes.NewJSONType(req.MQTTConfig)
+	task.TracerouteConfig = datatypes.NewJSONType(req.TracerouteConfig)
 
 	if err := s.MonitorRepo.Save(ctx, &task); err != nil {
 		return nil, err
@@ -192,6 +307,14 @@ func (s *MonitorService) DeleteMonitor(ctx context.Context, id string) error {
 		if err := s.MonitorRepo.DeleteById(ctx, id); err != nil {
 			return err
 		}
+		// 删除路由追踪快照
+		if err := s.routeRepo.DeleteByMonitorID(ctx, id); err != nil {
+			return err
+		}
+		// 删除故障记录
+		if err := s.uptimeService.IncidentRepo.DeleteByMonitorID(ctx, id); err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -199,6 +322,9 @@ func (s *MonitorService) DeleteMonitor(ctx context.Context, id string) error {
 		return err
 	}
 
+	// 清理进行中的故障状态
+	s.uptimeService.RemoveMonitor(id)
+
 	// 从调度器中移除
 	if s.scheduler != nil {
 		s.scheduler.RemoveTask(id)
@@ -290,28 +416,10 @@ func (s *MonitorService) SendMonitorTaskToAgents(ctx context.Context, monitor mo
 		return nil
 	}
 
-	// 构建监控项
-	item := protocol.MonitorItem{
-		ID:     monitor.ID,
-		Type:   monitor.Type,
-		Target: monitor.Target,
-	}
-
-	if monitor.Type == "http" || monitor.Type == "https" {
-		httpConfig := monitor.HTTPConfig.Data()
-		item.HTTPConfig = &httpConfig
-	} else if monitor.Type == "tcp" {
-		var tcpConfig = monitor.TCPConfig.Data()
-		item.TCPConfig = &tcpConfig
-	} else if monitor.Type == "icmp" || monitor.Type == "ping" {
-		var icmpConfig = monitor.ICMPConfig.Data()
-		item.ICMPConfig = &icmpConfig
-	}
-
 	// 构建 payload
 	payload := protocol.MonitorConfigPayload{
 		Interval: 0,
-		Items:    []protocol.MonitorItem{item},
+		Items:    []protocol.MonitorItem{buildMonitorItem(monitor)},
 	}
 
 	// 向每个目标探针发送
@@ -332,6 +440,53 @@ func (s *MonitorService) SendMonitorTaskToAgents(ctx context.Context, monitor mo
 	return nil
 }
 
+// buildMonitorItem 根据监控类型构建下发给探针的监控项
+func buildMonitorItem(monitor models.MonitorTask) protocol.MonitorItem {
+	item := protocol.MonitorItem{
+		ID:     monitor.ID,
+		Type:   monitor.Type,
+		Target: monitor.Target,
+	}
+
+	switch monitor.Type {
+	case "http", "https":
+		httpConfig := monitor.HTTPConfig.Data()
+		item.HTTPConfig = &httpConfig
+	case "tcp":
+		tcpConfig := monitor.TCPConfig.Data()
+		item.TCPConfig = &tcpConfig
+	case "icmp", "ping":
+		icmpConfig := monitor.ICMPConfig.Data()
+		item.ICMPConfig = &icmpConfig
+	case "udp":
+		udpConfig := monitor.UDPConfig.Data()
+		item.UDPConfig = &udpConfig
+	case "smtp":
+		smtpConfig := monitor.SMTPConfig.Data()
+		item.SMTPConfig = &smtpConfig
+	case "ssh":
+		sshConfig := monitor.SSHConfig.Data()
+		item.SSHConfig = &sshConfig
+	case "redis":
+		redisConfig := monitor.RedisConfig.Data()
+		item.RedisConfig = &redisConfig
+	case "mysql":
+		mysqlConfig := monitor.MySQLConfig.Data()
+		item.MySQLConfig = &mysqlConfig
+	case "postgresql":
+		postgresqlConfig := monitor.PostgreSQLConfig.Data()
+		item.PostgreSQLConfig = &postgresqlConfig
+	case "mqtt":
+		mqttConfig := monitor.MQTTConfig.Data()
+		item.MQTTConfig = &mqttConfig
+	case "traceroute":
+		tracerouteConfig := monitor.TracerouteConfig.Data()
+		item.TracerouteConfig = &tracerouteConfig
+	}
+
+	return item
+}
+
 // GetMonitorStatsByID 获取监控任务的统计数据（聚合后的单个监控详情）
 func (s *MonitorService) GetMonitorStatsByID(ctx context.Context, monitorID string) (*metric.PublicMonitorOverview, error) {
 	// 查询监控任务
@@ -11,6 +11,8 @@ const (
 	NotificationTypeTraffic   = "traffic"
 	NotificationTypeSSHLogin  = "ssh_login"
 	NotificationTypeTamperEvt = "tamper"
+	NotificationTypeRouteChg  = "route_change"
+	NotificationTypeLogMatch  = "log_match"
 )
 
 // NotificationService 统一通知发送入口
@@ -83,6 +85,8 @@ func isNotificationEnabled(config *models.AlertConfig, notificationType string)
 		return config.Notifications.SSHLoginSuccessEnabled
 	case NotificationTypeTamperEvt:
 		return config.Notifications.TamperEventEnabled
+	case NotificationTypeRouteChg:
+		return config.Rules.RouteChangeEnabled
 	default:
 		return true
 	}
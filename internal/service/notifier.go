@@ -105,6 +105,91 @@ var alertTypeMetadataMap = map[string]AlertTypeMetadata{
 		ShowThreshold: false,
 		ShowActual:    false,
 	},
+	"monitor_loss": {
+		Name:          "丢包告警",
+		ThresholdUnit: "%",
+		ValueUnit:     "%",
+		ShowThreshold: true,
+		ShowActual:    true,
+	},
+	"monitor_jitter": {
+		Name:          "抖动告警",
+		ThresholdUnit: "ms",
+		ValueUnit:     "ms",
+		ShowThreshold: true,
+		ShowActual:    true,
+	},
+	"log_match": {
+		Name:          "日志关键字告警",
+		ThresholdUnit: "",
+		ValueUnit:     "行",
+		ShowThreshold: false,
+		ShowActual:    true,
+	},
+	"route_change": {
+		Name:          "路由变化",
+		ThresholdUnit: "",
+		ValueUnit:     "",
+		ShowThreshold: false,
+		ShowActual:    false,
+	},
+	"route_loss": {
+		Name:          "路由丢包告警",
+		ThresholdUnit: "%",
+		ValueUnit:     "%",
+		ShowThreshold: true,
+		ShowActual:    true,
+	},
+	"custom": {
+		Name:          "自定义指标告警",
+		ShowThreshold: true,
+		ShowActual:    true,
+	},
+	"disk_forecast": {
+		Name:          "磁盘容量预测告警",
+		ThresholdUnit: "天",
+		ValueUnit:     "天",
+		ShowThreshold: true,
+		ShowActual:    true,
+	},
+	"anomaly": {
+		Name:          "异常检测告警",
+		ShowThreshold: true,
+		ShowActual:    true,
+	},
+	"unit_failed": {
+		Name:          "systemd 单元失败",
+		ThresholdUnit: "",
+		ValueUnit:     "",
+		ShowThreshold: false,
+		ShowActual:    false,
+	},
+	"container_restart": {
+		Name:          "容器反复重启",
+		ThresholdUnit: "次",
+		ValueUnit:     "次",
+		ShowThreshold: true,
+		ShowActual:    true,
+	},
+	"kernel": {
+		Name:          "内核指标",
+		ShowThreshold: true,
+		ShowActual:    true,
+	},
+	"raid_degraded": {
+		Name:          "RAID 阵列降级",
+		ThresholdUnit: "",
+		ValueUnit:     "",
+		ShowThreshold: false,
+		ShowActual:    false,
+	},
+	"disk_failing": {
+		Name:          "磁盘故障预警",
+		ThresholdUnit: "",
+		ValueUnit:     "",
+		ShowThreshold: false,
+		ShowActual:    false,
+	},
 }
 
 // 告警级别图标映射
@@ -30,6 +30,8 @@ const (
 	PropertyIDAlertConfig = "alert_config"
 	// PropertyIDDNSProviders DNS 服务商配置的固定 ID
 	PropertyIDDNSProviders = "dns_providers"
+	// PropertyIDRollupState 降采样处理进度的固定 ID
+	PropertyIDRollupState = "rollup_state"
 )
 
 var defaultPublicIPv4APIs = []string{
@@ -152,6 +154,15 @@ func (s *PropertyService) GetPublicIPConfig(ctx context.Context) (*models.Public
 	return &config, nil
 }
 
+// GetMetricsConfig 获取指标配置
+func (s *PropertyService) GetMetricsConfig(ctx context.Context) (*models.MetricsConfig, error) {
+	var config models.MetricsConfig
+	if err := s.GetValue(ctx, PropertyIDMetricsConfig, &config); err != nil {
+		return nil, fmt.Errorf("获取指标配置失败: %w", err)
+	}
+	return &config, nil
+}
+
 // GetAlertConfig 获取告警配置
 func (s *PropertyService) GetAlertConfig(ctx context.Context) (*models.AlertConfig, error) {
 	property, err := s.Get(ctx, PropertyIDAlertConfig)
@@ -353,6 +364,11 @@ func (s *PropertyService) InitializeDefaultConfigs(ctx context.Context) error {
 				IPv6APIs:        defaultPublicIPv6APIs,
 			},
 		},
+		{
+			ID:    PropertyIDMetricsConfig,
+			Name:  "指标配置",
+			Value: models.MetricsConfig{},
+		},
 		{
 			ID:    PropertyIDNotificationChannels,
 			Name:  "通知渠道配置",
@@ -369,24 +385,55 @@ func (s *PropertyService) InitializeDefaultConfigs(ctx context.Context) error {
 					TamperEventEnabled:     true,
 				},
 				Rules: models.AlertRules{
-					CPUEnabled:           true,
-					CPUThreshold:         80,
-					CPUDuration:          300, // 5分钟
-					MemoryEnabled:        true,
-					MemoryThreshold:      80,
-					MemoryDuration:       300, // 5分钟
-					DiskEnabled:          true,
-					DiskThreshold:        85,
-					DiskDuration:         300, // 5分钟
-					NetworkEnabled:       false,
-					NetworkThreshold:     100,
-					NetworkDuration:      300, // 5分钟
-					CertEnabled:          true,
-					CertThreshold:        30, // 30天
-					ServiceEnabled:       true,
-					ServiceDuration:      300, // 5分钟
-					AgentOfflineEnabled:  true,
-					AgentOfflineDuration: 300, // 5分钟
+					CPUEnabled:                true,
+					CPUThreshold:              80,
+					CPUDuration:               300, // 5分钟
+					MemoryEnabled:             true,
+					MemoryThreshold:           80,
+					MemoryDuration:            300, // 5分钟
+					DiskEnabled:               true,
+					DiskThreshold:             85,
+					DiskDuration:              300, // 5分钟
+					NetworkEnabled:            false,
+					NetworkThreshold:          100,
+					NetworkDuration:           300, // 5分钟
+					CertEnabled:               true,
+					CertThreshold:             30, // 30天
+					ServiceEnabled:            true,
+					ServiceDuration:           300, // 5分钟
+					AgentOfflineEnabled:       true,
+					AgentOfflineDuration:      300, // 5分钟
+					MonitorLossEnabled:        true,
+					MonitorLossThreshold:      5,
+					MonitorLossDuration:       300, // 5分钟
+					MonitorJitterEnabled:      false,
+					MonitorJitterThreshold:    50,
+					MonitorJitterDuration:     300, // 5分钟
+					RouteChangeEnabled:        true,
+					RouteLossEnabled:          true,
+					RouteLossThreshold:        30,
+					RouteLossDuration:         300, // 5分钟
+					DiskForecastEnabled:       true,
+					DiskForecastDays:          7,
+					DiskForecastWindow:        7,
+					AnomalyEnabled:            false,
+					AnomalySensitivity:        3,
+					AnomalyDuration:           600, // 10分钟
+					UnitFailedEnabled:         true,
+					UnitFailedDuration:        60,
+					ContainerRestartEnabled:   true,
+					ContainerRestartThreshold: 3,
+					ContainerRestartWindow:    600, // 10分钟
+					RaidDegradedEnabled:       true,
+					DiskFailingEnabled:        true,
+					DiskReallocatedThreshold:  10,
+					DiskWearThreshold:         90,
+					KernelRules: []models.KernelAlertRule{
+						{Enabled: true, Metric: "cpu_steal", Threshold: 20, Duration: 300},
+						{Enabled: true, Metric: "oom_kills", Threshold: 1, Duration: 0},
+						{Enabled: true, Metric: "fd_usage", Threshold: 90, Duration: 300},
+						{Enabled: true, Metric: "conntrack_usage", Threshold: 90, Duration: 300},
+					},
 				},
 			},
 		},
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dushixiang/pika/internal/config"
+	"github.com/dushixiang/pika/internal/remotewrite"
+	"github.com/dushixiang/pika/internal/vmclient"
+	"go.uber.org/zap"
+)
+
+// RemoteWriteService 将写入时序存储的指标同时转发到 Prometheus remote-write 目标
+type RemoteWriteService struct {
+	logger *zap.Logger
+	queues []*remotewrite.Queue
+}
+
+func NewRemoteWriteService(logger *zap.Logger, appConfig *config.AppConfig) *RemoteWriteService {
+	s := &RemoteWriteService{
+		logger: logger,
+	}
+
+	for i, cfg := range appConfig.RemoteWrite {
+		if cfg.URL == "" {
+			continue
+		}
+		name := cfg.Name
+		if name == "" {
+			name = fmt.Sprintf("remote-write-%d", i+1)
+		}
+		client := remotewrite.NewClient(remotewrite.Options{
+			Name:        name,
+			URL:         cfg.URL,
+			Username:    cfg.Username,
+			Password:    cfg.Password,
+			BearerToken: cfg.BearerToken,
+			Headers:     cfg.Headers,
+			Timeout:     time.Duration(cfg.Timeout) * time.Second,
+		})
+		s.queues = append(s.queues, remotewrite.NewQueue(logger, client, cfg.BatchSize, cfg.MaxBuffer))
+		logger.Info("已配置 Prometheus remote-write 目标", zap.String("name", name), zap.String("url", cfg.URL))
+	}
+
+	return s
+}
+
+// Enqueue 加入各个目标的发送队列
+func (s *RemoteWriteService) Enqueue(metrics []vmclient.Metric) {
+	for _, q := range s.queues {
+		q.Enqueue(metrics)
+	}
+}
+
+// Run 启动各个目标的发送任务
+func (s *RemoteWriteService) Run(ctx context.Context) {
+	for _, q := range s.queues {
+		go q.Run(ctx)
+	}
+}
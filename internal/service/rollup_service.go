@@ -0,0 +1,371 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/config"
+	"github.com/dushixiang/pika/internal/metricstore"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/vmclient"
+	"go.uber.org/zap"
+)
+
+const (
+	// rollupLag 窗口结束后等待迟到数据的时长
+	rollupLag = time.Minute
+	// rollupMaxBackfill 首次启用时回填的最长时间
+	rollupMaxBackfill = 24 * time.Hour
+	// rollupMaxWindows 每个层级单次最多处理的窗口数
+	rollupMaxWindows = 1440
+	// rollupWriteBatch 单次写入的序列数
+	rollupWriteBatch = 1000
+)
+
+// rollupMetrics 需要降采样的核心指标及聚合函数（图表、流量统计与可用率使用的序列）
+var rollupMetrics = map[string]string{
+	"pika_cpu_usage_percent":           "avg_over_time",
+	"pika_memory_usage_percent":        "avg_over_time",
+	"pika_disk_usage_percent":          "avg_over_time",
+	"pika_network_sent_bytes_rate":     "avg_over_time",
+	"pika_network_recv_bytes_rate":     "avg_over_time",
+	"pika_network_sent_bytes_total":    "max_over_time",
+	"pika_network_recv_bytes_total":    "max_over_time",
+	"pika_network_conn_established":    "avg_over_time",
+	"pika_network_conn_time_wait":      "avg_over_time",
+	"pika_network_conn_close_wait":     "avg_over_time",
+	"pika_network_conn_listen":         "avg_over_time",
+	"pika_disk_read_bytes_rate":        "avg_over_time",
+	"pika_disk_write_bytes_rate":       "avg_over_time",
+	"pika_gpu_utilization_percent":     "avg_over_time",
+	"pika_gpu_temperature_celsius":     "avg_over_time",
+	"pika_temperature_celsius":         "avg_over_time",
+	"pika_monitor_response_time_ms":    "avg_over_time",
+	"pika_monitor_status":              "avg_over_time",
+	"pika_monitor_packet_loss_percent": "avg_over_time",
+}
+
+// rollupMetricPattern 匹配查询语句中的 Pika 指标名称
+var rollupMetricPattern = regexp.MustCompile(`\bpika_[a-zA-Z0-9_]+`)
+
+// RollupTier 降采样层级
+type RollupTier struct {
+	Name       string        // 聚合粒度的 PromQL 表示，如 1m、1h
+	Resolution time.Duration // 聚合粒度
+	Retention  time.Duration // 保留时长
+}
+
+// Suffix 降采样序列的名称后缀，如 pika_cpu_usage_percent:rollup1m
+func (t *RollupTier) Suffix() string {
+	return ":rollup" + t.Name
+}
+
+// RollupService 降采样服务，定期将核心指标按层级聚合后写回时序存储，并为长时间范围的查询选择合适的层级
+type RollupService struct {
+	logger          *zap.Logger
+	propertyService *PropertyService
+	source          metricstore.MetricStore // 原始数据
+	target          metricstore.MetricStore // 降采样数据
+	enabled         bool
+	tiers           []*RollupTier // 按粒度从细到粗排列
+
+	mu    sync.RWMutex
+	state map[string]models.RollupTierState
+}
+
+// NewRollupService 创建降采样服务
+func NewRollupService(logger *zap.Logger, appConfig *config.AppConfig, propertyService *PropertyService, metricStore metricstore.MetricStore) *RollupService {
+	s := &RollupService{
+		logger:          logger,
+		propertyService: propertyService,
+		source:          metricStore,
+		target:          metricStore,
+		enabled:         true,
+		state:           make(map[string]models.RollupTierState),
+	}
+
+	tierConfigs := []config.RollupTierConfig{
+		{Resolution: "1m", RetentionDays: 30},
+		{Resolution: "1h", RetentionDays: 730},
+	}
+	if cfg := appConfig.Rollup; cfg != nil {
+		s.enabled = cfg.Enabled
+		if len(cfg.Tiers) > 0 {
+			tierConfigs = cfg.Tiers
+		}
+		if cfg.VictoriaMetricsURL != "" {
+			var writeTimeout, queryTimeout time.Duration
+			if vm := appConfig.VictoriaMetrics; vm != nil {
+				writeTimeout = time.Duration(vm.WriteTimeout) * time.Second
+				queryTimeout = time.Duration(vm.QueryTimeout) * time.Second
+			}
+			s.target = vmclient.NewVMClient(cfg.VictoriaMetricsURL, writeTimeout, queryTimeout)
+		}
+	}
+	if !s.enabled {
+		return s
+	}
+
+	for _, tierConfig := range tierConfigs {
+		tier, err := parseRollupTier(tierConfig)
+		if err != nil {
+			logger.Warn("忽略无效的降采样层级", zap.String("resolution", tierConfig.Resolution), zap.Error(err))
+			continue
+		}
+		s.tiers = append(s.tiers, tier)
+	}
+	sort.Slice(s.tiers, func(i, j int) bool {
+		return s.tiers[i].Resolution < s.tiers[j].Resolution
+	})
+
+	// 内置存储按层级分别清理过期数据
+	if store, ok := s.target.(metricstore.TieredRetention); ok {
+		for _, tier := range s.tiers {
+			store.SetTierRetention(tier.Suffix(), tier.Retention)
+		}
+	}
+	return s
+}
+
+// parseRollupTier 解析层级配置，粒度至少为 1 分钟且为整分钟
+func parseRollupTier(cfg config.RollupTierConfig) (*RollupTier, error) {
+	resolution, err := parseRollupDuration(cfg.Resolution)
+	if err != nil {
+		return nil, err
+	}
+	if resolution < time.Minute || resolution%time.Minute != 0 {
+		return nil, fmt.Errorf("聚合粒度必须为整分钟且不小于 1 分钟")
+	}
+	if cfg.RetentionDays <= 0 {
+		return nil, fmt.Errorf("保留天数必须大于 0")
+	}
+	return &RollupTier{
+		Name:       formatRollupDuration(resolution),
+		Resolution: resolution,
+		Retention:  time.Duration(cfg.RetentionDays) * 24 * time.Hour,
+	}, nil
+}
+
+// parseRollupDuration 解析时长，额外支持 d 单位
+func parseRollupDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("无效的时长: %s", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// formatRollupDuration 将时长格式化为 PromQL 时长（取能整除的最大单位）
+func formatRollupDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+}
+
+// Run 定期生成降采样数据
+func (s *RollupService) Run(ctx context.Context) {
+	if !s.enabled || len(s.tiers) == 0 {
+		return
+	}
+
+	state := make(map[string]models.RollupTierState)
+	if err := s.propertyService.GetValue(ctx, PropertyIDRollupState, &state); err != nil {
+		s.logger.Debug("降采样进度不存在，将从头开始", zap.Error(err))
+	}
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	s.process(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.process(ctx)
+		}
+	}
+}
+
+// process 处理所有层级中已结束且未处理的窗口
+func (s *RollupService) process(ctx context.Context) {
+	now := time.Now()
+	changed := false
+	for _, tier := range s.tiers {
+		state, ok := s.processTier(ctx, tier, now)
+		if !ok {
+			continue
+		}
+		s.mu.Lock()
+		s.state[tier.Name] = state
+		s.mu.Unlock()
+		changed = true
+	}
+	if !changed {
+		return
+	}
+
+	s.mu.RLock()
+	state := make(map[string]models.RollupTierState, len(s.state))
+	for k, v := range s.state {
+		state[k] = v
+	}
+	s.mu.RUnlock()
+	if err := s.propertyService.Set(ctx, PropertyIDRollupState, "降采样进度", state); err != nil {
+		s.logger.Error("保存降采样进度失败", zap.Error(err))
+	}
+}
+
+// processTier 聚合单个层级的窗口 (from, to]，返回更新后的进度
+func (s *RollupService) processTier(ctx context.Context, tier *RollupTier, now time.Time) (models.RollupTierState, bool) {
+	res := tier.Resolution.Milliseconds()
+	to := now.Add(-rollupLag).UnixMilli() / res * res
+
+	s.mu.RLock()
+	state := s.state[tier.Name]
+	s.mu.RUnlock()
+
+	from := state.Last
+	if earliest := (to - rollupMaxBackfill.Milliseconds()) / res * res; from < earliest {
+		from = earliest
+	}
+	if to <= from {
+		return state, false
+	}
+	if (to-from)/res > rollupMaxWindows {
+		to = from + rollupMaxWindows*res
+	}
+
+	for name, fn := range rollupMetrics {
+		query := fmt.Sprintf(`%s(%s[%s])`, fn, name, tier.Name)
+		result, err := s.source.QueryRange(ctx, query, time.UnixMilli(from+res), time.UnixMilli(to), tier.Resolution)
+		if err != nil {
+			// 本轮不推进进度，下次重试
+			s.logger.Error("查询降采样数据失败", zap.String("query", query), zap.Error(err))
+			return state, false
+		}
+		metrics := queryResultToMetrics(result, name+tier.Suffix())
+		for i := 0; i < len(metrics); i += rollupWriteBatch {
+			if err := s.target.Write(ctx, metrics[i:min(i+rollupWriteBatch, len(metrics))]); err != nil {
+				s.logger.Error("写入降采样数据失败", zap.String("tier", tier.Name), zap.Error(err))
+				return state, false
+			}
+		}
+	}
+
+	if state.First == 0 || state.First > from+res {
+		state.First = from + res
+	}
+	state.Last = to
+	return state, true
+}
+
+// queryResultToMetrics 将范围查询结果转换为多值序列，name 为空时保留原有的 __name__
+func queryResultToMetrics(result *vmclient.QueryResult, name string) []vmclient.Metric {
+	if result == nil {
+		return nil
+	}
+	metrics := make([]vmclient.Metric, 0, len(result.Data.Result))
+	for _, r := range result.Data.Result {
+		labels := make(map[string]string, len(r.Metric)+1)
+		for k, v := range r.Metric {
+			labels[k] = v
+		}
+		if name != "" {
+			labels["__name__"] = name
+		}
+
+		m := vmclient.Metric{Metric: labels}
+		for _, v := range r.Values {
+			if len(v) < 2 {
+				continue
+			}
+			ts, ok := v[0].(float64)
+			if !ok {
+				continue
+			}
+			valueStr, ok := v[1].(string)
+			if !ok {
+				continue
+			}
+			value, err := strconv.ParseFloat(valueStr, 64)
+			if err != nil {
+				continue
+			}
+			m.Timestamps = append(m.Timestamps, int64(ts*1000))
+			m.Values = append(m.Values, value)
+		}
+		if len(m.Values) > 0 {
+			metrics = append(metrics, m)
+		}
+	}
+	return metrics
+}
+
+// SelectTier 为查询范围选择最粗且满足条件的层级：粒度不超过查询步长，且已生成的数据覆盖查询起点，返回 nil 表示使用原始数据
+func (s *RollupService) SelectTier(start int64, step time.Duration) *RollupTier {
+	if !s.enabled {
+		return nil
+	}
+	now := time.Now().UnixMilli()
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.tiers) - 1; i >= 0; i-- {
+		tier := s.tiers[i]
+		if tier.Resolution > step {
+			continue
+		}
+		state := s.state[tier.Name]
+		if state.First == 0 || state.First > start+step.Milliseconds() {
+			continue
+		}
+		if start < now-tier.Retention.Milliseconds()-step.Milliseconds() {
+			continue
+		}
+		return tier
+	}
+	return nil
+}
+
+// TierLast 返回层级已处理到的时间（毫秒）
+func (s *RollupService) TierLast(tier *RollupTier) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.state[tier.Name].Last
+}
+
+// Store 返回降采样数据所在的存储
+func (s *RollupService) Store() metricstore.MetricStore {
+	return s.target
+}
+
+// RewriteQuery 将查询中的指标替换为对应层级的降采样序列，查询包含未降采样的指标时返回 false
+func RewriteQuery(query string, tier *RollupTier) (string, bool) {
+	ok := true
+	rewritten := rollupMetricPattern.ReplaceAllStringFunc(query, func(name string) string {
+		if _, exists := rollupMetrics[name]; !exists {
+			ok = false
+			return name
+		}
+		return name + tier.Suffix()
+	})
+	return rewritten, ok
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/config"
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/models"
+	"go.uber.org/zap"
+)
+
+func TestRewriteQuery(t *testing.T) {
+	tier := &RollupTier{Name: "1h"}
+
+	query, ok := RewriteQuery(`avg_over_time((pika_cpu_usage_percent{agent_id="a1"})[3600s:])`, tier)
+	if !ok || query != `avg_over_time((pika_cpu_usage_percent:rollup1h{agent_id="a1"})[3600s:])` {
+		t.Fatalf("unexpected rewrite: %s %v", query, ok)
+	}
+
+	// 包含未降采样的指标时保持原查询
+	if _, ok := RewriteQuery(`pika_cpu_usage_percent / pika_load1`, tier); ok {
+		t.Fatalf("query with non-rollup metric should not be rewritten")
+	}
+}
+
+func TestSelectTier(t *testing.T) {
+	s := NewRollupService(zap.NewNop(), &config.AppConfig{}, nil, nil)
+	now := time.Now()
+	start := now.Add(-30 * 24 * time.Hour).UnixMilli()
+
+	// 尚未生成降采样数据
+	if tier := s.SelectTier(start, time.Hour); tier != nil {
+		t.Fatalf("expected raw data, got %s", tier.Name)
+	}
+
+	s.state = map[string]models.RollupTierState{
+		"1m": {First: now.Add(-2 * 24 * time.Hour).UnixMilli(), Last: now.UnixMilli()},
+		"1h": {First: start - time.Hour.Milliseconds(), Last: now.UnixMilli()},
+	}
+	if tier := s.SelectTier(start, time.Hour); tier == nil || tier.Name != "1h" {
+		t.Fatalf("expected 1h tier, got %v", tier)
+	}
+	// 1m 层级未覆盖查询起点
+	if tier := s.SelectTier(start, 30*time.Minute); tier != nil {
+		t.Fatalf("expected raw data, got %s", tier.Name)
+	}
+	// 步长小于所有层级粒度
+	if tier := s.SelectTier(now.Add(-time.Hour).UnixMilli(), 10*time.Second); tier != nil {
+		t.Fatalf("expected raw data, got %s", tier.Name)
+	}
+	if tier := s.SelectTier(now.Add(-24*time.Hour).UnixMilli(), 2*time.Minute); tier == nil || tier.Name != "1m" {
+		t.Fatalf("expected 1m tier, got %v", tier)
+	}
+}
+
+func TestMergeSeries(t *testing.T) {
+	head := []metric.Series{{Name: "cpu", Labels: map[string]string{"agent_id": "a1"}, Data: []metric.DataPoint{{Timestamp: 1, Value: 1}}}}
+	tail := []metric.Series{
+		{Name: "cpu", Labels: map[string]string{"agent_id": "a1"}, Data: []metric.DataPoint{{Timestamp: 2, Value: 2}}},
+		{Name: "cpu", Labels: map[string]string{"agent_id": "a2"}, Data: []metric.DataPoint{{Timestamp: 2, Value: 3}}},
+	}
+	merged := mergeSeries(head, tail)
+	if len(merged) != 2 || len(merged[0].Data) != 2 || merged[0].Data[1].Value != 2 {
+		t.Fatalf("unexpected merge result: %+v", merged)
+	}
+}
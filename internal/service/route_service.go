@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/go-orz/cache"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// routeSnapshotInterval 路径未变化时的快照间隔
+	routeSnapshotInterval = time.Hour
+	// routeSnapshotRetention 路径快照保留时长
+	routeSnapshotRetention = 30 * 24 * time.Hour
+)
+
+// RouteService 路由追踪服务：补充 ASN/归属地、保存路径快照并检测路由变化
+type RouteService struct {
+	logger              *zap.Logger
+	SnapshotRepo        *repo.MonitorRouteSnapshotRepo
+	agentRepo           *repo.AgentRepo
+	alertRecordRepo     *repo.AlertRecordRepo
+	geoIPService        *GeoIPService
+	propertyService     *PropertyService
+	notificationService *NotificationService
+
+	lastSnapshots cache.Cache[string, *models.MonitorRouteSnapshot] // key: monitorId:agentId
+}
+
+func NewRouteService(logger *zap.Logger, db *gorm.DB, geoIPService *GeoIPService, propertyService *PropertyService, notificationService *NotificationService) *RouteService {
+	return &RouteService{
+		logger:              logger,
+		SnapshotRepo:        repo.NewMonitorRouteSnapshotRepo(db),
+		agentRepo:           repo.NewAgentRepo(db),
+		alertRecordRepo:     repo.NewAlertRecordRepo(db),
+		geoIPService:        geoIPService,
+		propertyService:     propertyService,
+		notificationService: notificationService,
+		lastSnapshots:       cache.New[string, *models.MonitorRouteSnapshot](time.Hour),
+	}
+}
+
+// HandleTracerouteResult 处理路由追踪结果，补充每跳的 ASN 与归属地并记录路径快照
+func (s *RouteService) HandleTracerouteResult(ctx context.Context, agentID string, data *protocol.MonitorData) {
+	if len(data.Hops) == 0 {
+		return
+	}
+
+	s.enrichHops(data.Hops)
+
+	path, asPath := buildRoutePath(data.Hops)
+	now := time.Now().UnixMilli()
+
+	prev := s.getLastSnapshot(ctx, data.MonitorId, agentID)
+	changed := prev == nil || !sameRoutePath(prev.Path, path)
+	asChanged := prev != nil && prev.ASPath != "" && asPath != "" && prev.ASPath != asPath
+
+	// 路径未变化时按固定间隔保存快照，避免快照表过快增长
+	if !changed && now-prev.CreatedAt < routeSnapshotInterval.Milliseconds() {
+		return
+	}
+
+	snapshot := &models.MonitorRouteSnapshot{
+		MonitorID: data.MonitorId,
+		AgentID:   agentID,
+		Target:    data.Target,
+		Path:      path,
+		ASPath:    asPath,
+		HopCount:  len(data.Hops),
+		Reached:   data.Status == "up",
+		Changed:   changed && prev != nil,
+		ASChanged: asChanged,
+		Hops:      datatypes.NewJSONSlice(data.Hops),
+		CreatedAt: now,
+	}
+	if err := s.SnapshotRepo.Create(ctx, snapshot); err != nil {
+		s.logger.Error("保存路由快照失败",
+			zap.String("monitorId", data.MonitorId),
+			zap.String("agentId", agentID),
+			zap.Error(err))
+		return
+	}
+	s.lastSnapshots.Set(routeSnapshotKey(data.MonitorId, agentID), snapshot, time.Hour)
+
+	if asChanged {
+		s.sendRouteChangeNotification(agentID, data, prev.ASPath, asPath, now)
+	}
+}
+
+// getLastSnapshot 获取上一次的路径快照，优先读取缓存
+func (s *RouteService) getLastSnapshot(ctx context.Context, monitorID, agentID string) *models.MonitorRouteSnapshot {
+	key := routeSnapshotKey(monitorID, agentID)
+	if snapshot, ok := s.lastSnapshots.Get(key); ok {
+		return snapshot
+	}
+
+	snapshot, err := s.SnapshotRepo.FindLatest(ctx, monitorID, agentID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			s.logger.Error("查询路由快照失败", zap.String("monitorId", monitorID), zap.Error(err))
+		}
+		return nil
+	}
+	s.lastSnapshots.Set(key, snapshot, time.Hour)
+	return snapshot
+}
+
+// enrichHops 为每一跳补充 ASN 与归属地
+func (s *RouteService) enrichHops(hops []protocol.TracerouteHop) {
+	if s.geoIPService == nil {
+		return
+	}
+	for i := range hops {
+		if hops[i].IP == "" {
+			continue
+		}
+		hops[i].ASN, hops[i].ASOrg = s.geoIPService.LookupASN(hops[i].IP)
+		hops[i].Location = s.geoIPService.LookupIP(hops[i].IP)
+	}
+}
+
+// sendRouteChangeNotification 记录 AS 路径变化告警并发送通知
+func (s *RouteService) sendRouteChangeNotification(agentID string, data *protocol.MonitorData, oldASPath, newASPath string, now int64) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		alertConfig, err := s.propertyService.GetAlertConfig(ctx)
+		if err != nil {
+			s.logger.Error("获取全局告警配置失败", zap.Error(err))
+			return
+		}
+		if !alertConfig.Enabled || !alertConfig.Rules.RouteChangeEnabled {
+			return
+		}
+
+		agent, err := s.agentRepo.FindById(ctx, agentID)
+		if err != nil {
+			s.logger.Error("获取探针信息失败", zap.String("agentId", agentID), zap.Error(err))
+			return
+		}
+
+		record := &models.AlertRecord{
+			AgentID:     agentID,
+			AgentName:   agent.Name,
+			AlertType:   "route_change",
+			Message:     fmt.Sprintf("监控项 %s 的 AS 路径发生变化：%s → %s", data.Target, oldASPath, newASPath),
+			Threshold:   0,
+			ActualValue: 0,
+			Level:       "warning",
+			Status:      "notice",
+			FiredAt:     now,
+			CreatedAt:   now,
+		}
+		if err := s.alertRecordRepo.CreateAlertRecord(ctx, record); err != nil {
+			s.logger.Error("创建路由变化告警记录失败", zap.String("monitorId", data.MonitorId), zap.Error(err))
+			return
+		}
+
+		if err := s.notificationService.SendAlertNotification(ctx, NotificationTypeRouteChg, record, &agent); err != nil {
+			s.logger.Error("发送路由变化通知失败",
+				zap.String("agentId", agentID),
+				zap.String("monitorId", data.MonitorId),
+				zap.Error(err),
+			)
+		}
+	}()
+}
+
+// Run 定时清理过期的路径快照
+func (s *RouteService) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			before := time.Now().Add(-routeSnapshotRetention).UnixMilli()
+			if err := s.SnapshotRepo.DeleteBefore(ctx, before); err != nil {
+				s.logger.Error("清理路由快照失败", zap.Error(err))
+			}
+		}
+	}
+}
+
+func routeSnapshotKey(monitorID, agentID string) string {
+	return monitorID + ":" + agentID
+}
+
+// buildRoutePath 生成逐跳 IP 路径与 AS 路径
+func buildRoutePath(hops []protocol.TracerouteHop) (string, string) {
+	ips := make([]string, 0, len(hops))
+	var asns []string
+	for _, hop := range hops {
+		if hop.IP == "" {
+			ips = append(ips, "*")
+		} else {
+			ips = append(ips, hop.IP)
+		}
+
+		// 合并相邻重复的 AS，忽略没有 ASN 的跳（私有地址、无响应）
+		if hop.ASN == 0 {
+			continue
+		}
+		asn := fmt.Sprintf("AS%d", hop.ASN)
+		if len(asns) == 0 || asns[len(asns)-1] != asn {
+			asns = append(asns, asn)
+		}
+	}
+	return strings.Join(ips, ">"), strings.Join(asns, ">")
+}
+
+// sameRoutePath 比较两条 IP 路径，无响应的跳（*）视为匹配任意 IP
+func sameRoutePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	hopsA := strings.Split(a, ">")
+	hopsB := strings.Split(b, ">")
+	if len(hopsA) != len(hopsB) {
+		return false
+	}
+	for i := range hopsA {
+		if hopsA[i] == "*" || hopsB[i] == "*" {
+			continue
+		}
+		if hopsA[i] != hopsB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// lastHopLoss 返回最后一个有响应的跳（通常是目标）的丢包率
+// 中间路由器常对 ICMP 限速，其丢包不代表链路丢包，只有延续到后续跳的丢包才有意义，因此以最后一跳为准
+func lastHopLoss(hops []protocol.TracerouteHop) (float64, int) {
+	for i := len(hops) - 1; i >= 0; i-- {
+		// 全部丢包的跳通常是不回应 ICMP 的路由器，不计入
+		if hops[i].Recv == 0 {
+			continue
+		}
+		return hops[i].Loss, hops[i].TTL
+	}
+	return 0, 0
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func TestBuildRoutePath(t *testing.T) {
+	hops := []protocol.TracerouteHop{
+		{TTL: 1, IP: "192.168.1.1"},
+		{TTL: 2},
+		{TTL: 3, IP: "202.97.1.1", ASN: 4134},
+		{TTL: 4, IP: "202.97.2.2", ASN: 4134},
+		{TTL: 5, IP: "59.43.1.1", ASN: 4809},
+	}
+
+	path, asPath := buildRoutePath(hops)
+	if path != "192.168.1.1>*>202.97.1.1>202.97.2.2>59.43.1.1" {
+		t.Fatalf("unexpected path: %s", path)
+	}
+	if asPath != "AS4134>AS4809" {
+		t.Fatalf("unexpected AS path: %s", asPath)
+	}
+}
+
+func TestSameRoutePath(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want bool
+	}{
+		{"10.0.0.1>10.0.0.2", "10.0.0.1>10.0.0.2", true},
+		{"10.0.0.1>*>10.0.0.3", "10.0.0.1>10.0.0.2>10.0.0.3", true},
+		{"10.0.0.1>10.0.0.2", "10.0.0.1>10.0.0.9", false},
+		{"10.0.0.1>10.0.0.2", "10.0.0.1>10.0.0.2>10.0.0.3", false},
+	}
+	for _, tc := range cases {
+		if got := sameRoutePath(tc.a, tc.b); got != tc.want {
+			t.Errorf("sameRoutePath(%q, %q) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestLastHopLoss(t *testing.T) {
+	hops := []protocol.TracerouteHop{
+		{TTL: 1, Sent: 3, Recv: 3},
+		{TTL: 2, Sent: 3, Recv: 0, Loss: 100},   // 不回应 ICMP 的路由器
+		{TTL: 3, Sent: 3, Recv: 1, Loss: 66.67}, // 对 ICMP 限速的中间路由器
+		{TTL: 4, Sent: 3, Recv: 3},
+		{TTL: 5, Sent: 3, Recv: 0, Loss: 100},
+	}
+
+	loss, ttl := lastHopLoss(hops)
+	if loss != 0 || ttl != 4 {
+		t.Fatalf("unexpected last hop loss: %.2f at hop %d", loss, ttl)
+	}
+
+	hops[3].Recv, hops[3].Loss = 2, 33.33
+	if loss, ttl = lastHopLoss(hops); loss != 33.33 || ttl != 4 {
+		t.Fatalf("unexpected last hop loss: %.2f at hop %d", loss, ttl)
+	}
+}
@@ -0,0 +1,972 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/config"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/go-orz/cache"
+	"github.com/go-orz/orz"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	// statusPageCacheTTL 公开状态页数据缓存时长
+	statusPageCacheTTL = 30 * time.Second
+	// statusPageIncidentHistory 已结束的公告在状态页上的展示时长
+	statusPageIncidentHistory = 14 * 24 * time.Hour
+	// maxStatusPageSubscribers 单个状态页的订阅者上限
+	maxStatusPageSubscribers = 10000
+)
+
+// 组件状态，按严重程度递增
+const (
+	ComponentStatusUnknown     = "unknown"
+	ComponentStatusOperational = "operational"
+	ComponentStatusMaintenance = "maintenance"
+	ComponentStatusDegraded    = "degraded"
+	ComponentStatusOutage      = "outage"
+)
+
+var componentStatusSeverity = map[string]int{
+	ComponentStatusUnknown:     0,
+	ComponentStatusOperational: 1,
+	ComponentStatusMaintenance: 2,
+	ComponentStatusDegraded:    3,
+	ComponentStatusOutage:      4,
+}
+
+var (
+	statusPageSlugRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+	incidentStatuses    = []string{"investigating", "identified", "monitoring", "resolved"}
+	maintenanceStatuses = []string{"scheduled", "in_progress", "completed"}
+	incidentImpacts     = []string{"none", "minor", "major", "critical"}
+)
+
+// StatusPageRequest 创建/更新状态页请求
+type StatusPageRequest struct {
+	Slug        string                   `json:"slug"`
+	Title       string                   `json:"title"`
+	Description string                   `json:"description"`
+	LogoBase64  string                   `json:"logoBase64"`
+	Enabled     bool                     `json:"enabled"`
+	Groups      []models.StatusPageGroup `json:"groups"`
+}
+
+// StatusPageIncidentRequest 创建故障或维护公告请求
+type StatusPageIncidentRequest struct {
+	Kind           string   `json:"kind"`
+	Title          string   `json:"title"`
+	Status         string   `json:"status"`
+	Impact         string   `json:"impact"`
+	ComponentIDs   []string `json:"componentIds"`
+	ScheduledStart int64    `json:"scheduledStart"`
+	ScheduledEnd   int64    `json:"scheduledEnd"`
+	Message        string   `json:"message"`
+}
+
+// StatusPageIncidentUpdateRequest 发布公告进展请求
+type StatusPageIncidentUpdateRequest struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// StatusPageSubscribeRequest 订阅状态页请求
+type StatusPageSubscribeRequest struct {
+	Type   string `json:"type"`   // email, webhook
+	Target string `json:"target"` // 邮箱地址或 Webhook URL
+}
+
+// PublicStatusPage 公开状态页数据
+type PublicStatusPage struct {
+	Slug         string                      `json:"slug"`
+	Title        string                      `json:"title"`
+	Description  string                      `json:"description"`
+	LogoBase64   string                      `json:"logoBase64"`
+	ICPCode      string                      `json:"icpCode"`
+	Status       string                      `json:"status"` // 整体状态，取所有组件中最严重的状态
+	Groups       []PublicStatusGroup         `json:"groups"`
+	Incidents    []models.StatusPageIncident `json:"incidents"`    // 进行中及近期结束的故障
+	Maintenances []models.StatusPageIncident `json:"maintenances"` // 未结束及近期完成的计划维护
+	UpdatedAt    int64                       `json:"updatedAt"`
+}
+
+// PublicStatusGroup 公开状态页的组件分组
+type PublicStatusGroup struct {
+	Name       string                  `json:"name"`
+	Components []PublicStatusComponent `json:"components"`
+}
+
+// PublicStatusComponent 公开状态页的组件，不包含监控目标、探针 IP 等内部信息
+type PublicStatusComponent struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Status      string   `json:"status"`
+	Uptime      *float64 `json:"uptime,omitempty"` // 近 30 天可用率(%)，仅监控任务组件
+}
+
+// StatusPageService 公开状态页服务
+type StatusPageService struct {
+	logger *zap.Logger
+	*orz.Service
+	PageRepo       *repo.StatusPageRepo
+	IncidentRepo   *repo.StatusPageIncidentRepo
+	updateRepo     *repo.StatusPageIncidentUpdateRepo
+	SubscriberRepo *repo.StatusPageSubscriberRepo
+	agentRepo      *repo.AgentRepo
+	monitorRepo    *repo.MonitorRepo
+
+	metricService   *MetricService
+	uptimeService   *UptimeService
+	propertyService *PropertyService
+	notifier        *Notifier
+	externalURL     string // 服务对外访问地址，用于生成确认与退订链接
+
+	pageCache cache.Cache[string, *PublicStatusPage] // key: slug
+}
+
+func NewStatusPageService(logger *zap.Logger, db *gorm.DB, appConfig *config.AppConfig, metricService *MetricService, uptimeService *UptimeService, propertyService *PropertyService, notifier *Notifier) *StatusPageService {
+	return &StatusPageService{
+		logger:          logger,
+		Service:         orz.NewService(db),
+		PageRepo:        repo.NewStatusPageRepo(db),
+		IncidentRepo:    repo.NewStatusPageIncidentRepo(db),
+		updateRepo:      repo.NewStatusPageIncidentUpdateRepo(db),
+		SubscriberRepo:  repo.NewStatusPageSubscriberRepo(db),
+		agentRepo:       repo.NewAgentRepo(db),
+		monitorRepo:     repo.NewMonitorRepo(db),
+		metricService:   metricService,
+		uptimeService:   uptimeService,
+		propertyService: propertyService,
+		notifier:        notifier,
+		externalURL:     strings.TrimRight(appConfig.ExternalURL, "/"),
+		pageCache:       cache.New[string, *PublicStatusPage](time.Minute),
+	}
+}
+
+// CreatePage 创建状态页
+func (s *StatusPageService) CreatePage(ctx context.Context, req *StatusPageRequest) (*models.StatusPage, error) {
+	if err := s.validatePage(ctx, "", req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	page := &models.StatusPage{
+		ID:          uuid.NewString(),
+		Slug:        req.Slug,
+		Title:       req.Title,
+		Description: req.Description,
+		LogoBase64:  req.LogoBase64,
+		Enabled:     req.Enabled,
+		Groups:      datatypes.NewJSONSlice(req.Groups),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.PageRepo.Create(ctx, page); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// UpdatePage 更新状态页
+func (s *StatusPageService) UpdatePage(ctx context.Context, id string, req *StatusPageRequest) (*models.StatusPage, error) {
+	page, err := s.PageRepo.FindById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validatePage(ctx, id, req); err != nil {
+		return nil, err
+	}
+
+	page.Slug = req.Slug
+	page.Title = req.Title
+	page.Description = req.Description
+	page.LogoBase64 = req.LogoBase64
+	page.Enabled = req.Enabled
+	page.Groups = datatypes.NewJSONSlice(req.Groups)
+	if err := s.PageRepo.Save(ctx, &page); err != nil {
+		return nil, err
+	}
+	s.pageCache.Reset()
+	return &page, nil
+}
+
+// DeletePage 删除状态页及其公告、订阅者
+func (s *StatusPageService) DeletePage(ctx context.Context, id string) error {
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.updateRepo.DeleteByPageID(ctx, id); err != nil {
+			return err
+		}
+		if err := s.IncidentRepo.DeleteByPageID(ctx, id); err != nil {
+			return err
+		}
+		if err := s.SubscriberRepo.DeleteByPageID(ctx, id); err != nil {
+			return err
+		}
+		return s.PageRepo.DeleteById(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	s.pageCache.Reset()
+	return nil
+}
+
+// validatePage 校验状态页配置，并为未设置 ID 的组件生成 ID
+func (s *StatusPageService) validatePage(ctx context.Context, id string, req *StatusPageRequest) error {
+	req.Slug = strings.ToLower(strings.TrimSpace(req.Slug))
+	if !statusPageSlugRegexp.MatchString(req.Slug) {
+		return orz.NewError(400, "访问路径只能包含小写字母、数字和短横线，且不超过 63 个字符")
+	}
+	exists, err := s.PageRepo.ExistsBySlug(ctx, req.Slug, id)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return orz.NewError(400, "访问路径已被其他状态页使用")
+	}
+
+	componentIDs := make(map[string]struct{})
+	for i := range req.Groups {
+		group := &req.Groups[i]
+		if strings.TrimSpace(group.Name) == "" {
+			return orz.NewError(400, "分组名称不能为空")
+		}
+		for j := range group.Components {
+			component := &group.Components[j]
+			if strings.TrimSpace(component.Name) == "" {
+				return orz.NewError(400, "组件名称不能为空")
+			}
+			if component.ID == "" {
+				component.ID = uuid.NewString()
+			}
+			if _, ok := componentIDs[component.ID]; ok {
+				return orz.NewError(400, "组件ID重复")
+			}
+			componentIDs[component.ID] = struct{}{}
+
+			switch component.Type {
+			case "monitor":
+				if _, ok, err := s.monitorRepo.FindByIdExists(ctx, component.TargetID); err != nil {
+					return err
+				} else if !ok {
+					return orz.NewError(400, fmt.Sprintf("组件 %s 关联的监控任务不存在", component.Name))
+				}
+			case "agent":
+				if _, ok, err := s.agentRepo.FindByIdExists(ctx, component.TargetID); err != nil {
+					return err
+				} else if !ok {
+					return orz.NewError(400, fmt.Sprintf("组件 %s 关联的探针不存在", component.Name))
+				}
+			default:
+				return orz.NewError(400, "组件类型只能是 monitor 或 agent")
+			}
+		}
+	}
+	return nil
+}
+
+// GetPublicPage 获取公开状态页数据，仅返回组件的聚合状态，不暴露监控目标与探针信息
+func (s *StatusPageService) GetPublicPage(ctx context.Context, slug string) (*PublicStatusPage, error) {
+	if cached, ok := s.pageCache.Get(slug); ok {
+		return cached, nil
+	}
+
+	page, err := s.PageRepo.FindBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, orz.NewError(404, "状态页不存在")
+		}
+		return nil, err
+	}
+	if !page.Enabled {
+		return nil, orz.NewError(404, "状态页不存在")
+	}
+
+	now := time.Now()
+	result := &PublicStatusPage{
+		Slug:         page.Slug,
+		Title:        page.Title,
+		Description:  page.Description,
+		LogoBase64:   page.LogoBase64,
+		Status:       ComponentStatusUnknown,
+		Groups:       []PublicStatusGroup{},
+		Incidents:    []models.StatusPageIncident{},
+		Maintenances: []models.StatusPageIncident{},
+		UpdatedAt:    now.UnixMilli(),
+	}
+
+	// 品牌信息复用系统配置
+	systemConfig, err := s.propertyService.GetSystemConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if result.Title == "" {
+		result.Title = systemConfig.SystemNameZh
+	}
+	if result.LogoBase64 == "" {
+		result.LogoBase64 = systemConfig.LogoBase64
+	}
+	result.ICPCode = systemConfig.ICPCode
+
+	incidents, err := s.loadIncidents(ctx, page.ID, now.Add(-statusPageIncidentHistory).UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	for _, incident := range incidents {
+		if incident.Kind == "maintenance" {
+			result.Maintenances = append(result.Maintenances, incident)
+		} else {
+			result.Incidents = append(result.Incidents, incident)
+		}
+	}
+
+	for _, group := range page.Groups {
+		publicGroup := PublicStatusGroup{Name: group.Name, Components: []PublicStatusComponent{}}
+		for _, component := range group.Components {
+			publicComponent := s.buildPublicComponent(ctx, component)
+			publicComponent.Status = applyIncidentStatus(publicComponent.Status, component.ID, incidents, now.UnixMilli())
+			publicGroup.Components = append(publicGroup.Components, publicComponent)
+
+			if componentStatusSeverity[publicComponent.Status] > componentStatusSeverity[result.Status] {
+				result.Status = publicComponent.Status
+			}
+		}
+		result.Groups = append(result.Groups, publicGroup)
+	}
+
+	s.pageCache.Set(slug, result, statusPageCacheTTL)
+	return result, nil
+}
+
+// buildPublicComponent 根据关联的监控任务或探针计算组件状态
+func (s *StatusPageService) buildPublicComponent(ctx context.Context, component models.StatusPageComponent) PublicStatusComponent {
+	publicComponent := PublicStatusComponent{
+		ID:          component.ID,
+		Name:        component.Name,
+		Description: component.Description,
+		Status:      ComponentStatusUnknown,
+	}
+
+	switch component.Type {
+	case "monitor":
+		monitor, err := s.monitorRepo.FindById(ctx, component.TargetID)
+		if err != nil || !monitor.Enabled {
+			return publicComponent
+		}
+		stats := s.metricService.GetMonitorStats(monitor.ID)
+		switch {
+		case stats.Status == "up" && stats.AgentStats.Down > 0:
+			publicComponent.Status = ComponentStatusDegraded
+		case stats.Status == "up":
+			publicComponent.Status = ComponentStatusOperational
+		case stats.Status == "down" && stats.AgentStats.Up > 0:
+			publicComponent.Status = ComponentStatusDegraded
+		case stats.Status == "down":
+			publicComponent.Status = ComponentStatusOutage
+		}
+
+		uptime, err := s.uptimeService.GetPeriodUptime(ctx, &monitor, "30d")
+		if err != nil {
+			s.logger.Debug("查询组件可用率失败", zap.String("monitorId", monitor.ID), zap.Error(err))
+		} else {
+			publicComponent.Uptime = uptime.Uptime
+		}
+	case "agent":
+		agent, err := s.agentRepo.FindById(ctx, component.TargetID)
+		if err != nil {
+			return publicComponent
+		}
+		if agent.Status == 1 {
+			publicComponent.Status = ComponentStatusOperational
+		} else {
+			publicComponent.Status = ComponentStatusOutage
+		}
+	}
+	return publicComponent
+}
+
+// applyIncidentStatus 根据进行中的故障与维护公告调整组件状态
+func applyIncidentStatus(status, componentID string, incidents []models.StatusPageIncident, now int64) string {
+	for _, incident := range incidents {
+		if incident.ResolvedAt > 0 || !slices.Contains(incident.ComponentIDs, componentID) {
+			continue
+		}
+
+		if incident.Kind == "maintenance" {
+			inWindow := incident.ScheduledStart <= now && (incident.ScheduledEnd == 0 || now <= incident.ScheduledEnd)
+			if incident.Status == "in_progress" || (incident.Status == "scheduled" && inWindow) {
+				// 维护期间的异常属于预期内，统一展示为维护中
+				status = ComponentStatusMaintenance
+			}
+			continue
+		}
+
+		var incidentStatus string
+		switch incident.Impact {
+		case "minor":
+			incidentStatus = ComponentStatusDegraded
+		case "major", "critical":
+			incidentStatus = ComponentStatusOutage
+		default:
+			continue
+		}
+		if status != ComponentStatusMaintenance && componentStatusSeverity[incidentStatus] > componentStatusSeverity[status] {
+			status = incidentStatus
+		}
+	}
+	return status
+}
+
+// loadIncidents 获取状态页需要展示的公告及其进展更新
+func (s *StatusPageService) loadIncidents(ctx context.Context, pageID string, resolvedAfter int64) ([]models.StatusPageIncident, error) {
+	incidents, err := s.IncidentRepo.FindVisible(ctx, pageID, resolvedAfter)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachUpdates(ctx, incidents); err != nil {
+		return nil, err
+	}
+	return incidents, nil
+}
+
+// attachUpdates 为公告填充进展更新
+func (s *StatusPageService) attachUpdates(ctx context.Context, incidents []models.StatusPageIncident) error {
+	ids := make([]string, 0, len(incidents))
+	for _, incident := range incidents {
+		ids = append(ids, incident.ID)
+	}
+	updates, err := s.updateRepo.FindByIncidentIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	byIncident := make(map[string][]models.StatusPageIncidentUpdate)
+	for _, update := range updates {
+		byIncident[update.IncidentID] = append(byIncident[update.IncidentID], update)
+	}
+	for i := range incidents {
+		incidents[i].Updates = byIncident[incidents[i].ID]
+		if incidents[i].Updates == nil {
+			incidents[i].Updates = []models.StatusPageIncidentUpdate{}
+		}
+	}
+	return nil
+}
+
+// GetIncident 获取公告详情（包含进展更新）
+func (s *StatusPageService) GetIncident(ctx context.Context, pageID, id string) (*models.StatusPageIncident, error) {
+	incident, err := s.findIncident(ctx, pageID, id)
+	if err != nil {
+		return nil, err
+	}
+	incidents := []models.StatusPageIncident{*incident}
+	if err := s.attachUpdates(ctx, incidents); err != nil {
+		return nil, err
+	}
+	return &incidents[0], nil
+}
+
+// findIncident 获取状态页下的公告，公告不属于该状态页时返回不存在
+func (s *StatusPageService) findIncident(ctx context.Context, pageID, id string) (*models.StatusPageIncident, error) {
+	incident, err := s.IncidentRepo.FindByPageAndID(ctx, pageID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, orz.NewError(404, "公告不存在")
+		}
+		return nil, err
+	}
+	return incident, nil
+}
+
+// CreateIncident 发布故障或计划维护公告，并通知订阅者
+func (s *StatusPageService) CreateIncident(ctx context.Context, pageID string, req *StatusPageIncidentRequest) (*models.StatusPageIncident, error) {
+	page, err := s.PageRepo.FindById(ctx, pageID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateIncidentRequest(&page, req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixMilli()
+	incident := &models.StatusPageIncident{
+		ID:             uuid.NewString(),
+		PageID:         pageID,
+		Kind:           req.Kind,
+		Title:          req.Title,
+		Status:         req.Status,
+		Impact:         req.Impact,
+		ComponentIDs:   datatypes.NewJSONSlice(req.ComponentIDs),
+		ScheduledStart: req.ScheduledStart,
+		ScheduledEnd:   req.ScheduledEnd,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if isIncidentClosedStatus(req.Status) {
+		incident.ResolvedAt = now
+	}
+	update := models.StatusPageIncidentUpdate{
+		ID:         uuid.NewString(),
+		IncidentID: incident.ID,
+		Status:     req.Status,
+		Message:    req.Message,
+		CreatedAt:  now,
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.IncidentRepo.Create(ctx, incident); err != nil {
+			return err
+		}
+		return s.updateRepo.Create(ctx, &update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	incident.Updates = []models.StatusPageIncidentUpdate{update}
+
+	s.pageCache.Reset()
+	s.notifySubscribers(&page, incident, &update, true)
+	return incident, nil
+}
+
+// AddIncidentUpdate 发布公告进展，同步更新公告状态并通知订阅者
+func (s *StatusPageService) AddIncidentUpdate(ctx context.Context, pageID, incidentID string, req *StatusPageIncidentUpdateRequest) (*models.StatusPageIncident, error) {
+	incident, err := s.findIncident(ctx, pageID, incidentID)
+	if err != nil {
+		return nil, err
+	}
+	page, err := s.PageRepo.FindById(ctx, incident.PageID)
+	if err != nil {
+		return nil, err
+	}
+	if !isValidIncidentStatus(incident.Kind, req.Status) {
+		return nil, orz.NewError(400, "无效的公告状态")
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		return nil, orz.NewError(400, "更新内容不能为空")
+	}
+
+	now := time.Now().UnixMilli()
+	update := models.StatusPageIncidentUpdate{
+		ID:         uuid.NewString(),
+		IncidentID: incident.ID,
+		Status:     req.Status,
+		Message:    req.Message,
+		CreatedAt:  now,
+	}
+
+	incident.Status = req.Status
+	if isIncidentClosedStatus(req.Status) {
+		if incident.ResolvedAt == 0 {
+			incident.ResolvedAt = now
+		}
+	} else {
+		incident.ResolvedAt = 0
+	}
+
+	err = s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.updateRepo.Create(ctx, &update); err != nil {
+			return err
+		}
+		return s.IncidentRepo.UpdateColumnsById(ctx, incident.ID, map[string]interface{}{
+			"status":      incident.Status,
+			"resolved_at": incident.ResolvedAt,
+			"updated_at":  now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.pageCache.Reset()
+	s.notifySubscribers(&page, incident, &update, false)
+	return s.GetIncident(ctx, pageID, incident.ID)
+}
+
+// DeleteIncident 删除公告及其进展更新
+func (s *StatusPageService) DeleteIncident(ctx context.Context, pageID, id string) error {
+	if _, err := s.findIncident(ctx, pageID, id); err != nil {
+		return err
+	}
+	err := s.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.updateRepo.DeleteByIncidentID(ctx, id); err != nil {
+			return err
+		}
+		return s.IncidentRepo.DeleteById(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	s.pageCache.Reset()
+	return nil
+}
+
+// validateIncidentRequest 校验公告请求
+func validateIncidentRequest(page *models.StatusPage, req *StatusPageIncidentRequest) error {
+	if req.Kind != "incident" && req.Kind != "maintenance" {
+		return orz.NewError(400, "公告类型只能是 incident 或 maintenance")
+	}
+	if strings.TrimSpace(req.Title) == "" {
+		return orz.NewError(400, "标题不能为空")
+	}
+	if strings.TrimSpace(req.Message) == "" {
+		return orz.NewError(400, "公告内容不能为空")
+	}
+	if req.Status == "" {
+		req.Status = "investigating"
+		if req.Kind == "maintenance" {
+			req.Status = "scheduled"
+		}
+	}
+	if !isValidIncidentStatus(req.Kind, req.Status) {
+		return orz.NewError(400, "无效的公告状态")
+	}
+	if req.Impact == "" {
+		req.Impact = "none"
+	}
+	if !slices.Contains(incidentImpacts, req.Impact) {
+		return orz.NewError(400, "影响程度只能是 none、minor、major 或 critical")
+	}
+	if req.Kind == "maintenance" {
+		if req.ScheduledStart <= 0 {
+			return orz.NewError(400, "计划维护必须设置开始时间")
+		}
+		if req.ScheduledEnd > 0 && req.ScheduledEnd <= req.ScheduledStart {
+			return orz.NewError(400, "维护结束时间必须晚于开始时间")
+		}
+	}
+
+	componentIDs := make(map[string]struct{})
+	for _, group := range page.Groups {
+		for _, component := range group.Components {
+			componentIDs[component.ID] = struct{}{}
+		}
+	}
+	for _, id := range req.ComponentIDs {
+		if _, ok := componentIDs[id]; !ok {
+			return orz.NewError(400, "受影响的组件不存在")
+		}
+	}
+	return nil
+}
+
+func isValidIncidentStatus(kind, status string) bool {
+	if kind == "maintenance" {
+		return slices.Contains(maintenanceStatuses, status)
+	}
+	return slices.Contains(incidentStatuses, status)
+}
+
+func isIncidentClosedStatus(status string) bool {
+	return status == "resolved" || status == "completed"
+}
+
+// Subscribe 访客订阅状态页的故障与维护通知
+// 邮件订阅需要访客点击确认邮件中的链接后才会生效，避免被用来向任意邮箱发送邮件
+func (s *StatusPageService) Subscribe(ctx context.Context, slug string, req *StatusPageSubscribeRequest) (*models.StatusPageSubscriber, error) {
+	page, err := s.PageRepo.FindBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, orz.NewError(404, "状态页不存在")
+		}
+		return nil, err
+	}
+	if !page.Enabled {
+		return nil, orz.NewError(404, "状态页不存在")
+	}
+
+	req.Target = strings.TrimSpace(req.Target)
+	var emailConfig map[string]interface{}
+	switch req.Type {
+	case "email":
+		addr, err := mail.ParseAddress(req.Target)
+		if err != nil {
+			return nil, orz.NewError(400, "邮箱地址格式错误")
+		}
+		req.Target = strings.ToLower(addr.Address)
+		if s.externalURL == "" {
+			return nil, orz.NewError(400, "服务端未配置对外访问地址，暂不支持邮件订阅")
+		}
+		if emailConfig = s.findEmailChannel(ctx); emailConfig == nil {
+			return nil, orz.NewError(400, "服务端未配置邮件通知渠道，暂不支持邮件订阅")
+		}
+	case "webhook":
+		if err := validateSubscriberWebhook(req.Target); err != nil {
+			return nil, orz.NewError(400, err.Error())
+		}
+	default:
+		return nil, orz.NewError(400, "订阅方式只能是 email 或 webhook")
+	}
+
+	// 重复订阅直接返回已有订阅
+	existing, err := s.SubscriberRepo.FindByTarget(ctx, page.ID, req.Type, req.Target)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	count, err := s.SubscriberRepo.CountByPageID(ctx, page.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxStatusPageSubscribers {
+		return nil, orz.NewError(400, "订阅人数已达上限")
+	}
+
+	subscriber := &models.StatusPageSubscriber{
+		ID:        uuid.NewString(),
+		PageID:    page.ID,
+		Type:      req.Type,
+		Target:    req.Target,
+		Token:     newSubscriberToken(),
+		CreatedAt: time.Now().UnixMilli(),
+	}
+	if req.Type == "email" {
+		subscriber.ConfirmToken = newSubscriberToken()
+	}
+	if err := s.SubscriberRepo.Create(ctx, subscriber); err != nil {
+		return nil, err
+	}
+
+	if subscriber.ConfirmToken != "" {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := s.sendConfirmEmail(ctx, emailConfig, page, *subscriber); err != nil {
+				s.logger.Warn("发送状态页订阅确认邮件失败",
+					zap.String("pageId", page.ID),
+					zap.String("subscriberId", subscriber.ID),
+					zap.Error(err))
+			}
+		}()
+	}
+	return subscriber, nil
+}
+
+// ConfirmSubscription 根据确认令牌确认邮件订阅
+func (s *StatusPageService) ConfirmSubscription(ctx context.Context, slug, token string) error {
+	page, err := s.PageRepo.FindBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return orz.NewError(404, "状态页不存在")
+		}
+		return err
+	}
+	affected, err := s.SubscriberRepo.ConfirmByToken(ctx, page.ID, token)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return orz.NewError(404, "订阅不存在或已确认")
+	}
+	return nil
+}
+
+// DeleteSubscriber 移除状态页下的订阅者
+func (s *StatusPageService) DeleteSubscriber(ctx context.Context, pageID, id string) error {
+	affected, err := s.SubscriberRepo.DeleteByPageAndID(ctx, pageID, id)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return orz.NewError(404, "订阅不存在")
+	}
+	return nil
+}
+
+func newSubscriberToken() string {
+	return strings.ReplaceAll(uuid.NewString(), "-", "")
+}
+
+// subscriberLink 生成订阅确认或退订链接，链接始终基于配置的对外访问地址，不使用请求中的 Host
+// 未配置对外访问地址时返回相对路径
+func (s *StatusPageService) subscriberLink(page *models.StatusPage, action, token string) string {
+	return fmt.Sprintf("%s/api/status-pages/%s/%s?token=%s", s.externalURL, url.PathEscape(page.Slug), action, token)
+}
+
+// Unsubscribe 根据退订令牌取消订阅
+func (s *StatusPageService) Unsubscribe(ctx context.Context, slug, token string) error {
+	page, err := s.PageRepo.FindBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return orz.NewError(404, "状态页不存在")
+		}
+		return err
+	}
+	affected, err := s.SubscriberRepo.DeleteByToken(ctx, page.ID, token)
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return orz.NewError(404, "订阅不存在或已退订")
+	}
+	return nil
+}
+
+// validateSubscriberWebhook 校验访客提交的 Webhook 地址，禁止指向内网地址
+func validateSubscriberWebhook(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return fmt.Errorf("Webhook 地址必须是 http 或 https URL")
+	}
+	ips, err := net.LookupIP(u.Hostname())
+	if err != nil {
+		return fmt.Errorf("无法解析 Webhook 地址: %v", err)
+	}
+	for _, ip := range ips {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+			return fmt.Errorf("Webhook 地址不能指向内网地址")
+		}
+	}
+	return nil
+}
+
+// notifySubscribers 异步通知状态页订阅者
+func (s *StatusPageService) notifySubscribers(page *models.StatusPage, incident *models.StatusPageIncident, update *models.StatusPageIncidentUpdate, created bool) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		defer cancel()
+
+		subscribers, err := s.SubscriberRepo.FindConfirmedByPageID(ctx, page.ID)
+		if err != nil {
+			s.logger.Error("获取状态页订阅者失败", zap.String("pageId", page.ID), zap.Error(err))
+			return
+		}
+		if len(subscribers) == 0 {
+			return
+		}
+
+		title := page.Title
+		if title == "" {
+			if systemConfig, err := s.propertyService.GetSystemConfig(ctx); err == nil {
+				title = systemConfig.SystemNameZh
+			}
+		}
+
+		event := "incident.updated"
+		if created {
+			event = "incident.created"
+		}
+		payload := map[string]interface{}{
+			"event":    event,
+			"page":     map[string]string{"slug": page.Slug, "title": title},
+			"incident": incident,
+			"update":   update,
+		}
+
+		emailConfig := s.findEmailChannel(ctx)
+		if emailConfig == nil {
+			s.logger.Warn("未配置邮件通知渠道，跳过状态页邮件订阅通知", zap.String("pageId", page.ID))
+		}
+		s.deliverToSubscribers(page.ID, subscribers, emailConfig != nil, func(subscriber models.StatusPageSubscriber) error {
+			unsubscribeURL := s.subscriberLink(page, "unsubscribe", subscriber.Token)
+			if subscriber.Type == "email" {
+				return s.sendSubscriberEmail(ctx, emailConfig, title, incident, update, unsubscribeURL, subscriber)
+			}
+			if err := validateSubscriberWebhook(subscriber.Target); err != nil {
+				return err
+			}
+			payload["unsubscribeUrl"] = unsubscribeURL
+			_, err := s.notifier.sendJSONRequest(ctx, subscriber.Target, payload)
+			return err
+		})
+	}()
+}
+
+// deliverToSubscribers 逐个通知订阅者，单个订阅者发送失败不影响其他订阅者，未配置邮件渠道时只跳过邮件订阅者
+func (s *StatusPageService) deliverToSubscribers(pageID string, subscribers []models.StatusPageSubscriber, emailEnabled bool, send func(subscriber models.StatusPageSubscriber) error) {
+	for _, subscriber := range subscribers {
+		if subscriber.ConfirmToken != "" || (subscriber.Type == "email" && !emailEnabled) {
+			continue
+		}
+		if subscriber.Type != "email" && subscriber.Type != "webhook" {
+			continue
+		}
+		if err := send(subscriber); err != nil {
+			s.logger.Warn("发送状态页订阅通知失败",
+				zap.String("pageId", pageID),
+				zap.String("subscriberId", subscriber.ID),
+				zap.String("type", subscriber.Type),
+				zap.Error(err))
+		}
+	}
+}
+
+// findEmailChannel 复用已配置的邮件通知渠道作为发件配置
+func (s *StatusPageService) findEmailChannel(ctx context.Context) map[string]interface{} {
+	channels, err := s.propertyService.GetNotificationChannelConfigs(ctx)
+	if err != nil {
+		s.logger.Error("获取通知渠道失败", zap.Error(err))
+		return nil
+	}
+	for _, channel := range channels {
+		if channel.Type == "email" && channel.Enabled {
+			return channel.Config
+		}
+	}
+	return nil
+}
+
+// sendSubscriberEmail 向订阅者发送公告邮件
+func (s *StatusPageService) sendSubscriberEmail(ctx context.Context, emailConfig map[string]interface{}, title string, incident *models.StatusPageIncident, update *models.StatusPageIncidentUpdate, unsubscribeURL string, subscriber models.StatusPageSubscriber) error {
+	config := make(map[string]interface{}, len(emailConfig)+2)
+	for k, v := range emailConfig {
+		config[k] = v
+	}
+	config["toEmail"] = subscriber.Target
+	config["subject"] = fmt.Sprintf("[%s] %s", title, incident.Title)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s\n\n", incident.Title))
+	sb.WriteString(fmt.Sprintf("状态：%s\n", update.Status))
+	if incident.Kind == "maintenance" && incident.ScheduledStart > 0 {
+		sb.WriteString(fmt.Sprintf("维护时间：%s", time.UnixMilli(incident.ScheduledStart).Format("2006-01-02 15:04")))
+		if incident.ScheduledEnd > 0 {
+			sb.WriteString(fmt.Sprintf(" ~ %s", time.UnixMilli(incident.ScheduledEnd).Format("2006-01-02 15:04")))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(fmt.Sprintf("\n%s\n", update.Message))
+	sb.WriteString(fmt.Sprintf("\n退订：%s\n", unsubscribeURL))
+
+	return s.notifier.SendEmailByConfig(ctx, config, sb.String())
+}
+
+// sendConfirmEmail 向新的邮件订阅者发送确认邮件
+func (s *StatusPageService) sendConfirmEmail(ctx context.Context, emailConfig map[string]interface{}, page *models.StatusPage, subscriber models.StatusPageSubscriber) error {
+	title := page.Title
+	if title == "" {
+		if systemConfig, err := s.propertyService.GetSystemConfig(ctx); err == nil {
+			title = systemConfig.SystemNameZh
+		}
+	}
+
+	config := make(map[string]interface{}, len(emailConfig)+2)
+	for k, v := range emailConfig {
+		config[k] = v
+	}
+	config["toEmail"] = subscriber.Target
+	config["subject"] = fmt.Sprintf("[%s] 请确认订阅", title)
+
+	body := fmt.Sprintf("您（或他人使用您的邮箱）订阅了 %s 的故障与维护通知。\n\n确认订阅：%s\n\n如果不是您本人操作，请忽略此邮件，未确认的订阅不会收到任何通知。\n",
+		title, s.subscriberLink(page, "confirm", subscriber.ConfirmToken))
+	return s.notifier.SendEmailByConfig(ctx, config, body)
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/models"
+	"go.uber.org/zap"
+)
+
+func TestApplyIncidentStatus(t *testing.T) {
+	now := int64(10000)
+	incidents := []models.StatusPageIncident{
+		{Kind: "incident", Status: "investigating", Impact: "minor", ComponentIDs: []string{"api"}},
+		{Kind: "incident", Status: "resolved", Impact: "critical", ComponentIDs: []string{"api"}, ResolvedAt: 9000},
+		{Kind: "maintenance", Status: "scheduled", ComponentIDs: []string{"db"}, ScheduledStart: 5000, ScheduledEnd: 20000},
+		{Kind: "maintenance", Status: "scheduled", ComponentIDs: []string{"web"}, ScheduledStart: 50000},
+	}
+
+	cases := []struct {
+		component string
+		status    string
+		want      string
+	}{
+		{"api", ComponentStatusOperational, ComponentStatusDegraded},
+		{"api", ComponentStatusOutage, ComponentStatusOutage},
+		{"db", ComponentStatusOutage, ComponentStatusMaintenance},
+		{"web", ComponentStatusOperational, ComponentStatusOperational},
+	}
+	for _, tc := range cases {
+		if got := applyIncidentStatus(tc.status, tc.component, incidents, now); got != tc.want {
+			t.Errorf("applyIncidentStatus(%s, %s) = %s, want %s", tc.status, tc.component, got, tc.want)
+		}
+	}
+}
+
+func TestDeliverToSubscribers(t *testing.T) {
+	s := &StatusPageService{logger: zap.NewNop()}
+	subscribers := []models.StatusPageSubscriber{
+		{ID: "email", Type: "email", Target: "ops@example.com"},
+		{ID: "pending", Type: "email", Target: "someone@example.com", ConfirmToken: "abc"},
+		{ID: "failing", Type: "webhook", Target: "https://a.example.com/hook"},
+		{ID: "webhook", Type: "webhook", Target: "https://b.example.com/hook"},
+	}
+
+	var sent []string
+	send := func(subscriber models.StatusPageSubscriber) error {
+		sent = append(sent, subscriber.ID)
+		if subscriber.ID == "failing" {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	// 未配置邮件渠道时跳过邮件订阅者，但仍通知其余订阅者
+	s.deliverToSubscribers("page", subscribers, false, send)
+	if len(sent) != 2 || sent[0] != "failing" || sent[1] != "webhook" {
+		t.Fatalf("unexpected deliveries without email channel: %v", sent)
+	}
+
+	sent = nil
+	s.deliverToSubscribers("page", subscribers, true, send)
+	if len(sent) != 3 || sent[0] != "email" {
+		t.Fatalf("unexpected deliveries: %v", sent)
+	}
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/metric"
+	"github.com/dushixiang/pika/internal/metricstore"
+	"github.com/dushixiang/pika/internal/models"
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/internal/repo"
+	"github.com/go-orz/toolkit/syncx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// incidentRetention 已恢复故障记录的保留时长，与最长的可用率统计周期一致
+const incidentRetention = 90 * 24 * time.Hour
+
+// uptimePeriods 可用率统计周期
+var uptimePeriods = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+	{"90d", 90 * 24 * time.Hour},
+}
+
+// UptimeService 可用率（SLA）服务：基于时序存储中的历史计算可用率，并根据 up→down→up 状态变化记录故障
+type UptimeService struct {
+	logger       *zap.Logger
+	IncidentRepo *repo.MonitorIncidentRepo
+	agentRepo    *repo.AgentRepo
+	metricStore  metricstore.MetricStore
+
+	mu        sync.Mutex
+	loaded    bool
+	incidents map[string]*models.MonitorIncident // 进行中的故障，key: monitorId:agentId
+
+	incidentLocks syncx.KeyedMutex // 按 monitorId:agentId 串行处理检测结果，不同监控任务互不阻塞
+}
+
+func NewUptimeService(logger *zap.Logger, db *gorm.DB, metricStore metricstore.MetricStore) *UptimeService {
+	return &UptimeService{
+		logger:       logger,
+		IncidentRepo: repo.NewMonitorIncidentRepo(db),
+		agentRepo:    repo.NewAgentRepo(db),
+		metricStore:  metricStore,
+		incidents:    make(map[string]*models.MonitorIncident),
+	}
+}
+
+// HandleMonitorResult 根据检测结果打开或关闭故障记录
+func (s *UptimeService) HandleMonitorResult(ctx context.Context, agentID string, data *protocol.MonitorData, timestamp int64) {
+	if data.Status != "up" && data.Status != "down" {
+		return
+	}
+	checkedAt := data.CheckedAt
+	if checkedAt == 0 {
+		checkedAt = timestamp
+	}
+
+	key := incidentKey(data.MonitorId, agentID)
+	unlock := s.incidentLocks.Lock(key)
+	defer unlock()
+
+	// 只在读写内存状态时持有全局锁，数据库读写在单个监控任务的锁内完成
+	s.mu.Lock()
+	s.loadOngoing(ctx)
+	incident, ok := s.incidents[key]
+	s.mu.Unlock()
+
+	if data.Status == "down" {
+		if ok {
+			if data.Error != "" && data.Error != incident.LastError {
+				if err := s.IncidentRepo.UpdateError(ctx, incident.ID, data.Error); err != nil {
+					s.logger.Error("更新故障记录失败", zap.Int64("incidentId", incident.ID), zap.Error(err))
+					return
+				}
+				incident.LastError = data.Error
+			}
+			return
+		}
+
+		incident = &models.MonitorIncident{
+			MonitorID: data.MonitorId,
+			AgentID:   agentID,
+			StartedAt: checkedAt,
+			Error:     data.Error,
+			LastError: data.Error,
+		}
+		if err := s.IncidentRepo.Create(ctx, incident); err != nil {
+			s.logger.Error("创建故障记录失败",
+				zap.String("monitorId", data.MonitorId),
+				zap.String("agentId", agentID),
+				zap.Error(err))
+			return
+		}
+		s.mu.Lock()
+		s.incidents[key] = incident
+		s.mu.Unlock()
+		return
+	}
+
+	if !ok {
+		return
+	}
+	duration := max(checkedAt-incident.StartedAt, 0)
+	if err := s.IncidentRepo.Close(ctx, incident.ID, checkedAt, duration); err != nil {
+		s.logger.Error("关闭故障记录失败", zap.Int64("incidentId", incident.ID), zap.Error(err))
+		return
+	}
+	s.mu.Lock()
+	delete(s.incidents, key)
+	s.mu.Unlock()
+}
+
+// loadOngoing 首次使用时从数据库加载进行中的故障，调用方需持有锁
+func (s *UptimeService) loadOngoing(ctx context.Context) {
+	if s.loaded {
+		return
+	}
+	incidents, err := s.IncidentRepo.FindOngoing(ctx)
+	if err != nil {
+		s.logger.Error("加载进行中的故障失败", zap.Error(err))
+		return
+	}
+	for i := range incidents {
+		s.incidents[incidentKey(incidents[i].MonitorID, incidents[i].AgentID)] = &incidents[i]
+	}
+	s.loaded = true
+}
+
+// RemoveMonitor 清理已删除监控任务在内存中的故障状态
+func (s *UptimeService) RemoveMonitor(monitorID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, incident := range s.incidents {
+		if incident.MonitorID == monitorID {
+			delete(s.incidents, key)
+		}
+	}
+}
+
+// GetUptime 计算监控任务在各统计周期内的整体及各探针可用率
+func (s *UptimeService) GetUptime(ctx context.Context, monitor *models.MonitorTask) (*metric.MonitorUptime, error) {
+	agentNames := s.getAgentNames(ctx)
+	result := &metric.MonitorUptime{MonitorID: monitor.ID}
+	for _, period := range uptimePeriods {
+		r, err := s.queryUptimeRange(ctx, monitor, period.Name, period.Duration, agentNames)
+		if err != nil {
+			return nil, err
+		}
+		result.Periods = append(result.Periods, *r)
+	}
+	return result, nil
+}
+
+// GetPeriodUptime 计算监控任务在单个统计周期内的可用率
+func (s *UptimeService) GetPeriodUptime(ctx context.Context, monitor *models.MonitorTask, period string) (*metric.MonitorUptimeRange, error) {
+	for _, p := range uptimePeriods {
+		if p.Name == period {
+			return s.queryUptimeRange(ctx, monitor, p.Name, p.Duration, nil)
+		}
+	}
+	return nil, fmt.Errorf("unsupported uptime period: %s", period)
+}
+
+// queryUptimeRange 查询单个统计周期内各探针的正常次数与检测次数
+func (s *UptimeService) queryUptimeRange(ctx context.Context, monitor *models.MonitorTask, period string, duration time.Duration, agentNames map[string]string) (*metric.MonitorUptimeRange, error) {
+	selector := fmt.Sprintf(`monitor_id="%s"`, monitor.ID)
+	if len(monitor.AgentIds) > 0 {
+		selector += fmt.Sprintf(`,agent_id=~"%s"`, strings.Join(monitor.AgentIds, "|"))
+	}
+
+	window := fmt.Sprintf("%ds", int64(duration.Seconds()))
+	upQuery := fmt.Sprintf(`sum by (agent_id) (sum_over_time(pika_monitor_status{%s}[%s]))`, selector, window)
+	totalQuery := fmt.Sprintf(`sum by (agent_id) (count_over_time(pika_monitor_status{%s}[%s]))`, selector, window)
+
+	upByAgent, err := s.queryByAgent(ctx, upQuery)
+	if err != nil {
+		return nil, err
+	}
+	totalByAgent, err := s.queryByAgent(ctx, totalQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	r := buildUptimeRange(period, upByAgent, totalByAgent, agentNames)
+	return &r, nil
+}
+
+// queryByAgent 执行即时查询并按 agent_id 返回结果
+func (s *UptimeService) queryByAgent(ctx context.Context, query string) (map[string]float64, error) {
+	resp, err := s.metricStore.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]float64, len(resp.Data.Result))
+	for _, r := range resp.Data.Result {
+		value, ok := r.InstantValue()
+		if !ok {
+			continue
+		}
+		values[r.Metric["agent_id"]] = value
+	}
+	return values, nil
+}
+
+// GetIncidents 获取时间范围内的故障列表，重叠的多探针故障合并为一次，按开始时间倒序
+func (s *UptimeService) GetIncidents(ctx context.Context, monitor *models.MonitorTask, start, end int64, showErrors bool) ([]metric.MonitorIncidentSummary, error) {
+	incidents, err := s.IncidentRepo.FindByMonitorIDAndRange(ctx, monitor.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// 仅保留当前仍分配给该任务的探针
+	if len(monitor.AgentIds) > 0 {
+		allowed := make(map[string]struct{}, len(monitor.AgentIds))
+		for _, id := range monitor.AgentIds {
+			allowed[id] = struct{}{}
+		}
+		filtered := incidents[:0]
+		for _, incident := range incidents {
+			if _, ok := allowed[incident.AgentID]; ok {
+				filtered = append(filtered, incident)
+			}
+		}
+		incidents = filtered
+	}
+
+	summaries := groupIncidents(incidents, s.getAgentNames(ctx), time.Now().UnixMilli())
+	if !showErrors {
+		for i := range summaries {
+			summaries[i].Errors = nil
+			for j := range summaries[i].Agents {
+				summaries[i].Agents[j].Error = ""
+				summaries[i].Agents[j].LastError = ""
+			}
+		}
+	}
+	return summaries, nil
+}
+
+// getAgentNames 获取探针名称映射
+func (s *UptimeService) getAgentNames(ctx context.Context) map[string]string {
+	names := make(map[string]string)
+	agents, err := s.agentRepo.FindAll(ctx)
+	if err != nil {
+		s.logger.Error("获取探针列表失败", zap.Error(err))
+		return names
+	}
+	for _, agent := range agents {
+		names[agent.ID] = agent.Name
+	}
+	return names
+}
+
+// Run 定时清理过期的故障记录
+func (s *UptimeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			before := time.Now().Add(-incidentRetention).UnixMilli()
+			if err := s.IncidentRepo.DeleteBefore(ctx, before); err != nil {
+				s.logger.Error("清理故障记录失败", zap.Error(err))
+			}
+		}
+	}
+}
+
+func incidentKey(monitorID, agentID string) string {
+	return monitorID + ":" + agentID
+}
+
+// buildUptimeRange 汇总单个统计周期的可用率
+func buildUptimeRange(period string, upByAgent, totalByAgent map[string]float64, agentNames map[string]string) metric.MonitorUptimeRange {
+	r := metric.MonitorUptimeRange{Period: period}
+
+	var up, total float64
+	for agentID, checks := range totalByAgent {
+		if checks <= 0 {
+			continue
+		}
+		up += upByAgent[agentID]
+		total += checks
+		r.Agents = append(r.Agents, metric.AgentUptimeSummary{
+			AgentID:   agentID,
+			AgentName: agentNames[agentID],
+			Uptime:    roundUptime(upByAgent[agentID] / checks * 100),
+			Checks:    int64(checks),
+		})
+	}
+	sort.Slice(r.Agents, func(i, j int) bool {
+		return r.Agents[i].AgentName < r.Agents[j].AgentName
+	})
+
+	if total > 0 {
+		uptime := roundUptime(up / total * 100)
+		r.Uptime = &uptime
+		r.Checks = int64(total)
+	}
+	return r
+}
+
+// roundUptime 可用率保留三位小数（99.999%）
+func roundUptime(v float64) float64 {
+	return float64(int64(v*1000+0.5)) / 1000
+}
+
+// groupIncidents 将时间上重叠的探针故障合并为一次监控故障，结果按开始时间倒序
+func groupIncidents(incidents []models.MonitorIncident, agentNames map[string]string, now int64) []metric.MonitorIncidentSummary {
+	sorted := make([]models.MonitorIncident, len(incidents))
+	copy(sorted, incidents)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartedAt < sorted[j].StartedAt
+	})
+
+	var summaries []metric.MonitorIncidentSummary
+	var current *metric.MonitorIncidentSummary
+	var currentEnd int64
+	seenErrors := make(map[string]struct{})
+
+	for _, incident := range sorted {
+		end := incident.EndedAt
+		if end == 0 {
+			end = now
+		}
+
+		if current == nil || incident.StartedAt > currentEnd {
+			if current != nil {
+				summaries = append(summaries, *current)
+			}
+			current = &metric.MonitorIncidentSummary{StartedAt: incident.StartedAt}
+			currentEnd = end
+			seenErrors = make(map[string]struct{})
+		}
+
+		if end > currentEnd {
+			currentEnd = end
+		}
+		if incident.EndedAt == 0 {
+			current.Ongoing = true
+		}
+		current.EndedAt = currentEnd
+		current.Duration = currentEnd - current.StartedAt
+		current.Agents = append(current.Agents, metric.MonitorIncidentDetail{
+			AgentID:   incident.AgentID,
+			AgentName: agentNames[incident.AgentID],
+			StartedAt: incident.StartedAt,
+			EndedAt:   incident.EndedAt,
+			Error:     incident.Error,
+			LastError: incident.LastError,
+		})
+		for _, msg := range []string{incident.Error, incident.LastError} {
+			if msg == "" {
+				continue
+			}
+			if _, ok := seenErrors[msg]; !ok {
+				seenErrors[msg] = struct{}{}
+				current.Errors = append(current.Errors, msg)
+			}
+		}
+	}
+	if current != nil {
+		summaries = append(summaries, *current)
+	}
+
+	for i := range summaries {
+		if summaries[i].Ongoing {
+			summaries[i].EndedAt = 0
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].StartedAt > summaries[j].StartedAt
+	})
+	return summaries
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dushixiang/pika/internal/models"
+)
+
+func TestGroupIncidents(t *testing.T) {
+	incidents := []models.MonitorIncident{
+		{AgentID: "a", StartedAt: 1000, EndedAt: 5000, Error: "timeout"},
+		{AgentID: "b", StartedAt: 3000, EndedAt: 8000, Error: "timeout", LastError: "connection refused"},
+		{AgentID: "a", StartedAt: 20000, EndedAt: 0, Error: "timeout"},
+	}
+	names := map[string]string{"a": "agent-a", "b": "agent-b"}
+
+	summaries := groupIncidents(incidents, names, 30000)
+	if len(summaries) != 2 {
+		t.Fatalf("expected 2 incidents, got %d", len(summaries))
+	}
+
+	// 按开始时间倒序，第一条为仍在持续的故障
+	ongoing := summaries[0]
+	if !ongoing.Ongoing || ongoing.EndedAt != 0 || ongoing.Duration != 10000 {
+		t.Fatalf("unexpected ongoing incident: %+v", ongoing)
+	}
+
+	merged := summaries[1]
+	if merged.StartedAt != 1000 || merged.EndedAt != 8000 || merged.Duration != 7000 {
+		t.Fatalf("unexpected merged incident: %+v", merged)
+	}
+	if len(merged.Agents) != 2 || merged.Agents[1].AgentName != "agent-b" {
+		t.Fatalf("unexpected affected agents: %+v", merged.Agents)
+	}
+	if len(merged.Errors) != 2 || merged.Errors[0] != "timeout" || merged.Errors[1] != "connection refused" {
+		t.Fatalf("unexpected errors: %v", merged.Errors)
+	}
+}
+
+func TestBuildUptimeRange(t *testing.T) {
+	up := map[string]float64{"a": 99, "b": 50}
+	total := map[string]float64{"a": 100, "b": 100}
+
+	r := buildUptimeRange("24h", up, total, map[string]string{"a": "agent-a", "b": "agent-b"})
+	if r.Uptime == nil || *r.Uptime != 74.5 || r.Checks != 200 {
+		t.Fatalf("unexpected overall uptime: %+v", r)
+	}
+	if len(r.Agents) != 2 || r.Agents[0].Uptime != 99 || r.Agents[1].Uptime != 50 {
+		t.Fatalf("unexpected agent uptime: %+v", r.Agents)
+	}
+
+	empty := buildUptimeRange("7d", nil, nil, nil)
+	if empty.Uptime != nil {
+		t.Fatalf("expected nil uptime without data")
+	}
+}
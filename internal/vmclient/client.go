@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -35,6 +36,23 @@ type ResultData struct {
 type Result struct {
 	Metric map[string]string `json:"metric"`
 	Values [][]interface{}   `json:"values"` // [[timestamp, value], ...]
+	Value  []interface{}     `json:"value"`  // 即时查询结果 [timestamp, value]
+}
+
+// InstantValue 解析即时查询结果的值
+func (r Result) InstantValue() (float64, bool) {
+	if len(r.Value) < 2 {
+		return 0, false
+	}
+	valueStr, ok := r.Value[1].(string)
+	if !ok {
+		return 0, false
+	}
+	var value float64
+	if _, err := fmt.Sscanf(valueStr, "%f", &value); err != nil {
+		return 0, false
+	}
+	return value, true
 }
 
 // DataPoint 数据点
@@ -152,12 +170,12 @@ func (c *VMClient) QueryRange(ctx context.Context, query string, start, end time
 		params.Set("step", fmt.Sprintf("%ds", int(autoStep.Seconds())))
 	}
 
-	reqURL := fmt.Sprintf("%s/api/v1/query_range?%s", c.baseURL, params.Encode())
-
-	req, err := http.NewRequestWithContext(reqCtx, "GET", reqURL, nil)
+	// 使用 POST 表单提交，避免查询语句过长超出 URL 长度限制
+	req, err := http.NewRequestWithContext(reqCtx, "POST", c.baseURL+"/api/v1/query_range", strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -190,12 +208,11 @@ func (c *VMClient) Query(ctx context.Context, query string) (*QueryResult, error
 	params := url.Values{}
 	params.Set("query", query)
 
-	reqURL := fmt.Sprintf("%s/api/v1/query?%s", c.baseURL, params.Encode())
-
-	req, err := http.NewRequestWithContext(reqCtx, "GET", reqURL, nil)
+	req, err := http.NewRequestWithContext(reqCtx, "POST", c.baseURL+"/api/v1/query", strings.NewReader(params.Encode()))
 	if err != nil {
 		return nil, fmt.Errorf("create request failed: %w", err)
 	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/dushixiang/pika/internal/config"
 	"github.com/dushixiang/pika/internal/handler"
+	"github.com/dushixiang/pika/internal/metricstore"
 	"github.com/dushixiang/pika/internal/service"
 	"github.com/dushixiang/pika/internal/vmclient"
 	"github.com/dushixiang/pika/internal/websocket"
@@ -19,8 +20,8 @@ import (
 // InitializeApp 初始化应用
 func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*AppComponents, error) {
 	wire.Build(
-		// VictoriaMetrics Client
-		provideVMClient,
+		// 时序存储
+		provideMetricStore,
 
 		service.NewAccountService,
 		service.NewAgentService,
@@ -39,6 +40,16 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 		service.NewDDNSService,
 		service.NewSSHLoginService,
 		service.NewPublicIPService,
+		service.NewRouteService,
+		service.NewUptimeService,
+		service.NewStatusPageService,
+		service.NewRemoteWriteService,
+		service.NewFleetService,
+		service.NewRollupService,
+		service.NewDiskForecastService,
+		service.NewAgentConfigProfileService,
+		service.NewLogWatchService,
+		service.NewLogForwardService,
 
 		service.NewNotifier,
 		// WebSocket Manager
@@ -55,6 +66,13 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 		handler.NewDNSProviderHandler,
 		handler.NewDDNSHandler,
 		handler.NewSSHLoginHandler,
+		handler.NewStatusPageHandler,
+		handler.NewPrometheusHandler,
+		handler.NewMetricQueryHandler,
+		handler.NewFleetHandler,
+		handler.NewAgentConfigProfileHandler,
+		handler.NewLogWatchHandler,
+		handler.NewLogForwardHandler,
 
 		// App Components
 		wire.Struct(new(AppComponents), "*"),
@@ -74,30 +92,47 @@ type AppComponents struct {
 	DNSProviderHandler *handler.DNSProviderHandler
 	DDNSHandler        *handler.DDNSHandler
 	SSHLoginHandler    *handler.SSHLoginHandler
-
-	AgentService    *service.AgentService
-	TrafficService  *service.TrafficService
-	MetricService   *service.MetricService
-	AlertService    *service.AlertService
-	PropertyService *service.PropertyService
-	MonitorService  *service.MonitorService
-	ApiKeyService   *service.ApiKeyService
-	TamperService   *service.TamperService
-	DDNSService     *service.DDNSService
-	SSHLoginService *service.SSHLoginService
-	PublicIPService *service.PublicIPService
-
-	WSManager *websocket.Manager
-	VMClient  *vmclient.VMClient
+	StatusPageHandler  *handler.StatusPageHandler
+	PrometheusHandler  *handler.PrometheusHandler
+	MetricQueryHandler *handler.MetricQueryHandler
+	FleetHandler       *handler.FleetHandler
+
+	AgentConfigProfileHandler *handler.AgentConfigProfileHandler
+	LogWatchHandler           *handler.LogWatchHandler
+	LogForwardHandler         *handler.LogForwardHandler
+
+	AgentService        *service.AgentService
+	TrafficService      *service.TrafficService
+	MetricService       *service.MetricService
+	AlertService        *service.AlertService
+	PropertyService     *service.PropertyService
+	MonitorService      *service.MonitorService
+	ApiKeyService       *service.ApiKeyService
+	TamperService       *service.TamperService
+	DDNSService         *service.DDNSService
+	SSHLoginService     *service.SSHLoginService
+	PublicIPService     *service.PublicIPService
+	RouteService        *service.RouteService
+	UptimeService       *service.UptimeService
+	RemoteWriteService  *service.RemoteWriteService
+	RollupService       *service.RollupService
+	DiskForecastService *service.DiskForecastService
+	LogForwardService   *service.LogForwardService
+
+	WSManager   *websocket.Manager
+	MetricStore metricstore.MetricStore
 }
 
-// provideVMClient 提供 VictoriaMetrics 客户端
-func provideVMClient(cfg *config.AppConfig, logger *zap.Logger) *vmclient.VMClient {
-	// 检查配置
+// provideMetricStore 提供时序存储：启用 VictoriaMetrics 时使用 VictoriaMetrics，否则使用基于应用数据库的内置存储
+func provideMetricStore(cfg *config.AppConfig, logger *zap.Logger, db *gorm.DB) metricstore.MetricStore {
 	if cfg.VictoriaMetrics == nil || !cfg.VictoriaMetrics.Enabled {
-		logger.Info("VictoriaMetrics is not enabled, using default configuration")
-		// 返回一个默认配置的客户端（用于本地开发）
-		return vmclient.NewVMClient("http://localhost:8428", 30*time.Second, 60*time.Second)
+		retentionDays := 7
+		if cfg.EmbeddedMetrics != nil && cfg.EmbeddedMetrics.RetentionDays > 0 {
+			retentionDays = cfg.EmbeddedMetrics.RetentionDays
+		}
+		logger.Info("VictoriaMetrics is not enabled, using embedded metric store",
+			zap.Int("retentionDays", retentionDays))
+		return metricstore.NewEmbeddedStore(logger, db, time.Duration(retentionDays)*24*time.Hour)
 	}
 
 	// 使用配置创建客户端
@@ -9,6 +9,7 @@ package internal
 import (
 	"github.com/dushixiang/pika/internal/config"
 	"github.com/dushixiang/pika/internal/handler"
+	"github.com/dushixiang/pika/internal/metricstore"
 	"github.com/dushixiang/pika/internal/service"
 	"github.com/dushixiang/pika/internal/vmclient"
 	"github.com/dushixiang/pika/internal/websocket"
@@ -31,53 +32,83 @@ func InitializeApp(logger *zap.Logger, db *gorm.DB, cfg *config.AppConfig) (*App
 	notifier := service.NewNotifier(logger)
 	notificationService := service.NewNotificationService(logger, propertyService, notifier)
 	trafficService := service.NewTrafficService(logger, db, notificationService)
-	vmClient := provideVMClient(cfg, logger)
-	metricService := service.NewMetricService(logger, db, propertyService, trafficService, vmClient)
 	geoIPService, err := service.NewGeoIPService(logger, cfg)
 	if err != nil {
 		return nil, err
 	}
+	routeService := service.NewRouteService(logger, db, geoIPService, propertyService, notificationService)
+	metricStore := provideMetricStore(cfg, logger, db)
+	uptimeService := service.NewUptimeService(logger, db, metricStore)
+	remoteWriteService := service.NewRemoteWriteService(logger, cfg)
+	rollupService := service.NewRollupService(logger, cfg, propertyService, metricStore)
+	metricService := service.NewMetricService(logger, db, propertyService, trafficService, routeService, uptimeService, remoteWriteService, rollupService, metricStore)
 	agentService := service.NewAgentService(logger, db, apiKeyService, metricService, geoIPService)
 	manager := websocket.NewManager(logger)
-	monitorService := service.NewMonitorService(logger, db, metricService, manager)
+	monitorService := service.NewMonitorService(logger, db, metricService, uptimeService, manager)
 	tamperService := service.NewTamperService(logger, db, manager, notificationService)
 	ddnsService := service.NewDDNSService(logger, db, propertyService, manager)
 	sshLoginService := service.NewSSHLoginService(logger, db, manager, geoIPService, notificationService)
-	publicIPService := service.NewPublicIPService(logger, propertyService, manager)
-	agentHandler := handler.NewAgentHandler(logger, agentService, trafficService, metricService, monitorService, tamperService, ddnsService, sshLoginService, apiKeyService, propertyService, manager)
+	alertService := service.NewAlertService(logger, db, propertyService, monitorService, metricService, notifier)
+	diskForecastService := service.NewDiskForecastService(logger, metricStore, propertyService, alertService)
+	agentConfigProfileService := service.NewAgentConfigProfileService(logger, db, manager)
+	logWatchService := service.NewLogWatchService(logger, db, manager, notificationService)
+	logForwardService := service.NewLogForwardService(logger, db, cfg, manager)
+	agentHandler := handler.NewAgentHandler(logger, agentService, trafficService, metricService, monitorService, tamperService, ddnsService, sshLoginService, apiKeyService, propertyService, diskForecastService, agentConfigProfileService, logWatchService, logForwardService, manager)
 	apiKeyHandler := handler.NewApiKeyHandler(logger, apiKeyService)
-	alertService := service.NewAlertService(logger, db, propertyService, monitorService, notifier)
 	alertHandler := handler.NewAlertHandler(logger, alertService)
 	propertyHandler := handler.NewPropertyHandler(logger, propertyService, notifier)
-	monitorHandler := handler.NewMonitorHandler(logger, monitorService, metricService, agentService)
+	monitorHandler := handler.NewMonitorHandler(logger, monitorService, metricService, agentService, routeService, uptimeService)
 	tamperHandler := handler.NewTamperHandler(logger, tamperService)
 	dnsProviderHandler := handler.NewDNSProviderHandler(logger, propertyService)
 	ddnsHandler := handler.NewDDNSHandler(logger, ddnsService)
 	sshLoginHandler := handler.NewSSHLoginHandler(logger, sshLoginService)
+	statusPageService := service.NewStatusPageService(logger, db, cfg, metricService, uptimeService, propertyService, notifier)
+	statusPageHandler := handler.NewStatusPageHandler(logger, statusPageService)
+	prometheusHandler := handler.NewPrometheusHandler(logger, cfg, metricService)
+	metricQueryHandler := handler.NewMetricQueryHandler(logger, agentService, metricService)
+	fleetService := service.NewFleetService(logger, metricService, metricStore)
+	fleetHandler := handler.NewFleetHandler(logger, agentService, fleetService, diskForecastService)
+	agentConfigProfileHandler := handler.NewAgentConfigProfileHandler(logger, agentConfigProfileService)
+	logWatchHandler := handler.NewLogWatchHandler(logger, logWatchService)
+	logForwardHandler := handler.NewLogForwardHandler(logger, logForwardService)
+	publicIPService := service.NewPublicIPService(logger, propertyService, manager)
 	appComponents := &AppComponents{
-		AccountHandler:     accountHandler,
-		AgentHandler:       agentHandler,
-		ApiKeyHandler:      apiKeyHandler,
-		AlertHandler:       alertHandler,
-		PropertyHandler:    propertyHandler,
-		MonitorHandler:     monitorHandler,
-		TamperHandler:      tamperHandler,
-		DNSProviderHandler: dnsProviderHandler,
-		DDNSHandler:        ddnsHandler,
-		SSHLoginHandler:    sshLoginHandler,
-		AgentService:       agentService,
-		TrafficService:     trafficService,
-		MetricService:      metricService,
-		AlertService:       alertService,
-		PropertyService:    propertyService,
-		MonitorService:     monitorService,
-		ApiKeyService:      apiKeyService,
-		TamperService:      tamperService,
-		DDNSService:        ddnsService,
-		SSHLoginService:    sshLoginService,
-		PublicIPService:    publicIPService,
-		WSManager:          manager,
-		VMClient:           vmClient,
+		AccountHandler:            accountHandler,
+		AgentHandler:              agentHandler,
+		ApiKeyHandler:             apiKeyHandler,
+		AlertHandler:              alertHandler,
+		PropertyHandler:           propertyHandler,
+		MonitorHandler:            monitorHandler,
+		TamperHandler:             tamperHandler,
+		DNSProviderHandler:        dnsProviderHandler,
+		DDNSHandler:               ddnsHandler,
+		SSHLoginHandler:           sshLoginHandler,
+		StatusPageHandler:         statusPageHandler,
+		PrometheusHandler:         prometheusHandler,
+		MetricQueryHandler:        metricQueryHandler,
+		FleetHandler:              fleetHandler,
+		AgentConfigProfileHandler: agentConfigProfileHandler,
+		LogWatchHandler:           logWatchHandler,
+		LogForwardHandler:         logForwardHandler,
+		AgentService:              agentService,
+		TrafficService:            trafficService,
+		MetricService:             metricService,
+		AlertService:              alertService,
+		PropertyService:           propertyService,
+		MonitorService:            monitorService,
+		ApiKeyService:             apiKeyService,
+		TamperService:             tamperService,
+		DDNSService:               ddnsService,
+		SSHLoginService:           sshLoginService,
+		PublicIPService:           publicIPService,
+		RouteService:              routeService,
+		UptimeService:             uptimeService,
+		RemoteWriteService:        remoteWriteService,
+		RollupService:             rollupService,
+		DiskForecastService:       diskForecastService,
+		LogForwardService:         logForwardService,
+		WSManager:                 manager,
+		MetricStore:               metricStore,
 	}
 	return appComponents, nil
 }
@@ -96,30 +127,46 @@ type AppComponents struct {
 	DNSProviderHandler *handler.DNSProviderHandler
 	DDNSHandler        *handler.DDNSHandler
 	SSHLoginHandler    *handler.SSHLoginHandler
-
-	AgentService    *service.AgentService
-	TrafficService  *service.TrafficService
-	MetricService   *service.MetricService
-	AlertService    *service.AlertService
-	PropertyService *service.PropertyService
-	MonitorService  *service.MonitorService
-	ApiKeyService   *service.ApiKeyService
-	TamperService   *service.TamperService
-	DDNSService     *service.DDNSService
-	SSHLoginService *service.SSHLoginService
-	PublicIPService *service.PublicIPService
-
-	WSManager *websocket.Manager
-	VMClient  *vmclient.VMClient
+	StatusPageHandler  *handler.StatusPageHandler
+	PrometheusHandler  *handler.PrometheusHandler
+	MetricQueryHandler *handler.MetricQueryHandler
+	FleetHandler       *handler.FleetHandler
+
+	AgentConfigProfileHandler *handler.AgentConfigProfileHandler
+	LogWatchHandler           *handler.LogWatchHandler
+	LogForwardHandler         *handler.LogForwardHandler
+
+	AgentService        *service.AgentService
+	TrafficService      *service.TrafficService
+	MetricService       *service.MetricService
+	AlertService        *service.AlertService
+	PropertyService     *service.PropertyService
+	MonitorService      *service.MonitorService
+	ApiKeyService       *service.ApiKeyService
+	TamperService       *service.TamperService
+	DDNSService         *service.DDNSService
+	SSHLoginService     *service.SSHLoginService
+	PublicIPService     *service.PublicIPService
+	RouteService        *service.RouteService
+	UptimeService       *service.UptimeService
+	RemoteWriteService  *service.RemoteWriteService
+	RollupService       *service.RollupService
+	DiskForecastService *service.DiskForecastService
+	LogForwardService   *service.LogForwardService
+
+	WSManager   *websocket.Manager
+	MetricStore metricstore.MetricStore
 }
 
-// provideVMClient 提供 VictoriaMetrics 客户端
-func provideVMClient(cfg *config.AppConfig, logger *zap.Logger) *vmclient.VMClient {
-
+// provideMetricStore 提供时序存储：启用 VictoriaMetrics 时使用 VictoriaMetrics，否则使用基于应用数据库的内置存储
+func provideMetricStore(cfg *config.AppConfig, logger *zap.Logger, db *gorm.DB) metricstore.MetricStore {
 	if cfg.VictoriaMetrics == nil || !cfg.VictoriaMetrics.Enabled {
-		logger.Info("VictoriaMetrics is not enabled, using default configuration")
-
-		return vmclient.NewVMClient("http://localhost:8428", 30*time.Second, 60*time.Second)
+		retentionDays := 7
+		if cfg.EmbeddedMetrics != nil && cfg.EmbeddedMetrics.RetentionDays > 0 {
+			retentionDays = cfg.EmbeddedMetrics.RetentionDays
+		}
+		logger.Info("VictoriaMetrics is not enabled, using embedded metric store", zap.Int("retentionDays", retentionDays))
+		return metricstore.NewEmbeddedStore(logger, db, time.Duration(retentionDays)*24*time.Hour)
 	}
 
 	writeTimeout := time.Duration(cfg.VictoriaMetrics.WriteTimeout) * time.Second
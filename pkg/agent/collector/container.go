@@ -0,0 +1,224 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+// containerCPUSample 容器上一次采集的 CPU 累计值
+type containerCPUSample struct {
+	containerUsage uint64
+	systemUsage    uint64
+}
+
+// ContainerCollector 容器状态采集器，通过 Docker Engine API 读取容器列表、状态与资源占用
+type ContainerCollector struct {
+	client  *http.Client
+	baseURL string
+	timeout time.Duration
+	include []*regexp.Regexp
+	prevCPU map[string]containerCPUSample
+}
+
+// NewContainerCollector 创建容器状态采集器
+func NewContainerCollector(cfg config.ContainersConfig) *ContainerCollector {
+	c := &ContainerCollector{
+		client:  &http.Client{},
+		baseURL: strings.TrimRight(cfg.Endpoint, "/"),
+		timeout: time.Duration(cfg.Timeout) * time.Second,
+		prevCPU: make(map[string]containerCPUSample),
+	}
+	// unix socket 通过自定义拨号访问，请求地址的主机名不会被使用
+	if u, err := url.Parse(cfg.Endpoint); err == nil && u.Scheme == "unix" {
+		socketPath := u.Path
+		c.client.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socketPath)
+			},
+		}
+		c.baseURL = "http://docker"
+	}
+	// 正则已在加载配置时校验
+	for _, pattern := range cfg.Include {
+		c.include = append(c.include, regexp.MustCompile(pattern))
+	}
+	return c
+}
+
+// dockerContainer 容器列表接口返回的单个容器
+type dockerContainer struct {
+	ID    string   `json:"Id"`
+	Names []string `json:"Names"`
+	Image string   `json:"Image"`
+	State string   `json:"State"`
+}
+
+// dockerInspect 容器详情接口返回的部分字段
+type dockerInspect struct {
+	RestartCount uint64 `json:"RestartCount"`
+	State        struct {
+		Status   string `json:"Status"`
+		ExitCode int    `json:"ExitCode"`
+		Health   *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+}
+
+// dockerStats 容器资源统计接口返回的部分字段
+type dockerStats struct {
+	CPUStats struct {
+		CPUUsage struct {
+			TotalUsage uint64 `json:"total_usage"`
+		} `json:"cpu_usage"`
+		SystemUsage uint64 `json:"system_cpu_usage"`
+		OnlineCPUs  uint32 `json:"online_cpus"`
+	} `json:"cpu_stats"`
+	MemoryStats struct {
+		Usage uint64            `json:"usage"`
+		Limit uint64            `json:"limit"`
+		Stats map[string]uint64 `json:"stats"`
+	} `json:"memory_stats"`
+}
+
+// Collect 采集所有匹配容器的状态，运行中的容器额外采集 CPU 与内存占用
+// CPU 使用率根据与上一次采集的差值计算，容器首次出现时为 0
+func (c *ContainerCollector) Collect() ([]protocol.ContainerState, error) {
+	var list []dockerContainer
+	if err := c.get("/containers/json?all=true", &list); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(list))
+	containers := make([]protocol.ContainerState, 0, len(list))
+	for _, item := range list {
+		name := item.ID
+		if len(item.Names) > 0 {
+			name = strings.TrimPrefix(item.Names[0], "/")
+		}
+		if !c.accept(name) {
+			continue
+		}
+		seen[item.ID] = struct{}{}
+
+		state := protocol.ContainerState{
+			ID:    shortContainerID(item.ID),
+			Name:  name,
+			Image: item.Image,
+			State: item.State,
+		}
+
+		var inspect dockerInspect
+		if err := c.get("/containers/"+item.ID+"/json", &inspect); err == nil {
+			state.Restarts = inspect.RestartCount
+			state.ExitCode = inspect.State.ExitCode
+			if inspect.State.Status != "" {
+				state.State = inspect.State.Status
+			}
+			if inspect.State.Health != nil {
+				state.Health = inspect.State.Health.Status
+			}
+		}
+
+		if state.State == "running" {
+			var stats dockerStats
+			if err := c.get("/containers/"+item.ID+"/stats?stream=false&one-shot=true", &stats); err == nil {
+				c.applyStats(item.ID, &state, &stats)
+			}
+		}
+		containers = append(containers, state)
+	}
+
+	// 清理已删除容器的 CPU 记录
+	for id := range c.prevCPU {
+		if _, ok := seen[id]; !ok {
+			delete(c.prevCPU, id)
+		}
+	}
+	return containers, nil
+}
+
+// applyStats 计算容器 CPU 使用率与内存占用
+func (c *ContainerCollector) applyStats(id string, state *protocol.ContainerState, stats *dockerStats) {
+	current := containerCPUSample{
+		containerUsage: stats.CPUStats.CPUUsage.TotalUsage,
+		systemUsage:    stats.CPUStats.SystemUsage,
+	}
+	if prev, ok := c.prevCPU[id]; ok {
+		containerDelta := safeDelta(current.containerUsage, prev.containerUsage)
+		systemDelta := safeDelta(current.systemUsage, prev.systemUsage)
+		if systemDelta > 0 {
+			cpus := float64(stats.CPUStats.OnlineCPUs)
+			if cpus == 0 {
+				cpus = 1
+			}
+			state.CPUPercent = float64(containerDelta) / float64(systemDelta) * cpus * 100
+		}
+	}
+	c.prevCPU[id] = current
+
+	// 与 docker stats 一致，内存占用不含可回收的页缓存（cgroup v2 为 inactive_file，v1 为 cache）
+	usage := stats.MemoryStats.Usage
+	cache, ok := stats.MemoryStats.Stats["inactive_file"]
+	if !ok {
+		cache = stats.MemoryStats.Stats["cache"]
+	}
+	if cache < usage {
+		usage -= cache
+	}
+	state.MemoryUsage = usage
+	state.MemoryLimit = stats.MemoryStats.Limit
+}
+
+// accept 检查容器名称是否在白名单中
+func (c *ContainerCollector) accept(name string) bool {
+	if len(c.include) == 0 {
+		return true
+	}
+	for _, re := range c.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// get 请求 Docker API 并解析 JSON 响应
+func (c *ContainerCollector) get(path string, v interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// shortContainerID 截取容器 ID 的前 12 位，与 docker ps 一致
+func shortContainerID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,92 @@
+package collector
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+func TestContainerCollector(t *testing.T) {
+	// 通过 unix socket 模拟 Docker API
+	socketPath := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Skipf("unix socket not supported: %v", err)
+	}
+
+	var cpuUsage, systemUsage uint64 = 1_000_000_000, 100_000_000_000
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]map[string]interface{}{
+			{"Id": "aaaaaaaaaaaaaaaa", "Names": []string{"/web"}, "Image": "nginx:1.27", "State": "running"},
+			{"Id": "bbbbbbbbbbbbbbbb", "Names": []string{"/worker"}, "Image": "app:latest", "State": "restarting"},
+			{"Id": "cccccccccccccccc", "Names": []string{"/ignored"}, "Image": "busybox", "State": "exited"},
+		})
+	})
+	mux.HandleFunc("/containers/aaaaaaaaaaaaaaaa/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"RestartCount":0,"State":{"Status":"running","ExitCode":0,"Health":{"Status":"healthy"}}}`))
+	})
+	mux.HandleFunc("/containers/bbbbbbbbbbbbbbbb/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"RestartCount":5,"State":{"Status":"restarting","ExitCode":137}}`))
+	})
+	mux.HandleFunc("/containers/aaaaaaaaaaaaaaaa/stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("stream") != "false" {
+			t.Errorf("stats should not stream")
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"cpu_stats": map[string]interface{}{
+				"cpu_usage":        map[string]interface{}{"total_usage": cpuUsage},
+				"system_cpu_usage": systemUsage,
+				"online_cpus":      4,
+			},
+			"memory_stats": map[string]interface{}{
+				"usage": 300 << 20,
+				"limit": 1 << 30,
+				"stats": map[string]uint64{"inactive_file": 100 << 20},
+			},
+		})
+	})
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	c := NewContainerCollector(config.ContainersConfig{
+		Enabled:  true,
+		Endpoint: "unix://" + socketPath,
+		Include:  []string{"^(web|worker)$"},
+		Timeout:  5,
+	})
+
+	containers, err := c.Collect()
+	if err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	if len(containers) != 2 {
+		t.Fatalf("expected 2 containers, got %+v", containers)
+	}
+	web, worker := containers[0], containers[1]
+	if web.ID != "aaaaaaaaaaaa" || web.Name != "web" || web.Health != "healthy" ||
+		web.MemoryUsage != 200<<20 || web.MemoryLimit != 1<<30 || web.CPUPercent != 0 {
+		t.Fatalf("unexpected web container: %+v", web)
+	}
+	if worker.State != "restarting" || worker.Restarts != 5 || worker.ExitCode != 137 {
+		t.Fatalf("unexpected worker container: %+v", worker)
+	}
+
+	// 两次采集之间容器使用了 1 秒 CPU，系统总计 10 秒（4 核），即单核 40%
+	cpuUsage += 1_000_000_000
+	systemUsage += 10_000_000_000
+	containers, err = c.Collect()
+	if err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	if got := containers[0].CPUPercent; got < 39.99 || got > 40.01 {
+		t.Fatalf("unexpected cpu percent: %v", got)
+	}
+}
@@ -1,6 +1,7 @@
 package collector
 
 import (
+	"math"
 	"runtime"
 	"sync"
 	"time"
@@ -53,21 +54,55 @@ func (c *CPUCollector) init() {
 func (c *CPUCollector) Collect() (*protocol.CPUData, error) {
 	c.init()
 
-	// 获取 CPU 总体使用率
-	percentages, err := cpu.Percent(time.Second, false)
+	// 间隔1秒采集两次 CPU 时间，计算总体使用率及各类时间占比
+	first, err := cpu.Times(false)
 	if err != nil {
 		return nil, err
 	}
-
-	cpuPercent := 0.0
-	if len(percentages) > 0 {
-		cpuPercent = percentages[0]
+	time.Sleep(time.Second)
+	second, err := cpu.Times(false)
+	if err != nil {
+		return nil, err
 	}
 
-	return &protocol.CPUData{
+	data := &protocol.CPUData{
 		LogicalCores:  c.logicalCores,
 		PhysicalCores: c.physicalCores,
 		ModelName:     c.modelName,
-		UsagePercent:  cpuPercent,
-	}, nil
+	}
+	if len(first) > 0 && len(second) > 0 {
+		data.UsagePercent, data.Times = cpuTimesPercent(first[0], second[0])
+	}
+	return data, nil
+}
+
+// cpuTimesPercent 根据两次采集的 CPU 时间计算总体使用率及各类时间占比
+// 与 gopsutil 的 cpu.Percent 一致：Linux 上 guest 时间已计入 user，不重复统计；iowait 视为空闲
+func cpuTimesPercent(t1, t2 cpu.TimesStat) (float64, *protocol.CPUTimesData) {
+	all := func(t cpu.TimesStat) float64 {
+		total := t.User + t.System + t.Idle + t.Nice + t.Iowait + t.Irq + t.Softirq + t.Steal
+		if runtime.GOOS != "linux" {
+			total += t.Guest + t.GuestNice
+		}
+		return total
+	}
+
+	delta := all(t2) - all(t1)
+	if delta <= 0 {
+		return 0, nil
+	}
+	percent := func(a, b float64) float64 {
+		return math.Min(100, math.Max(0, (b-a)/delta*100))
+	}
+
+	times := &protocol.CPUTimesData{
+		User:    percent(t1.User+t1.Nice, t2.User+t2.Nice),
+		System:  percent(t1.System, t2.System),
+		IOWait:  percent(t1.Iowait, t2.Iowait),
+		IRQ:     percent(t1.Irq, t2.Irq),
+		SoftIRQ: percent(t1.Softirq, t2.Softirq),
+		Steal:   percent(t1.Steal, t2.Steal),
+	}
+	usage := 100 - percent(t1.Idle+t1.Iowait, t2.Idle+t2.Iowait)
+	return usage, times
 }
@@ -0,0 +1,205 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+	"github.com/dushixiang/pika/pkg/agent/custommetric"
+)
+
+// maxExporterBodySize 单次抓取的响应体上限
+const maxExporterBodySize = 32 << 20
+
+// exporterTargetLabels 由抓取目标设置的标签，exporter 输出中的同名标签会被重命名为 exported_<name>
+var exporterTargetLabels = []string{"job", "instance", "agent_id"}
+
+// exporterTarget 单个抓取目标
+type exporterTarget struct {
+	cfg        config.ExporterConfig
+	instance   string
+	include    []*regexp.Regexp
+	exclude    []*regexp.Regexp
+	lastScrape time.Time
+}
+
+// ExporterCollector Prometheus exporter 抓取采集器
+type ExporterCollector struct {
+	client  *http.Client
+	targets []*exporterTarget
+}
+
+// NewExporterCollector 创建 exporter 抓取采集器
+func NewExporterCollector(cfg *config.Config) *ExporterCollector {
+	c := &ExporterCollector{
+		client: &http.Client{},
+	}
+	for _, exporterCfg := range cfg.Collector.Exporters {
+		target := &exporterTarget{cfg: exporterCfg}
+		if u, err := url.Parse(exporterCfg.URL); err == nil {
+			target.instance = u.Host
+		}
+		// 正则已在加载配置时校验
+		for _, pattern := range exporterCfg.Include {
+			target.include = append(target.include, regexp.MustCompile(pattern))
+		}
+		for _, pattern := range exporterCfg.Exclude {
+			target.exclude = append(target.exclude, regexp.MustCompile(pattern))
+		}
+		c.targets = append(c.targets, target)
+	}
+	return c
+}
+
+// Collect 并发抓取所有到期的目标，每个目标额外输出 up 指标表示抓取是否成功
+func (c *ExporterCollector) Collect() []protocol.CustomMetricData {
+	now := time.Now()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var result []protocol.CustomMetricData
+	for _, target := range c.targets {
+		interval := time.Duration(target.cfg.Interval) * time.Second
+		if interval > 0 && now.Sub(target.lastScrape) < interval {
+			continue
+		}
+		target.lastScrape = now
+
+		wg.Add(1)
+		go func(target *exporterTarget) {
+			defer wg.Done()
+			metrics, err := c.scrape(target)
+			up := protocol.CustomMetricData{
+				Name:   "up",
+				Kind:   protocol.CustomMetricKindGauge,
+				Labels: target.labels(nil),
+				Value:  1,
+			}
+			if err != nil {
+				up.Value = 0
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			result = append(result, up)
+			result = append(result, metrics...)
+		}(target)
+	}
+	wg.Wait()
+
+	return result
+}
+
+// scrape 抓取单个目标并按名称过滤
+func (c *ExporterCollector) scrape(target *exporterTarget) ([]protocol.CustomMetricData, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(target.cfg.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.cfg.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxExporterBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	// 个别无法解析的行直接跳过
+	samples, err := custommetric.ParsePrometheus(body)
+	if len(samples) == 0 && err != nil {
+		return nil, err
+	}
+
+	metrics := make([]protocol.CustomMetricData, 0, len(samples))
+	for _, sample := range samples {
+		if len(metrics) >= target.cfg.MaxSamples {
+			break
+		}
+		// NaN/Inf 无法编码为 JSON，直接丢弃
+		if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) || !target.accept(sample.Name) {
+			continue
+		}
+		kind := protocol.CustomMetricKindGauge
+		if sample.IsCounter() {
+			kind = protocol.CustomMetricKindCounter
+		}
+		metrics = append(metrics, protocol.CustomMetricData{
+			Name:   sample.Name,
+			Kind:   kind,
+			Labels: target.labels(sample.Labels),
+			Value:  sample.Value,
+		})
+	}
+	return metrics, nil
+}
+
+// accept 检查指标名称是否通过白名单与黑名单
+func (t *exporterTarget) accept(name string) bool {
+	if len(t.include) > 0 {
+		matched := false
+		for _, re := range t.include {
+			if re.MatchString(name) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for _, re := range t.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// labels 合并样本标签与目标标签，目标标签优先，冲突的样本标签重命名为 exported_<name>
+func (t *exporterTarget) labels(sampleLabels map[string]string) map[string]string {
+	labels := make(map[string]string, len(sampleLabels)+len(t.cfg.Labels)+2)
+	for k, v := range sampleLabels {
+		labels[k] = v
+	}
+
+	targetLabels := make(map[string]string, len(t.cfg.Labels)+2)
+	for k, v := range t.cfg.Labels {
+		targetLabels[k] = v
+	}
+	targetLabels["job"] = t.cfg.Name
+	targetLabels["instance"] = t.instance
+
+	for _, name := range exporterTargetLabels {
+		if v, ok := labels[name]; ok {
+			labels["exported_"+name] = v
+			delete(labels, name)
+		}
+	}
+	for k, v := range targetLabels {
+		if existing, ok := labels[k]; ok && existing != v {
+			labels["exported_"+k] = existing
+		}
+		labels[k] = v
+	}
+	return labels
+}
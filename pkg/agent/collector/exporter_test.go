@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+func TestExporterCollector(t *testing.T) {
+	exporter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`# TYPE node_cpu_seconds_total counter
+node_cpu_seconds_total{cpu="0",mode="idle"} 1234.5
+node_load1 0.42
+go_goroutines{job="inner"} 12
+node_scrape_collector_success{collector="arp"} NaN
+`))
+	}))
+	defer exporter.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Collector.Exporters = []config.ExporterConfig{
+		{Name: "node", URL: exporter.URL + "/metrics", Include: []string{"^node_", "^go_"}, Exclude: []string{"^node_load"}, Labels: map[string]string{"env": "prod"}},
+		{Name: "down", URL: "http://127.0.0.1:1/metrics"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	metrics := NewExporterCollector(cfg).Collect()
+	byName := make(map[string][]protocol.CustomMetricData)
+	for _, m := range metrics {
+		byName[m.Name] = append(byName[m.Name], m)
+	}
+
+	if _, ok := byName["node_load1"]; ok {
+		t.Fatalf("excluded metric should be dropped")
+	}
+	if _, ok := byName["node_scrape_collector_success"]; ok {
+		t.Fatalf("NaN sample should be dropped")
+	}
+
+	cpu := byName["node_cpu_seconds_total"]
+	if len(cpu) != 1 || cpu[0].Kind != protocol.CustomMetricKindCounter || cpu[0].Value != 1234.5 {
+		t.Fatalf("unexpected cpu metric: %+v", cpu)
+	}
+	if cpu[0].Labels["job"] != "node" || cpu[0].Labels["env"] != "prod" || cpu[0].Labels["mode"] != "idle" {
+		t.Fatalf("unexpected labels: %v", cpu[0].Labels)
+	}
+
+	goroutines := byName["go_goroutines"]
+	if len(goroutines) != 1 || goroutines[0].Labels["exported_job"] != "inner" || goroutines[0].Labels["job"] != "node" {
+		t.Fatalf("conflicting job label should be renamed: %+v", goroutines)
+	}
+
+	up := make(map[string]float64)
+	for _, m := range byName["up"] {
+		up[m.Labels["job"]] = m.Value
+	}
+	if up["node"] != 1 || up["down"] != 0 {
+		t.Fatalf("unexpected up metrics: %v", up)
+	}
+}
@@ -0,0 +1,437 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+const (
+	// smartctlTimeout 单块磁盘读取 SMART 的超时时间，休眠的磁盘通过 -n standby 跳过，不会被唤醒
+	smartctlTimeout = 10 * time.Second
+	// maxSmartDisks 最多读取的磁盘数量
+	maxSmartDisks = 64
+)
+
+var (
+	// mdstatDevicesPattern 匹配 "[2/1]" 形式的成员设备数与正常设备数
+	mdstatDevicesPattern = regexp.MustCompile(`\[(\d+)/(\d+)\]`)
+	// mdstatSyncPattern 匹配 "recovery = 12.6%" 形式的同步进度
+	mdstatSyncPattern = regexp.MustCompile(`(recovery|resync|reshape|check|repair)\s*=\s*([\d.]+)%`)
+	// mdstatPendingPattern 匹配 "resync=DELAYED" 形式的等待中的同步
+	mdstatPendingPattern = regexp.MustCompile(`(recovery|resync|reshape|check|repair)\s*=\s*(DELAYED|PENDING)`)
+)
+
+// HardwareCollector 硬件健康采集器，读取磁盘 SMART、mdadm/ZFS 阵列状态与 EDAC 内存 ECC 错误计数
+// smartctl 读取较慢，按配置的间隔在后台刷新，Collect 只返回最近一次的结果，不阻塞采集周期
+type HardwareCollector struct {
+	procRoot string
+	sysRoot  string
+	interval time.Duration
+
+	mu         sync.Mutex
+	refreshing bool
+	lastAt     time.Time
+	last       *protocol.HardwareData
+}
+
+// NewHardwareCollector 创建硬件健康采集器，未启用时返回 nil
+func NewHardwareCollector(cfg *config.Config) *HardwareCollector {
+	if !cfg.Collector.Hardware.Enabled {
+		return nil
+	}
+	return &HardwareCollector{
+		procRoot: "/proc",
+		sysRoot:  "/sys",
+		interval: time.Duration(cfg.Collector.Hardware.Interval) * time.Second,
+	}
+}
+
+// Collect 返回最近一次的硬件健康数据，到达刷新间隔时在后台重新采集
+// 非 Linux 系统、首次采集尚未完成或没有任何可用数据时返回 nil
+func (h *HardwareCollector) Collect() (*protocol.HardwareData, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.refreshing && (h.lastAt.IsZero() || time.Since(h.lastAt) >= h.interval) {
+		h.refreshing = true
+		go h.refresh(h.last)
+	}
+	return h.last, nil
+}
+
+// refresh 重新采集硬件健康数据并更新缓存
+func (h *HardwareCollector) refresh(previous *protocol.HardwareData) {
+	var previousDisks []protocol.SmartDiskData
+	if previous != nil {
+		previousDisks = previous.Disks
+	}
+	data := &protocol.HardwareData{
+		Disks: h.collectSmart(previousDisks),
+		ECC:   h.collectEDAC(),
+	}
+	data.Arrays = parseMdstat(h.readFile("mdstat"))
+	data.Arrays = append(data.Arrays, h.collectZpools()...)
+	if len(data.Disks) == 0 && len(data.Arrays) == 0 && len(data.ECC) == 0 {
+		data = nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.refreshing = false
+	h.lastAt = time.Now()
+	h.last = data
+}
+
+// collectSmart 通过 smartctl 扫描并读取所有磁盘的 SMART 信息，未安装 smartmontools 时返回 nil
+// 处于休眠状态的磁盘不会被唤醒，沿用上一次读取到的结果
+func (h *HardwareCollector) collectSmart(previous []protocol.SmartDiskData) []protocol.SmartDiskData {
+	if _, err := exec.LookPath("smartctl"); err != nil {
+		return nil
+	}
+
+	output, err := runHardwareCommand("smartctl", "--scan", "-j")
+	if err != nil && len(output) == 0 {
+		slog.Debug("扫描 SMART 设备失败", "error", err)
+		return nil
+	}
+	devices, err := parseSmartScan(output)
+	if err != nil {
+		slog.Debug("解析 SMART 设备列表失败", "error", err)
+		return nil
+	}
+	if len(devices) > maxSmartDisks {
+		devices = devices[:maxSmartDisks]
+	}
+
+	previousByDevice := make(map[string]protocol.SmartDiskData, len(previous))
+	for _, disk := range previous {
+		previousByDevice[disk.Device] = disk
+	}
+
+	var disks []protocol.SmartDiskData
+	for _, device := range devices {
+		disk, ok := readSmart(device)
+		if !ok {
+			disk, ok = previousByDevice[device.Name]
+		}
+		if ok {
+			disks = append(disks, disk)
+		}
+	}
+	return disks
+}
+
+// readSmart 读取单块磁盘的 SMART 信息，磁盘休眠、无法打开或不支持 SMART 时返回 false
+func readSmart(device smartScanDevice) (protocol.SmartDiskData, bool) {
+	// -n standby：磁盘处于休眠状态时不读取，避免唤醒磁盘
+	args := []string{"-j", "-a", "-n", "standby"}
+	if device.Type != "" {
+		args = append(args, "-d", device.Type)
+	}
+	args = append(args, device.Name)
+	// smartctl 的退出码是状态位掩码，磁盘存在问题时同样非 0，只要输出了 JSON 就继续解析
+	output, err := runHardwareCommand("smartctl", args...)
+	if len(output) == 0 {
+		slog.Debug("读取 SMART 信息失败", "device", device.Name, "error", err)
+		return protocol.SmartDiskData{}, false
+	}
+	disk, ok, err := parseSmartctl(output)
+	if err != nil {
+		slog.Debug("解析 SMART 信息失败", "device", device.Name, "error", err)
+		return protocol.SmartDiskData{}, false
+	}
+	return disk, ok
+}
+
+// collectZpools 读取 ZFS 存储池状态，未安装 ZFS 时返回 nil
+func (h *HardwareCollector) collectZpools() []protocol.RaidArrayData {
+	if _, err := exec.LookPath("zpool"); err != nil {
+		return nil
+	}
+	output, err := runHardwareCommand("zpool", "list", "-H", "-o", "name,health")
+	if err != nil {
+		slog.Debug("读取 ZFS 存储池状态失败", "error", err)
+		return nil
+	}
+	return parseZpoolList(string(output))
+}
+
+// collectEDAC 读取 EDAC 内存控制器的 ECC 错误计数，未加载 EDAC 驱动（非 ECC 内存或虚拟机）时返回 nil
+func (h *HardwareCollector) collectEDAC() []protocol.EDACData {
+	dirs, err := filepath.Glob(filepath.Join(h.sysRoot, "devices/system/edac/mc/mc*"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(dirs)
+
+	var result []protocol.EDACData
+	for _, dir := range dirs {
+		ce, ceErr := readUintFile(filepath.Join(dir, "ce_count"))
+		ue, ueErr := readUintFile(filepath.Join(dir, "ue_count"))
+		if ceErr != nil && ueErr != nil {
+			continue
+		}
+		result = append(result, protocol.EDACData{
+			Controller:        filepath.Base(dir),
+			CorrectedErrors:   ce,
+			UncorrectedErrors: ue,
+		})
+	}
+	return result
+}
+
+// readFile 读取 procRoot 下的文件，失败时返回空字符串
+func (h *HardwareCollector) readFile(name string) string {
+	data, err := os.ReadFile(filepath.Join(h.procRoot, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// runHardwareCommand 执行外部命令并返回标准输出
+func runHardwareCommand(name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), smartctlTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, name, args...).Output()
+}
+
+// readUintFile 读取只包含一个整数的文件
+func readUintFile(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
+
+// smartScanDevice smartctl --scan -j 输出中的设备
+type smartScanDevice struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// parseSmartScan 解析 smartctl --scan -j 的输出
+func parseSmartScan(output []byte) ([]smartScanDevice, error) {
+	var scan struct {
+		Devices []smartScanDevice `json:"devices"`
+	}
+	if err := json.Unmarshal(output, &scan); err != nil {
+		return nil, err
+	}
+	return scan.Devices, nil
+}
+
+// smartctlOutput smartctl -j -a 输出中用到的字段
+type smartctlOutput struct {
+	Smartctl struct {
+		ExitStatus int `json:"exit_status"`
+	} `json:"smartctl"`
+	Device struct {
+		Name     string `json:"name"`
+		Protocol string `json:"protocol"`
+	} `json:"device"`
+	ModelName    string `json:"model_name"`
+	ScsiModel    string `json:"scsi_model_name"`
+	SerialNumber string `json:"serial_number"`
+	SmartStatus  *struct {
+		Passed bool `json:"passed"`
+	} `json:"smart_status"`
+	Temperature struct {
+		Current float64 `json:"current"`
+	} `json:"temperature"`
+	PowerOnTime struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	AtaSmartAttributes struct {
+		Table []struct {
+			ID    int `json:"id"`
+			Value int `json:"value"`
+			Raw   struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+	EnduranceUsed *struct {
+		CurrentPercent float64 `json:"current_percent"`
+	} `json:"endurance_used"`
+	NvmeLog *struct {
+		CriticalWarning int     `json:"critical_warning"`
+		PercentageUsed  float64 `json:"percentage_used"`
+		MediaErrors     uint64  `json:"media_errors"`
+	} `json:"nvme_smart_health_information_log"`
+	ScsiGrownDefectList *uint64 `json:"scsi_grown_defect_list"`
+}
+
+// parseSmartctl 解析 smartctl -j -a 的输出，设备无法打开或不支持 SMART 时返回 false
+func parseSmartctl(output []byte) (protocol.SmartDiskData, bool, error) {
+	var out smartctlOutput
+	if err := json.Unmarshal(output, &out); err != nil {
+		return protocol.SmartDiskData{}, false, err
+	}
+	// 位 0：命令行解析失败，位 1：设备打开失败
+	if out.Smartctl.ExitStatus&0x3 != 0 || out.SmartStatus == nil {
+		return protocol.SmartDiskData{}, false, nil
+	}
+
+	disk := protocol.SmartDiskData{
+		Device:       out.Device.Name,
+		Model:        out.ModelName,
+		Serial:       out.SerialNumber,
+		Protocol:     out.Device.Protocol,
+		Passed:       out.SmartStatus.Passed,
+		Temperature:  out.Temperature.Current,
+		PowerOnHours: out.PowerOnTime.Hours,
+	}
+	if disk.Model == "" {
+		disk.Model = out.ScsiModel
+	}
+
+	// ATA 属性：5 重映射扇区，197 待映射扇区，198 离线无法校正扇区
+	// SSD 寿命属性的标准化值表示剩余寿命百分比：177 Wear_Leveling_Count（三星）、202 Percent_Lifetime_Remain（英睿达）、
+	// 231 SSD_Life_Left、233 Media_Wearout_Indicator（英特尔）
+	var remaining *int
+	for _, attr := range out.AtaSmartAttributes.Table {
+		switch attr.ID {
+		case 5:
+			disk.ReallocatedSectors = attr.Raw.Value
+		case 197:
+			disk.PendingSectors = attr.Raw.Value
+		case 198:
+			disk.OfflineUncorrectable = attr.Raw.Value
+		case 177, 202, 231, 233:
+			if remaining == nil && attr.Value >= 0 && attr.Value <= 100 {
+				value := attr.Value
+				remaining = &value
+			}
+		}
+	}
+	switch {
+	case out.NvmeLog != nil:
+		disk.MediaErrors = out.NvmeLog.MediaErrors
+		disk.CriticalWarning = out.NvmeLog.CriticalWarning
+		wear := out.NvmeLog.PercentageUsed
+		disk.WearPercent = &wear
+	case out.EnduranceUsed != nil:
+		// 新版 smartctl 从 ATA 设备统计中读取已用寿命
+		wear := out.EnduranceUsed.CurrentPercent
+		disk.WearPercent = &wear
+	case remaining != nil:
+		wear := float64(100 - *remaining)
+		disk.WearPercent = &wear
+	}
+	if out.ScsiGrownDefectList != nil {
+		disk.ReallocatedSectors = *out.ScsiGrownDefectList
+	}
+	return disk, true, nil
+}
+
+// parseMdstat 解析 /proc/mdstat 中的软 RAID 阵列状态，格式如下:
+//
+//	md1 : active raid5 sdd1[3](F) sdc1[1] sde1[0]
+//	      3906764800 blocks super 1.2 level 5, 512k chunk, algorithm 2 [3/2] [UU_]
+//	      [==>..................]  recovery = 12.6% (246400000/1953382400) finish=120.5min speed=236000K/sec
+func parseMdstat(content string) []protocol.RaidArrayData {
+	var arrays []protocol.RaidArrayData
+	var current *protocol.RaidArrayData
+	var hasCounts bool
+	var members, spares int
+
+	finish := func() {
+		if current == nil {
+			return
+		}
+		// raid0、linear 等没有冗余的阵列不输出 [n/m]，按成员设备计数
+		if !hasCounts {
+			current.Devices = members - spares
+			current.ActiveDevices = current.Devices - current.FailedDevices
+		}
+		current.Degraded = current.State == "inactive" || current.FailedDevices > 0 ||
+			current.ActiveDevices < current.Devices
+		arrays = append(arrays, *current)
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && fields[1] == ":" && strings.HasPrefix(fields[0], "md") {
+			finish()
+			current = &protocol.RaidArrayData{Name: fields[0], Type: "mdadm", State: fields[2]}
+			hasCounts, members, spares = false, 0, 0
+			for _, field := range fields[3:] {
+				switch {
+				case strings.HasPrefix(field, "("):
+					// (auto-read-only)、(read-only)
+				case strings.HasPrefix(field, "raid"), field == "linear", field == "multipath":
+					current.Level = field
+				case strings.Contains(field, "["):
+					members++
+					if strings.HasSuffix(field, "(F)") {
+						current.FailedDevices++
+					} else if strings.HasSuffix(field, "(S)") {
+						spares++
+					}
+				}
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			finish()
+			continue
+		}
+		if m := mdstatDevicesPattern.FindStringSubmatch(line); m != nil && !hasCounts {
+			current.Devices, _ = strconv.Atoi(m[1])
+			current.ActiveDevices, _ = strconv.Atoi(m[2])
+			hasCounts = true
+		}
+		if m := mdstatSyncPattern.FindStringSubmatch(line); m != nil {
+			current.SyncAction = m[1]
+			current.SyncProgress, _ = strconv.ParseFloat(m[2], 64)
+		} else if m := mdstatPendingPattern.FindStringSubmatch(line); m != nil {
+			current.SyncAction = m[1]
+		}
+	}
+	finish()
+	return arrays
+}
+
+// parseZpoolList 解析 zpool list -H -o name,health 的输出，健康状态不是 ONLINE 的存储池视为降级
+func parseZpoolList(output string) []protocol.RaidArrayData {
+	var pools []protocol.RaidArrayData
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		pools = append(pools, protocol.RaidArrayData{
+			Name:     fields[0],
+			Type:     "zfs",
+			State:    fields[1],
+			Degraded: fields[1] != "ONLINE",
+		})
+	}
+	return pools
+}
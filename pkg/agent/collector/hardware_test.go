@@ -0,0 +1,87 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func TestParseSmartctl(t *testing.T) {
+	output, err := os.ReadFile(filepath.Join("testdata", "smartctl_ata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk, ok, err := parseSmartctl(output)
+	if err != nil || !ok {
+		t.Fatalf("parse ata failed: ok=%v err=%v", ok, err)
+	}
+	if disk.Device != "/dev/sda" || disk.Protocol != "ATA" || !disk.Passed || disk.Temperature != 34 || disk.PowerOnHours != 35120 ||
+		disk.ReallocatedSectors != 12 || disk.PendingSectors != 2 || disk.OfflineUncorrectable != 1 {
+		t.Fatalf("unexpected ata disk: %+v", disk)
+	}
+	if disk.WearPercent == nil || *disk.WearPercent != 13 {
+		t.Fatalf("unexpected ata wear: %v", disk.WearPercent)
+	}
+
+	output, err = os.ReadFile(filepath.Join("testdata", "smartctl_nvme.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk, ok, err = parseSmartctl(output)
+	if err != nil || !ok {
+		t.Fatalf("parse nvme failed: ok=%v err=%v", ok, err)
+	}
+	if disk.Passed || disk.CriticalWarning != 4 || disk.MediaErrors != 3 || disk.WearPercent == nil || *disk.WearPercent != 93 {
+		t.Fatalf("unexpected nvme disk: %+v", disk)
+	}
+
+	// 设备打开失败时只有 smartctl 段
+	if _, ok, err := parseSmartctl([]byte(`{"smartctl":{"exit_status":2}}`)); err != nil || ok {
+		t.Fatalf("expected open failure to be skipped: ok=%v err=%v", ok, err)
+	}
+}
+
+func TestParseMdstat(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join("testdata", "mdstat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	arrays := parseMdstat(string(content))
+	want := []protocol.RaidArrayData{
+		{Name: "md0", Type: "mdadm", Level: "raid1", State: "active", Devices: 2, ActiveDevices: 2},
+		{Name: "md1", Type: "mdadm", Level: "raid5", State: "active", Degraded: true, Devices: 3, ActiveDevices: 2, FailedDevices: 1, SyncAction: "recovery", SyncProgress: 12.6},
+		{Name: "md2", Type: "mdadm", Level: "raid0", State: "active", Devices: 2, ActiveDevices: 2},
+		{Name: "md3", Type: "mdadm", State: "inactive", Degraded: true},
+	}
+	if len(arrays) != len(want) {
+		t.Fatalf("unexpected arrays: %+v", arrays)
+	}
+	for i := range want {
+		if arrays[i] != want[i] {
+			t.Fatalf("array %d: got %+v, want %+v", i, arrays[i], want[i])
+		}
+	}
+
+	pools := parseZpoolList("tank\tONLINE\nbackup\tDEGRADED\n")
+	if len(pools) != 2 || pools[0].Degraded || !pools[1].Degraded || pools[1].Type != "zfs" {
+		t.Fatalf("unexpected pools: %+v", pools)
+	}
+}
+
+func TestHardwareCollectorEDAC(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "devices/system/edac/mc/mc0")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "ce_count"), []byte("17\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "ue_count"), []byte("0\n"), 0644)
+
+	h := &HardwareCollector{sysRoot: root}
+	ecc := h.collectEDAC()
+	if len(ecc) != 1 || ecc[0] != (protocol.EDACData{Controller: "mc0", CorrectedErrors: 17}) {
+		t.Fatalf("unexpected ecc: %+v", ecc)
+	}
+}
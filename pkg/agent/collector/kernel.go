@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// KernelCollector 内核统计采集器，读取 /proc 下的 PSI、上下文切换、文件句柄、熵、conntrack 与 OOM 统计
+type KernelCollector struct {
+	procRoot string
+
+	// 上一次采集的累计值，用于计算速率
+	lastAt         time.Time
+	lastCtxt       uint64
+	lastInterrupts uint64
+}
+
+// NewKernelCollector 创建内核统计采集器
+func NewKernelCollector() *KernelCollector {
+	return &KernelCollector{procRoot: "/proc"}
+}
+
+// Collect 采集内核统计数据，非 Linux 系统返回 nil
+// 上下文切换与中断速率根据与上一次采集的差值计算，首次采集时为 0
+func (k *KernelCollector) Collect() (*protocol.KernelData, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	ctxt, interrupts, err := k.readStat()
+	if err != nil {
+		return nil, err
+	}
+
+	data := &protocol.KernelData{}
+	now := time.Now()
+	if !k.lastAt.IsZero() {
+		if elapsed := now.Sub(k.lastAt).Seconds(); elapsed > 0 {
+			data.ContextSwitchesRate = float64(safeDelta(ctxt, k.lastCtxt)) / elapsed
+			data.InterruptsRate = float64(safeDelta(interrupts, k.lastInterrupts)) / elapsed
+		}
+	}
+	k.lastAt, k.lastCtxt, k.lastInterrupts = now, ctxt, interrupts
+
+	data.Pressure = k.readPressure()
+
+	// file-nr 格式: 已分配 已分配未使用 上限
+	if fields := strings.Fields(k.readFile("sys/fs/file-nr")); len(fields) == 3 {
+		data.FileHandlesAllocated, _ = strconv.ParseUint(fields[0], 10, 64)
+		data.FileHandlesMax, _ = strconv.ParseUint(fields[2], 10, 64)
+	}
+	data.EntropyAvailable = k.readUint("sys/kernel/random/entropy_avail")
+	data.ConntrackCount = k.readUint("sys/net/netfilter/nf_conntrack_count")
+	data.ConntrackMax = k.readUint("sys/net/netfilter/nf_conntrack_max")
+
+	// oom_kill 计数自 Linux 4.13 起提供
+	for _, line := range strings.Split(k.readFile("vmstat"), "\n") {
+		if value, ok := strings.CutPrefix(line, "oom_kill "); ok {
+			data.OOMKills, _ = strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+			break
+		}
+	}
+
+	return data, nil
+}
+
+// readStat 读取 /proc/stat 中的上下文切换与中断累计次数
+func (k *KernelCollector) readStat() (ctxt, interrupts uint64, err error) {
+	file, err := os.Open(filepath.Join(k.procRoot, "stat"))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	// intr 行包含每个中断号的计数，可能很长
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "ctxt":
+			ctxt, _ = strconv.ParseUint(fields[1], 10, 64)
+		case "intr":
+			interrupts, _ = strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	return ctxt, interrupts, scanner.Err()
+}
+
+// readPressure 读取 PSI 压力，内核不支持时返回 nil
+func (k *KernelCollector) readPressure() *protocol.PressureData {
+	cpuSome, _, ok := k.readPressureFile("cpu")
+	if !ok {
+		return nil
+	}
+	memorySome, memoryFull, _ := k.readPressureFile("memory")
+	ioSome, ioFull, _ := k.readPressureFile("io")
+	return &protocol.PressureData{
+		CPUSome:    cpuSome,
+		MemorySome: memorySome,
+		MemoryFull: memoryFull,
+		IOSome:     ioSome,
+		IOFull:     ioFull,
+	}
+}
+
+// readPressureFile 解析 /proc/pressure/<resource> 中 some 与 full 行的 avg10
+// 格式: some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func (k *KernelCollector) readPressureFile(resource string) (some, full float64, ok bool) {
+	content := k.readFile(filepath.Join("pressure", resource))
+	if content == "" {
+		return 0, 0, false
+	}
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		value, found := strings.CutPrefix(fields[1], "avg10=")
+		if !found {
+			continue
+		}
+		avg10, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "some":
+			some, ok = avg10, true
+		case "full":
+			full = avg10
+		}
+	}
+	return some, full, ok
+}
+
+// readUint 读取只包含一个整数的文件，不存在时返回 0
+func (k *KernelCollector) readUint(name string) uint64 {
+	value, _ := strconv.ParseUint(strings.TrimSpace(k.readFile(name)), 10, 64)
+	return value
+}
+
+// readFile 读取 procRoot 下的文件，失败时返回空字符串
+func (k *KernelCollector) readFile(name string) string {
+	data, err := os.ReadFile(filepath.Join(k.procRoot, name))
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
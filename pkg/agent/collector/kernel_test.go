@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+func TestKernelCollector(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("kernel stats are linux only")
+	}
+
+	root := t.TempDir()
+	files := map[string]string{
+		"stat":                                 "cpu  1 2 3 4\nintr 1000 1 2 3\nctxt 5000\nbtime 1\n",
+		"pressure/cpu":                         "some avg10=12.50 avg60=3.00 avg300=1.00 total=100\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		"pressure/memory":                      "some avg10=1.25 avg60=0.00 avg300=0.00 total=1\nfull avg10=0.50 avg60=0.00 avg300=0.00 total=1\n",
+		"pressure/io":                          "some avg10=30.00 avg60=0.00 avg300=0.00 total=1\nfull avg10=20.00 avg60=0.00 avg300=0.00 total=1\n",
+		"sys/fs/file-nr":                       "2048\t0\t100000\n",
+		"sys/kernel/random/entropy_avail":      "256\n",
+		"sys/net/netfilter/nf_conntrack_count": "900\n",
+		"sys/net/netfilter/nf_conntrack_max":   "1000\n",
+		"vmstat":                               "nr_free_pages 1\noom_kill 3\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	k := &KernelCollector{procRoot: root}
+	data, err := k.Collect()
+	if err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	if data.ContextSwitchesRate != 0 || data.FileHandlesAllocated != 2048 || data.FileHandlesMax != 100000 ||
+		data.EntropyAvailable != 256 || data.ConntrackCount != 900 || data.ConntrackMax != 1000 || data.OOMKills != 3 {
+		t.Fatalf("unexpected kernel data: %+v", data)
+	}
+	p := data.Pressure
+	if p == nil || p.CPUSome != 12.5 || p.MemorySome != 1.25 || p.MemoryFull != 0.5 || p.IOSome != 30 || p.IOFull != 20 {
+		t.Fatalf("unexpected pressure: %+v", p)
+	}
+
+	// 第二次采集计算速率
+	os.WriteFile(filepath.Join(root, "stat"), []byte("intr 3000 1\nctxt 9000\n"), 0644)
+	k.lastAt = k.lastAt.Add(-2e9)
+	data, err = k.Collect()
+	if err != nil {
+		t.Fatalf("collect failed: %v", err)
+	}
+	if data.ContextSwitchesRate < 1900 || data.ContextSwitchesRate > 2000 || data.InterruptsRate < 950 || data.InterruptsRate > 1000 {
+		t.Fatalf("unexpected rates: %+v", data)
+	}
+}
+
+func TestCPUTimesPercent(t *testing.T) {
+	t1 := cpu.TimesStat{User: 100, System: 50, Idle: 800, Iowait: 10, Steal: 40}
+	t2 := cpu.TimesStat{User: 120, System: 60, Idle: 850, Iowait: 20, Steal: 50}
+	usage, times := cpuTimesPercent(t1, t2)
+	// 总计 100，其中 idle+iowait 60
+	if usage != 40 || times.User != 20 || times.System != 10 || times.IOWait != 10 || times.Steal != 10 {
+		t.Fatalf("unexpected cpu times: usage=%v %+v", usage, times)
+	}
+}
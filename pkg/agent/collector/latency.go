@@ -0,0 +1,69 @@
+package collector
+
+import (
+	"math"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// rttStats 计算 RTT 的最小值、平均值、最大值与标准差（毫秒）
+func rttStats(rtts []float64) (minRtt, avgRtt, maxRtt, stdDev float64) {
+	if len(rtts) == 0 {
+		return 0, 0, 0, 0
+	}
+
+	minRtt, maxRtt = rtts[0], rtts[0]
+	var sum float64
+	for _, rtt := range rtts {
+		sum += rtt
+		minRtt = math.Min(minRtt, rtt)
+		maxRtt = math.Max(maxRtt, rtt)
+	}
+	avgRtt = sum / float64(len(rtts))
+
+	var variance float64
+	for _, rtt := range rtts {
+		variance += (rtt - avgRtt) * (rtt - avgRtt)
+	}
+	stdDev = math.Sqrt(variance / float64(len(rtts)))
+
+	return roundFloat(minRtt), roundFloat(avgRtt), roundFloat(maxRtt), roundFloat(stdDev)
+}
+
+// roundFloat 保留两位小数
+func roundFloat(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// rttJitter 计算抖动：相邻两次 RTT 差值绝对值的平均（毫秒）
+func rttJitter(rtts []float64) float64 {
+	if len(rtts) < 2 {
+		return 0
+	}
+	var sum float64
+	for i := 1; i < len(rtts); i++ {
+		sum += math.Abs(rtts[i] - rtts[i-1])
+	}
+	return roundFloat(sum / float64(len(rtts)-1))
+}
+
+// durationsToMillis 将 RTT 转换为毫秒
+func durationsToMillis(durations []time.Duration) []float64 {
+	result := make([]float64, 0, len(durations))
+	for _, d := range durations {
+		result = append(result, float64(d.Microseconds())/1000)
+	}
+	return result
+}
+
+// applyLatencyStats 将多次探测的统计结果写入监控结果
+func applyLatencyStats(result *protocol.MonitorData, sent int, rtts []float64) {
+	result.PacketsSent = sent
+	result.PacketsRecv = len(rtts)
+	if sent > 0 {
+		result.PacketLoss = roundFloat(float64(sent-len(rtts)) / float64(sent) * 100)
+	}
+	result.MinRtt, result.AvgRtt, result.MaxRtt, result.StdDevRtt = rttStats(rtts)
+	result.Jitter = rttJitter(rtts)
+}
@@ -0,0 +1,57 @@
+package collector
+
+import (
+	"net"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func TestApplyLatencyStats(t *testing.T) {
+	var result protocol.MonitorData
+	applyLatencyStats(&result, 5, []float64{10, 12, 11, 15})
+
+	if result.PacketsSent != 5 || result.PacketsRecv != 4 {
+		t.Fatalf("unexpected packet counts: %d/%d", result.PacketsRecv, result.PacketsSent)
+	}
+	if result.PacketLoss != 20 {
+		t.Fatalf("expected 20%% loss, got %.2f", result.PacketLoss)
+	}
+	if result.MinRtt != 10 || result.MaxRtt != 15 || result.AvgRtt != 12 {
+		t.Fatalf("unexpected rtt stats: min=%.2f avg=%.2f max=%.2f", result.MinRtt, result.AvgRtt, result.MaxRtt)
+	}
+	// |12-10| + |11-12| + |15-11| = 7，平均 2.33
+	if result.Jitter != 2.33 {
+		t.Fatalf("expected jitter 2.33, got %.2f", result.Jitter)
+	}
+}
+
+func TestCheckTCPMulti(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	c := NewMonitorCollector()
+	result := c.checkTCP(protocol.MonitorItem{
+		Type:      "tcp",
+		Target:    ln.Addr().String(),
+		TCPConfig: &protocol.TCPMonitorConfig{Timeout: 2, Count: 3},
+	})
+	if result.Status != "up" {
+		t.Fatalf("expected up, got %s: %s", result.Status, result.Error)
+	}
+	if result.PacketsSent != 3 || result.PacketsRecv != 3 || result.PacketLoss != 0 {
+		t.Fatalf("unexpected stats: %+v", result)
+	}
+}
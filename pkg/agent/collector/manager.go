@@ -25,10 +25,19 @@ type Manager struct {
 	gpuCollector               *GPUCollector
 	monitorCollector           *MonitorCollector
 	ddnsCollector              *DDNSCollector
+	exporterCollector          *ExporterCollector
+	processCollector           *ProcessCollector
+	serviceCollector           *ServiceCollector
+	kernelCollector            *KernelCollector
+	hardwareCollector          *HardwareCollector
 }
 
 // NewManager 创建采集器管理器
 func NewManager(cfg *config.Config) *Manager {
+	var processCollector *ProcessCollector
+	if cfg.Collector.Process.Enabled {
+		processCollector = NewProcessCollector(cfg)
+	}
 	return &Manager{
 		cpuCollector:               NewCPUCollector(),
 		memoryCollector:            NewMemoryCollector(),
@@ -41,6 +50,11 @@ func NewManager(cfg *config.Config) *Manager {
 		gpuCollector:               NewGPUCollector(),
 		monitorCollector:           NewMonitorCollector(),
 		ddnsCollector:              nil, // DDNS 采集器需要配置后才能初始化
+		exporterCollector:          NewExporterCollector(cfg),
+		processCollector:           processCollector,
+		serviceCollector:           NewServiceCollector(cfg),
+		kernelCollector:            NewKernelCollector(),
+		hardwareCollector:          NewHardwareCollector(cfg),
 	}
 }
 
@@ -138,6 +152,83 @@ func (m *Manager) CollectAndSendMonitor(conn WebSocketWriter, items []protocol.M
 	return m.sendMetrics(conn, protocol.MetricTypeMonitor, monitorDataList)
 }
 
+// CollectAndSendExporter 抓取并发送 Prometheus exporter 指标
+func (m *Manager) CollectAndSendExporter(conn WebSocketWriter) error {
+	exporterDataList := m.exporterCollector.Collect()
+	if len(exporterDataList) == 0 {
+		return nil
+	}
+	return m.sendMetrics(conn, protocol.MetricTypeExporter, exporterDataList)
+}
+
+// CollectAndSendKernel 采集并发送内核统计
+func (m *Manager) CollectAndSendKernel(conn WebSocketWriter) error {
+	kernelData, err := m.kernelCollector.Collect()
+	if err != nil || kernelData == nil {
+		// 内核统计不是必须的，非 Linux 系统无数据
+		return err
+	}
+	return m.sendMetrics(conn, protocol.MetricTypeKernel, kernelData)
+}
+
+// CollectAndSendHardware 采集并发送硬件健康数据
+func (m *Manager) CollectAndSendHardware(conn WebSocketWriter) error {
+	if m.hardwareCollector == nil {
+		return nil // 硬件健康采集未启用
+	}
+	hardwareData, err := m.hardwareCollector.Collect()
+	if err != nil || hardwareData == nil {
+		// 非 Linux 系统或没有可用的硬件健康数据
+		return err
+	}
+	return m.sendMetrics(conn, protocol.MetricTypeHardware, hardwareData)
+}
+
+// CollectAndSendProcess 采集并发送进程指标
+func (m *Manager) CollectAndSendProcess(conn WebSocketWriter) error {
+	if m.processCollector == nil {
+		return nil // 进程采集未启用
+	}
+	processData, err := m.processCollector.Collect()
+	if err != nil {
+		return err
+	}
+	return m.sendMetrics(conn, protocol.MetricTypeProcess, processData)
+}
+
+// CollectAndSendService 采集并发送 systemd 单元与容器状态
+func (m *Manager) CollectAndSendService(conn WebSocketWriter) error {
+	if m.serviceCollector == nil {
+		return nil // 未配置 systemd 单元或容器采集
+	}
+	serviceData, err := m.serviceCollector.Collect()
+	if err != nil {
+		return err
+	}
+	if serviceData == nil {
+		return nil
+	}
+	return m.sendMetrics(conn, protocol.MetricTypeService, serviceData)
+}
+
+// SendCustom 发送自定义指标
+func (m *Manager) SendCustom(conn WebSocketWriter, customDataList []protocol.CustomMetricData) error {
+	if len(customDataList) == 0 {
+		return nil
+	}
+	return m.sendMetrics(conn, protocol.MetricTypeCustom, customDataList)
+}
+
+// SendBufferStats 发送离线指标缓存状态
+func (m *Manager) SendBufferStats(conn WebSocketWriter, stats protocol.MetricsBufferStats) error {
+	return m.sendMetrics(conn, protocol.MetricTypeAgentBuffer, &stats)
+}
+
+// SendAgentHealth 发送探针自身运行状态
+func (m *Manager) SendAgentHealth(conn WebSocketWriter, health protocol.AgentHealth) error {
+	return m.sendMetrics(conn, protocol.MetricTypeAgentHealth, &health)
+}
+
 // UpdateDDNSConfig 更新 DDNS 配置
 func (m *Manager) UpdateDDNSConfig(config *protocol.DDNSConfigData) {
 	if config == nil || !config.Enabled {
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"strings"
@@ -62,6 +63,22 @@ func (c *MonitorCollector) Collect(items []protocol.MonitorItem) []protocol.Moni
 			result = c.checkTCP(item)
 		case "icmp", "ping":
 			result = c.checkICMP(item)
+		case "udp":
+			result = c.checkUDP(item)
+		case "smtp":
+			result = c.checkSMTP(item)
+		case "ssh":
+			result = c.checkSSH(item)
+		case "redis":
+			result = c.checkRedis(item)
+		case "mysql":
+			result = c.checkMySQL(item)
+		case "postgresql":
+			result = c.checkPostgreSQL(item)
+		case "mqtt":
+			result = c.checkMQTT(item)
+		case "traceroute":
+			result = c.checkTraceroute(item)
 		default:
 			result = protocol.MonitorData{
 				MonitorId: item.ID,
@@ -217,6 +234,11 @@ func (c *MonitorCollector) checkTCP(item protocol.MonitorItem) protocol.MonitorD
 		timeout = tcpCfg.Timeout
 	}
 
+	// 多次尝试模式：统计连接成功率与延迟分布
+	if tcpCfg != nil && tcpCfg.Count > 1 {
+		return c.checkTCPMulti(item, result, tcpCfg.Count, time.Duration(timeout)*time.Second)
+	}
+
 	// 连接并计时
 	startTime := time.Now()
 	conn, err := net.DialTimeout("tcp", item.Target, time.Duration(timeout)*time.Second)
@@ -236,6 +258,40 @@ func (c *MonitorCollector) checkTCP(item protocol.MonitorItem) protocol.MonitorD
 	return result
 }
 
+// checkTCPMulti 多次建立 TCP 连接，统计连接失败率、RTT 分布与抖动
+func (c *MonitorCollector) checkTCPMulti(item protocol.MonitorItem, result protocol.MonitorData, count int, timeout time.Duration) protocol.MonitorData {
+	rtts := make([]float64, 0, count)
+	var lastErr error
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			time.Sleep(100 * time.Millisecond)
+		}
+
+		startTime := time.Now()
+		conn, err := net.DialTimeout("tcp", item.Target, timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		rtts = append(rtts, float64(time.Since(startTime).Microseconds())/1000)
+		_ = conn.Close()
+	}
+
+	applyLatencyStats(&result, count, rtts)
+	if len(rtts) == 0 {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("all %d connection attempts failed: %v", count, lastErr)
+		result.Message = "100% loss"
+		return result
+	}
+
+	result.Status = "up"
+	result.ResponseTime = int64(math.Round(result.AvgRtt))
+	result.Message = fmt.Sprintf("TCP connected - %d/%d attempts, %.2fms avg, %.2fms jitter, %.0f%% loss",
+		result.PacketsRecv, result.PacketsSent, result.AvgRtt, result.Jitter, result.PacketLoss)
+	return result
+}
+
 // checkICMP 检查 ICMP (Ping)
 func (c *MonitorCollector) checkICMP(item protocol.MonitorItem) protocol.MonitorData {
 	result := protocol.MonitorData{
@@ -289,14 +345,15 @@ func (c *MonitorCollector) checkICMP(item protocol.MonitorItem) protocol.Monitor
 
 	// 获取统计信息
 	stats := pinger.Statistics()
+	applyLatencyStats(&result, stats.PacketsSent, durationsToMillis(stats.Rtts))
 
 	// 检查是否有成功的包
 	if stats.PacketsRecv > 0 {
 		result.Status = "up"
 		result.ResponseTime = stats.AvgRtt.Milliseconds()
 		packetLoss := int(stats.PacketLoss)
-		result.Message = fmt.Sprintf("ICMP Echo Reply - %d/%d packets, %dms avg, %d%% loss",
-			stats.PacketsRecv, stats.PacketsSent, stats.AvgRtt.Milliseconds(), packetLoss)
+		result.Message = fmt.Sprintf("ICMP Echo Reply - %d/%d packets, %dms avg, %.2fms jitter, %d%% loss",
+			stats.PacketsRecv, stats.PacketsSent, stats.AvgRtt.Milliseconds(), result.Jitter, packetLoss)
 	} else {
 		result.Status = "down"
 		result.Error = fmt.Sprintf("all %d ping attempts failed (timeout: %ds)", count, timeout)
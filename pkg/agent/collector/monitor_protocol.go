@@ -0,0 +1,658 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// 协议类监控的默认超时时间（秒）
+const defaultProtocolTimeout = 10
+
+// protocolTimeout 返回协议检查的超时时间
+func protocolTimeout(timeout int) time.Duration {
+	if timeout <= 0 {
+		timeout = defaultProtocolTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// newMonitorResult 创建监控结果
+func newMonitorResult(item protocol.MonitorItem) protocol.MonitorData {
+	return protocol.MonitorData{
+		MonitorId: item.ID,
+		Type:      item.Type,
+		Target:    item.Target,
+		CheckedAt: time.Now().UnixMilli(),
+	}
+}
+
+// checkUDP 发送 UDP 数据包并等待响应
+func (c *MonitorCollector) checkUDP(item protocol.MonitorItem) protocol.MonitorData {
+	result := newMonitorResult(item)
+
+	udpCfg := item.UDPConfig
+	if udpCfg == nil {
+		udpCfg = &protocol.UDPMonitorConfig{}
+	}
+	timeout := protocolTimeout(udpCfg.Timeout)
+
+	payload := []byte(udpCfg.Payload)
+	if udpCfg.PayloadHex {
+		decoded, err := hex.DecodeString(strings.ReplaceAll(udpCfg.Payload, " ", ""))
+		if err != nil {
+			result.Status = "down"
+			result.Error = fmt.Sprintf("invalid hex payload: %v", err)
+			return result
+		}
+		payload = decoded
+	}
+
+	startTime := time.Now()
+	conn, err := net.DialTimeout("udp", item.Target, timeout)
+	if err != nil {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("dial failed: %v", err)
+		return result
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write(payload); err != nil {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("send failed: %v", err)
+		return result
+	}
+
+	buf := make([]byte, 64*1024)
+	n, err := conn.Read(buf)
+	result.ResponseTime = time.Since(startTime).Milliseconds()
+	if err != nil {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("no response: %v", err)
+		return result
+	}
+
+	if udpCfg.ExpectedContent != "" {
+		if !bytes.Contains(buf[:n], []byte(udpCfg.ExpectedContent)) {
+			result.Status = "down"
+			result.Error = fmt.Sprintf("response does not contain expected string: %s", udpCfg.ExpectedContent)
+			result.ContentMatch = false
+			return result
+		}
+		result.ContentMatch = true
+	}
+
+	result.Status = "up"
+	result.Message = fmt.Sprintf("UDP %d bytes received - %dms", n, result.ResponseTime)
+	return result
+}
+
+// checkSMTP 完成 SMTP 问候与 EHLO 握手，可选检查 STARTTLS
+func (c *MonitorCollector) checkSMTP(item protocol.MonitorItem) protocol.MonitorData {
+	result := newMonitorResult(item)
+
+	smtpCfg := item.SMTPConfig
+	if smtpCfg == nil {
+		smtpCfg = &protocol.SMTPMonitorConfig{}
+	}
+	timeout := protocolTimeout(smtpCfg.Timeout)
+	heloName := smtpCfg.HeloName
+	if heloName == "" {
+		heloName = "localhost"
+	}
+
+	host, _, err := net.SplitHostPort(item.Target)
+	if err != nil {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("invalid target: %v", err)
+		return result
+	}
+
+	startTime := time.Now()
+	conn, err := net.DialTimeout("tcp", item.Target, timeout)
+	if err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = fmt.Sprintf("connection failed: %v", err)
+		return result
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = fmt.Sprintf("smtp greeting failed: %v", err)
+		return result
+	}
+	defer client.Close()
+
+	if err := client.Hello(heloName); err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = fmt.Sprintf("EHLO failed: %v", err)
+		return result
+	}
+
+	if smtpCfg.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			result.ResponseTime = time.Since(startTime).Milliseconds()
+			result.Status = "down"
+			result.Error = "server does not support STARTTLS"
+			return result
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: host, InsecureSkipVerify: true}); err != nil {
+			result.ResponseTime = time.Since(startTime).Milliseconds()
+			result.Status = "down"
+			result.Error = fmt.Sprintf("STARTTLS failed: %v", err)
+			return result
+		}
+		if state, ok := client.TLSConnectionState(); ok && len(state.PeerCertificates) > 0 {
+			expiryTime := state.PeerCertificates[0].NotAfter
+			result.CertExpiryTime = expiryTime.UnixMilli()
+			result.CertDaysLeft = int(time.Until(expiryTime).Hours() / 24)
+		}
+	}
+
+	result.ResponseTime = time.Since(startTime).Milliseconds()
+	_ = client.Quit()
+
+	result.Status = "up"
+	result.Message = fmt.Sprintf("SMTP EHLO ok - %dms", result.ResponseTime)
+	return result
+}
+
+// checkSSH 读取 SSH 服务端 banner 并校验版本信息
+func (c *MonitorCollector) checkSSH(item protocol.MonitorItem) protocol.MonitorData {
+	result := newMonitorResult(item)
+
+	sshCfg := item.SSHConfig
+	if sshCfg == nil {
+		sshCfg = &protocol.SSHMonitorConfig{}
+	}
+	timeout := protocolTimeout(sshCfg.Timeout)
+
+	startTime := time.Now()
+	conn, err := net.DialTimeout("tcp", item.Target, timeout)
+	if err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = fmt.Sprintf("connection failed: %v", err)
+		return result
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	// RFC 4253: 服务端在版本行之前可以发送其他行，最多读取若干行
+	reader := bufio.NewReader(conn)
+	var banner string
+	for i := 0; i < 10; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			result.ResponseTime = time.Since(startTime).Milliseconds()
+			result.Status = "down"
+			result.Error = fmt.Sprintf("read banner failed: %v", err)
+			return result
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "SSH-") {
+			banner = line
+			break
+		}
+	}
+	result.ResponseTime = time.Since(startTime).Milliseconds()
+
+	if banner == "" {
+		result.Status = "down"
+		result.Error = "no SSH version banner received"
+		return result
+	}
+
+	if sshCfg.ExpectedBanner != "" {
+		if !strings.Contains(banner, sshCfg.ExpectedBanner) {
+			result.Status = "down"
+			result.Error = fmt.Sprintf("banner does not contain expected string: %s", sshCfg.ExpectedBanner)
+			result.Message = banner
+			result.ContentMatch = false
+			return result
+		}
+		result.ContentMatch = true
+	}
+
+	result.Status = "up"
+	result.Message = fmt.Sprintf("%s - %dms", banner, result.ResponseTime)
+	return result
+}
+
+// checkRedis 发送 PING 命令，可选先进行 AUTH 认证
+func (c *MonitorCollector) checkRedis(item protocol.MonitorItem) protocol.MonitorData {
+	result := newMonitorResult(item)
+
+	redisCfg := item.RedisConfig
+	if redisCfg == nil {
+		redisCfg = &protocol.RedisMonitorConfig{}
+	}
+	timeout := protocolTimeout(redisCfg.Timeout)
+
+	startTime := time.Now()
+	conn, err := net.DialTimeout("tcp", item.Target, timeout)
+	if err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = fmt.Sprintf("connection failed: %v", err)
+		return result
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	reader := bufio.NewReader(conn)
+
+	if redisCfg.Password != "" {
+		args := []string{"AUTH", redisCfg.Password}
+		if redisCfg.Username != "" {
+			args = []string{"AUTH", redisCfg.Username, redisCfg.Password}
+		}
+		reply, err := redisCommand(conn, reader, args...)
+		if err != nil {
+			result.ResponseTime = time.Since(startTime).Milliseconds()
+			result.Status = "down"
+			result.Error = fmt.Sprintf("AUTH failed: %v", err)
+			return result
+		}
+		if !strings.HasPrefix(reply, "+OK") {
+			result.ResponseTime = time.Since(startTime).Milliseconds()
+			result.Status = "down"
+			result.Error = fmt.Sprintf("AUTH failed: %s", reply)
+			return result
+		}
+	}
+
+	reply, err := redisCommand(conn, reader, "PING")
+	result.ResponseTime = time.Since(startTime).Milliseconds()
+	if err != nil {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("PING failed: %v", err)
+		return result
+	}
+	if reply != "+PONG" {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("unexpected PING reply: %s", reply)
+		return result
+	}
+
+	result.Status = "up"
+	result.Message = fmt.Sprintf("Redis PONG - %dms", result.ResponseTime)
+	return result
+}
+
+// redisCommand 以 RESP 格式发送命令并读取单行响应
+func redisCommand(w io.Writer, r *bufio.Reader, args ...string) (string, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&buf, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return "", err
+	}
+
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if strings.HasPrefix(line, "-") {
+		return "", errors.New(strings.TrimPrefix(line, "-"))
+	}
+	return line, nil
+}
+
+// checkMySQL 检查 MySQL 握手包，配置了用户名时登录并执行查询
+func (c *MonitorCollector) checkMySQL(item protocol.MonitorItem) protocol.MonitorData {
+	result := newMonitorResult(item)
+
+	mysqlCfg := item.MySQLConfig
+	if mysqlCfg == nil {
+		mysqlCfg = &protocol.MySQLMonitorConfig{}
+	}
+	timeout := protocolTimeout(mysqlCfg.Timeout)
+
+	startTime := time.Now()
+	if mysqlCfg.Username == "" {
+		serverVersion, err := readMySQLHandshake(item.Target, timeout)
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		if err != nil {
+			result.Status = "down"
+			result.Error = err.Error()
+			return result
+		}
+		result.Status = "up"
+		result.Message = fmt.Sprintf("MySQL %s - %dms", serverVersion, result.ResponseTime)
+		return result
+	}
+
+	dsnCfg := mysql.NewConfig()
+	dsnCfg.User = mysqlCfg.Username
+	dsnCfg.Passwd = mysqlCfg.Password
+	dsnCfg.Net = "tcp"
+	dsnCfg.Addr = item.Target
+	dsnCfg.DBName = mysqlCfg.Database
+	dsnCfg.Timeout = timeout
+	dsnCfg.ReadTimeout = timeout
+	dsnCfg.WriteTimeout = timeout
+
+	if err := runSQLCheck("mysql", dsnCfg.FormatDSN(), mysqlCfg.Query, timeout); err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = err.Error()
+		return result
+	}
+	result.ResponseTime = time.Since(startTime).Milliseconds()
+
+	result.Status = "up"
+	result.Message = fmt.Sprintf("MySQL login ok - %dms", result.ResponseTime)
+	return result
+}
+
+// readMySQLHandshake 读取 MySQL 初始握手包，返回服务端版本
+func readMySQLHandshake(target string, timeout time.Duration) (string, error) {
+	conn, err := net.DialTimeout("tcp", target, timeout)
+	if err != nil {
+		return "", fmt.Errorf("connection failed: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", fmt.Errorf("read handshake failed: %v", err)
+	}
+	length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	if length == 0 || length > 64*1024 {
+		return "", fmt.Errorf("invalid handshake packet length: %d", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		return "", fmt.Errorf("read handshake failed: %v", err)
+	}
+
+	switch payload[0] {
+	case 0x0a:
+		// 协议版本 10，后面是以 NUL 结尾的服务端版本
+		version := payload[1:]
+		if idx := bytes.IndexByte(version, 0); idx >= 0 {
+			version = version[:idx]
+		}
+		return string(version), nil
+	case 0xff:
+		// 错误包: 0xff + 2 字节错误码 + 错误信息
+		if len(payload) < 3 {
+			return "", errors.New("server returned error packet")
+		}
+		code := binary.LittleEndian.Uint16(payload[1:3])
+		return "", fmt.Errorf("server error %d: %s", code, string(payload[3:]))
+	default:
+		return "", fmt.Errorf("unsupported protocol version: %d", payload[0])
+	}
+}
+
+// checkPostgreSQL 检查 PostgreSQL 协议握手，配置了用户名时登录并执行查询
+func (c *MonitorCollector) checkPostgreSQL(item protocol.MonitorItem) protocol.MonitorData {
+	result := newMonitorResult(item)
+
+	pgCfg := item.PostgreSQLConfig
+	if pgCfg == nil {
+		pgCfg = &protocol.PostgreSQLMonitorConfig{}
+	}
+	timeout := protocolTimeout(pgCfg.Timeout)
+
+	startTime := time.Now()
+	if pgCfg.Username == "" {
+		sslSupported, err := probePostgreSQL(item.Target, timeout)
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		if err != nil {
+			result.Status = "down"
+			result.Error = err.Error()
+			return result
+		}
+		ssl := "ssl off"
+		if sslSupported {
+			ssl = "ssl on"
+		}
+		result.Status = "up"
+		result.Message = fmt.Sprintf("PostgreSQL handshake ok (%s) - %dms", ssl, result.ResponseTime)
+		return result
+	}
+
+	sslMode := pgCfg.SSLMode
+	if sslMode == "" {
+		sslMode = "prefer"
+	}
+	query := url.Values{}
+	query.Set("sslmode", sslMode)
+	query.Set("connect_timeout", strconv.Itoa(int(timeout.Seconds())))
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(pgCfg.Username, pgCfg.Password),
+		Host:     item.Target,
+		Path:     "/" + pgCfg.Database,
+		RawQuery: query.Encode(),
+	}
+
+	if err := runSQLCheck("pgx", dsn.String(), pgCfg.Query, timeout); err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = err.Error()
+		return result
+	}
+	result.ResponseTime = time.Since(startTime).Milliseconds()
+
+	result.Status = "up"
+	result.Message = fmt.Sprintf("PostgreSQL login ok - %dms", result.ResponseTime)
+	return result
+}
+
+// probePostgreSQL 发送 SSLRequest 并根据响应判断是否为 PostgreSQL 服务
+func probePostgreSQL(target string, timeout time.Duration) (bool, error) {
+	conn, err := net.DialTimeout("tcp", target, timeout)
+	if err != nil {
+		return false, fmt.Errorf("connection failed: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	// SSLRequest: 长度 8 + 魔数 80877103
+	request := make([]byte, 8)
+	binary.BigEndian.PutUint32(request[0:4], 8)
+	binary.BigEndian.PutUint32(request[4:8], 80877103)
+	if _, err := conn.Write(request); err != nil {
+		return false, fmt.Errorf("send SSLRequest failed: %v", err)
+	}
+
+	reply := make([]byte, 1)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return false, fmt.Errorf("read SSLRequest reply failed: %v", err)
+	}
+
+	switch reply[0] {
+	case 'S':
+		return true, nil
+	case 'N':
+		return false, nil
+	case 'E':
+		return false, errors.New("server rejected SSLRequest")
+	default:
+		return false, fmt.Errorf("unexpected SSLRequest reply: %q", reply[0])
+	}
+}
+
+// runSQLCheck 使用 database/sql 登录数据库并执行可选查询
+func runSQLCheck(driver, dsn, query string, timeout time.Duration) error {
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return fmt.Errorf("open connection failed: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		return fmt.Errorf("login failed: %v", err)
+	}
+
+	if query == "" {
+		return nil
+	}
+
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("query failed: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query failed: %v", err)
+	}
+	return nil
+}
+
+// MQTT CONNACK 返回码说明
+var mqttConnackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// checkMQTT 发送 MQTT 3.1.1 CONNECT 报文并等待 CONNACK
+func (c *MonitorCollector) checkMQTT(item protocol.MonitorItem) protocol.MonitorData {
+	result := newMonitorResult(item)
+
+	mqttCfg := item.MQTTConfig
+	if mqttCfg == nil {
+		mqttCfg = &protocol.MQTTMonitorConfig{}
+	}
+	timeout := protocolTimeout(mqttCfg.Timeout)
+	clientID := mqttCfg.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("pika-%d", time.Now().UnixNano())
+	}
+
+	startTime := time.Now()
+	conn, err := net.DialTimeout("tcp", item.Target, timeout)
+	if err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = fmt.Sprintf("connection failed: %v", err)
+		return result
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+
+	if _, err := conn.Write(buildMQTTConnect(clientID, mqttCfg.Username, mqttCfg.Password)); err != nil {
+		result.ResponseTime = time.Since(startTime).Milliseconds()
+		result.Status = "down"
+		result.Error = fmt.Sprintf("send CONNECT failed: %v", err)
+		return result
+	}
+
+	connack := make([]byte, 4)
+	_, err = io.ReadFull(conn, connack)
+	result.ResponseTime = time.Since(startTime).Milliseconds()
+	if err != nil {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("read CONNACK failed: %v", err)
+		return result
+	}
+	if connack[0] != 0x20 || connack[1] != 0x02 {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("unexpected CONNACK packet: % x", connack)
+		return result
+	}
+	if code := connack[3]; code != 0 {
+		reason, ok := mqttConnackErrors[code]
+		if !ok {
+			reason = "unknown error"
+		}
+		result.Status = "down"
+		result.Error = fmt.Sprintf("connection refused (%d): %s", code, reason)
+		return result
+	}
+
+	// 主动断开连接
+	_, _ = conn.Write([]byte{0xe0, 0x00})
+
+	result.Status = "up"
+	result.Message = fmt.Sprintf("MQTT CONNACK accepted - %dms", result.ResponseTime)
+	return result
+}
+
+// buildMQTTConnect 构建 MQTT 3.1.1 CONNECT 报文
+func buildMQTTConnect(clientID, username, password string) []byte {
+	var body bytes.Buffer
+	writeString := func(s string) {
+		_ = binary.Write(&body, binary.BigEndian, uint16(len(s)))
+		body.WriteString(s)
+	}
+
+	// 可变报头: 协议名、协议级别、连接标志、保活时间
+	writeString("MQTT")
+	body.WriteByte(0x04)
+	flags := byte(0x02) // clean session
+	if username != "" {
+		flags |= 0x80
+		if password != "" {
+			flags |= 0x40
+		}
+	}
+	body.WriteByte(flags)
+	_ = binary.Write(&body, binary.BigEndian, uint16(30))
+
+	// 有效载荷
+	writeString(clientID)
+	if username != "" {
+		writeString(username)
+		if password != "" {
+			writeString(password)
+		}
+	}
+
+	packet := []byte{0x10}
+	remaining := body.Len()
+	for {
+		b := byte(remaining % 128)
+		remaining /= 128
+		if remaining > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if remaining == 0 {
+			break
+		}
+	}
+	return append(packet, body.Bytes()...)
+}
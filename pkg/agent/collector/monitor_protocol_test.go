@@ -0,0 +1,201 @@
+package collector
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// serveTCPOnce 启动只处理一个连接的 TCP 服务
+func serveTCPOnce(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+	return ln.Addr().String()
+}
+
+func TestCheckUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 1024)
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		_, _ = pc.WriteTo(append([]byte("echo:"), buf[:n]...), addr)
+	}()
+
+	c := NewMonitorCollector()
+	result := c.checkUDP(protocol.MonitorItem{
+		Type:   "udp",
+		Target: pc.LocalAddr().String(),
+		UDPConfig: &protocol.UDPMonitorConfig{
+			Timeout:         2,
+			Payload:         "7069 6e67",
+			PayloadHex:      true,
+			ExpectedContent: "echo:ping",
+		},
+	})
+	if result.Status != "up" {
+		t.Fatalf("expected up, got %s: %s", result.Status, result.Error)
+	}
+}
+
+func TestCheckSSH(t *testing.T) {
+	addr := serveTCPOnce(t, func(conn net.Conn) {
+		_, _ = conn.Write([]byte("SSH-2.0-OpenSSH_9.6\r\n"))
+	})
+
+	c := NewMonitorCollector()
+	result := c.checkSSH(protocol.MonitorItem{
+		Type:      "ssh",
+		Target:    addr,
+		SSHConfig: &protocol.SSHMonitorConfig{Timeout: 2, ExpectedBanner: "OpenSSH"},
+	})
+	if result.Status != "up" {
+		t.Fatalf("expected up, got %s: %s", result.Status, result.Error)
+	}
+}
+
+func TestCheckSMTP(t *testing.T) {
+	addr := serveTCPOnce(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		_, _ = conn.Write([]byte("220 mail.example.com ESMTP\r\n"))
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			switch {
+			case strings.HasPrefix(line, "EHLO"):
+				_, _ = conn.Write([]byte("250-mail.example.com\r\n250 SIZE 1024\r\n"))
+			case strings.HasPrefix(line, "QUIT"):
+				_, _ = conn.Write([]byte("221 bye\r\n"))
+				return
+			default:
+				_, _ = conn.Write([]byte("502 not implemented\r\n"))
+			}
+		}
+	})
+
+	c := NewMonitorCollector()
+	result := c.checkSMTP(protocol.MonitorItem{Type: "smtp", Target: addr})
+	if result.Status != "up" {
+		t.Fatalf("expected up, got %s: %s", result.Status, result.Error)
+	}
+}
+
+func TestCheckRedis(t *testing.T) {
+	addr := serveTCPOnce(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			upper := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(upper, "AUTH"):
+				_, _ = conn.Write([]byte("+OK\r\n"))
+			case strings.HasPrefix(upper, "PING"):
+				_, _ = conn.Write([]byte("+PONG\r\n"))
+				return
+			}
+		}
+	})
+
+	c := NewMonitorCollector()
+	result := c.checkRedis(protocol.MonitorItem{
+		Type:        "redis",
+		Target:      addr,
+		RedisConfig: &protocol.RedisMonitorConfig{Timeout: 2, Password: "secret"},
+	})
+	if result.Status != "up" {
+		t.Fatalf("expected up, got %s: %s", result.Status, result.Error)
+	}
+}
+
+func TestCheckMySQLHandshake(t *testing.T) {
+	addr := serveTCPOnce(t, func(conn net.Conn) {
+		payload := append([]byte{0x0a}, []byte("8.0.36\x00")...)
+		header := []byte{byte(len(payload)), 0, 0, 0}
+		_, _ = conn.Write(append(header, payload...))
+	})
+
+	c := NewMonitorCollector()
+	result := c.checkMySQL(protocol.MonitorItem{Type: "mysql", Target: addr})
+	if result.Status != "up" {
+		t.Fatalf("expected up, got %s: %s", result.Status, result.Error)
+	}
+	if !strings.Contains(result.Message, "8.0.36") {
+		t.Fatalf("expected server version in message, got %s", result.Message)
+	}
+}
+
+func TestCheckPostgreSQLHandshake(t *testing.T) {
+	addr := serveTCPOnce(t, func(conn net.Conn) {
+		request := make([]byte, 8)
+		if _, err := io.ReadFull(conn, request); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{'N'})
+	})
+
+	c := NewMonitorCollector()
+	result := c.checkPostgreSQL(protocol.MonitorItem{Type: "postgresql", Target: addr})
+	if result.Status != "up" {
+		t.Fatalf("expected up, got %s: %s", result.Status, result.Error)
+	}
+}
+
+func TestCheckMQTT(t *testing.T) {
+	for name, code := range map[string]byte{"accepted": 0, "rejected": 5} {
+		t.Run(name, func(t *testing.T) {
+			addr := serveTCPOnce(t, func(conn net.Conn) {
+				header := make([]byte, 2)
+				if _, err := io.ReadFull(conn, header); err != nil || header[0] != 0x10 {
+					return
+				}
+				body := make([]byte, header[1])
+				if _, err := io.ReadFull(conn, body); err != nil {
+					return
+				}
+				_, _ = conn.Write([]byte{0x20, 0x02, 0x00, code})
+			})
+
+			c := NewMonitorCollector()
+			result := c.checkMQTT(protocol.MonitorItem{
+				Type:       "mqtt",
+				Target:     addr,
+				MQTTConfig: &protocol.MQTTMonitorConfig{Timeout: 2, Username: "u", Password: "p"},
+			})
+			want := "up"
+			if code != 0 {
+				want = "down"
+			}
+			if result.Status != want {
+				t.Fatalf("expected %s, got %s: %s", want, result.Status, result.Error)
+			}
+		})
+	}
+}
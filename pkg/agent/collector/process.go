@@ -0,0 +1,282 @@
+package collector
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// maxProcessCmdlineLength 上报的命令行长度上限
+const maxProcessCmdlineLength = 256
+
+// processSample 进程上一次采集的累计值，用于计算 CPU 使用率和 IO 速率
+type processSample struct {
+	createTime int64
+	cpuSeconds float64
+	readBytes  uint64
+	writeBytes uint64
+	at         time.Time
+}
+
+// processStat 单次采集中单个进程的资源占用
+type processStat struct {
+	proc       *process.Process
+	createTime int64
+	metric     protocol.ProcessMetric
+
+	// 名称和命令行按需读取，多个关注规则共用
+	identityLoaded bool
+	name           string
+	cmdline        string
+}
+
+// identity 读取并缓存进程名称和命令行
+func (s *processStat) identity() (string, string) {
+	if !s.identityLoaded {
+		s.name, _ = s.proc.Name()
+		s.cmdline, _ = s.proc.Cmdline()
+		s.identityLoaded = true
+	}
+	return s.name, s.cmdline
+}
+
+// processWatch 关注的进程
+type processWatch struct {
+	cfg     config.WatchProcessConfig
+	pattern *regexp.Regexp
+
+	// 匹配进程中最早启动的进程，其变化视为一次重启
+	leaderPID        int32
+	leaderCreateTime int64
+	restarts         uint64
+}
+
+// ProcessCollector 进程资源采集器
+type ProcessCollector struct {
+	topN    int
+	watches []*processWatch
+	prev    map[int32]processSample
+}
+
+// NewProcessCollector 创建进程采集器
+func NewProcessCollector(cfg *config.Config) *ProcessCollector {
+	c := &ProcessCollector{
+		topN: cfg.Collector.Process.TopN,
+		prev: make(map[int32]processSample),
+	}
+	for _, watchCfg := range cfg.Collector.Process.Watch {
+		watch := &processWatch{cfg: watchCfg}
+		// 正则已在加载配置时校验
+		if watchCfg.Pattern != "" {
+			watch.pattern = regexp.MustCompile(watchCfg.Pattern)
+		}
+		c.watches = append(c.watches, watch)
+	}
+	return c
+}
+
+// Collect 采集排名靠前的进程和关注进程的资源占用
+// CPU 使用率和 IO 速率根据与上一次采集的差值计算，进程首次出现时为 0
+func (c *ProcessCollector) Collect() (*protocol.ProcessMetricsData, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	var totalMemory uint64
+	if vm, err := mem.VirtualMemory(); err == nil {
+		totalMemory = vm.Total
+	}
+
+	now := time.Now()
+	next := make(map[int32]processSample, len(procs))
+	stats := make([]*processStat, 0, len(procs))
+	for _, p := range procs {
+		createTime, err := p.CreateTime()
+		if err != nil {
+			continue
+		}
+		times, err := p.Times()
+		if err != nil {
+			continue
+		}
+
+		sample := processSample{
+			createTime: createTime,
+			cpuSeconds: times.User + times.System,
+			at:         now,
+		}
+		if io, err := p.IOCounters(); err == nil {
+			sample.readBytes = io.ReadBytes
+			sample.writeBytes = io.WriteBytes
+		}
+		next[p.Pid] = sample
+
+		stat := &processStat{
+			proc:       p,
+			createTime: createTime,
+			metric:     protocol.ProcessMetric{PID: p.Pid},
+		}
+		if prev, ok := c.prev[p.Pid]; ok && prev.createTime == createTime {
+			if elapsed := now.Sub(prev.at).Seconds(); elapsed > 0 {
+				if delta := sample.cpuSeconds - prev.cpuSeconds; delta > 0 {
+					stat.metric.CPUPercent = delta / elapsed * 100
+				}
+				stat.metric.ReadBytesRate = uint64(float64(safeDelta(sample.readBytes, prev.readBytes)) / elapsed)
+				stat.metric.WriteBytesRate = uint64(float64(safeDelta(sample.writeBytes, prev.writeBytes)) / elapsed)
+			}
+		}
+		if memInfo, err := p.MemoryInfo(); err == nil && memInfo != nil {
+			stat.metric.MemoryRSS = memInfo.RSS
+			if totalMemory > 0 {
+				stat.metric.MemoryPercent = float64(memInfo.RSS) / float64(totalMemory) * 100
+			}
+		}
+		if fds, err := p.NumFDs(); err == nil {
+			stat.metric.NumFDs = fds
+		}
+		stats = append(stats, stat)
+	}
+	c.prev = next
+
+	data := &protocol.ProcessMetricsData{}
+	for _, stat := range selectTopProcesses(stats, c.topN) {
+		fillProcessDetails(stat)
+		data.Top = append(data.Top, stat.metric)
+	}
+	for _, watch := range c.watches {
+		data.Watched = append(data.Watched, watch.collect(stats))
+	}
+	return data, nil
+}
+
+// selectTopProcesses 分别按 CPU、内存、IO、文件描述符取前 n 个进程，返回按 CPU 使用率降序排列的并集
+func selectTopProcesses(stats []*processStat, n int) []*processStat {
+	selected := make(map[int32]*processStat)
+	rankers := []func(m *protocol.ProcessMetric) float64{
+		func(m *protocol.ProcessMetric) float64 { return m.CPUPercent },
+		func(m *protocol.ProcessMetric) float64 { return float64(m.MemoryRSS) },
+		func(m *protocol.ProcessMetric) float64 { return float64(m.ReadBytesRate + m.WriteBytesRate) },
+		func(m *protocol.ProcessMetric) float64 { return float64(m.NumFDs) },
+	}
+
+	sorted := make([]*processStat, len(stats))
+	copy(sorted, stats)
+	for _, rank := range rankers {
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return rank(&sorted[i].metric) > rank(&sorted[j].metric)
+		})
+		for i := 0; i < n && i < len(sorted); i++ {
+			// 指标为 0 的进程没有排名意义
+			if rank(&sorted[i].metric) <= 0 {
+				break
+			}
+			selected[sorted[i].metric.PID] = sorted[i]
+		}
+	}
+
+	result := make([]*processStat, 0, len(selected))
+	for _, stat := range selected {
+		result = append(result, stat)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].metric.CPUPercent != result[j].metric.CPUPercent {
+			return result[i].metric.CPUPercent > result[j].metric.CPUPercent
+		}
+		return result[i].metric.PID < result[j].metric.PID
+	})
+	return result
+}
+
+// fillProcessDetails 补充进程名称、命令行等信息，仅对需要上报的进程调用
+func fillProcessDetails(stat *processStat) {
+	name, cmdline := stat.identity()
+	if len(cmdline) > maxProcessCmdlineLength {
+		cmdline = cmdline[:maxProcessCmdlineLength]
+	}
+	stat.metric.Name = name
+	stat.metric.Cmdline = cmdline
+	stat.metric.Username, _ = stat.proc.Username()
+	if threads, err := stat.proc.NumThreads(); err == nil {
+		stat.metric.NumThreads = threads
+	}
+}
+
+// collect 汇总匹配到的进程并检测重启
+func (w *processWatch) collect(stats []*processStat) protocol.WatchedProcessMetric {
+	result := protocol.WatchedProcessMetric{Name: w.cfg.Name}
+
+	var leader *processStat
+	for _, stat := range stats {
+		if !w.match(stat) {
+			continue
+		}
+		result.Count++
+		result.CPUPercent += stat.metric.CPUPercent
+		result.MemoryRSS += stat.metric.MemoryRSS
+		if threads, err := stat.proc.NumThreads(); err == nil {
+			result.NumThreads += threads
+		}
+		if leader == nil || stat.createTime < leader.createTime ||
+			(stat.createTime == leader.createTime && stat.metric.PID < leader.metric.PID) {
+			leader = stat
+		}
+	}
+
+	if leader != nil {
+		w.observeLeader(leader.metric.PID, leader.createTime)
+	}
+	result.Restarts = w.restarts
+	return result
+}
+
+// observeLeader 记录当前最早启动的进程，与上次记录的不是同一进程时计为一次重启
+// 进程退出期间保留上次的记录，重新拉起后同样计为重启
+func (w *processWatch) observeLeader(pid int32, createTime int64) {
+	if w.leaderPID != 0 && (w.leaderPID != pid || w.leaderCreateTime != createTime) {
+		w.restarts++
+	}
+	w.leaderPID = pid
+	w.leaderCreateTime = createTime
+}
+
+// match 检查进程是否匹配关注规则
+func (w *processWatch) match(stat *processStat) bool {
+	if w.cfg.SystemdUnit != "" && !inSystemdUnit(stat.metric.PID, w.cfg.SystemdUnit) {
+		return false
+	}
+	if w.pattern != nil {
+		name, cmdline := stat.identity()
+		return w.pattern.MatchString(name) || (cmdline != "" && w.pattern.MatchString(cmdline))
+	}
+	return true
+}
+
+// inSystemdUnit 根据 /proc/<pid>/cgroup 判断进程是否属于指定的 systemd 单元
+func inSystemdUnit(pid int32, unit string) bool {
+	data, err := os.ReadFile("/proc/" + strconv.Itoa(int(pid)) + "/cgroup")
+	if err != nil {
+		return false
+	}
+	suffix := "/" + unit
+	for _, line := range strings.Split(string(data), "\n") {
+		// 格式: hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		path := parts[2]
+		if strings.HasSuffix(path, suffix) || strings.Contains(path, suffix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func TestSelectTopProcesses(t *testing.T) {
+	stats := []*processStat{
+		{metric: protocol.ProcessMetric{PID: 1, CPUPercent: 90, MemoryRSS: 10}},
+		{metric: protocol.ProcessMetric{PID: 2, CPUPercent: 50, MemoryRSS: 20}},
+		{metric: protocol.ProcessMetric{PID: 3, CPUPercent: 1, MemoryRSS: 900}},
+		{metric: protocol.ProcessMetric{PID: 4, CPUPercent: 0, NumFDs: 5000}},
+		{metric: protocol.ProcessMetric{PID: 5, WriteBytesRate: 1 << 20}},
+		{metric: protocol.ProcessMetric{PID: 6}},
+	}
+
+	top := selectTopProcesses(stats, 1)
+	var pids []int32
+	for _, stat := range top {
+		pids = append(pids, stat.metric.PID)
+	}
+	// 每个维度的第一名取并集，按 CPU 降序排列
+	want := []int32{1, 3, 4, 5}
+	if len(pids) != len(want) {
+		t.Fatalf("unexpected top processes: %v", pids)
+	}
+	for i := range want {
+		if pids[i] != want[i] {
+			t.Fatalf("unexpected top processes: %v", pids)
+		}
+	}
+}
+
+func TestProcessWatchRestarts(t *testing.T) {
+	w := &processWatch{}
+	w.observeLeader(100, 1000)
+	w.observeLeader(100, 1000)
+	if w.restarts != 0 {
+		t.Fatalf("same leader should not count as restart, got %d", w.restarts)
+	}
+	// 进程退出后以新的 pid 拉起
+	w.observeLeader(200, 2000)
+	// pid 复用但启动时间不同
+	w.observeLeader(200, 3000)
+	if w.restarts != 2 {
+		t.Fatalf("expected 2 restarts, got %d", w.restarts)
+	}
+}
@@ -0,0 +1,132 @@
+package collector
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+// systemctlTimeout 单次 systemctl 调用的超时时间
+const systemctlTimeout = 5 * time.Second
+
+// systemdUnitProperties 读取的 systemd 单元属性
+const systemdUnitProperties = "Id,LoadState,ActiveState,SubState,Result,NRestarts,ExecMainStatus,MainPID"
+
+// ServiceCollector systemd 单元与容器状态采集器
+type ServiceCollector struct {
+	units      []string
+	containers *ContainerCollector
+}
+
+// NewServiceCollector 创建服务状态采集器，未配置任何采集对象时返回 nil
+func NewServiceCollector(cfg *config.Config) *ServiceCollector {
+	servicesCfg := cfg.Collector.Services
+	c := &ServiceCollector{}
+	if runtime.GOOS == "linux" {
+		c.units = servicesCfg.SystemdUnits
+	}
+	if servicesCfg.Containers.Enabled {
+		c.containers = NewContainerCollector(servicesCfg.Containers)
+	}
+	if len(c.units) == 0 && c.containers == nil {
+		return nil
+	}
+	return c
+}
+
+// Collect 采集 systemd 单元与容器状态，单项失败不影响另一项
+func (c *ServiceCollector) Collect() (*protocol.ServiceStateData, error) {
+	data := &protocol.ServiceStateData{}
+	var firstErr error
+
+	if len(c.units) > 0 {
+		units, err := collectSystemdUnits(c.units)
+		if err != nil {
+			firstErr = err
+		}
+		data.Units = units
+	}
+
+	if c.containers != nil {
+		containers, err := c.containers.Collect()
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		data.Containers = containers
+	}
+
+	if len(data.Units) == 0 && len(data.Containers) == 0 {
+		return nil, firstErr
+	}
+	return data, nil
+}
+
+// collectSystemdUnits 通过 systemctl show 一次性读取所有单元的状态
+func collectSystemdUnits(units []string) ([]protocol.SystemdUnitState, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), systemctlTimeout)
+	defer cancel()
+
+	args := append([]string{"show", "--no-pager", "-p", systemdUnitProperties}, units...)
+	output, err := exec.CommandContext(ctx, "systemctl", args...).Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseSystemdShow(string(output)), nil
+}
+
+// parseSystemdShow 解析 systemctl show 的输出，多个单元之间以空行分隔
+func parseSystemdShow(output string) []protocol.SystemdUnitState {
+	var units []protocol.SystemdUnitState
+	var current *protocol.SystemdUnitState
+
+	flush := func() {
+		if current != nil && current.Name != "" {
+			units = append(units, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		if current == nil {
+			current = &protocol.SystemdUnitState{}
+		}
+		switch key {
+		case "Id":
+			current.Name = value
+		case "LoadState":
+			current.LoadState = value
+		case "ActiveState":
+			current.ActiveState = value
+		case "SubState":
+			current.SubState = value
+		case "Result":
+			current.Result = value
+		case "NRestarts":
+			current.Restarts, _ = strconv.ParseUint(value, 10, 64)
+		case "ExecMainStatus":
+			current.ExitCode, _ = strconv.Atoi(value)
+		case "MainPID":
+			pid, _ := strconv.ParseInt(value, 10, 32)
+			current.MainPID = int32(pid)
+		}
+	}
+	flush()
+	return units
+}
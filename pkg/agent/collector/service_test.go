@@ -0,0 +1,36 @@
+package collector
+
+import "testing"
+
+func TestParseSystemdShow(t *testing.T) {
+	output := `Id=nginx.service
+LoadState=loaded
+ActiveState=failed
+SubState=failed
+Result=exit-code
+NRestarts=3
+ExecMainStatus=1
+MainPID=0
+
+Id=missing.service
+LoadState=not-found
+ActiveState=inactive
+SubState=dead
+Result=success
+NRestarts=0
+ExecMainStatus=0
+MainPID=0
+`
+	units := parseSystemdShow(output)
+	if len(units) != 2 {
+		t.Fatalf("expected 2 units, got %d", len(units))
+	}
+	nginx := units[0]
+	if nginx.Name != "nginx.service" || nginx.ActiveState != "failed" || nginx.Result != "exit-code" ||
+		nginx.Restarts != 3 || nginx.ExitCode != 1 {
+		t.Fatalf("unexpected unit: %+v", nginx)
+	}
+	if units[1].LoadState != "not-found" {
+		t.Fatalf("unexpected unit: %+v", units[1])
+	}
+}
@@ -0,0 +1,264 @@
+package collector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+const (
+	defaultTracerouteMaxHops = 30
+	defaultTracerouteCount   = 3
+	defaultTracerouteTimeout = 2
+	maxTracerouteHops        = 64
+	maxTracerouteCount       = 10
+)
+
+// traceSeq 用于区分并发执行的路由追踪，避免多个任务互相抢占响应
+var traceSeq uint32
+
+// traceHopStats 单跳的原始探测数据
+type traceHopStats struct {
+	ip   string
+	sent int
+	rtts []float64
+}
+
+// checkTraceroute 执行 MTR 风格的路由追踪，记录每一跳的 RTT 与丢包
+func (c *MonitorCollector) checkTraceroute(item protocol.MonitorItem) protocol.MonitorData {
+	result := newMonitorResult(item)
+
+	maxHops := defaultTracerouteMaxHops
+	count := defaultTracerouteCount
+	timeout := defaultTracerouteTimeout
+	if cfg := item.TracerouteConfig; cfg != nil {
+		if cfg.MaxHops > 0 {
+			maxHops = min(cfg.MaxHops, maxTracerouteHops)
+		}
+		if cfg.Count > 0 {
+			count = min(cfg.Count, maxTracerouteCount)
+		}
+		if cfg.Timeout > 0 {
+			timeout = cfg.Timeout
+		}
+	}
+
+	hops, reached, err := runTraceroute(item.Target, maxHops, count, time.Duration(timeout)*time.Second)
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+		return result
+	}
+	result.Hops = hops
+
+	if !reached {
+		result.Status = "down"
+		result.Error = fmt.Sprintf("destination not reached within %d hops", maxHops)
+		return result
+	}
+
+	last := hops[len(hops)-1]
+	result.Status = "up"
+	result.ResponseTime = int64(math.Round(last.AvgRtt))
+	result.Message = fmt.Sprintf("%d hops, %.2fms avg, %.0f%% loss", len(hops), last.AvgRtt, last.Loss)
+	return result
+}
+
+// runTraceroute 逐轮递增 TTL 发送 ICMP Echo，根据 Time Exceeded / Echo Reply 统计每一跳
+func runTraceroute(target string, maxHops, count int, wait time.Duration) ([]protocol.TracerouteHop, bool, error) {
+	dst, err := net.ResolveIPAddr("ip", target)
+	if err != nil {
+		return nil, false, fmt.Errorf("resolve target failed: %v", err)
+	}
+
+	isV4 := dst.IP.To4() != nil
+	network, address, proto := "ip4:icmp", "0.0.0.0", 1
+	var echoType icmp.Type = ipv4.ICMPTypeEcho
+	if !isV4 {
+		network, address, proto = "ip6:ipv6-icmp", "::", 58
+		echoType = ipv6.ICMPTypeEchoRequest
+	}
+
+	// 路由追踪需要接收 Time Exceeded 报文，只能使用原始套接字
+	conn, err := icmp.ListenPacket(network, address)
+	if err != nil {
+		return nil, false, fmt.Errorf("open raw socket failed (requires root or CAP_NET_RAW): %v", err)
+	}
+	defer conn.Close()
+
+	id := (os.Getpid() + int(atomic.AddUint32(&traceSeq, 1))) & 0xffff
+	stats := make([]traceHopStats, maxHops+1)
+	destTTL := 0
+	buf := make([]byte, 1500)
+
+	for round := 0; round < count; round++ {
+		lastTTL := maxHops
+		if destTTL > 0 {
+			lastTTL = destTTL
+		}
+
+		sentAt := make(map[int]time.Time, lastTTL)
+		for ttl := 1; ttl <= lastTTL; ttl++ {
+			if isV4 {
+				err = conn.IPv4PacketConn().SetTTL(ttl)
+			} else {
+				err = conn.IPv6PacketConn().SetHopLimit(ttl)
+			}
+			if err != nil {
+				return nil, false, fmt.Errorf("set ttl failed: %v", err)
+			}
+
+			seq := round*maxHops + ttl
+			msg := icmp.Message{
+				Type: echoType,
+				Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("pika-traceroute")},
+			}
+			packet, err := msg.Marshal(nil)
+			if err != nil {
+				return nil, false, fmt.Errorf("build probe failed: %v", err)
+			}
+			if _, err := conn.WriteTo(packet, dst); err != nil {
+				return nil, false, fmt.Errorf("send probe failed: %v", err)
+			}
+			sentAt[seq] = time.Now()
+			stats[ttl].sent++
+		}
+
+		// 等待本轮响应，直到全部收到或超时
+		deadline := time.Now().Add(wait)
+		for len(sentAt) > 0 {
+			_ = conn.SetReadDeadline(deadline)
+			n, peer, err := conn.ReadFrom(buf)
+			if err != nil {
+				break
+			}
+			receivedAt := time.Now()
+
+			seq, reached, ok := parseTraceReply(proto, buf[:n], id, isV4)
+			if !ok {
+				continue
+			}
+			sent, ok := sentAt[seq]
+			if !ok {
+				continue
+			}
+			delete(sentAt, seq)
+
+			ttl := seq - round*maxHops
+			stats[ttl].ip = peerIP(peer)
+			stats[ttl].rtts = append(stats[ttl].rtts, float64(receivedAt.Sub(sent).Microseconds())/1000)
+			if reached && (destTTL == 0 || ttl < destTTL) {
+				destTTL = ttl
+			}
+		}
+	}
+
+	// 到达目标时截断到目标所在跳，否则截断到最后一个有响应的跳
+	last := destTTL
+	if last == 0 {
+		for ttl := maxHops; ttl > 0; ttl-- {
+			if len(stats[ttl].rtts) > 0 {
+				last = ttl
+				break
+			}
+		}
+	}
+	if last == 0 {
+		return nil, false, fmt.Errorf("no response from any hop")
+	}
+
+	hops := make([]protocol.TracerouteHop, 0, last)
+	for ttl := 1; ttl <= last; ttl++ {
+		hops = append(hops, buildTracerouteHop(ttl, stats[ttl]))
+	}
+	return hops, destTTL > 0, nil
+}
+
+// parseTraceReply 解析 ICMP 响应，返回对应的探测序号以及是否为目标的 Echo Reply
+func parseTraceReply(proto int, b []byte, id int, isV4 bool) (seq int, reached bool, ok bool) {
+	msg, err := icmp.ParseMessage(proto, b)
+	if err != nil {
+		return 0, false, false
+	}
+
+	switch body := msg.Body.(type) {
+	case *icmp.Echo:
+		if msg.Type != ipv4.ICMPTypeEchoReply && msg.Type != ipv6.ICMPTypeEchoReply {
+			return 0, false, false
+		}
+		if body.ID != id {
+			return 0, false, false
+		}
+		return body.Seq, true, true
+	case *icmp.TimeExceeded:
+		seq, ok = parseQuotedEcho(body.Data, id, isV4)
+		return seq, false, ok
+	case *icmp.DstUnreach:
+		seq, ok = parseQuotedEcho(body.Data, id, isV4)
+		return seq, false, ok
+	default:
+		return 0, false, false
+	}
+}
+
+// parseQuotedEcho 从 ICMP 差错报文携带的原始数据中提取 Echo 请求序号
+func parseQuotedEcho(data []byte, id int, isV4 bool) (int, bool) {
+	headerLen := 40
+	echoType := byte(128)
+	if isV4 {
+		if len(data) < 20 {
+			return 0, false
+		}
+		headerLen = int(data[0]&0x0f) * 4
+		echoType = 8
+	}
+	if len(data) < headerLen+8 {
+		return 0, false
+	}
+
+	inner := data[headerLen:]
+	if inner[0] != echoType {
+		return 0, false
+	}
+	if int(binary.BigEndian.Uint16(inner[4:6])) != id {
+		return 0, false
+	}
+	return int(binary.BigEndian.Uint16(inner[6:8])), true
+}
+
+// peerIP 提取响应方 IP
+func peerIP(addr net.Addr) string {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.String()
+	case *net.UDPAddr:
+		return a.IP.String()
+	default:
+		return addr.String()
+	}
+}
+
+// buildTracerouteHop 根据原始探测数据计算单跳统计
+func buildTracerouteHop(ttl int, s traceHopStats) protocol.TracerouteHop {
+	hop := protocol.TracerouteHop{
+		TTL:  ttl,
+		IP:   s.ip,
+		Sent: s.sent,
+		Recv: len(s.rtts),
+	}
+	if s.sent > 0 {
+		hop.Loss = roundFloat(float64(s.sent-hop.Recv) / float64(s.sent) * 100)
+	}
+	hop.MinRtt, hop.AvgRtt, hop.MaxRtt, hop.StdDevRtt = rttStats(s.rtts)
+	return hop
+}
@@ -2,13 +2,16 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"strings"
 	"time"
 
+	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/pkg/agent/utils"
 	"gopkg.in/yaml.v3"
 )
@@ -29,6 +32,37 @@ type Config struct {
 
 	// 自动更新配置
 	AutoUpdate AutoUpdateConfig `yaml:"auto_update"`
+
+	// 自定义指标配置
+	CustomMetrics CustomMetricsConfig `yaml:"custom_metrics"`
+
+	// 离线指标缓存配置
+	Buffer BufferConfig `yaml:"buffer"`
+
+	// 日志监控配置
+	LogWatch LogWatchConfig `yaml:"log_watch"`
+}
+
+// LogWatchConfig 日志监控配置，限制服务端下发的日志监控规则可以读取的文件
+type LogWatchConfig struct {
+	// 允许读取的目录（含子目录）或文件，必须为绝对路径，符号链接按实际路径判断
+	// 默认 Linux/macOS: ["/var/log"]，Windows 为空；为空时拒绝所有日志监控规则
+	AllowedPaths []string `yaml:"allowed_paths"`
+}
+
+// BufferConfig 离线指标缓存配置，连接不可用时指标写入本地缓存，重连后补发
+type BufferConfig struct {
+	// 是否启用离线缓存（默认 true）
+	Enabled bool `yaml:"enabled"`
+
+	// 缓存数据大小上限（MB，默认 50），超出时丢弃最早的数据
+	MaxSizeMB int `yaml:"max_size_mb"`
+
+	// 缓存数据最长保留时间（小时，默认 72），超出时丢弃
+	MaxAgeHours int `yaml:"max_age_hours"`
+
+	// 离线期间的缓存间隔（秒），大于采集间隔时按此间隔抽样缓存，0 表示缓存每次采集（默认 0）
+	DownsampleInterval int `yaml:"downsample_interval"`
 }
 
 // ServerConfig 服务器配置
@@ -91,6 +125,105 @@ type CollectorConfig struct {
 	//   Linux/macOS: ["/", "/data", "/home"]
 	//   Windows: ["C:", "D:"]
 	DiskInclude []string `yaml:"disk_include"`
+
+	// 抓取本机 Prometheus exporter（如 node_exporter、mysqld_exporter 或应用 /metrics 接口）
+	// 抓取结果附加 job、instance 标签后随采集周期上报，服务端保留原始指标名写入时序存储
+	Exporters []ExporterConfig `yaml:"exporters"`
+
+	// 进程采集配置
+	Process ProcessConfig `yaml:"process"`
+
+	// systemd 单元与容器状态采集配置
+	Services ServicesConfig `yaml:"services"`
+
+	// 硬件健康采集配置
+	Hardware HardwareConfig `yaml:"hardware"`
+}
+
+// HardwareConfig 硬件健康采集配置（仅 Linux）
+// 通过 smartctl 读取磁盘 SMART，读取 /proc/mdstat 与 zpool 获取阵列状态，读取 EDAC 获取内存 ECC 错误计数
+type HardwareConfig struct {
+	// 是否启用硬件健康采集（默认 true）
+	Enabled bool `yaml:"enabled"`
+
+	// 采集间隔（秒，默认 300），smartctl 读取较慢，无需每个采集周期执行
+	Interval int `yaml:"interval"`
+}
+
+// ServicesConfig systemd 单元与容器状态采集配置
+type ServicesConfig struct {
+	// 关注的 systemd 单元（如 nginx.service），为空时不采集（仅 Linux）
+	SystemdUnits []string `yaml:"systemd_units"`
+
+	// 容器状态采集
+	Containers ContainersConfig `yaml:"containers"`
+}
+
+// ContainersConfig 容器状态采集配置，通过 Docker Engine API 获取容器状态与资源占用
+// Podman 等提供 Docker 兼容接口的运行时同样适用
+type ContainersConfig struct {
+	// 是否启用容器状态采集
+	Enabled bool `yaml:"enabled"`
+
+	// Docker API 地址，支持 unix:///var/run/docker.sock 或 http://127.0.0.1:2375（默认 unix:///var/run/docker.sock）
+	Endpoint string `yaml:"endpoint"`
+
+	// 容器名称白名单（正则表达式），为空时采集所有容器
+	Include []string `yaml:"include"`
+
+	// 请求超时（秒，默认 5）
+	Timeout int `yaml:"timeout"`
+}
+
+// ProcessConfig 进程采集配置
+type ProcessConfig struct {
+	// 是否启用进程采集（默认 true）
+	Enabled bool `yaml:"enabled"`
+
+	// 按 CPU、内存、IO、文件描述符分别上报的排名进程数量（默认 10）
+	TopN int `yaml:"top_n"`
+
+	// 关注的进程，无论是否进入排名都会持续上报
+	Watch []WatchProcessConfig `yaml:"watch"`
+}
+
+// WatchProcessConfig 关注的进程配置，Pattern 与 SystemdUnit 至少配置一项
+type WatchProcessConfig struct {
+	// 名称，作为 process 标签
+	Name string `yaml:"name"`
+
+	// 匹配进程名或命令行的正则表达式
+	Pattern string `yaml:"pattern"`
+
+	// systemd 单元名称（如 nginx.service），匹配该单元下的所有进程（仅 Linux）
+	SystemdUnit string `yaml:"systemd_unit"`
+}
+
+// ExporterConfig Prometheus exporter 抓取配置
+type ExporterConfig struct {
+	// 任务名称，作为 job 标签
+	Name string `yaml:"name"`
+
+	// 抓取地址，如 http://127.0.0.1:9100/metrics
+	URL string `yaml:"url"`
+
+	// 抓取间隔（秒），默认与采集间隔相同
+	Interval int `yaml:"interval"`
+
+	// 抓取超时（秒，默认 5）
+	Timeout int `yaml:"timeout"`
+
+	// 指标名称白名单（正则表达式），为空时保留所有指标
+	Include []string `yaml:"include"`
+
+	// 指标名称黑名单（正则表达式），在白名单之后生效
+	Exclude []string `yaml:"exclude"`
+
+	// 附加标签
+	Labels map[string]string `yaml:"labels"`
+
+	// 单次抓取的样本数上限（默认 5000），超出部分会被丢弃
+	MaxSamples int `yaml:"max_samples"`
 }
 
 // AutoUpdateConfig 自动更新配置
@@ -102,6 +235,54 @@ type AutoUpdateConfig struct {
 	CheckInterval string `yaml:"check_interval"`
 }
 
+// CustomMetricsConfig 自定义指标配置
+// 本机脚本可通过 HTTP、Unix socket 或 StatsD UDP 推送指标，也可由 exec 插件定期执行脚本采集，
+// 指标随采集周期上报，服务端以 pika_custom_ 前缀存储
+type CustomMetricsConfig struct {
+	// 是否启用自定义指标
+	Enabled bool `yaml:"enabled"`
+
+	// HTTP 接收地址（仅允许本机地址，如 127.0.0.1:9109），为空则不启用
+	// POST /api/v1/push?format=prometheus|influx|statsd，POST /write 兼容 InfluxDB 行协议
+	HTTPListen string `yaml:"http_listen"`
+
+	// Unix socket 路径（如 /run/pika/metrics.sock），接口与 HTTP 相同，为空则不启用
+	SocketPath string `yaml:"socket_path"`
+
+	// StatsD UDP 接收地址（仅允许本机地址，如 127.0.0.1:8125），为空则不启用
+	StatsDListen string `yaml:"statsd_listen"`
+
+	// 序列数量上限（默认 1000），超出后新序列会被丢弃
+	MaxSeries int `yaml:"max_series"`
+
+	// 序列过期时间（秒，默认 300），超过该时间未更新的序列不再上报
+	StaleAfter int `yaml:"stale_after"`
+
+	// exec 插件列表
+	Exec []ExecPluginConfig `yaml:"exec"`
+}
+
+// ExecPluginConfig exec 插件配置，定期执行命令并解析标准输出
+type ExecPluginConfig struct {
+	// 插件名称，作为 plugin 标签附加到采集的指标上
+	Name string `yaml:"name"`
+
+	// 命令及参数（不经过 shell，如需管道请使用 ["sh", "-c", "..."]）
+	Command []string `yaml:"command"`
+
+	// 执行间隔（秒，默认 60）
+	Interval int `yaml:"interval"`
+
+	// 执行超时（秒，默认 10）
+	Timeout int `yaml:"timeout"`
+
+	// 输出格式：prometheus（默认）、influx、statsd
+	Format string `yaml:"format"`
+
+	// 附加标签
+	Labels map[string]string `yaml:"labels"`
+}
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() *Config {
 	return &Config{
@@ -122,14 +303,38 @@ func DefaultConfig() *Config {
 		Collector: CollectorConfig{
 			Interval:          5,
 			HeartbeatInterval: 30,
+			Process: ProcessConfig{
+				Enabled: true,
+				TopN:    10,
+			},
+			Hardware: HardwareConfig{
+				Enabled:  true,
+				Interval: 300,
+			},
 		},
 		AutoUpdate: AutoUpdateConfig{
 			Enabled:       true,
 			CheckInterval: "10m",
 		},
+		Buffer: BufferConfig{
+			Enabled:     true,
+			MaxSizeMB:   50,
+			MaxAgeHours: 72,
+		},
+		LogWatch: LogWatchConfig{
+			AllowedPaths: defaultLogWatchPaths(),
+		},
 	}
 }
 
+// defaultLogWatchPaths 默认允许日志监控读取的目录
+func defaultLogWatchPaths() []string {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	return []string{"/var/log"}
+}
+
 // GetDefaultConfigPath 获取默认配置文件路径
 func GetDefaultConfigPath() string {
 	var homeDir = utils.GetSafeHomeDir()
@@ -215,6 +420,38 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	for i := range c.Collector.Exporters {
+		if err := c.Collector.Exporters[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Collector.Process.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Collector.Services.Containers.validate(); err != nil {
+		return err
+	}
+
+	if c.Collector.Hardware.Interval <= 0 {
+		c.Collector.Hardware.Interval = 300
+	}
+
+	if err := c.CustomMetrics.validate(); err != nil {
+		return err
+	}
+
+	if c.Buffer.MaxSizeMB <= 0 {
+		c.Buffer.MaxSizeMB = 50
+	}
+	if c.Buffer.MaxAgeHours <= 0 {
+		c.Buffer.MaxAgeHours = 72
+	}
+	if c.Buffer.DownsampleInterval < 0 {
+		c.Buffer.DownsampleInterval = 0
+	}
+
 	// 验证日志等级
 	if c.Agent.LogLevel == "" {
 		c.Agent.LogLevel = "info"
@@ -232,6 +469,270 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+// validate 验证 exporter 抓取配置并填充默认值
+func (e *ExporterConfig) validate() error {
+	u, err := url.Parse(e.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("exporter '%s' 的抓取地址无效: %s", e.Name, e.URL)
+	}
+	if e.Name == "" {
+		e.Name = u.Host
+	}
+	if e.Timeout <= 0 {
+		e.Timeout = 5
+	}
+	if e.MaxSamples <= 0 {
+		e.MaxSamples = 5000
+	}
+	for _, pattern := range append(append([]string{}, e.Include...), e.Exclude...) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("exporter '%s' 的过滤规则 '%s' 无效: %w", e.Name, pattern, err)
+		}
+	}
+	return nil
+}
+
+// validate 验证进程采集配置并填充默认值
+func (p *ProcessConfig) validate() error {
+	if p.TopN <= 0 {
+		p.TopN = 10
+	}
+	for i := range p.Watch {
+		watch := &p.Watch[i]
+		if watch.Pattern == "" && watch.SystemdUnit == "" {
+			return fmt.Errorf("关注进程 '%s' 未配置匹配规则或 systemd 单元", watch.Name)
+		}
+		if watch.Pattern != "" {
+			if _, err := regexp.Compile(watch.Pattern); err != nil {
+				return fmt.Errorf("关注进程 '%s' 的匹配规则 '%s' 无效: %w", watch.Name, watch.Pattern, err)
+			}
+		}
+		if watch.Name == "" {
+			watch.Name = watch.SystemdUnit
+			if watch.Name == "" {
+				watch.Name = watch.Pattern
+			}
+		}
+	}
+	return nil
+}
+
+// validate 验证容器状态采集配置并填充默认值
+func (c *ContainersConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = "unix:///var/run/docker.sock"
+	}
+	u, err := url.Parse(c.Endpoint)
+	if err != nil || (u.Scheme != "unix" && u.Scheme != "http" && u.Scheme != "https") {
+		return fmt.Errorf("容器 API 地址无效: %s", c.Endpoint)
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = 5
+	}
+	for _, pattern := range c.Include {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("容器名称过滤规则 '%s' 无效: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// validate 验证自定义指标配置并填充默认值
+func (c *CustomMetricsConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.MaxSeries <= 0 {
+		c.MaxSeries = 1000
+	}
+	if c.StaleAfter <= 0 {
+		c.StaleAfter = 300
+	}
+
+	// 接收端口没有鉴权，只允许监听本机地址
+	for _, addr := range []string{c.HTTPListen, c.StatsDListen} {
+		if addr == "" {
+			continue
+		}
+		if err := validateLoopbackAddr(addr); err != nil {
+			return err
+		}
+	}
+
+	for i := range c.Exec {
+		plugin := &c.Exec[i]
+		if len(plugin.Command) == 0 {
+			return fmt.Errorf("exec 插件 '%s' 未配置命令", plugin.Name)
+		}
+		if plugin.Name == "" {
+			plugin.Name = filepath.Base(plugin.Command[0])
+		}
+		if plugin.Interval <= 0 {
+			plugin.Interval = 60
+		}
+		if plugin.Timeout <= 0 {
+			plugin.Timeout = 10
+		}
+		switch plugin.Format {
+		case "":
+			plugin.Format = "prometheus"
+		case "prometheus", "influx", "statsd":
+		default:
+			return fmt.Errorf("exec 插件 '%s' 的输出格式无效: %s (可选值: prometheus, influx, statsd)", plugin.Name, plugin.Format)
+		}
+	}
+	return nil
+}
+
+// validateLoopbackAddr 检查监听地址是否为本机地址
+func validateLoopbackAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("监听地址格式错误 '%s': %w", addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("自定义指标接收地址 '%s' 必须为本机地址", addr)
+	}
+	return nil
+}
+
+// WithRemote 将服务端下发的配置模板覆盖到配置副本上，未设置的项沿用当前配置
+// 返回的副本与原配置共享未被覆盖的切片，调用方不应修改
+func (c *Config) WithRemote(remote *protocol.RemoteAgentConfig) (*Config, error) {
+	cfg := *c
+	if remote == nil {
+		return &cfg, nil
+	}
+
+	if remote.Interval < 0 || remote.Interval > 3600 {
+		return nil, fmt.Errorf("采集间隔无效: %d (可选范围: 1-3600 秒)", remote.Interval)
+	}
+	if remote.Interval > 0 {
+		cfg.Collector.Interval = remote.Interval
+	}
+	if remote.HeartbeatInterval < 0 || remote.HeartbeatInterval > 3600 {
+		return nil, fmt.Errorf("心跳间隔无效: %d (可选范围: 1-3600 秒)", remote.HeartbeatInterval)
+	}
+	if remote.HeartbeatInterval > 0 {
+		cfg.Collector.HeartbeatInterval = remote.HeartbeatInterval
+	}
+
+	for _, pattern := range append(append([]string{}, remote.NetworkInclude...), remote.NetworkExclude...) {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("网卡过滤规则 '%s' 无效: %w", pattern, err)
+		}
+	}
+	if remote.NetworkInclude != nil {
+		cfg.Collector.NetworkInclude = remote.NetworkInclude
+	}
+	if remote.NetworkExclude != nil {
+		cfg.Collector.NetworkExclude = remote.NetworkExclude
+	}
+	if remote.DiskInclude != nil {
+		cfg.Collector.DiskInclude = remote.DiskInclude
+	}
+
+	if remote.AutoUpdate != nil {
+		if remote.AutoUpdate.Enabled {
+			duration, err := time.ParseDuration(remote.AutoUpdate.CheckInterval)
+			if err != nil {
+				return nil, fmt.Errorf("更新检查间隔格式错误: %w", err)
+			}
+			if duration < time.Minute {
+				return nil, fmt.Errorf("更新检查间隔不能小于 1 分钟: %s", remote.AutoUpdate.CheckInterval)
+			}
+		}
+		cfg.AutoUpdate = AutoUpdateConfig{
+			Enabled:       remote.AutoUpdate.Enabled,
+			CheckInterval: remote.AutoUpdate.CheckInterval,
+		}
+		if cfg.AutoUpdate.CheckInterval == "" {
+			cfg.AutoUpdate.CheckInterval = c.AutoUpdate.CheckInterval
+		}
+	}
+
+	return &cfg, nil
+}
+
+// MaskedYAML 返回遮蔽密钥后的配置内容，用于诊断信息
+func (c *Config) MaskedYAML() (string, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("序列化配置失败: %w", err)
+	}
+
+	// 序列化后再解析得到独立的副本，遮蔽时不影响原配置
+	var masked Config
+	if err := yaml.Unmarshal(data, &masked); err != nil {
+		return "", fmt.Errorf("解析配置失败: %w", err)
+	}
+	if masked.Server.APIKey != "" {
+		masked.Server.APIKey = utils.MaskToken(masked.Server.APIKey)
+	}
+	masked.Server.Endpoint = utils.MaskURL(masked.Server.Endpoint)
+	for i := range masked.Collector.Exporters {
+		masked.Collector.Exporters[i].URL = utils.MaskURL(masked.Collector.Exporters[i].URL)
+	}
+	masked.Collector.Services.Containers.Endpoint = utils.MaskURL(masked.Collector.Services.Containers.Endpoint)
+	for i := range masked.CustomMetrics.Exec {
+		masked.CustomMetrics.Exec[i].Command = utils.MaskCommand(masked.CustomMetrics.Exec[i].Command)
+	}
+
+	data, err = yaml.Marshal(&masked)
+	if err != nil {
+		return "", fmt.Errorf("序列化配置失败: %w", err)
+	}
+	return string(data), nil
+}
+
+// minSecretLength SecretReplacer 替换的最短密钥长度
+const minSecretLength = 4
+
+// SecretReplacer 返回将配置中的密钥替换为遮蔽值的 Replacer，用于处理日志、错误信息等可能原样带出密钥的文本
+// 覆盖 API Key、各地址中的密码与凭据类查询参数以及 exec 插件命令中的凭据参数
+func (c *Config) SecretReplacer() *strings.Replacer {
+	var pairs []string
+	add := func(raw, masked string) {
+		// 过短的值替换后会误伤日志中的普通文本
+		if len(raw) >= minSecretLength && raw != masked {
+			pairs = append(pairs, raw, masked)
+		}
+	}
+	addURL := func(rawURL string) {
+		add(rawURL, utils.MaskURL(rawURL))
+		// HTTP 客户端的错误信息使用重新编码后的地址
+		if u, err := url.Parse(rawURL); err == nil {
+			add(u.String(), utils.MaskURL(u.String()))
+		}
+	}
+
+	add(c.Server.APIKey, utils.MaskToken(c.Server.APIKey))
+	addURL(c.Server.Endpoint)
+	for _, exporter := range c.Collector.Exporters {
+		addURL(exporter.URL)
+	}
+	addURL(c.Collector.Services.Containers.Endpoint)
+	for _, plugin := range c.CustomMetrics.Exec {
+		// 按词处理，sh -c 脚本中的凭据出现在错误信息里时同样能被替换
+		var words []string
+		for _, arg := range plugin.Command {
+			words = append(words, strings.Fields(arg)...)
+		}
+		masked := utils.MaskCommand(words)
+		for i, word := range words {
+			add(word, masked[i])
+		}
+	}
+	return strings.NewReplacer(pairs...)
+}
+
 // GetCollectorInterval 获取采集间隔时长
 func (c *Config) GetCollectorInterval() time.Duration {
 	return time.Duration(c.Collector.Interval) * time.Second
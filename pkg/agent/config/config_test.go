@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMaskedYAML(t *testing.T) {
+	cfg := &Config{}
+	cfg.Server.APIKey = "0123456789abcdef"
+	cfg.Collector.Exporters = []ExporterConfig{{Name: "app", URL: "http://127.0.0.1:8080/metrics?token=exporter-secret"}}
+	cfg.CustomMetrics.Exec = []ExecPluginConfig{{Name: "mysql", Command: []string{"mysql", "-pmysql-secret", "-e", "SHOW STATUS"}}}
+
+	masked, err := cfg.MaskedYAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"0123456789abcdef", "exporter-secret", "mysql-secret"} {
+		if strings.Contains(masked, secret) {
+			t.Fatalf("masked config leaks %q:\n%s", secret, masked)
+		}
+	}
+	// 遮蔽不影响原配置
+	if cfg.CustomMetrics.Exec[0].Command[1] != "-pmysql-secret" {
+		t.Fatalf("original config modified: %v", cfg.CustomMetrics.Exec[0].Command)
+	}
+
+	line := cfg.SecretReplacer().Replace(`Get "http://127.0.0.1:8080/metrics?token=exporter-secret": connection refused; exec mysql -pmysql-secret failed`)
+	if strings.Contains(line, "exporter-secret") || strings.Contains(line, "mysql-secret") {
+		t.Fatalf("replacer leaks secret: %s", line)
+	}
+}
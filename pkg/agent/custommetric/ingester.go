@@ -0,0 +1,242 @@
+package custommetric
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+)
+
+// maxPushBodySize 单次推送的请求体上限
+const maxPushBodySize = 4 << 20
+
+// Ingester 自定义指标接收器，负责本地推送接口与 exec 插件
+type Ingester struct {
+	cfg      config.CustomMetricsConfig
+	registry *Registry
+}
+
+// NewIngester 创建自定义指标接收器
+func NewIngester(cfg config.CustomMetricsConfig) *Ingester {
+	return &Ingester{
+		cfg:      cfg,
+		registry: NewRegistry(cfg.MaxSeries, time.Duration(cfg.StaleAfter)*time.Second),
+	}
+}
+
+// Enabled 是否启用自定义指标
+func (i *Ingester) Enabled() bool {
+	return i.cfg.Enabled
+}
+
+// Collect 获取当前所有自定义指标
+func (i *Ingester) Collect() []protocol.CustomMetricData {
+	if !i.cfg.Enabled {
+		return nil
+	}
+	return i.registry.Collect()
+}
+
+// Run 启动本地接收接口与 exec 插件，阻塞直到 ctx 取消
+func (i *Ingester) Run(ctx context.Context) {
+	if !i.cfg.Enabled {
+		return
+	}
+
+	if i.cfg.HTTPListen != "" {
+		listener, err := net.Listen("tcp", i.cfg.HTTPListen)
+		if err != nil {
+			slog.Warn("自定义指标 HTTP 接口监听失败", "addr", i.cfg.HTTPListen, "error", err)
+		} else {
+			slog.Info("自定义指标 HTTP 接口已启动", "addr", i.cfg.HTTPListen)
+			go i.serveHTTP(ctx, listener)
+		}
+	}
+
+	if i.cfg.SocketPath != "" {
+		listener, err := listenUnix(i.cfg.SocketPath)
+		if err != nil {
+			slog.Warn("自定义指标 Unix socket 监听失败", "path", i.cfg.SocketPath, "error", err)
+		} else {
+			slog.Info("自定义指标 Unix socket 已启动", "path", i.cfg.SocketPath)
+			go i.serveHTTP(ctx, listener)
+		}
+	}
+
+	if i.cfg.StatsDListen != "" {
+		conn, err := net.ListenPacket("udp", i.cfg.StatsDListen)
+		if err != nil {
+			slog.Warn("StatsD 接口监听失败", "addr", i.cfg.StatsDListen, "error", err)
+		} else {
+			slog.Info("StatsD 接口已启动", "addr", i.cfg.StatsDListen)
+			go i.serveStatsD(ctx, conn)
+		}
+	}
+
+	for _, plugin := range i.cfg.Exec {
+		go i.runExecPlugin(ctx, plugin)
+	}
+
+	<-ctx.Done()
+}
+
+// listenUnix 监听 Unix socket，启动前清理残留的 socket 文件
+func listenUnix(path string) (net.Listener, error) {
+	if runtime.GOOS == "windows" {
+		return nil, fmt.Errorf("Windows 不支持 Unix socket")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0660); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// serveHTTP 提供推送接口
+func (i *Ingester) serveHTTP(ctx context.Context, listener net.Listener) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/push", func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatPrometheus
+		}
+		i.handlePush(w, r, format)
+	})
+	// 兼容 InfluxDB v1 写入接口
+	mux.HandleFunc("/write", func(w http.ResponseWriter, r *http.Request) {
+		i.handlePush(w, r, FormatInflux)
+	})
+
+	server := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Warn("自定义指标接口异常退出", "error", err)
+	}
+}
+
+// handlePush 处理推送请求，可解析的行会被接收，存在错误行时返回 400
+func (i *Ingester) handlePush(w http.ResponseWriter, r *http.Request, format string) {
+	if r.Method != http.MethodPost && r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !ValidFormat(format) {
+		http.Error(w, "unsupported format: "+format, http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPushBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	samples, parseErr := Parse(format, body)
+	if dropped := i.registry.Add(samples, nil); dropped > 0 {
+		slog.Warn("自定义指标序列数量超过上限，部分样本被丢弃", "dropped", dropped)
+	}
+	if parseErr != nil {
+		http.Error(w, parseErr.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveStatsD 接收 StatsD UDP 数据包
+func (i *Ingester) serveStatsD(ctx context.Context, conn net.PacketConn) {
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	buf := make([]byte, 65535)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Warn("StatsD 接口异常退出", "error", err)
+			}
+			return
+		}
+		samples, err := ParseStatsD(buf[:n])
+		if err != nil {
+			slog.Debug("解析 StatsD 数据失败", "error", err)
+		}
+		if dropped := i.registry.Add(samples, nil); dropped > 0 {
+			slog.Warn("自定义指标序列数量超过上限，部分样本被丢弃", "dropped", dropped)
+		}
+	}
+}
+
+// runExecPlugin 按间隔执行插件命令
+func (i *Ingester) runExecPlugin(ctx context.Context, plugin config.ExecPluginConfig) {
+	labels := make(map[string]string, len(plugin.Labels)+1)
+	for k, v := range plugin.Labels {
+		labels[k] = v
+	}
+	labels["plugin"] = plugin.Name
+
+	ticker := time.NewTicker(time.Duration(plugin.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		i.execOnce(ctx, plugin, labels)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// execOnce 执行一次插件命令并解析输出
+func (i *Ingester) execOnce(ctx context.Context, plugin config.ExecPluginConfig, labels map[string]string) {
+	execCtx, cancel := context.WithTimeout(ctx, time.Duration(plugin.Timeout)*time.Second)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(execCtx, plugin.Command[0], plugin.Command[1:]...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("exec 插件执行失败", "plugin", plugin.Name, "error", err, "stderr", stderr.String())
+		}
+		return
+	}
+
+	samples, err := Parse(plugin.Format, stdout.Bytes())
+	if err != nil {
+		slog.Warn("exec 插件输出解析失败", "plugin", plugin.Name, "error", err)
+	}
+	if dropped := i.registry.Add(samples, labels); dropped > 0 {
+		slog.Warn("自定义指标序列数量超过上限，部分样本被丢弃", "plugin", plugin.Name, "dropped", dropped)
+	}
+}
@@ -0,0 +1,351 @@
+package custommetric
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 支持的数据格式
+const (
+	FormatPrometheus = "prometheus"
+	FormatInflux     = "influx"
+	FormatStatsD     = "statsd"
+)
+
+// 样本类型
+const (
+	kindGauge   = "gauge"   // 瞬时值
+	kindCounter = "counter" // 累计值（如 Prometheus counter）
+	kindDelta   = "delta"   // 增量，累加到计数器（StatsD c）
+	kindTiming  = "timing"  // 耗时/分布，按上报周期求平均（StatsD ms/h/d）
+)
+
+// Sample 解析出的单个样本
+type Sample struct {
+	Name     string
+	Labels   map[string]string
+	Value    float64
+	Kind     string
+	Relative bool // StatsD 带符号的 gauge，表示在原值基础上增减
+}
+
+// IsCounter 是否为累计值（Prometheus counter）
+func (s Sample) IsCounter() bool {
+	return s.Kind == kindCounter
+}
+
+// ValidFormat 检查数据格式是否受支持
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatPrometheus, FormatInflux, FormatStatsD:
+		return true
+	}
+	return false
+}
+
+// Parse 按指定格式解析数据，遇到无法解析的行时跳过并返回第一个错误
+func Parse(format string, data []byte) ([]Sample, error) {
+	switch format {
+	case FormatPrometheus, "":
+		return ParsePrometheus(data)
+	case FormatInflux:
+		return ParseInflux(data)
+	case FormatStatsD:
+		return ParseStatsD(data)
+	default:
+		return nil, fmt.Errorf("不支持的数据格式: %s", format)
+	}
+}
+
+// eachLine 遍历非空行，收集第一个错误
+func eachLine(data []byte, fn func(line string) error) error {
+	var firstErr error
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := fn(line); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("第 %d 行: %w", lineNo, err)
+		}
+	}
+	if err := scanner.Err(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// ParsePrometheus 解析 Prometheus 文本格式，# TYPE 声明为 counter 的指标按累计值处理
+func ParsePrometheus(data []byte) ([]Sample, error) {
+	var samples []Sample
+	types := make(map[string]string)
+
+	err := eachLine(data, func(line string) error {
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				types[fields[2]] = fields[3]
+			}
+			return nil
+		}
+
+		nameEnd := strings.IndexAny(line, "{ \t")
+		if nameEnd <= 0 {
+			return fmt.Errorf("缺少指标值")
+		}
+		name := line[:nameEnd]
+		rest := line[nameEnd:]
+
+		var labels map[string]string
+		if rest[0] == '{' {
+			var err error
+			labels, rest, err = parsePrometheusLabels(rest[1:])
+			if err != nil {
+				return err
+			}
+		}
+
+		fields := strings.Fields(rest)
+		if len(fields) == 0 {
+			return fmt.Errorf("缺少指标值")
+		}
+		value, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return fmt.Errorf("无效的指标值: %s", fields[0])
+		}
+
+		kind := kindGauge
+		if types[name] == "counter" || types[strings.TrimSuffix(name, "_total")] == "counter" {
+			kind = kindCounter
+		}
+		samples = append(samples, Sample{Name: name, Labels: labels, Value: value, Kind: kind})
+		return nil
+	})
+	return samples, err
+}
+
+// parsePrometheusLabels 解析 {} 内的标签，返回标签及右花括号之后的内容
+func parsePrometheusLabels(s string) (map[string]string, string, error) {
+	labels := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return nil, "", fmt.Errorf("标签未闭合")
+		}
+		if s[0] == '}' {
+			return labels, s[1:], nil
+		}
+
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, "", fmt.Errorf("无效的标签")
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = strings.TrimLeft(s[eq+1:], " \t")
+		if s == "" || s[0] != '"' {
+			return nil, "", fmt.Errorf("标签值必须使用双引号")
+		}
+
+		var b strings.Builder
+		i := 1
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				switch s[i] {
+				case 'n':
+					b.WriteByte('\n')
+				default:
+					b.WriteByte(s[i])
+				}
+				continue
+			}
+			b.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, "", fmt.Errorf("标签值未闭合")
+		}
+		labels[key] = b.String()
+		s = s[i+1:]
+	}
+}
+
+// ParseInflux 解析 InfluxDB 行协议，指标名为 measurement_field（字段名为 value 时直接使用 measurement），
+// 字符串字段会被忽略，布尔值转换为 1/0
+func ParseInflux(data []byte) ([]Sample, error) {
+	var samples []Sample
+
+	err := eachLine(data, func(line string) error {
+		if strings.HasPrefix(line, "#") {
+			return nil
+		}
+
+		parts := splitUnescaped(line, ' ', true)
+		if len(parts) < 2 {
+			return fmt.Errorf("缺少字段")
+		}
+
+		series := splitUnescaped(parts[0], ',', false)
+		measurement := unescapeInflux(series[0])
+		if measurement == "" {
+			return fmt.Errorf("缺少 measurement")
+		}
+		var labels map[string]string
+		for _, tag := range series[1:] {
+			kv := splitUnescaped(tag, '=', false)
+			if len(kv) != 2 {
+				return fmt.Errorf("无效的标签: %s", tag)
+			}
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[unescapeInflux(kv[0])] = unescapeInflux(kv[1])
+		}
+
+		for _, field := range splitUnescaped(parts[1], ',', true) {
+			kv := splitUnescaped(field, '=', true)
+			if len(kv) != 2 {
+				return fmt.Errorf("无效的字段: %s", field)
+			}
+			value, ok, err := parseInfluxValue(kv[1])
+			if err != nil {
+				return err
+			}
+			if !ok {
+				continue
+			}
+			name := measurement
+			if key := unescapeInflux(kv[0]); key != "value" {
+				name = measurement + "_" + key
+			}
+			samples = append(samples, Sample{Name: name, Labels: labels, Value: value, Kind: kindGauge})
+		}
+		return nil
+	})
+	return samples, err
+}
+
+// parseInfluxValue 解析字段值，字符串字段返回 ok=false
+func parseInfluxValue(raw string) (float64, bool, error) {
+	if raw == "" {
+		return 0, false, fmt.Errorf("字段值为空")
+	}
+	if raw[0] == '"' {
+		return 0, false, nil
+	}
+	switch raw {
+	case "t", "T", "true", "True", "TRUE":
+		return 1, true, nil
+	case "f", "F", "false", "False", "FALSE":
+		return 0, true, nil
+	}
+	if last := raw[len(raw)-1]; last == 'i' || last == 'u' {
+		raw = raw[:len(raw)-1]
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("无效的字段值: %s", raw)
+	}
+	return value, true, nil
+}
+
+// splitUnescaped 按未转义的分隔符切分，quoted 为 true 时忽略双引号内的分隔符
+func splitUnescaped(s string, sep byte, quoted bool) []string {
+	var parts []string
+	start := 0
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"' && quoted:
+			inQuote = !inQuote
+		case s[i] == sep && !inQuote:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unescapeInflux 去除行协议中的转义字符
+func unescapeInflux(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// ParseStatsD 解析 StatsD 协议（支持 DogStatsD 风格的 #tag:value 标签），
+// 计数器按采样率换算后累加，耗时类指标按上报周期求平均，set 类型不支持
+func ParseStatsD(data []byte) ([]Sample, error) {
+	var samples []Sample
+
+	err := eachLine(data, func(line string) error {
+		sections := strings.Split(line, "|")
+		if len(sections) < 2 {
+			return fmt.Errorf("缺少指标类型")
+		}
+		colon := strings.LastIndexByte(sections[0], ':')
+		if colon <= 0 {
+			return fmt.Errorf("缺少指标值")
+		}
+		name := sections[0][:colon]
+		rawValue := sections[0][colon+1:]
+		value, err := strconv.ParseFloat(rawValue, 64)
+		if err != nil {
+			return fmt.Errorf("无效的指标值: %s", rawValue)
+		}
+
+		sample := Sample{Name: name, Value: value}
+		switch sections[1] {
+		case "g":
+			sample.Kind = kindGauge
+			sample.Relative = strings.HasPrefix(rawValue, "+") || strings.HasPrefix(rawValue, "-")
+		case "c":
+			sample.Kind = kindDelta
+		case "ms", "h", "d":
+			sample.Kind = kindTiming
+		default:
+			return fmt.Errorf("不支持的指标类型: %s", sections[1])
+		}
+
+		for _, section := range sections[2:] {
+			switch {
+			case strings.HasPrefix(section, "@"):
+				rate, err := strconv.ParseFloat(section[1:], 64)
+				if err != nil || rate <= 0 || rate > 1 {
+					return fmt.Errorf("无效的采样率: %s", section)
+				}
+				if sample.Kind == kindDelta {
+					sample.Value /= rate
+				}
+			case strings.HasPrefix(section, "#"):
+				sample.Labels = make(map[string]string)
+				for _, tag := range strings.Split(section[1:], ",") {
+					if tag == "" {
+						continue
+					}
+					key, val, _ := strings.Cut(tag, ":")
+					sample.Labels[key] = val
+				}
+			}
+		}
+		samples = append(samples, sample)
+		return nil
+	})
+	return samples, err
+}
@@ -0,0 +1,105 @@
+package custommetric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func TestParsePrometheus(t *testing.T) {
+	data := []byte(`# HELP queue_depth 队列长度
+# TYPE orders counter
+queue_depth{queue="email",env="prod"} 42
+orders_total{shop="a \"b\""} 1027 1700000000000
+bad_line
+`)
+	samples, err := ParsePrometheus(data)
+	if err == nil {
+		t.Fatalf("expected error for bad line")
+	}
+	if len(samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(samples))
+	}
+	if samples[0].Name != "queue_depth" || samples[0].Value != 42 || samples[0].Kind != kindGauge {
+		t.Fatalf("unexpected sample: %+v", samples[0])
+	}
+	if samples[0].Labels["queue"] != "email" || samples[0].Labels["env"] != "prod" {
+		t.Fatalf("unexpected labels: %v", samples[0].Labels)
+	}
+	if samples[1].Kind != kindCounter || samples[1].Labels["shop"] != `a "b"` {
+		t.Fatalf("unexpected sample: %+v", samples[1])
+	}
+}
+
+func TestParseInflux(t *testing.T) {
+	data := []byte(`queue,name=email\ out depth=12i,consumers=3,state="ok",healthy=true 1700000000000000000
+temperature value=21.5`)
+	samples, err := ParseInflux(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[string]float64{
+		"queue_depth":     12,
+		"queue_consumers": 3,
+		"queue_healthy":   1,
+		"temperature":     21.5,
+	}
+	if len(samples) != len(want) {
+		t.Fatalf("expected %d samples, got %d: %+v", len(want), len(samples), samples)
+	}
+	for _, s := range samples {
+		if want[s.Name] != s.Value {
+			t.Fatalf("unexpected sample: %+v", s)
+		}
+	}
+	if samples[0].Labels["name"] != "email out" {
+		t.Fatalf("unexpected labels: %v", samples[0].Labels)
+	}
+}
+
+func TestParseStatsD(t *testing.T) {
+	data := []byte("jobs.done:2|c|@0.5|#queue:email\nlatency:120|ms\nconn:+3|g\nusers:1|s")
+	samples, err := ParseStatsD(data)
+	if err == nil {
+		t.Fatalf("expected error for set type")
+	}
+	if len(samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(samples))
+	}
+	if samples[0].Value != 4 || samples[0].Kind != kindDelta || samples[0].Labels["queue"] != "email" {
+		t.Fatalf("unexpected sample: %+v", samples[0])
+	}
+	if samples[1].Kind != kindTiming || !samples[2].Relative {
+		t.Fatalf("unexpected samples: %+v", samples[1:])
+	}
+}
+
+func TestRegistryCollect(t *testing.T) {
+	r := NewRegistry(2, time.Minute)
+	r.Add([]Sample{
+		{Name: "jobs", Value: 2, Kind: kindDelta},
+		{Name: "latency", Value: 100, Kind: kindTiming},
+		{Name: "latency", Value: 200, Kind: kindTiming},
+	}, nil)
+	r.Add([]Sample{{Name: "jobs", Value: 3, Kind: kindDelta}}, nil)
+	if dropped := r.Add([]Sample{{Name: "extra", Value: 1, Kind: kindGauge}}, nil); dropped != 1 {
+		t.Fatalf("expected 1 dropped sample, got %d", dropped)
+	}
+
+	result := r.Collect()
+	if len(result) != 2 {
+		t.Fatalf("expected 2 metrics, got %d", len(result))
+	}
+	if result[0].Name != "jobs" || result[0].Value != 5 || result[0].Kind != protocol.CustomMetricKindCounter {
+		t.Fatalf("unexpected metric: %+v", result[0])
+	}
+	if result[1].Name != "latency" || result[1].Value != 150 {
+		t.Fatalf("unexpected metric: %+v", result[1])
+	}
+
+	// 耗时类指标在周期内没有新样本时不再上报
+	if result = r.Collect(); len(result) != 1 {
+		t.Fatalf("expected 1 metric, got %d", len(result))
+	}
+}
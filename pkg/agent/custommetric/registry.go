@@ -0,0 +1,153 @@
+package custommetric
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// series 单条自定义指标序列的当前状态
+type series struct {
+	name      string
+	labels    map[string]string
+	kind      string
+	value     float64
+	sum       float64 // 耗时类指标在当前周期内的累计值
+	count     int     // 耗时类指标在当前周期内的样本数
+	updatedAt time.Time
+}
+
+// Registry 自定义指标注册表，合并各来源的样本并在采集时输出当前值
+type Registry struct {
+	mu         sync.Mutex
+	series     map[string]*series
+	maxSeries  int
+	staleAfter time.Duration
+}
+
+// NewRegistry 创建注册表
+func NewRegistry(maxSeries int, staleAfter time.Duration) *Registry {
+	return &Registry{
+		series:     make(map[string]*series),
+		maxSeries:  maxSeries,
+		staleAfter: staleAfter,
+	}
+}
+
+// seriesKey 生成序列唯一标识（名称 + 排序后的标签）
+func seriesKey(name string, labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteByte(0)
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(labels[k])
+	}
+	return b.String()
+}
+
+// Add 写入样本，extraLabels 会附加到每个样本上（样本自身标签优先），返回因超出序列上限而丢弃的样本数
+func (r *Registry) Add(samples []Sample, extraLabels map[string]string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	dropped := 0
+	for _, sample := range samples {
+		// NaN/Inf 无法编码为 JSON，直接丢弃
+		if math.IsNaN(sample.Value) || math.IsInf(sample.Value, 0) {
+			continue
+		}
+		labels := sample.Labels
+		if len(extraLabels) > 0 {
+			labels = make(map[string]string, len(sample.Labels)+len(extraLabels))
+			for k, v := range extraLabels {
+				labels[k] = v
+			}
+			for k, v := range sample.Labels {
+				labels[k] = v
+			}
+		}
+
+		key := seriesKey(sample.Name, labels)
+		s, ok := r.series[key]
+		if !ok {
+			if r.maxSeries > 0 && len(r.series) >= r.maxSeries {
+				dropped++
+				continue
+			}
+			s = &series{name: sample.Name, labels: labels, kind: sample.Kind}
+			r.series[key] = s
+		}
+
+		switch sample.Kind {
+		case kindDelta:
+			s.value += sample.Value
+		case kindTiming:
+			s.sum += sample.Value
+			s.count++
+		case kindGauge:
+			if sample.Relative {
+				s.value += sample.Value
+			} else {
+				s.value = sample.Value
+			}
+		default:
+			s.value = sample.Value
+		}
+		s.kind = sample.Kind
+		s.updatedAt = now
+	}
+	return dropped
+}
+
+// Collect 输出所有未过期序列的当前值，耗时类指标输出本周期平均值后清零
+func (r *Registry) Collect() []protocol.CustomMetricData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	keys := make([]string, 0, len(r.series))
+	for key, s := range r.series {
+		if r.staleAfter > 0 && now.Sub(s.updatedAt) > r.staleAfter {
+			delete(r.series, key)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]protocol.CustomMetricData, 0, len(keys))
+	for _, key := range keys {
+		s := r.series[key]
+		data := protocol.CustomMetricData{
+			Name:   s.name,
+			Kind:   protocol.CustomMetricKindGauge,
+			Labels: s.labels,
+			Value:  s.value,
+		}
+		switch s.kind {
+		case kindCounter, kindDelta:
+			data.Kind = protocol.CustomMetricKindCounter
+		case kindTiming:
+			if s.count == 0 {
+				continue
+			}
+			data.Value = s.sum / float64(s.count)
+			s.sum, s.count = 0, 0
+		}
+		result = append(result, data)
+	}
+	return result
+}
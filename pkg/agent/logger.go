@@ -5,10 +5,48 @@ import (
 	"log/slog"
 	"os"
 	"strings"
+	"sync"
 
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
+// recentLogLines 内存中保留的最近日志行数，用于诊断信息
+const recentLogLines = 500
+
+var recentLogs = &logRing{lines: make([]string, recentLogLines)}
+
+// logRing 保存最近的日志行，与日志输出位置无关
+type logRing struct {
+	mu    sync.Mutex
+	lines []string
+	next  int
+	full  bool
+}
+
+func (r *logRing) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		r.lines[r.next] = line
+		r.next = (r.next + 1) % len(r.lines)
+		if r.next == 0 {
+			r.full = true
+		}
+	}
+	return len(p), nil
+}
+
+// RecentLogs 返回最近的日志（按时间顺序）
+func RecentLogs() []string {
+	r := recentLogs
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]string(nil), r.lines[:r.next]...)
+	}
+	return append(append([]string(nil), r.lines[r.next:]...), r.lines[:r.next]...)
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level      string
@@ -62,7 +100,7 @@ func InitLogger(cfg *LogConfig) {
 			return a
 		},
 	}
-	handler := slog.NewTextHandler(writer, opts)
+	handler := slog.NewTextHandler(io.MultiWriter(writer, recentLogs), opts)
 	logger := slog.New(handler)
 	slog.SetDefault(logger)
 }
@@ -0,0 +1,72 @@
+package logwatch
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// allowlist 允许服务端规则读取的目录范围，来自本地 agent.yaml 的 log_watch.allowed_paths，
+// 服务端无法修改，避免通过下发规则读取任意文件（探针通常以 root 运行）
+type allowlist struct {
+	// roots 允许的目录或文件，同时包含配置的路径与解析符号链接后的实际路径
+	roots []string
+}
+
+func newAllowlist(paths []string) *allowlist {
+	a := &allowlist{}
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		if !filepath.IsAbs(p) {
+			slog.Warn("日志监控允许路径必须为绝对路径，已忽略", "path", p)
+			continue
+		}
+		p = filepath.Clean(p)
+		a.roots = append(a.roots, p)
+		if real, err := filepath.EvalSymlinks(p); err == nil && real != p {
+			a.roots = append(a.roots, real)
+		}
+	}
+	return a
+}
+
+// allows 判断路径是否位于允许的目录下（含子目录）或为允许的文件，规则路径中的通配符不会跨越目录
+func (a *allowlist) allows(path string) bool {
+	if !filepath.IsAbs(path) {
+		return false
+	}
+	path = filepath.Clean(path)
+	for _, root := range a.roots {
+		if pathWithin(path, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsFile 按解析符号链接后的实际路径判断文件是否允许读取，避免允许目录中的链接指向其他文件
+func (a *allowlist) allowsFile(path string) bool {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return false
+	}
+	return a.allows(real)
+}
+
+func pathWithin(path, root string) bool {
+	if runtime.GOOS == "windows" {
+		path = strings.ToLower(path)
+		root = strings.ToLower(root)
+	}
+	if path == root {
+		return true
+	}
+	if !strings.HasSuffix(root, string(os.PathSeparator)) {
+		root += string(os.PathSeparator)
+	}
+	return strings.HasPrefix(path, root)
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package logwatch
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID 返回文件的 inode，用于识别日志轮转
+func fileID(info os.FileInfo) uint64 {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Ino)
+	}
+	return 0
+}
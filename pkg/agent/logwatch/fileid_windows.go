@@ -0,0 +1,10 @@
+//go:build windows
+
+package logwatch
+
+import "os"
+
+// fileID Windows 下不使用文件标识，轮转只能通过文件变小识别
+func fileID(info os.FileInfo) uint64 {
+	return 0
+}
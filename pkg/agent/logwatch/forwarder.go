@@ -0,0 +1,267 @@
+package logwatch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/utils"
+)
+
+const (
+	// forwardStateFileName 系统日志的读取位置（journald 游标或 syslog 文件位置），探针重启后继续读取
+	forwardStateFileName = "logforward_state.json"
+	// forwardBatchSize 每批最多上报的日志条数
+	forwardBatchSize = 500
+	// forwardFlushInterval 未攒满一批时的上报间隔
+	forwardFlushInterval = 5 * time.Second
+	// forwardQueueSize 等待上报的批次上限，连接断开期间超出的日志直接丢弃
+	forwardQueueSize = 20
+	// maxMessageBytes 单条日志最多保留的字节数
+	maxMessageBytes = 4 * 1024
+)
+
+// syslogPaths 没有 journald 时依次尝试的 syslog 文件
+var syslogPaths = []string{"/var/log/syslog", "/var/log/messages"}
+
+// forwardState 系统日志的读取位置
+type forwardState struct {
+	Cursor string     `json:"cursor,omitempty"` // journald 游标
+	Path   string     `json:"path,omitempty"`   // syslog 文件路径
+	Offset fileOffset `json:"offset"`           // syslog 文件读取位置
+}
+
+// sourceEntry 日志来源读取到的日志及读取后的位置
+type sourceEntry struct {
+	entry protocol.LogEntry
+	state forwardState
+}
+
+// logSource 系统日志来源
+type logSource interface {
+	// name 来源描述，随配置应用结果上报
+	name() string
+	// run 持续读取日志直到 ctx 取消，state 为上次保存的读取位置
+	run(ctx context.Context, state forwardState, out chan<- sourceEntry)
+}
+
+// Forwarder 按服务端下发的配置读取 journald 或 syslog，过滤后批量上报
+type Forwarder struct {
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	done    chan struct{}
+	current protocol.LogForwardConfig
+	source  string
+	batches chan protocol.LogEntryBatch
+
+	// 以下字段只在转发循环中访问
+	statePath  string
+	state      forwardState
+	savedState forwardState
+	dropped    int
+}
+
+// NewForwarder 创建系统日志转发器
+func NewForwarder() *Forwarder {
+	return newForwarder(filepath.Join(utils.GetSafeHomeDir(), ".pika", forwardStateFileName))
+}
+
+func newForwarder(statePath string) *Forwarder {
+	return &Forwarder{
+		batches:   make(chan protocol.LogEntryBatch, forwardQueueSize),
+		statePath: statePath,
+	}
+}
+
+// Batches 获取待上报的日志批次
+func (f *Forwarder) Batches() <-chan protocol.LogEntryBatch {
+	return f.batches
+}
+
+// Apply 应用转发配置并返回日志来源，配置无效或没有可用的日志来源时保持原有配置不变
+func (f *Forwarder) Apply(ctx context.Context, cfg protocol.LogForwardConfig) (string, error) {
+	if cfg.Priority < 0 || cfg.Priority > 7 {
+		return "", fmt.Errorf("日志级别无效: %d", cfg.Priority)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// 重连后服务端会重新下发相同的配置，无需重启
+	if f.cancel != nil && cfg.Enabled && cfg.Priority == f.current.Priority && slices.Equal(cfg.Units, f.current.Units) {
+		return f.source, nil
+	}
+
+	var src logSource
+	if cfg.Enabled {
+		var err error
+		if src, err = detectSource(cfg); err != nil {
+			return "", err
+		}
+	}
+
+	f.stopLocked()
+	f.current = cfg
+	f.source = ""
+	if src == nil {
+		slog.Info("系统日志转发已停止")
+		return "", nil
+	}
+
+	f.source = src.name()
+	f.start(ctx, src)
+	slog.Info("系统日志转发已启动", "source", f.source, "units", cfg.Units, "priority", cfg.Priority)
+	return f.source, nil
+}
+
+// Stop 停止转发并保存读取位置
+func (f *Forwarder) Stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopLocked()
+}
+
+func (f *Forwarder) stopLocked() {
+	if f.cancel == nil {
+		return
+	}
+	f.cancel()
+	<-f.done
+	f.cancel = nil
+	f.done = nil
+}
+
+func (f *Forwarder) start(ctx context.Context, src logSource) {
+	f.state = f.loadState()
+	f.savedState = f.state
+	loopCtx, cancel := context.WithCancel(ctx)
+	f.cancel = cancel
+	f.done = make(chan struct{})
+	go f.run(loopCtx, src, f.done)
+}
+
+// detectSource 优先使用 journald，没有时使用 syslog 文件
+func detectSource(cfg protocol.LogForwardConfig) (logSource, error) {
+	if runtime.GOOS == "linux" {
+		if bin, err := exec.LookPath("journalctl"); err == nil {
+			return &journalSource{bin: bin, units: cfg.Units, priority: cfg.Priority}, nil
+		}
+	}
+	for _, path := range syslogPaths {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return newSyslogSource(path, cfg.Units, cfg.Priority), nil
+		}
+	}
+	return nil, errors.New("未找到 journalctl 或 syslog 日志文件")
+}
+
+// run 转发循环，攒满一批或到达上报间隔时放入上报队列
+func (f *Forwarder) run(ctx context.Context, src logSource, done chan struct{}) {
+	defer close(done)
+
+	entries := make(chan sourceEntry, forwardBatchSize)
+	srcDone := make(chan struct{})
+	go func() {
+		defer close(srcDone)
+		src.run(ctx, f.state, entries)
+	}()
+
+	ticker := time.NewTicker(forwardFlushInterval)
+	defer ticker.Stop()
+	batch := make([]protocol.LogEntry, 0, forwardBatchSize)
+
+	for {
+		select {
+		case <-ctx.Done():
+			<-srcDone
+			f.flush(batch)
+			f.saveState()
+			return
+		case e := <-entries:
+			batch = append(batch, e.entry)
+			f.state = e.state
+			if len(batch) >= forwardBatchSize {
+				f.flush(batch)
+				batch = make([]protocol.LogEntry, 0, forwardBatchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				f.flush(batch)
+				batch = make([]protocol.LogEntry, 0, forwardBatchSize)
+			}
+			f.saveState()
+		}
+	}
+}
+
+// flush 放入上报队列，队列已满时丢弃并在下一批中报告丢弃数量
+func (f *Forwarder) flush(batch []protocol.LogEntry) {
+	if len(batch) == 0 {
+		return
+	}
+	select {
+	case f.batches <- protocol.LogEntryBatch{Entries: batch, Dropped: f.dropped}:
+		f.dropped = 0
+	default:
+		if f.dropped == 0 {
+			slog.Warn("系统日志上报队列已满，开始丢弃日志")
+		}
+		f.dropped += len(batch)
+	}
+}
+
+func (f *Forwarder) loadState() forwardState {
+	var state forwardState
+	data, err := os.ReadFile(f.statePath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("读取系统日志读取位置失败", "path", f.statePath, "error", err)
+		}
+		return state
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		slog.Warn("系统日志读取位置文件无效，从最新日志开始读取", "path", f.statePath, "error", err)
+		return forwardState{}
+	}
+	return state
+}
+
+func (f *Forwarder) saveState() {
+	if f.state == f.savedState {
+		return
+	}
+	data, err := json.Marshal(f.state)
+	if err == nil {
+		err = writeFileAtomic(f.statePath, data)
+	}
+	if err != nil {
+		slog.Warn("保存系统日志读取位置失败", "error", err)
+		return
+	}
+	f.savedState = f.state
+}
+
+// truncateMessage 截断过长的日志，保证结果是有效的 UTF-8
+func truncateMessage(message string) string {
+	message = strings.TrimRight(message, "\r\n")
+	if len(message) > maxMessageBytes {
+		cut := maxMessageBytes
+		for cut > 0 && !utf8.RuneStart(message[cut]) {
+			cut--
+		}
+		message = message[:cut]
+	}
+	return strings.ToValidUTF8(message, "�")
+}
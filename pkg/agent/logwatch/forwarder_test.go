@@ -0,0 +1,92 @@
+package logwatch
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func TestParseJournalEntry(t *testing.T) {
+	// journalctl --output=json 的输出，第二行 MESSAGE 包含不可打印字符，以字节数组表示
+	lines := []string{
+		`{"__CURSOR":"s=abc;i=1","__REALTIME_TIMESTAMP":"1760752800123456","PRIORITY":"3","_SYSTEMD_UNIT":"nginx.service","SYSLOG_IDENTIFIER":"nginx","MESSAGE":"connect() failed"}`,
+		`{"__CURSOR":"s=abc;i=2","__REALTIME_TIMESTAMP":"1760752801000000","PRIORITY":"4","_COMM":"app","MESSAGE":[104,105,27,10]}`,
+	}
+
+	entry, cursor, err := parseJournalEntry([]byte(lines[0]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := protocol.LogEntry{Timestamp: 1760752800123, Priority: 3, Unit: "nginx.service", Identifier: "nginx", Message: "connect() failed"}
+	if entry != want || cursor != "s=abc;i=1" {
+		t.Fatalf("unexpected entry: %+v cursor=%s", entry, cursor)
+	}
+
+	entry, cursor, err = parseJournalEntry([]byte(lines[1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Identifier != "app" || entry.Message != "hi\x1b" || entry.Priority != 4 || cursor != "s=abc;i=2" {
+		t.Fatalf("unexpected entry: %+v cursor=%s", entry, cursor)
+	}
+}
+
+func TestSyslogSourceParse(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 30, 0, time.Local)
+	s := newSyslogSource("/var/log/syslog", []string{"sshd.service"}, 3)
+
+	entry, ok := s.parse("Dec 31 23:59:59 web1 sshd[812]: error: kex_exchange_identification: Connection closed", now)
+	if !ok || entry.Identifier != "sshd" || entry.Priority != 3 || !strings.HasPrefix(entry.Message, "error: kex") {
+		t.Fatalf("unexpected entry: %+v ok=%v", entry, ok)
+	}
+	if ts := time.UnixMilli(entry.Timestamp); ts.Year() != 2025 {
+		t.Fatalf("expected previous year, got %v", ts)
+	}
+
+	if _, ok := s.parse("2026-01-01T00:00:10.000000+00:00 web1 sshd[812]: Accepted publickey for root", now); ok {
+		t.Fatal("info line should be filtered by priority")
+	}
+	if _, ok := s.parse("Jan  1 00:00:10 web1 cron[9]: error: job failed", now); ok {
+		t.Fatal("other identifiers should be filtered by unit")
+	}
+}
+
+type fakeSource struct {
+	entries []protocol.LogEntry
+}
+
+func (s *fakeSource) name() string { return "fake" }
+
+func (s *fakeSource) run(ctx context.Context, state forwardState, out chan<- sourceEntry) {
+	for i, entry := range s.entries {
+		out <- sourceEntry{entry: entry, state: forwardState{Cursor: strings.Repeat("c", i+1)}}
+	}
+	<-ctx.Done()
+}
+
+func TestForwarderFlushesBatchAndSavesCursor(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), forwardStateFileName)
+	f := newForwarder(statePath)
+	f.start(context.Background(), &fakeSource{entries: []protocol.LogEntry{
+		{Message: "a"}, {Message: "b"}, {Message: "c"},
+	}})
+
+	time.Sleep(100 * time.Millisecond)
+	f.Stop()
+
+	select {
+	case batch := <-f.Batches():
+		if len(batch.Entries) != 3 || batch.Entries[2].Message != "c" {
+			t.Fatalf("unexpected batch: %+v", batch)
+		}
+	default:
+		t.Fatal("expected a batch on stop")
+	}
+	if state := newForwarder(statePath).loadState(); state.Cursor != "ccc" {
+		t.Fatalf("unexpected saved state: %+v", state)
+	}
+}
@@ -0,0 +1,175 @@
+package logwatch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// journalRestartDelay journalctl 异常退出后的重启间隔
+const journalRestartDelay = 10 * time.Second
+
+// journalSource 通过 journalctl --follow 读取 journald 日志，级别和单元过滤交给 journalctl
+type journalSource struct {
+	bin      string
+	units    []string
+	priority int
+}
+
+func (s *journalSource) name() string {
+	return "journald"
+}
+
+func (s *journalSource) args(cursor string) []string {
+	args := []string{"--follow", "--output=json", "--no-pager", "--priority=" + strconv.Itoa(s.priority)}
+	for _, unit := range s.units {
+		args = append(args, "--unit="+unit)
+	}
+	if cursor != "" {
+		args = append(args, "--after-cursor="+cursor)
+	} else {
+		// 没有保存的游标时只读取新产生的日志
+		args = append(args, "--lines=0")
+	}
+	return args
+}
+
+func (s *journalSource) run(ctx context.Context, state forwardState, out chan<- sourceEntry) {
+	cursor := state.Cursor
+	for {
+		next, err := s.follow(ctx, cursor, out)
+		if ctx.Err() != nil {
+			return
+		}
+		if next == cursor && cursor != "" {
+			// 未读到任何日志就退出，通常是游标对应的日志已被清理，改为从最新日志开始
+			slog.Warn("journalctl 无法从保存的游标继续读取，从最新日志开始", "error", err)
+			next = ""
+		} else {
+			slog.Warn("journalctl 已退出，稍后重启", "error", err, "delay", journalRestartDelay)
+		}
+		cursor = next
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(journalRestartDelay):
+		}
+	}
+}
+
+// follow 运行 journalctl 直到退出，返回最后读取的游标
+func (s *journalSource) follow(ctx context.Context, cursor string, out chan<- sourceEntry) (string, error) {
+	cmd := exec.CommandContext(ctx, s.bin, s.args(cursor)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return cursor, err
+	}
+	if err := cmd.Start(); err != nil {
+		return cursor, err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		entry, entryCursor, err := parseJournalEntry(scanner.Bytes())
+		if err != nil {
+			slog.Debug("解析 journald 日志失败", "error", err)
+			continue
+		}
+		if entryCursor != "" {
+			cursor = entryCursor
+		}
+		select {
+		case out <- sourceEntry{entry: entry, state: forwardState{Cursor: cursor}}:
+		case <-ctx.Done():
+			_ = cmd.Wait()
+			return cursor, ctx.Err()
+		}
+	}
+
+	// 读取出错时 journalctl 可能阻塞在写入上，先结束进程再等待退出
+	scanErr := scanner.Err()
+	if scanErr != nil {
+		_ = cmd.Process.Kill()
+	}
+	err = cmd.Wait()
+	if err == nil {
+		err = scanErr
+	}
+	if err == nil {
+		err = errors.New("journalctl 意外退出")
+	}
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		err = fmt.Errorf("%w: %s", err, msg)
+	}
+	return cursor, err
+}
+
+// parseJournalEntry 解析 journalctl --output=json 输出的一行，返回日志条目和游标
+func parseJournalEntry(line []byte) (protocol.LogEntry, string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return protocol.LogEntry{}, "", err
+	}
+
+	entry := protocol.LogEntry{
+		Priority:   6,
+		Unit:       journalField(fields["_SYSTEMD_UNIT"]),
+		Identifier: journalField(fields["SYSLOG_IDENTIFIER"]),
+		Message:    truncateMessage(journalField(fields["MESSAGE"])),
+	}
+	if entry.Identifier == "" {
+		entry.Identifier = journalField(fields["_COMM"])
+	}
+	if priority, err := strconv.Atoi(journalField(fields["PRIORITY"])); err == nil {
+		entry.Priority = priority
+	}
+	if usec, err := strconv.ParseInt(journalField(fields["__REALTIME_TIMESTAMP"]), 10, 64); err == nil {
+		entry.Timestamp = usec / 1000
+	} else {
+		entry.Timestamp = time.Now().UnixMilli()
+	}
+	return entry, journalField(fields["__CURSOR"]), nil
+}
+
+// journalField 读取 journald 字段值。字段值通常是字符串，包含不可打印字符时是字节数组，
+// 同一字段有多个值时是数组（取第一个值），超过大小限制时为 null
+func journalField(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+
+	var values []json.RawMessage
+	if err := json.Unmarshal(raw, &values); err != nil || len(values) == 0 {
+		return ""
+	}
+	if first := bytes.TrimSpace(values[0]); len(first) > 0 && (first[0] == '"' || first[0] == '[') {
+		return journalField(values[0])
+	}
+	var data []int
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return ""
+	}
+	buf := make([]byte, len(data))
+	for i, b := range data {
+		buf[i] = byte(b)
+	}
+	return string(buf)
+}
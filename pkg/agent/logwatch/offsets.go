@@ -0,0 +1,97 @@
+package logwatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// offsetFileName 各日志文件的读取位置，探针重启后从上次位置继续读取
+const offsetFileName = "logwatch_offsets.json"
+
+// fileOffset 日志文件的读取位置
+type fileOffset struct {
+	FileID uint64 `json:"fileId"` // 文件标识（inode），与当前文件不一致说明已轮转
+	Offset int64  `json:"offset"` // 已处理的字节数
+}
+
+// offsetStore 读取位置的本地持久化，只在监控循环中访问
+type offsetStore struct {
+	path    string
+	offsets map[string]fileOffset
+	dirty   bool
+}
+
+func loadOffsetStore(path string) *offsetStore {
+	s := &offsetStore{
+		path:    path,
+		offsets: make(map[string]fileOffset),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("读取日志读取位置失败", "path", path, "error", err)
+		}
+		return s
+	}
+	if err := json.Unmarshal(data, &s.offsets); err != nil {
+		slog.Warn("日志读取位置文件无效，从文件末尾开始读取", "path", path, "error", err)
+		s.offsets = make(map[string]fileOffset)
+	}
+	return s
+}
+
+func (s *offsetStore) get(path string) (fileOffset, bool) {
+	offset, ok := s.offsets[path]
+	return offset, ok
+}
+
+func (s *offsetStore) set(path string, offset fileOffset) {
+	if s.offsets[path] != offset {
+		s.offsets[path] = offset
+		s.dirty = true
+	}
+}
+
+// retain 只保留仍在跟踪的文件
+func (s *offsetStore) retain(paths map[string]*tailer) {
+	for path := range s.offsets {
+		if _, ok := paths[path]; !ok {
+			delete(s.offsets, path)
+			s.dirty = true
+		}
+	}
+}
+
+// save 有变化时写入本地文件
+func (s *offsetStore) save() error {
+	if !s.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(s.offsets)
+	if err != nil {
+		return fmt.Errorf("序列化日志读取位置失败: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("保存日志读取位置失败: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// writeFileAtomic 先写临时文件再重命名，避免探针异常退出时留下不完整的文件
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
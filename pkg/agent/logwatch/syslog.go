@@ -0,0 +1,160 @@
+package logwatch
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// syslogSource 没有 journald 时跟踪 syslog 文件。syslog 文件不记录级别，按关键字推断级别，按程序名匹配单元
+type syslogSource struct {
+	path     string
+	units    map[string]bool
+	priority int
+}
+
+func newSyslogSource(path string, units []string, priority int) *syslogSource {
+	s := &syslogSource{path: path, priority: priority}
+	if len(units) > 0 {
+		s.units = make(map[string]bool, len(units))
+		for _, unit := range units {
+			s.units[strings.TrimSuffix(unit, ".service")] = true
+		}
+	}
+	return s
+}
+
+func (s *syslogSource) name() string {
+	return s.path
+}
+
+func (s *syslogSource) run(ctx context.Context, state forwardState, out chan<- sourceEntry) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	var t *tailer
+	defer func() {
+		if t != nil {
+			t.close()
+		}
+	}()
+
+	openFailed := false
+	for {
+		if t == nil {
+			var err error
+			t, err = openTailer(s.path, state.Offset, state.Path == s.path, false)
+			if err != nil && !openFailed {
+				slog.Warn("打开 syslog 文件失败", "path", s.path, "error", err)
+			}
+			openFailed = err != nil
+		}
+		if t != nil {
+			if err := t.read(func(line string) {
+				entry, ok := s.parse(line, time.Now())
+				if !ok {
+					return
+				}
+				select {
+				case out <- sourceEntry{entry: entry, state: forwardState{Path: s.path, Offset: t.position()}}:
+				case <-ctx.Done():
+				}
+			}); err != nil {
+				slog.Warn("读取 syslog 文件失败", "path", s.path, "error", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// parse 解析并过滤一行 syslog
+func (s *syslogSource) parse(line string, now time.Time) (protocol.LogEntry, bool) {
+	if strings.TrimSpace(line) == "" {
+		return protocol.LogEntry{}, false
+	}
+	ts, identifier, message := parseSyslogLine(line, now)
+	if s.units != nil && !s.units[identifier] {
+		return protocol.LogEntry{}, false
+	}
+	priority := guessPriority(message)
+	if priority > s.priority {
+		return protocol.LogEntry{}, false
+	}
+	return protocol.LogEntry{
+		Timestamp:  ts.UnixMilli(),
+		Priority:   priority,
+		Identifier: identifier,
+		Message:    truncateMessage(message),
+	}, true
+}
+
+// parseSyslogLine 解析 "Oct 18 10:00:00 host sshd[123]: message" 或以 RFC3339 时间开头的 syslog 行，
+// 无法识别的格式整行作为日志内容并使用当前时间
+func parseSyslogLine(line string, now time.Time) (time.Time, string, string) {
+	var ts time.Time
+	var rest string
+	if field, after, ok := strings.Cut(line, " "); ok {
+		if t, err := time.Parse(time.RFC3339Nano, field); err == nil {
+			ts, rest = t, after
+		}
+	}
+	if ts.IsZero() && len(line) > len(time.Stamp) {
+		if t, err := time.ParseInLocation(time.Stamp, line[:len(time.Stamp)], now.Location()); err == nil {
+			// 传统格式不含年份，跨年时日期会晚于当前时间
+			ts = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, now.Location())
+			if ts.After(now.Add(24 * time.Hour)) {
+				ts = ts.AddDate(-1, 0, 0)
+			}
+			rest = strings.TrimPrefix(line[len(time.Stamp):], " ")
+		}
+	}
+	if ts.IsZero() {
+		return now, "", line
+	}
+
+	// 跳过主机名
+	_, body, ok := strings.Cut(rest, " ")
+	if !ok {
+		return ts, "", rest
+	}
+	tag, message, ok := strings.Cut(body, ": ")
+	if !ok || strings.Contains(tag, " ") {
+		return ts, "", body
+	}
+	if i := strings.IndexByte(tag, '['); i > 0 {
+		tag = tag[:i]
+	}
+	return ts, tag, message
+}
+
+// guessPriority 按关键字推断 syslog 行的级别：panic/fatal/crit 为 2，error/fail 为 3，warn 为 4，其余为 6
+func guessPriority(message string) int {
+	lower := strings.ToLower(message)
+	switch {
+	case containsAny(lower, "panic", "fatal", "critical", "emerg"):
+		return 2
+	case containsAny(lower, "error", "fail", "segfault", "oom-kill", "out of memory"):
+		return 3
+	case containsAny(lower, "warn"):
+		return 4
+	default:
+		return 6
+	}
+}
+
+func containsAny(s string, keywords ...string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
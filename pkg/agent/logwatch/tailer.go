@@ -0,0 +1,166 @@
+package logwatch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// maxLineBytes 单行最多保留的字节数，超出部分截断
+	maxLineBytes = 8 * 1024
+	// maxReadBytes 每次轮询单个文件最多读取的字节数，积压较多时分多次读取，避免阻塞其他文件
+	maxReadBytes = 4 * 1024 * 1024
+)
+
+// tailer 跟踪单个日志文件，处理轮转（重命名后新建）与截断（copytruncate）
+type tailer struct {
+	path       string
+	file       *os.File
+	id         uint64
+	offset     int64  // 已处理完整行的位置
+	partial    []byte // 尚未以换行结尾的内容（最多保留 maxLineBytes）
+	partialLen int64  // 尚未以换行结尾的实际字节数
+}
+
+// openTailer 打开日志文件。有保存的读取位置时从该位置继续，文件已轮转或被截断则从头读取；
+// 没有保存的位置时，fromStart 为 true 从头读取（跟踪开始后新出现的文件），否则从文件末尾开始
+func openTailer(path string, saved fileOffset, hasSaved bool, fromStart bool) (*tailer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	t := &tailer{path: path, file: file, id: fileID(info)}
+	switch {
+	case hasSaved && saved.FileID == t.id && saved.Offset <= info.Size():
+		t.offset = saved.Offset
+	case hasSaved || fromStart:
+		t.offset = 0
+	default:
+		t.offset = info.Size()
+	}
+	if _, err := file.Seek(t.offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// position 返回当前读取位置，未完成的行下次重新读取
+func (t *tailer) position() fileOffset {
+	return fileOffset{FileID: t.id, Offset: t.offset}
+}
+
+// read 读取新增的完整行
+func (t *tailer) read(onLine func(line string)) error {
+	rotated := false
+	info, err := os.Stat(t.path)
+	switch {
+	case err != nil:
+		// 文件被移走且尚未新建时继续读完旧文件
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	case t.id != 0 && fileID(info) != t.id:
+		rotated = true
+	case info.Size() < t.offset+t.partialLen:
+		// 文件被截断，从头读取
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		t.offset = 0
+		t.resetPartial()
+	}
+
+	eof, err := t.drain(onLine)
+	if err != nil || !rotated || !eof {
+		return err
+	}
+
+	// 旧文件已读完，切换到新文件并从头读取
+	file, err := os.Open(t.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	newInfo, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	t.file.Close()
+	t.file = file
+	t.id = fileID(newInfo)
+	t.offset = 0
+	t.resetPartial()
+	_, err = t.drain(onLine)
+	return err
+}
+
+// drain 读取到文件末尾或达到单次读取上限，读到末尾时返回 true
+func (t *tailer) drain(onLine func(line string)) (bool, error) {
+	buf := make([]byte, 32*1024)
+	read := 0
+	for read < maxReadBytes {
+		n, err := t.file.Read(buf)
+		if n > 0 {
+			read += n
+			t.consume(buf[:n], onLine)
+		}
+		if errors.Is(err, io.EOF) || (err == nil && n == 0) {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func (t *tailer) consume(data []byte, onLine func(line string)) {
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			t.appendPartial(data)
+			return
+		}
+		t.appendPartial(data[:i])
+		line := strings.TrimRight(string(t.partial), "\r")
+		t.offset += t.partialLen + 1
+		t.resetPartial()
+		onLine(line)
+		data = data[i+1:]
+	}
+}
+
+func (t *tailer) appendPartial(data []byte) {
+	t.partialLen += int64(len(data))
+	if room := maxLineBytes - len(t.partial); room > 0 {
+		if len(data) > room {
+			data = data[:room]
+		}
+		t.partial = append(t.partial, data...)
+	}
+}
+
+func (t *tailer) resetPartial() {
+	t.partial = t.partial[:0]
+	t.partialLen = 0
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
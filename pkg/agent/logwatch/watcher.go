@@ -0,0 +1,326 @@
+package logwatch
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/utils"
+)
+
+const (
+	// pollInterval 日志文件轮询间隔
+	pollInterval = time.Second
+	// rescanInterval 重新匹配通配符路径并保存读取位置的间隔
+	rescanInterval = 10 * time.Second
+	// maxFiles 最多同时跟踪的文件数
+	maxFiles = 64
+
+	defaultWindow      = 60
+	defaultSampleLines = 5
+	maxSampleLines     = 20
+)
+
+// rule 编译后的日志监控规则
+type rule struct {
+	protocol.LogWatchRule
+	pattern *regexp.Regexp
+	exclude *regexp.Regexp
+}
+
+// matchState 规则在单个文件上的限流状态，窗口内的匹配合并为一个事件
+type matchState struct {
+	window   time.Duration
+	pending  *protocol.LogWatchEvent
+	lastSent time.Time
+}
+
+// Watcher 按服务端下发的规则跟踪日志文件，匹配的行经限流合并后作为事件上报
+type Watcher struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+	events chan protocol.LogWatchEvent
+
+	allowed *allowlist
+
+	// 以下字段只在监控循环中访问，Apply 在循环停止后才会修改
+	offsets   *offsetStore
+	rules     []*rule
+	pathRules map[string][]*rule
+	tailers   map[string]*tailer
+	states    map[string]*matchState
+}
+
+// NewWatcher 创建日志监控器，规则只能读取 allowedPaths 中的目录或文件
+func NewWatcher(allowedPaths []string) *Watcher {
+	return newWatcher(filepath.Join(utils.GetSafeHomeDir(), ".pika", offsetFileName), allowedPaths)
+}
+
+func newWatcher(offsetPath string, allowedPaths []string) *Watcher {
+	return &Watcher{
+		allowed: newAllowlist(allowedPaths),
+		events:  make(chan protocol.LogWatchEvent, 100),
+		offsets: loadOffsetStore(offsetPath),
+		tailers: make(map[string]*tailer),
+		states:  make(map[string]*matchState),
+	}
+}
+
+// Events 获取事件通道
+func (w *Watcher) Events() <-chan protocol.LogWatchEvent {
+	return w.events
+}
+
+// Apply 应用日志监控规则并返回当前跟踪的文件，规则无效或路径不在允许范围内时保持原有规则不变
+func (w *Watcher) Apply(ctx context.Context, rules []protocol.LogWatchRule) ([]string, error) {
+	compiled, err := compileRules(rules, w.allowed)
+	if err != nil {
+		return nil, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.stopLocked()
+	w.rules = compiled
+	w.states = make(map[string]*matchState)
+	if len(compiled) == 0 {
+		slog.Info("日志监控已停止")
+		return nil, nil
+	}
+
+	w.scan(true)
+	files := w.files()
+
+	loopCtx, cancel := context.WithCancel(ctx)
+	w.cancel = cancel
+	w.done = make(chan struct{})
+	go w.run(loopCtx, w.done)
+
+	slog.Info("日志监控已启动", "rules", len(compiled), "files", len(files))
+	return files, nil
+}
+
+// Stop 停止监控并保存读取位置
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopLocked()
+}
+
+func (w *Watcher) stopLocked() {
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	<-w.done
+	w.cancel = nil
+	w.done = nil
+}
+
+func compileRules(rules []protocol.LogWatchRule, allowed *allowlist) ([]*rule, error) {
+	compiled := make([]*rule, 0, len(rules))
+	for _, r := range rules {
+		if r.Path == "" {
+			return nil, fmt.Errorf("规则 '%s' 未设置日志路径", r.Name)
+		}
+		if _, err := filepath.Match(r.Path, ""); err != nil {
+			return nil, fmt.Errorf("规则 '%s' 的日志路径无效: %w", r.Name, err)
+		}
+		if !allowed.allows(r.Path) {
+			return nil, fmt.Errorf("规则 '%s' 的日志路径 %s 不在探针允许的范围内，请在 agent.yaml 的 log_watch.allowed_paths 中添加", r.Name, r.Path)
+		}
+		pattern, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("规则 '%s' 的匹配规则无效: %w", r.Name, err)
+		}
+		c := &rule{LogWatchRule: r, pattern: pattern}
+		if r.Exclude != "" {
+			if c.exclude, err = regexp.Compile(r.Exclude); err != nil {
+				return nil, fmt.Errorf("规则 '%s' 的排除规则无效: %w", r.Name, err)
+			}
+		}
+		if c.Window <= 0 {
+			c.Window = defaultWindow
+		}
+		if c.SampleLines <= 0 {
+			c.SampleLines = defaultSampleLines
+		}
+		c.SampleLines = min(c.SampleLines, maxSampleLines)
+		if c.Level == "" {
+			c.Level = "warning"
+		}
+		compiled = append(compiled, c)
+	}
+	return compiled, nil
+}
+
+// run 监控循环
+func (w *Watcher) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	lastScan := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			w.poll(time.Now())
+			w.flush(time.Time{}, true)
+			w.closeAll()
+			return
+		case now := <-ticker.C:
+			if now.Sub(lastScan) >= rescanInterval {
+				w.scan(false)
+				w.saveOffsets()
+				lastScan = now
+			}
+			w.poll(now)
+		}
+	}
+}
+
+// scan 按通配符匹配日志文件，打开新出现的文件并关闭不再匹配的文件
+func (w *Watcher) scan(initial bool) {
+	pathRules := make(map[string][]*rule)
+	for _, r := range w.rules {
+		matches, _ := filepath.Glob(r.Path)
+		for _, path := range matches {
+			if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			// 允许目录中的符号链接可能指向范围之外的文件
+			if !w.allowed.allowsFile(path) {
+				if initial {
+					slog.Warn("日志文件的实际路径不在允许范围内，忽略", "path", path)
+				}
+				continue
+			}
+			if _, ok := pathRules[path]; !ok && len(pathRules) >= maxFiles {
+				slog.Warn("跟踪的日志文件过多，忽略", "path", path, "max", maxFiles)
+				continue
+			}
+			pathRules[path] = append(pathRules[path], r)
+		}
+	}
+	w.pathRules = pathRules
+
+	// 不再匹配的文件（规则变化或文件被移走）已在之前的轮询中读完，直接关闭
+	for path, t := range w.tailers {
+		if _, ok := pathRules[path]; !ok {
+			w.offsets.set(path, t.position())
+			t.close()
+			delete(w.tailers, path)
+		}
+	}
+
+	for path := range pathRules {
+		if _, ok := w.tailers[path]; ok {
+			continue
+		}
+		saved, hasSaved := w.offsets.get(path)
+		t, err := openTailer(path, saved, hasSaved, !initial)
+		if err != nil {
+			slog.Warn("打开日志文件失败", "path", path, "error", err)
+			continue
+		}
+		w.tailers[path] = t
+	}
+}
+
+// files 返回当前跟踪的文件
+func (w *Watcher) files() []string {
+	files := make([]string, 0, len(w.tailers))
+	for path := range w.tailers {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// poll 读取所有文件的新增内容并上报到期的事件
+func (w *Watcher) poll(now time.Time) {
+	for path, t := range w.tailers {
+		rules := w.pathRules[path]
+		if err := t.read(func(line string) {
+			w.match(rules, path, line, now)
+		}); err != nil {
+			slog.Warn("读取日志文件失败", "path", path, "error", err)
+		}
+	}
+	w.flush(now, false)
+}
+
+func (w *Watcher) match(rules []*rule, path, line string, now time.Time) {
+	for _, r := range rules {
+		if !r.pattern.MatchString(line) || (r.exclude != nil && r.exclude.MatchString(line)) {
+			continue
+		}
+
+		key := r.ID + "\x00" + path
+		state, ok := w.states[key]
+		if !ok {
+			state = &matchState{window: time.Duration(r.Window) * time.Second}
+			w.states[key] = state
+		}
+		if state.pending == nil {
+			state.pending = &protocol.LogWatchEvent{
+				RuleID:   r.ID,
+				RuleName: r.Name,
+				Path:     path,
+				Level:    r.Level,
+				FirstAt:  now.UnixMilli(),
+			}
+		}
+		event := state.pending
+		event.Count++
+		event.LastAt = now.UnixMilli()
+		if len(event.Lines) < r.SampleLines {
+			event.Lines = append(event.Lines, line)
+		}
+	}
+}
+
+// flush 上报已过限流窗口的事件，force 为 true 时上报全部
+func (w *Watcher) flush(now time.Time, force bool) {
+	for _, state := range w.states {
+		if state.pending == nil || (!force && now.Sub(state.lastSent) < state.window) {
+			continue
+		}
+
+		select {
+		case w.events <- *state.pending:
+		default:
+			slog.Warn("日志事件队列已满，丢弃事件", "rule", state.pending.RuleName, "path", state.pending.Path)
+		}
+		state.pending = nil
+		state.lastSent = now
+	}
+}
+
+func (w *Watcher) saveOffsets() {
+	for path, t := range w.tailers {
+		w.offsets.set(path, t.position())
+	}
+	w.offsets.retain(w.tailers)
+	if err := w.offsets.save(); err != nil {
+		slog.Warn("保存日志读取位置失败", "error", err)
+	}
+}
+
+func (w *Watcher) closeAll() {
+	w.saveOffsets()
+	for path, t := range w.tailers {
+		t.close()
+		delete(w.tailers, path)
+	}
+}
@@ -0,0 +1,173 @@
+package logwatch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+func newTestWatcher(t *testing.T, dir string, rules ...protocol.LogWatchRule) *Watcher {
+	t.Helper()
+	w := newWatcher(filepath.Join(dir, offsetFileName), []string{dir})
+	compiled, err := compileRules(rules, w.allowed)
+	if err != nil {
+		t.Fatalf("compileRules: %v", err)
+	}
+	w.rules = compiled
+	w.scan(true)
+	return w
+}
+
+func appendLines(t *testing.T, path string, lines ...string) {
+	t.Helper()
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	for _, line := range lines {
+		if _, err := f.WriteString(line + "\n"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func drainEvents(w *Watcher) []protocol.LogWatchEvent {
+	var events []protocol.LogWatchEvent
+	for {
+		select {
+		case event := <-w.events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestWatcherMatchesNewLinesWithRateLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "error.log")
+	appendLines(t, path, "[error] old line before watching")
+
+	w := newTestWatcher(t, dir, protocol.LogWatchRule{
+		ID: "r1", Name: "nginx", Path: filepath.Join(dir, "*.log"),
+		Pattern: `\[error\]`, Exclude: "favicon", Window: 60, SampleLines: 2,
+	})
+
+	now := time.Now()
+	appendLines(t, path, "[error] upstream timed out", "[info] ok", "[error] favicon.ico not found")
+	w.poll(now)
+	events := drainEvents(w)
+	if len(events) != 1 || events[0].Count != 1 || events[0].Lines[0] != "[error] upstream timed out" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	// 窗口内的匹配合并，窗口结束后上报一次
+	appendLines(t, path, "[error] a", "[error] b", "[error] c")
+	w.poll(now.Add(10 * time.Second))
+	if events := drainEvents(w); len(events) != 0 {
+		t.Fatalf("expected rate limited, got %+v", events)
+	}
+	w.poll(now.Add(61 * time.Second))
+	events = drainEvents(w)
+	if len(events) != 1 || events[0].Count != 3 || len(events[0].Lines) != 2 {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestWatcherHandlesRotationAndTruncation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	appendLines(t, path, "start")
+
+	w := newTestWatcher(t, dir, protocol.LogWatchRule{
+		ID: "r1", Name: "oom", Path: path, Pattern: "Out of memory", Window: 1,
+	})
+
+	now := time.Now()
+	// 重命名后新建文件：读完旧文件后从头读取新文件
+	appendLines(t, path, "Out of memory: killed process 1")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendLines(t, path, "Out of memory: killed process 2")
+	w.poll(now)
+	events := drainEvents(w)
+	if len(events) != 1 || events[0].Count != 2 {
+		t.Fatalf("unexpected events after rotation: %+v", events)
+	}
+
+	// copytruncate：文件变小后从头读取
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	w.poll(now.Add(time.Second))
+	appendLines(t, path, "Out of memory: killed process 3")
+	w.poll(now.Add(2 * time.Second))
+	events = drainEvents(w)
+	if len(events) != 1 || events[0].Lines[0] != "Out of memory: killed process 3" {
+		t.Fatalf("unexpected events after truncation: %+v", events)
+	}
+}
+
+func TestWatcherResumesFromSavedOffset(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	rule := protocol.LogWatchRule{ID: "r1", Name: "panic", Path: path, Pattern: "panic"}
+	appendLines(t, path, "panic: before watching")
+
+	w := newTestWatcher(t, dir, rule)
+	w.closeAll()
+
+	// 探针停止期间写入的内容在重启后补读
+	appendLines(t, path, "panic: while stopped", "partial")
+	w = newTestWatcher(t, dir, rule)
+	w.poll(time.Now())
+	events := drainEvents(w)
+	if len(events) != 1 || events[0].Lines[0] != "panic: while stopped" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestWatcherRejectsPathsOutsideAllowlist(t *testing.T) {
+	root := t.TempDir()
+	allowed := filepath.Join(root, "logs")
+	outside := filepath.Join(root, "secret")
+	for _, dir := range []string{allowed, outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	appendLines(t, filepath.Join(outside, "shadow"), "root:secret")
+
+	w := newWatcher(filepath.Join(root, offsetFileName), []string{allowed})
+	for _, path := range []string{
+		filepath.Join(outside, "shadow"),
+		filepath.Join(allowed, "..", "secret", "shadow"),
+		filepath.Join(allowed, "*", "..", "..", "secret", "*"),
+		allowed + "*",
+		"logs/app.log",
+	} {
+		_, err := w.Apply(t.Context(), []protocol.LogWatchRule{{Name: "r", Path: path, Pattern: "."}})
+		if err == nil {
+			t.Errorf("rule path %s should be rejected", path)
+		}
+	}
+
+	// 允许目录中指向范围之外的符号链接不会被跟踪
+	if err := os.Symlink(filepath.Join(outside, "shadow"), filepath.Join(allowed, "link.log")); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+	appendLines(t, filepath.Join(allowed, "app.log"), "start")
+	files, err := w.Apply(t.Context(), []protocol.LogWatchRule{{Name: "r", Path: filepath.Join(allowed, "*.log"), Pattern: "."}})
+	w.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0] != filepath.Join(allowed, "app.log") {
+		t.Fatalf("unexpected files: %v", files)
+	}
+}
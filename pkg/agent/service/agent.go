@@ -9,15 +9,19 @@ import (
 	"log/slog"
 	"os"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/pkg/agent/audit"
 	"github.com/dushixiang/pika/pkg/agent/collector"
 	"github.com/dushixiang/pika/pkg/agent/config"
+	"github.com/dushixiang/pika/pkg/agent/custommetric"
 	"github.com/dushixiang/pika/pkg/agent/id"
+	"github.com/dushixiang/pika/pkg/agent/logwatch"
 	"github.com/dushixiang/pika/pkg/agent/sshmonitor"
 	"github.com/dushixiang/pika/pkg/agent/tamper"
 	"github.com/dushixiang/pika/pkg/version"
@@ -37,6 +41,10 @@ var (
 type safeConn struct {
 	conn *websocket.Conn
 	mu   sync.Mutex
+
+	// 服务端支持的能力，注册成功后设置
+	batchMetrics bool // 支持 metrics_batch 消息
+	ackMetrics   bool // 支持批量指标写入确认
 }
 
 // WriteJSON 线程安全地写入 JSON 消息
@@ -75,18 +83,51 @@ type Agent struct {
 	metricsBuffer    *metricsBuffer
 	tamperProtector  *tamper.Protector
 	sshMonitor       *sshmonitor.Monitor
+	logWatcher       *logwatch.Watcher
+	logForwarder     *logwatch.Forwarder
+	customMetrics    *custommetric.Ingester
+
+	// 服务端配置模板，校验后经 configCh 交给采集循环应用
+	remoteConfig      *remoteConfig
+	configCh          chan *config.Config
+	collectInterval   atomic.Int64 // 当前采集间隔
+	heartbeatInterval atomic.Int64 // 当前心跳间隔
+	updaterCancel     context.CancelFunc
+
+	// 探针自身运行状态
+	health *healthTracker
 }
 
 // New 创建 Agent 实例
 func New(cfg *config.Config) *Agent {
-	return &Agent{
-		cfg:              cfg,
-		idMgr:            id.NewManager(),
-		collectorManager: collector.NewManager(cfg),
-		metricsBuffer:    newMetricsBuffer(),
-		tamperProtector:  tamper.NewProtector(),
-		sshMonitor:       sshmonitor.NewMonitor(),
-	}
+	a := &Agent{
+		cfg:          cfg,
+		idMgr:        id.NewManager(),
+		remoteConfig: newRemoteConfig(cfg),
+		configCh:     make(chan *config.Config, 1),
+		health:       newHealthTracker(),
+	}
+
+	// 优先使用上次下发的配置模板，服务端不可达时也能按模板运行
+	if remote := a.remoteConfig.LoadPersisted(); remote != nil {
+		a.cfg.Collector.Interval = remote.Collector.Interval
+		a.cfg.Collector.HeartbeatInterval = remote.Collector.HeartbeatInterval
+		a.cfg.Collector.NetworkInclude = remote.Collector.NetworkInclude
+		a.cfg.Collector.NetworkExclude = remote.Collector.NetworkExclude
+		a.cfg.Collector.DiskInclude = remote.Collector.DiskInclude
+		a.cfg.AutoUpdate = remote.AutoUpdate
+	}
+	a.collectInterval.Store(int64(cfg.GetCollectorInterval()))
+	a.heartbeatInterval.Store(int64(cfg.GetHeartbeatInterval()))
+
+	a.collectorManager = collector.NewManager(cfg)
+	a.metricsBuffer = newMetricsBuffer(cfg.Buffer)
+	a.tamperProtector = tamper.NewProtector()
+	a.sshMonitor = sshmonitor.NewMonitor()
+	a.logWatcher = logwatch.NewWatcher(cfg.LogWatch.AllowedPaths)
+	a.logForwarder = logwatch.NewForwarder()
+	a.customMetrics = custommetric.NewIngester(cfg.CustomMetrics)
+	return a
 }
 
 // Start 启动探针服务
@@ -95,7 +136,18 @@ func (a *Agent) Start(ctx context.Context) error {
 	ctx, cancel := context.WithCancel(ctx)
 	a.cancel = cancel
 
+	if a.metricsBuffer != nil {
+		defer a.metricsBuffer.Close()
+	}
+	// 退出时保存日志读取位置
+	defer a.logWatcher.Stop()
+	defer a.logForwarder.Stop()
+
+	// 启动自动更新（如果启用），配置模板修改自动更新配置时会重新启动
+	a.startUpdater(ctx)
+
 	go a.metricsLoop(ctx)
+	go a.customMetrics.Run(ctx)
 
 	// 启动探针主循环
 	b := &backoff.Backoff{
@@ -153,7 +205,9 @@ func (a *Agent) runOnce(ctx context.Context, onConnected func()) error {
 	slog.Info("正在连接到服务器", "url", wsURL)
 
 	// 创建自定义的 Dialer
-	var dialer = websocket.DefaultDialer
+	var dialer = *websocket.DefaultDialer
+	// 请求 permessage-deflate 压缩，服务端不支持时自动回退为不压缩
+	dialer.EnableCompression = true
 	if a.cfg.Server.InsecureSkipVerify {
 		dialer.TLSClientConfig = &tls.Config{
 			InsecureSkipVerify: true,
@@ -222,6 +276,11 @@ func (a *Agent) runOnce(ctx context.Context, onConnected func()) error {
 		}
 	})
 
+	// 补发离线缓存的指标
+	wg.Go(func() {
+		a.bufferFlushLoop(conn, done)
+	})
+
 	// 启动防篡改事件监控
 	wg.Go(func() {
 		a.tamperEventLoop(ctx, conn, done)
@@ -232,6 +291,16 @@ func (a *Agent) runOnce(ctx context.Context, onConnected func()) error {
 		a.sshLoginEventLoop(ctx, conn, done)
 	})
 
+	// 启动日志匹配事件上报
+	wg.Go(func() {
+		a.logWatchEventLoop(ctx, conn, done)
+	})
+
+	// 启动系统日志上报
+	wg.Go(func() {
+		a.logForwardLoop(ctx, conn, done)
+	})
+
 	// 等待第一个错误或上下文取消
 	var returnErr error
 	select {
@@ -283,6 +352,10 @@ func (a *Agent) readLoop(conn *websocket.Conn, done chan struct{}) error {
 		}
 
 		switch msg.Type {
+		case protocol.MessageTypeMetricsAck:
+			if a.metricsBuffer != nil {
+				a.metricsBuffer.HandleAck(msg.Data)
+			}
 		case protocol.MessageTypeCommand:
 			go a.handleCommand(msg.Data)
 		case protocol.MessageTypeMonitorConfig:
@@ -295,6 +368,15 @@ func (a *Agent) readLoop(conn *websocket.Conn, done chan struct{}) error {
 			go a.handlePublicIPConfig(msg.Data)
 		case protocol.MessageTypeSSHLoginConfig:
 			go a.handleSSHLoginConfig(conn, msg.Data)
+		case protocol.MessageTypeAgentConfig:
+			// 在读取循环中同步处理，保证配置按下发顺序应用
+			a.handleAgentConfig(msg.Data)
+		case protocol.MessageTypeLogWatchConfig:
+			// 同步处理，保证规则按下发顺序应用
+			a.handleLogWatchConfig(msg.Data)
+		case protocol.MessageTypeLogForwardConfig:
+			// 同步处理，保证配置按下发顺序应用
+			a.handleLogForwardConfig(msg.Data)
 		case protocol.MessageTypeUninstall:
 			go a.handleUninstall()
 		default:
@@ -368,7 +450,10 @@ func (a *Agent) registerAgent(conn *safeConn) error {
 		return fmt.Errorf("解析注册响应失败: %w", err)
 	}
 
-	slog.Info("注册成功", "agentId", registerResp.AgentID, "status", registerResp.Status)
+	conn.batchMetrics = slices.Contains(registerResp.Capabilities, protocol.CapabilityMetricsBatch)
+	conn.ackMetrics = slices.Contains(registerResp.Capabilities, protocol.CapabilityMetricsAck)
+	slog.Info("注册成功", "agentId", registerResp.AgentID, "status", registerResp.Status, "batchMetrics", conn.batchMetrics)
+	a.health.recordConnected()
 	return nil
 }
 
@@ -403,7 +488,8 @@ func (a *Agent) handleMonitorConfig(data json.RawMessage) {
 
 // heartbeatLoop 心跳循环
 func (a *Agent) heartbeatLoop(ctx context.Context, conn *safeConn, done chan struct{}) error {
-	ticker := time.NewTicker(a.cfg.GetHeartbeatInterval())
+	interval := time.Duration(a.heartbeatInterval.Load())
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -416,6 +502,12 @@ func (a *Agent) heartbeatLoop(ctx context.Context, conn *safeConn, done chan str
 				return fmt.Errorf("发送心跳失败: %w", err)
 			}
 			//slog.Info("心跳已发送")
+
+			// 配置模板可能修改了心跳间隔
+			if current := time.Duration(a.heartbeatInterval.Load()); current != interval {
+				interval = current
+				ticker.Reset(interval)
+			}
 		case <-done:
 			return nil
 		case <-ctx.Done():
@@ -448,6 +540,36 @@ func (a *Agent) getCollectorManager() *collector.Manager {
 	return a.collectorManager
 }
 
+// bufferFlushLoop 连接建立后立即补发离线缓存，之后每个采集周期检查一次，直到连接断开
+func (a *Agent) bufferFlushLoop(conn *safeConn, done chan struct{}) {
+	if a.metricsBuffer == nil {
+		return
+	}
+
+	interval := time.Duration(a.collectInterval.Load())
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if sent, err := a.metricsBuffer.Flush(conn, conn.batchMetrics, conn.ackMetrics, done); err != nil {
+			slog.Warn("发送缓存指标失败", "error", err, "sent", sent)
+		} else if sent > 0 {
+			slog.Info("已发送缓存指标", "count", sent)
+		}
+
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		if current := time.Duration(a.collectInterval.Load()); current != interval {
+			interval = current
+			ticker.Reset(interval)
+		}
+	}
+}
+
 // metricsLoop 指标采集循环
 func (a *Agent) metricsLoop(ctx context.Context) {
 	manager := a.getCollectorManager()
@@ -472,6 +594,10 @@ func (a *Agent) metricsLoop(ctx context.Context) {
 			if err := a.collectAndSendAllMetrics(manager); err != nil {
 				slog.Warn("数据采集失败", "error", err)
 			}
+		case cfg := <-a.configCh:
+			// 在两次采集之间应用配置模板，采集器读取配置时无需加锁
+			a.applyConfig(ctx, cfg)
+			ticker.Reset(a.cfg.GetCollectorInterval())
 		case <-ctx.Done():
 			return
 		}
@@ -485,75 +611,120 @@ func (a *Agent) collectAndSendAllMetrics(manager *collector.Manager) error {
 	}
 
 	conn := a.getActiveConn()
-	if conn != nil {
-		if sent, err := a.metricsBuffer.Flush(conn); err != nil {
-			slog.Warn("发送缓存指标失败", "error", err)
-		} else if sent > 0 {
-			slog.Info("已发送缓存指标", "count", sent)
-		}
-	}
-
 	writer := newMetricsWriter(conn, a.metricsBuffer)
 	var hasError bool
 
 	// CPU 动态指标
-	if err := manager.CollectAndSendCPU(writer); err != nil {
+	if err := a.health.track("cpu", func() error { return manager.CollectAndSendCPU(writer) }); err != nil {
 		slog.Warn("发送CPU指标失败", "error", err)
 		hasError = true
 	}
 
 	// 内存动态指标
-	if err := manager.CollectAndSendMemory(writer); err != nil {
+	if err := a.health.track("memory", func() error { return manager.CollectAndSendMemory(writer) }); err != nil {
 		slog.Warn("发送内存指标失败", "error", err)
 		hasError = true
 	}
 
 	// 磁盘指标
-	if err := manager.CollectAndSendDisk(writer); err != nil {
+	if err := a.health.track("disk", func() error { return manager.CollectAndSendDisk(writer) }); err != nil {
 		slog.Warn("发送磁盘指标失败", "error", err)
 		hasError = true
 	}
 
 	// 磁盘 IO 指标
-	if err := manager.CollectAndSendDiskIO(writer); err != nil {
+	if err := a.health.track("disk_io", func() error { return manager.CollectAndSendDiskIO(writer) }); err != nil {
 		slog.Warn("发送磁盘IO指标失败", "error", err)
 		hasError = true
 	}
 
 	// 网络指标
-	if err := manager.CollectAndSendNetwork(writer); err != nil {
+	if err := a.health.track("network", func() error { return manager.CollectAndSendNetwork(writer) }); err != nil {
 		slog.Warn("发送网络指标失败", "error", err)
 		hasError = true
 	}
 
 	// 网络连接统计
-	if err := manager.CollectAndSendNetworkConnection(writer); err != nil {
+	if err := a.health.track("network_connection", func() error { return manager.CollectAndSendNetworkConnection(writer) }); err != nil {
 		slog.Warn("发送网络连接统计失败", "error", err)
 		hasError = true
 	}
 
 	// 主机信息（包含 Load）
-	if err := manager.CollectAndSendHost(writer); err != nil {
+	if err := a.health.track("host", func() error { return manager.CollectAndSendHost(writer) }); err != nil {
 		slog.Warn("发送主机信息失败", "error", err)
 		hasError = true
 	}
 
 	// GPU 信息（可选）
-	if err := manager.CollectAndSendGPU(writer); err != nil {
+	if err := a.health.track("gpu", func() error { return manager.CollectAndSendGPU(writer) }); err != nil {
 		slog.Info("发送GPU信息失败", "error", err)
 	}
 
 	// 温度信息（可选）
-	if err := manager.CollectAndSendTemperature(writer); err != nil {
+	if err := a.health.track("temperature", func() error { return manager.CollectAndSendTemperature(writer) }); err != nil {
 		slog.Info("发送温度信息失败", "error", err)
 	}
 
+	// 内核统计（可选）
+	if err := a.health.track("kernel", func() error { return manager.CollectAndSendKernel(writer) }); err != nil {
+		slog.Warn("发送内核统计失败", "error", err)
+	}
+
+	// 硬件健康（可选）
+	if err := a.health.track("hardware", func() error { return manager.CollectAndSendHardware(writer) }); err != nil {
+		slog.Warn("发送硬件健康数据失败", "error", err)
+	}
+
+	// 进程指标（可选）
+	if err := a.health.track("process", func() error { return manager.CollectAndSendProcess(writer) }); err != nil {
+		slog.Warn("发送进程指标失败", "error", err)
+	}
+
+	// systemd 单元与容器状态（可选）
+	if err := a.health.track("service", func() error { return manager.CollectAndSendService(writer) }); err != nil {
+		slog.Warn("发送服务状态失败", "error", err)
+	}
+
+	// Prometheus exporter 指标（可选）
+	if err := a.health.track("exporter", func() error { return manager.CollectAndSendExporter(writer) }); err != nil {
+		slog.Warn("发送 exporter 指标失败", "error", err)
+	}
+
+	// 自定义指标（可选）
+	if err := a.health.track("custom", func() error { return manager.SendCustom(writer, a.customMetrics.Collect()) }); err != nil {
+		slog.Warn("发送自定义指标失败", "error", err)
+	}
+
+	// 离线缓存状态
+	if a.metricsBuffer != nil {
+		if err := manager.SendBufferStats(writer, a.metricsBuffer.Stats()); err != nil {
+			slog.Warn("发送缓存状态失败", "error", err)
+		}
+	}
+
+	// 探针自身运行状态
+	if a.health.reportDue(time.Now()) {
+		if err := manager.SendAgentHealth(writer, a.healthSnapshot()); err != nil {
+			slog.Warn("发送探针运行状态失败", "error", err)
+		}
+	}
+
+	// 本次采集的所有指标合并为一条消息发送
+	if err := writer.Flush(); err != nil {
+		slog.Warn("发送指标失败", "error", err)
+	} else if conn != nil && !writer.buffered && !writer.dropped {
+		a.health.recordSend()
+	}
+
 	if writer.buffered {
 		if conn == nil {
 			slog.Info("当前连接不可用，指标已写入缓存")
 		} else if writer.sendErr != nil {
 			slog.Warn("发送指标失败，已写入缓存", "error", writer.sendErr)
 		}
+	} else if writer.dropped {
+		slog.Debug("当前连接不可用，本次采集的指标未缓存")
 	}
 
 	if hasError {
@@ -580,6 +751,8 @@ func (a *Agent) handleCommand(data json.RawMessage) {
 	switch cmdReq.Type {
 	case "vps_audit":
 		a.handleVPSAudit(conn, cmdReq.ID)
+	case "diagnostics":
+		a.handleDiagnostics(conn, cmdReq.ID)
 	default:
 		slog.Warn("未知指令类型", "type", cmdReq.Type)
 		a.sendCommandResponse(conn, cmdReq.ID, cmdReq.Type, "error", "未知指令类型", "")
@@ -679,6 +852,7 @@ func (a *Agent) handleTamperProtect(data json.RawMessage) {
 	message := fmt.Sprintf("防篡改保护已更新: 新增 %d 个, 移除 %d 个, 当前保护 %d 个目录",
 		len(result.Added), len(result.Removed), len(result.Current))
 	slog.Info(message)
+	a.health.setFeature("tamper", len(result.Current) > 0)
 	a.sendTamperProtectResponse(true, message, result.Current, result.Added, result.Removed)
 }
 
@@ -767,6 +941,7 @@ func (a *Agent) handleDDNSConfig(data json.RawMessage) {
 		return
 	}
 
+	a.health.setFeature("ddns", ddnsConfig.Enabled)
 	if !ddnsConfig.Enabled {
 		slog.Info("DDNS 已禁用，跳过 IP 检查")
 		return
@@ -976,6 +1151,8 @@ func (a *Agent) handleSSHLoginConfig(conn *websocket.Conn, data json.RawMessage)
 		return
 	}
 
+	a.health.setFeature("ssh_monitor", sshLoginConfig.Enabled)
+
 	// 发送成功结果
 	message := "配置已成功应用"
 	if sshLoginConfig.Enabled {
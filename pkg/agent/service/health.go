@@ -0,0 +1,190 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent"
+	"github.com/shirou/gopsutil/v4/process"
+)
+
+// healthReportInterval 探针自身运行状态的上报间隔
+const healthReportInterval = time.Minute
+
+// healthTracker 记录探针自身的运行状态：采集耗时与错误、连接次数、最近发送时间、功能启用状态
+type healthTracker struct {
+	mu          sync.Mutex
+	startedAt   time.Time
+	collectors  map[string]*protocol.CollectorHealth
+	features    map[string]bool
+	connections uint64
+	lastSendAt  int64
+	lastReport  time.Time
+}
+
+func newHealthTracker() *healthTracker {
+	return &healthTracker{
+		startedAt:  time.Now(),
+		collectors: make(map[string]*protocol.CollectorHealth),
+		features:   make(map[string]bool),
+	}
+}
+
+// track 执行一次采集并记录耗时与结果
+func (h *healthTracker) track(name string, fn func() error) error {
+	start := time.Now()
+	err := fn()
+	h.observe(name, time.Since(start), err)
+	return err
+}
+
+func (h *healthTracker) observe(name string, duration time.Duration, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c, ok := h.collectors[name]
+	if !ok {
+		c = &protocol.CollectorHealth{Name: name}
+		h.collectors[name] = c
+	}
+	c.Runs++
+	c.DurationMs = float64(duration.Microseconds()) / 1000
+	if err != nil {
+		c.Errors++
+		c.LastError = err.Error()
+		c.LastErrorAt = time.Now().UnixMilli()
+	}
+}
+
+// setFeature 记录功能启用状态
+func (h *healthTracker) setFeature(name string, enabled bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.features[name] = enabled
+}
+
+// recordConnected 记录一次连接注册成功
+func (h *healthTracker) recordConnected() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.connections++
+}
+
+// recordSend 记录一次成功发送指标
+func (h *healthTracker) recordSend() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastSendAt = time.Now().UnixMilli()
+}
+
+// reportDue 距上次上报超过上报间隔时返回 true
+func (h *healthTracker) reportDue(now time.Time) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if now.Sub(h.lastReport) < healthReportInterval {
+		return false
+	}
+	h.lastReport = now
+	return true
+}
+
+// snapshot 生成当前运行状态
+func (h *healthTracker) snapshot() protocol.AgentHealth {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	health := protocol.AgentHealth{
+		Version:    GetVersion(),
+		Goroutines: runtime.NumGoroutine(),
+		RSSBytes:   memStats.Sys,
+		HeapBytes:  memStats.HeapAlloc,
+	}
+	if proc, err := process.NewProcess(int32(os.Getpid())); err == nil {
+		if memInfo, err := proc.MemoryInfo(); err == nil {
+			health.RSSBytes = memInfo.RSS
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	health.StartedAt = h.startedAt.UnixMilli()
+	health.UptimeSeconds = int64(time.Since(h.startedAt).Seconds())
+	if h.connections > 1 {
+		health.Reconnects = h.connections - 1
+	}
+	health.LastSendAt = h.lastSendAt
+	health.Features = make(map[string]bool, len(h.features))
+	for name, enabled := range h.features {
+		health.Features[name] = enabled
+	}
+	health.Collectors = make([]protocol.CollectorHealth, 0, len(h.collectors))
+	for _, c := range h.collectors {
+		health.Collectors = append(health.Collectors, *c)
+	}
+	sort.Slice(health.Collectors, func(i, j int) bool {
+		return health.Collectors[i].Name < health.Collectors[j].Name
+	})
+	return health
+}
+
+// healthSnapshot 生成包含缓存与功能状态的运行状态，采集错误中的密钥已遮蔽
+func (a *Agent) healthSnapshot() protocol.AgentHealth {
+	a.health.setFeature("custom_metrics", a.customMetrics.Enabled())
+	a.health.setFeature("buffer", a.metricsBuffer != nil)
+	a.health.setFeature("config_profile", a.remoteConfig.Current() != nil)
+
+	health := a.health.snapshot()
+	if a.metricsBuffer != nil {
+		health.BufferEntries = a.metricsBuffer.Stats().Entries
+	}
+	// 采集错误可能带出 exporter 地址中的 token 或 exec 命令参数
+	replacer := a.remoteConfig.base.SecretReplacer()
+	for i := range health.Collectors {
+		health.Collectors[i].LastError = replacer.Replace(health.Collectors[i].LastError)
+	}
+	return health
+}
+
+// handleDiagnostics 处理诊断信息指令，返回运行状态、配置与最近的日志，密钥均已遮蔽
+func (a *Agent) handleDiagnostics(conn *safeConn, cmdID string) {
+	bundle := protocol.DiagnosticsBundle{
+		GeneratedAt:   time.Now().UnixMilli(),
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		GoVersion:     runtime.Version(),
+		Health:        a.healthSnapshot(),
+		ConfigProfile: a.remoteConfig.Current(),
+	}
+
+	// 本地配置不会在运行中修改，服务端配置模板单独列出
+	localConfig, err := a.remoteConfig.base.MaskedYAML()
+	if err != nil {
+		localConfig = fmt.Sprintf("读取配置失败: %v", err)
+	}
+	bundle.Config = localConfig
+
+	// 日志中可能出现完整的 API Key（如下载地址）、exporter 地址或 exec 命令，统一遮蔽
+	replacer := a.remoteConfig.base.SecretReplacer()
+	bundle.Logs = agent.RecentLogs()
+	for i, line := range bundle.Logs {
+		bundle.Logs[i] = replacer.Replace(line)
+	}
+
+	result, err := json.Marshal(bundle)
+	if err != nil {
+		slog.Error("序列化诊断信息失败", "error", err)
+		a.sendCommandResponse(conn, cmdID, "diagnostics", "error", "序列化结果失败", "")
+		return
+	}
+
+	slog.Info("诊断信息已生成", "logs", len(bundle.Logs))
+	a.sendCommandResponse(conn, cmdID, "diagnostics", "success", "", string(result))
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthTrackerSnapshot(t *testing.T) {
+	h := newHealthTracker()
+	h.recordConnected()
+	h.recordConnected()
+	h.setFeature("tamper", true)
+
+	_ = h.track("disk", func() error { return nil })
+	_ = h.track("cpu", func() error { return errors.New("boom") })
+	_ = h.track("cpu", func() error { return nil })
+
+	health := h.snapshot()
+	if health.Reconnects != 1 || !health.Features["tamper"] {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+	if len(health.Collectors) != 2 || health.Collectors[0].Name != "cpu" {
+		t.Fatalf("unexpected collectors: %+v", health.Collectors)
+	}
+	cpu := health.Collectors[0]
+	if cpu.Runs != 2 || cpu.Errors != 1 || cpu.LastError != "boom" {
+		t.Fatalf("unexpected cpu health: %+v", cpu)
+	}
+}
+
+func TestHealthTrackerReportDue(t *testing.T) {
+	h := newHealthTracker()
+	now := time.Now()
+	if !h.reportDue(now) {
+		t.Fatal("first report should be due")
+	}
+	if h.reportDue(now.Add(healthReportInterval / 2)) {
+		t.Fatal("report should not be due within interval")
+	}
+	if !h.reportDue(now.Add(healthReportInterval)) {
+		t.Fatal("report should be due after interval")
+	}
+}
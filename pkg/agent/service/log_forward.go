@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// handleLogForwardConfig 处理服务端下发的系统日志转发配置
+func (a *Agent) handleLogForwardConfig(data json.RawMessage) {
+	var logForwardConfig protocol.LogForwardConfig
+	if err := json.Unmarshal(data, &logForwardConfig); err != nil {
+		slog.Warn("解析系统日志转发配置失败", "error", err)
+		a.sendLogForwardConfigResult(false, false, "", err.Error())
+		return
+	}
+
+	source, err := a.logForwarder.Apply(context.Background(), logForwardConfig)
+	if err != nil {
+		slog.Warn("应用系统日志转发配置失败", "error", err)
+		a.sendLogForwardConfigResult(false, logForwardConfig.Enabled, "", err.Error())
+		return
+	}
+	a.health.setFeature("log_forward", logForwardConfig.Enabled)
+
+	message := "系统日志转发已禁用"
+	if logForwardConfig.Enabled {
+		message = "系统日志转发已启用"
+	}
+	a.sendLogForwardConfigResult(true, logForwardConfig.Enabled, source, message)
+}
+
+// sendLogForwardConfigResult 发送系统日志转发配置应用结果
+func (a *Agent) sendLogForwardConfigResult(success bool, enabled bool, source string, message string) {
+	conn := a.getActiveConn()
+	if conn == nil {
+		return
+	}
+
+	if err := conn.WriteJSON(protocol.OutboundMessage{
+		Type: protocol.MessageTypeLogForwardConfigResult,
+		Data: protocol.LogForwardConfigResult{
+			Success: success,
+			Enabled: enabled,
+			Source:  source,
+			Message: message,
+		},
+	}); err != nil {
+		slog.Warn("发送系统日志转发配置应用结果失败", "error", err)
+	}
+}
+
+// logForwardLoop 系统日志上报循环
+func (a *Agent) logForwardLoop(ctx context.Context, conn *safeConn, done chan struct{}) {
+	batchCh := a.logForwarder.Batches()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case batch := <-batchCh:
+			if err := conn.WriteJSON(protocol.OutboundMessage{
+				Type: protocol.MessageTypeLogEntries,
+				Data: batch,
+			}); err != nil {
+				slog.Warn("发送系统日志失败", "entries", len(batch.Entries), "error", err)
+			} else {
+				slog.Debug("已上报系统日志", "entries", len(batch.Entries), "dropped", batch.Dropped)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/dushixiang/pika/internal/protocol"
+)
+
+// handleLogWatchConfig 处理服务端下发的日志监控规则
+func (a *Agent) handleLogWatchConfig(data json.RawMessage) {
+	var logWatchConfig protocol.LogWatchConfig
+	if err := json.Unmarshal(data, &logWatchConfig); err != nil {
+		slog.Warn("解析日志监控配置失败", "error", err)
+		a.sendLogWatchConfigResult(logWatchConfig.Version, false, err.Error(), nil)
+		return
+	}
+
+	files, err := a.logWatcher.Apply(context.Background(), logWatchConfig.Rules)
+	if err != nil {
+		slog.Warn("应用日志监控规则失败", "error", err)
+		a.sendLogWatchConfigResult(logWatchConfig.Version, false, err.Error(), nil)
+		return
+	}
+	a.health.setFeature("log_watch", len(logWatchConfig.Rules) > 0)
+
+	message := "日志监控已停止"
+	if len(logWatchConfig.Rules) > 0 {
+		message = fmt.Sprintf("日志监控已启用: %d 条规则, 跟踪 %d 个文件", len(logWatchConfig.Rules), len(files))
+	}
+	a.sendLogWatchConfigResult(logWatchConfig.Version, true, message, files)
+}
+
+// sendLogWatchConfigResult 发送日志监控规则应用结果
+func (a *Agent) sendLogWatchConfigResult(version string, success bool, message string, files []string) {
+	conn := a.getActiveConn()
+	if conn == nil {
+		return
+	}
+
+	if err := conn.WriteJSON(protocol.OutboundMessage{
+		Type: protocol.MessageTypeLogWatchConfigResult,
+		Data: protocol.LogWatchConfigResult{
+			Version: version,
+			Success: success,
+			Message: message,
+			Files:   files,
+		},
+	}); err != nil {
+		slog.Warn("发送日志监控配置应用结果失败", "error", err)
+	}
+}
+
+// logWatchEventLoop 日志匹配事件上报循环
+func (a *Agent) logWatchEventLoop(ctx context.Context, conn *safeConn, done chan struct{}) {
+	eventCh := a.logWatcher.Events()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case event := <-eventCh:
+			if err := conn.WriteJSON(protocol.OutboundMessage{
+				Type: protocol.MessageTypeLogWatchEvent,
+				Data: event,
+			}); err != nil {
+				slog.Warn("发送日志匹配事件失败", "error", err)
+			} else {
+				slog.Info("已上报日志匹配事件", "rule", event.RuleName, "path", event.Path, "count", event.Count)
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -12,26 +13,65 @@ import (
 
 	"github.com/dushixiang/pika/internal/protocol"
 	"github.com/dushixiang/pika/pkg/agent/collector"
+	"github.com/dushixiang/pika/pkg/agent/config"
 	"github.com/dushixiang/pika/pkg/agent/utils"
 	bolt "go.etcd.io/bbolt"
 )
 
 const (
 	metricsBufferDBName  = "metrics_buffer.db"
-	metricsBufferBucket  = "metrics_buffer"
+	metricsBufferBucket  = "metrics_buffer_v2"
 	metricsBufferTimeout = 2 * time.Second
+	// metricsBufferLegacyBucket 旧版本缓存桶，值为不带写入时间的 JSON，打开时迁移
+	metricsBufferLegacyBucket = "metrics_buffer"
+	// metricsFlushBatchSize 补发缓存时单个批量消息包含的指标项数量
+	metricsFlushBatchSize = 500
+	// metricsFlushLegacyEntries 服务端不支持批量消息时单轮逐条补发的缓存条数
+	metricsFlushLegacyEntries = 100
+	// metricsAckTimeout 等待服务端确认的超时时间
+	metricsAckTimeout = 15 * time.Second
 )
 
+// errMetricsAckTimeout 等待服务端确认超时
+var errMetricsAckTimeout = errors.New("等待服务端确认超时")
+
+// metricsBuffer 离线指标缓存
+// 每条缓存的值为 8 字节写入时间（毫秒，大端序）加消息 JSON，键为递增序列，按写入顺序遍历即从旧到新
+// 超出大小或保留时间时丢弃最早的数据；补发时等待服务端确认写入存储后才删除
 type metricsBuffer struct {
-	path string
-	mu   sync.Mutex
+	path               string
+	maxBytes           uint64
+	maxAge             time.Duration
+	downsampleInterval time.Duration
+
+	mu         sync.Mutex
+	db         *bolt.DB
+	entries    uint64
+	size       uint64
+	dropped    uint64
+	downsample uint64
+	lastAdmit  time.Time
+
+	batchID uint64
+	ackMu   sync.Mutex
+	acks    map[uint64]chan protocol.MetricsAck
 }
 
-func newMetricsBuffer() *metricsBuffer {
-	path := filepath.Join(utils.GetSafeHomeDir(), ".pika", metricsBufferDBName)
-	return &metricsBuffer{path: path}
+// newMetricsBuffer 创建离线指标缓存，未启用时返回 nil
+func newMetricsBuffer(cfg config.BufferConfig) *metricsBuffer {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &metricsBuffer{
+		path:               filepath.Join(utils.GetSafeHomeDir(), ".pika", metricsBufferDBName),
+		maxBytes:           uint64(cfg.MaxSizeMB) * 1024 * 1024,
+		maxAge:             time.Duration(cfg.MaxAgeHours) * time.Hour,
+		downsampleInterval: time.Duration(cfg.DownsampleInterval) * time.Second,
+		acks:               make(map[uint64]chan protocol.MetricsAck),
+	}
 }
 
+// Append 写入一条消息，超出大小上限时丢弃最早的数据
 func (b *metricsBuffer) Append(v interface{}) error {
 	payload, err := json.Marshal(v)
 	if err != nil {
@@ -45,14 +85,22 @@ func (b *metricsBuffer) Append(v interface{}) error {
 	if err != nil {
 		return err
 	}
-	defer db.Close()
 
-	if err := db.Update(func(tx *bolt.Tx) error {
+	now := time.Now()
+	value := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint64(value, uint64(now.UnixMilli()))
+	copy(value[8:], payload)
+
+	return db.Update(func(tx *bolt.Tx) error {
 		bucket, err := tx.CreateBucketIfNotExists([]byte(metricsBufferBucket))
 		if err != nil {
 			return fmt.Errorf("创建指标缓存桶失败: %w", err)
 		}
 
+		if err := b.evict(bucket, now, uint64(len(value))); err != nil {
+			return err
+		}
+
 		seq, err := bucket.NextSequence()
 		if err != nil {
 			return fmt.Errorf("获取指标缓存序列失败: %w", err)
@@ -60,81 +108,363 @@ func (b *metricsBuffer) Append(v interface{}) error {
 
 		key := make([]byte, 8)
 		binary.BigEndian.PutUint64(key, seq)
-		if err := bucket.Put(key, payload); err != nil {
+		if err := bucket.Put(key, value); err != nil {
 			return fmt.Errorf("写入指标缓存失败: %w", err)
 		}
-
+		b.entries++
+		b.size += uint64(len(value))
 		return nil
-	}); err != nil {
+	})
+}
+
+// admit 离线降采样，距上次缓存不足降采样间隔时返回 false
+func (b *metricsBuffer) admit(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.downsampleInterval > 0 && !b.lastAdmit.IsZero() && now.Sub(b.lastAdmit) < b.downsampleInterval {
+		b.downsample++
+		return false
+	}
+	b.lastAdmit = now
+	return true
+}
+
+// evict 丢弃超出保留时间的数据，并在写入 incoming 字节后超出大小上限时继续丢弃最早的数据
+// 调用方需持有 b.mu
+func (b *metricsBuffer) evict(bucket *bolt.Bucket, now time.Time, incoming uint64) error {
+	expireBefore := now.Add(-b.maxAge).UnixMilli()
+
+	cursor := bucket.Cursor()
+	for k, v := cursor.First(); k != nil; k, v = cursor.First() {
+		expired := b.maxAge > 0 && bufferedAt(v) < expireBefore
+		oversize := b.maxBytes > 0 && b.size+incoming > b.maxBytes
+		if !expired && !oversize {
+			break
+		}
+		if err := cursor.Delete(); err != nil {
+			return fmt.Errorf("删除过期缓存失败: %w", err)
+		}
+		b.forget(uint64(len(v)))
+		b.dropped++
+	}
+	return nil
+}
+
+// forget 更新删除一条缓存后的统计，调用方需持有 b.mu
+func (b *metricsBuffer) forget(size uint64) {
+	if b.entries > 0 {
+		b.entries--
+	}
+	if b.size >= size {
+		b.size -= size
+	} else {
+		b.size = 0
+	}
+}
+
+// Stats 返回缓存状态
+func (b *metricsBuffer) Stats() protocol.MetricsBufferStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// 首次调用时打开数据库以统计已有缓存，打开失败时只返回计数
+	db, _ := b.openDB()
+	stats := protocol.MetricsBufferStats{
+		Entries:          b.entries,
+		SizeBytes:        b.size,
+		DroppedTotal:     b.dropped,
+		DownsampledTotal: b.downsample,
+	}
+	if db != nil && b.entries > 0 {
+		_ = db.View(func(tx *bolt.Tx) error {
+			if bucket := tx.Bucket([]byte(metricsBufferBucket)); bucket != nil {
+				if k, v := bucket.Cursor().First(); k != nil {
+					stats.OldestTimestamp = bufferedAt(v)
+				}
+			}
+			return nil
+		})
+	}
+	return stats
+}
+
+// Flush 补发缓存的指标，直到缓存为空、发送失败或 done 关闭
+// batch 为 true 时将多条缓存合并为 metrics_batch 消息发送；ack 为 true 时等待服务端确认写入后再删除缓存
+func (b *metricsBuffer) Flush(writer collector.WebSocketWriter, batch, ack bool, done <-chan struct{}) (int, error) {
+	var sent int
+	for {
+		select {
+		case <-done:
+			return sent, nil
+		default:
+		}
+
+		limit := metricsFlushLegacyEntries
+		if batch {
+			limit = metricsFlushBatchSize
+		}
+		keys, messages, payloads, err := b.read(limit, batch)
+		if err != nil {
+			return sent, err
+		}
+		if len(keys) == 0 {
+			return sent, nil
+		}
+
+		if batch {
+			err = b.sendBatch(writer, payloads, ack, done)
+		} else {
+			err = sendLegacy(writer, messages)
+		}
+		if err != nil {
+			return sent, err
+		}
+
+		if err := b.delete(keys); err != nil {
+			return sent, err
+		}
+		sent += len(keys)
+	}
+}
+
+// sendBatch 发送一批指标，需要确认时等待服务端回复
+func (b *metricsBuffer) sendBatch(writer collector.WebSocketWriter, payloads []protocol.MetricsPayload, ack bool, done <-chan struct{}) error {
+	msg := protocol.MetricsBatch{Items: payloads}
+	if !ack {
+		return writer.WriteJSON(protocol.OutboundMessage{Type: protocol.MessageTypeMetricsBatch, Data: msg})
+	}
+
+	b.ackMu.Lock()
+	b.batchID++
+	msg.ID = b.batchID
+	ackCh := make(chan protocol.MetricsAck, 1)
+	b.acks[msg.ID] = ackCh
+	b.ackMu.Unlock()
+
+	defer func() {
+		b.ackMu.Lock()
+		delete(b.acks, msg.ID)
+		b.ackMu.Unlock()
+	}()
+
+	if err := writer.WriteJSON(protocol.OutboundMessage{Type: protocol.MessageTypeMetricsBatch, Data: msg}); err != nil {
 		return err
 	}
 
+	timer := time.NewTimer(metricsAckTimeout)
+	defer timer.Stop()
+	select {
+	case result := <-ackCh:
+		if !result.Success {
+			return fmt.Errorf("服务端写入缓存指标失败: %s", result.Message)
+		}
+		return nil
+	case <-timer.C:
+		return errMetricsAckTimeout
+	case <-done:
+		return errMetricsAckTimeout
+	}
+}
+
+// sendLegacy 服务端不支持批量消息时逐条补发，已缓存的批量消息拆分后发送
+func sendLegacy(writer collector.WebSocketWriter, messages []bufferedMessage) error {
+	for _, msg := range messages {
+		if msg.Type == protocol.MessageTypeMetrics {
+			if err := writer.WriteJSON(protocol.OutboundMessage{Type: msg.Type, Data: msg.Data}); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, payload := range msg.Payloads {
+			if err := writer.WriteJSON(protocol.OutboundMessage{Type: protocol.MessageTypeMetrics, Data: payload}); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func (b *metricsBuffer) Flush(writer collector.WebSocketWriter) (int, error) {
+// HandleAck 处理服务端的批量指标确认
+func (b *metricsBuffer) HandleAck(data json.RawMessage) {
+	var ack protocol.MetricsAck
+	if err := json.Unmarshal(data, &ack); err != nil {
+		slog.Warn("解析指标确认失败", "error", err)
+		return
+	}
+
+	b.ackMu.Lock()
+	ackCh, ok := b.acks[ack.ID]
+	b.ackMu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case ackCh <- ack:
+	default:
+	}
+}
+
+// bufferedMessage 一条缓存消息
+type bufferedMessage struct {
+	Type     protocol.MessageType
+	Data     json.RawMessage
+	Payloads []protocol.MetricsPayload
+}
+
+// read 从最早的缓存开始读取，batch 为 true 时按指标项数量限制，否则按条数限制
+// 解析失败的缓存直接删除
+func (b *metricsBuffer) read(limit int, batch bool) ([][]byte, []bufferedMessage, []protocol.MetricsPayload, error) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	db, err := b.openDB()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return 0, nil
-		}
-		return 0, err
+		return nil, nil, nil, err
+	}
+	if b.entries == 0 {
+		return nil, nil, nil, nil
 	}
-	defer db.Close()
 
 	var (
-		sent    int
-		sendErr error
+		keys     [][]byte
+		messages []bufferedMessage
+		payloads []protocol.MetricsPayload
 	)
-
-	if err := db.Update(func(tx *bolt.Tx) error {
+	err = db.Update(func(tx *bolt.Tx) error {
 		bucket := tx.Bucket([]byte(metricsBufferBucket))
 		if bucket == nil {
 			return nil
 		}
+		if err := b.evict(bucket, time.Now(), 0); err != nil {
+			return err
+		}
 
+		var corrupted [][]byte
 		cursor := bucket.Cursor()
 		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
-			if sendErr != nil {
-				break
-			}
-
-			var msg protocol.OutboundMessage
-			if err := json.Unmarshal(v, &msg); err != nil {
+			key := append([]byte(nil), k...)
+			msg, err := decodeBufferedMessage(v)
+			if err != nil {
 				slog.Warn("缓存指标解析失败，已跳过", "error", err)
-				if err := cursor.Delete(); err != nil {
-					return fmt.Errorf("删除损坏缓存失败: %w", err)
-				}
+				corrupted = append(corrupted, key)
 				continue
 			}
 
-			if err := writer.WriteJSON(msg); err != nil {
-				sendErr = err
+			keys = append(keys, key)
+			messages = append(messages, msg)
+			payloads = append(payloads, msg.Payloads...)
+			if (batch && len(payloads) >= limit) || (!batch && len(keys) >= limit) {
 				break
 			}
+		}
 
-			if err := cursor.Delete(); err != nil {
-				return fmt.Errorf("删除已发送缓存失败: %w", err)
+		for _, key := range corrupted {
+			if err := b.deleteKey(bucket, key); err != nil {
+				return err
 			}
-			sent++
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return keys, messages, payloads, nil
+}
+
+// delete 删除已发送的缓存，发送期间已被淘汰的缓存会被忽略
+func (b *metricsBuffer) delete(keys [][]byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 
+	db, err := b.openDB()
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(metricsBufferBucket))
+		if bucket == nil {
+			return nil
+		}
+		for _, key := range keys {
+			if err := b.deleteKey(bucket, key); err != nil {
+				return err
+			}
+		}
 		return nil
-	}); err != nil {
-		return sent, err
+	})
+}
+
+// deleteKey 删除一条缓存并更新统计，调用方需持有 b.mu
+func (b *metricsBuffer) deleteKey(bucket *bolt.Bucket, key []byte) error {
+	v := bucket.Get(key)
+	if v == nil {
+		return nil
+	}
+	size := uint64(len(v))
+	if err := bucket.Delete(key); err != nil {
+		return fmt.Errorf("删除已发送缓存失败: %w", err)
+	}
+	b.forget(size)
+	return nil
+}
+
+// decodeBufferedMessage 解析一条缓存，返回消息及其包含的指标项
+func decodeBufferedMessage(value []byte) (bufferedMessage, error) {
+	if len(value) < 8 {
+		return bufferedMessage{}, fmt.Errorf("缓存数据长度无效: %d", len(value))
+	}
+
+	var msg protocol.InputMessage
+	if err := json.Unmarshal(value[8:], &msg); err != nil {
+		return bufferedMessage{}, err
+	}
+
+	var inputs []protocol.InputMetricsPayload
+	switch msg.Type {
+	case protocol.MessageTypeMetrics:
+		var input protocol.InputMetricsPayload
+		if err := json.Unmarshal(msg.Data, &input); err != nil {
+			return bufferedMessage{}, err
+		}
+		inputs = append(inputs, input)
+	case protocol.MessageTypeMetricsBatch:
+		var batch protocol.InputMetricsBatch
+		if err := json.Unmarshal(msg.Data, &batch); err != nil {
+			return bufferedMessage{}, err
+		}
+		inputs = batch.Items
+	default:
+		return bufferedMessage{}, fmt.Errorf("未知的缓存消息类型: %s", msg.Type)
 	}
 
-	if sendErr != nil {
-		return sent, sendErr
+	// 指标数据保持原始 JSON，发送时不再重新解析
+	result := bufferedMessage{Type: msg.Type, Data: msg.Data}
+	for _, input := range inputs {
+		result.Payloads = append(result.Payloads, protocol.MetricsPayload{
+			Type:      input.Type,
+			Data:      input.Data,
+			Timestamp: input.Timestamp,
+		})
 	}
+	return result, nil
+}
 
-	return sent, nil
+// bufferedAt 读取缓存的写入时间（毫秒）
+func bufferedAt(value []byte) int64 {
+	if len(value) < 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(value))
 }
 
+// openDB 打开缓存数据库并保持打开，首次打开时统计已有缓存并迁移旧版本数据
+// 调用方需持有 b.mu
 func (b *metricsBuffer) openDB() (*bolt.DB, error) {
+	if b.db != nil {
+		return b.db, nil
+	}
+
 	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
 		return nil, fmt.Errorf("创建指标缓存目录失败: %w", err)
 	}
@@ -144,13 +474,71 @@ func (b *metricsBuffer) openDB() (*bolt.DB, error) {
 		return nil, fmt.Errorf("打开指标缓存数据库失败: %w", err)
 	}
 
+	var entries, size uint64
+	if err := db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(metricsBufferBucket))
+		if err != nil {
+			return fmt.Errorf("创建指标缓存桶失败: %w", err)
+		}
+
+		// 旧版本缓存没有写入时间，按迁移时间写入新桶
+		if legacy := tx.Bucket([]byte(metricsBufferLegacyBucket)); legacy != nil {
+			now := uint64(time.Now().UnixMilli())
+			if err := legacy.ForEach(func(_, v []byte) error {
+				seq, err := bucket.NextSequence()
+				if err != nil {
+					return err
+				}
+				key := make([]byte, 8)
+				binary.BigEndian.PutUint64(key, seq)
+				value := make([]byte, 8+len(v))
+				binary.BigEndian.PutUint64(value, now)
+				copy(value[8:], v)
+				return bucket.Put(key, value)
+			}); err != nil {
+				return fmt.Errorf("迁移旧版本指标缓存失败: %w", err)
+			}
+			if err := tx.DeleteBucket([]byte(metricsBufferLegacyBucket)); err != nil {
+				return fmt.Errorf("删除旧版本指标缓存失败: %w", err)
+			}
+		}
+
+		return bucket.ForEach(func(_, v []byte) error {
+			entries++
+			size += uint64(len(v))
+			return nil
+		})
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	b.db = db
+	b.entries = entries
+	b.size = size
 	return db, nil
 }
 
+// Close 关闭缓存数据库
+func (b *metricsBuffer) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.db == nil {
+		return nil
+	}
+	err := b.db.Close()
+	b.db = nil
+	return err
+}
+
+// metricsWriter 收集一次采集的所有指标，Flush 时合并发送，连接不可用或发送失败时写入缓存
 type metricsWriter struct {
 	conn     *safeConn
 	buffer   *metricsBuffer
+	pending  []protocol.MetricsPayload
 	buffered bool
+	dropped  bool
 	sendErr  error
 }
 
@@ -161,23 +549,63 @@ func newMetricsWriter(conn *safeConn, buffer *metricsBuffer) *metricsWriter {
 	}
 }
 
+// WriteJSON 指标消息暂存到批次中，其他消息直接发送
 func (w *metricsWriter) WriteJSON(v interface{}) error {
-	if w.conn == nil {
-		if err := w.buffer.Append(v); err != nil {
-			return err
+	if msg, ok := v.(protocol.OutboundMessage); ok && msg.Type == protocol.MessageTypeMetrics {
+		if payload, ok := msg.Data.(protocol.MetricsPayload); ok {
+			w.pending = append(w.pending, payload)
+			return nil
 		}
-		w.buffered = true
+	}
+	return w.write(v)
+}
+
+// Flush 发送暂存的指标，服务端不支持批量消息时逐条发送
+// 连接不可用时整批写入缓存（按配置降采样），重连后由 metricsBuffer.Flush 补发
+func (w *metricsWriter) Flush() error {
+	pending := w.pending
+	w.pending = nil
+	if len(pending) == 0 {
 		return nil
 	}
 
-	if err := w.conn.WriteJSON(v); err != nil {
-		if bufferErr := w.buffer.Append(v); bufferErr != nil {
-			return fmt.Errorf("写入指标缓存失败: %w", bufferErr)
+	if w.conn == nil && w.buffer != nil && !w.buffer.admit(time.Now()) {
+		w.dropped = true
+		return nil
+	}
+
+	if w.conn != nil && !w.conn.batchMetrics {
+		for _, payload := range pending {
+			if err := w.write(protocol.OutboundMessage{Type: protocol.MessageTypeMetrics, Data: payload}); err != nil {
+				return err
+			}
 		}
-		w.buffered = true
-		w.sendErr = err
 		return nil
 	}
 
+	return w.write(protocol.OutboundMessage{
+		Type: protocol.MessageTypeMetricsBatch,
+		Data: protocol.MetricsBatch{Items: pending},
+	})
+}
+
+// write 发送消息，失败时写入缓存，未启用缓存时丢弃
+func (w *metricsWriter) write(v interface{}) error {
+	if w.conn != nil {
+		err := w.conn.WriteJSON(v)
+		if err == nil {
+			return nil
+		}
+		w.sendErr = err
+	}
+
+	if w.buffer == nil {
+		w.dropped = true
+		return nil
+	}
+	if err := w.buffer.Append(v); err != nil {
+		return fmt.Errorf("写入指标缓存失败: %w", err)
+	}
+	w.buffered = true
 	return nil
 }
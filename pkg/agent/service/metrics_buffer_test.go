@@ -0,0 +1,239 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/gorilla/websocket"
+)
+
+// ackWriter 模拟服务端，记录收到的消息并按 success 回复确认
+type ackWriter struct {
+	buffer  *metricsBuffer
+	success bool
+	msgs    []protocol.OutboundMessage
+}
+
+func (w *ackWriter) WriteJSON(v interface{}) error {
+	msg := v.(protocol.OutboundMessage)
+	w.msgs = append(w.msgs, msg)
+	if batch, ok := msg.Data.(protocol.MetricsBatch); ok && batch.ID != 0 {
+		data, _ := json.Marshal(protocol.MetricsAck{ID: batch.ID, Success: w.success})
+		go w.buffer.HandleAck(data)
+	}
+	return nil
+}
+
+func newTestMetricsBuffer(t *testing.T) *metricsBuffer {
+	t.Helper()
+	b := &metricsBuffer{
+		path:   filepath.Join(t.TempDir(), metricsBufferDBName),
+		maxAge: time.Hour,
+		acks:   make(map[uint64]chan protocol.MetricsAck),
+	}
+	t.Cleanup(func() { b.Close() })
+	return b
+}
+
+func appendTick(t *testing.T, b *metricsBuffer, ts int64) {
+	t.Helper()
+	err := b.Append(protocol.OutboundMessage{
+		Type: protocol.MessageTypeMetricsBatch,
+		Data: protocol.MetricsBatch{Items: []protocol.MetricsPayload{
+			{Type: protocol.MetricTypeCPU, Data: map[string]float64{"usagePercent": 1}, Timestamp: ts},
+			{Type: protocol.MetricTypeMemory, Data: map[string]float64{"usagePercent": 2}, Timestamp: ts},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+}
+
+func TestMetricsBufferEvictsOldestWhenFull(t *testing.T) {
+	b := newTestMetricsBuffer(t)
+	appendTick(t, b, 1)
+	entrySize := b.Stats().SizeBytes
+	b.maxBytes = entrySize * 3
+
+	for ts := int64(2); ts <= 5; ts++ {
+		appendTick(t, b, ts)
+	}
+
+	stats := b.Stats()
+	if stats.Entries != 3 || stats.DroppedTotal != 2 || stats.SizeBytes > b.maxBytes {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	// 剩余的应是最新的三次采集
+	_, _, payloads, err := b.read(metricsFlushBatchSize, true)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(payloads) != 6 || payloads[0].Timestamp != 3 || payloads[5].Timestamp != 5 {
+		t.Fatalf("unexpected payloads: %+v", payloads)
+	}
+}
+
+func TestMetricsBufferDownsample(t *testing.T) {
+	b := newTestMetricsBuffer(t)
+	b.downsampleInterval = time.Minute
+
+	now := time.Now()
+	admitted := 0
+	for i := 0; i < 12; i++ {
+		if b.admit(now.Add(time.Duration(i) * 10 * time.Second)) {
+			admitted++
+		}
+	}
+	if admitted != 2 || b.Stats().DownsampledTotal != 10 {
+		t.Fatalf("admitted %d, stats %+v", admitted, b.Stats())
+	}
+}
+
+func TestMetricsBufferFlushWaitsForAck(t *testing.T) {
+	b := newTestMetricsBuffer(t)
+	for ts := int64(1); ts <= 3; ts++ {
+		appendTick(t, b, ts)
+	}
+
+	// 服务端写入失败时保留缓存
+	writer := &ackWriter{buffer: b, success: false}
+	sent, err := b.Flush(writer, true, true, nil)
+	if err == nil || sent != 0 || b.Stats().Entries != 3 {
+		t.Fatalf("sent %d, err %v, stats %+v", sent, err, b.Stats())
+	}
+
+	// 确认后删除，三次采集合并为一个批量消息
+	writer = &ackWriter{buffer: b, success: true}
+	sent, err = b.Flush(writer, true, true, nil)
+	if err != nil || sent != 3 || b.Stats().Entries != 0 {
+		t.Fatalf("sent %d, err %v, stats %+v", sent, err, b.Stats())
+	}
+	if len(writer.msgs) != 1 || len(writer.msgs[0].Data.(protocol.MetricsBatch).Items) != 6 {
+		t.Fatalf("unexpected messages: %+v", writer.msgs)
+	}
+}
+
+func TestMetricsBufferFlushLegacyServer(t *testing.T) {
+	b := newTestMetricsBuffer(t)
+	appendTick(t, b, 1)
+
+	writer := &ackWriter{buffer: b}
+	sent, err := b.Flush(writer, false, false, nil)
+	if err != nil || sent != 1 {
+		t.Fatalf("sent %d, err %v", sent, err)
+	}
+	// 不支持批量消息的服务端逐条接收
+	if len(writer.msgs) != 2 || writer.msgs[0].Type != protocol.MessageTypeMetrics {
+		t.Fatalf("unexpected messages: %+v", writer.msgs)
+	}
+}
+
+// dialTestServer 启动 WebSocket 服务端并返回已连接的客户端，服务端收到的消息类型写入 received
+func dialTestServer(t *testing.T) (*safeConn, <-chan protocol.MessageType) {
+	t.Helper()
+	received := make(chan protocol.MessageType, 16)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			var msg protocol.OutboundMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			received <- msg.Type
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return &safeConn{conn: conn}, received
+}
+
+func writeTick(t *testing.T, w *metricsWriter) {
+	t.Helper()
+	for _, metricType := range []protocol.MetricType{protocol.MetricTypeCPU, protocol.MetricTypeMemory} {
+		err := w.WriteJSON(protocol.OutboundMessage{
+			Type: protocol.MessageTypeMetrics,
+			Data: protocol.MetricsPayload{Type: metricType, Data: map[string]float64{"usagePercent": 1}, Timestamp: 1},
+		})
+		if err != nil {
+			t.Fatalf("WriteJSON: %v", err)
+		}
+	}
+}
+
+func TestMetricsWriterFlush(t *testing.T) {
+	tests := []struct {
+		name         string
+		batchMetrics bool
+		want         []protocol.MessageType
+	}{
+		{"batch", true, []protocol.MessageType{protocol.MessageTypeMetricsBatch}},
+		// 不支持批量消息的服务端逐条发送
+		{"legacy", false, []protocol.MessageType{protocol.MessageTypeMetrics, protocol.MessageTypeMetrics}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, received := dialTestServer(t)
+			conn.batchMetrics = tt.batchMetrics
+
+			w := newMetricsWriter(conn, nil)
+			writeTick(t, w)
+			if err := w.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			for i, want := range tt.want {
+				select {
+				case got := <-received:
+					if got != want {
+						t.Fatalf("message %d: got %s, want %s", i, got, want)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("message %d not received", i)
+				}
+			}
+			select {
+			case got := <-received:
+				t.Fatalf("unexpected message %s", got)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestMetricsWriterFlushWithoutConnection(t *testing.T) {
+	b := newTestMetricsBuffer(t)
+
+	w := newMetricsWriter(nil, b)
+	writeTick(t, w)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	// 一次采集的所有指标作为一条缓存写入
+	if !w.buffered || w.dropped || b.Stats().Entries != 1 {
+		t.Fatalf("buffered %v, dropped %v, stats %+v", w.buffered, w.dropped, b.Stats())
+	}
+	_, _, payloads, err := b.read(metricsFlushBatchSize, true)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(payloads) != 2 {
+		t.Fatalf("unexpected payloads: %+v", payloads)
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/dushixiang/pika/internal/protocol"
+	"github.com/dushixiang/pika/pkg/agent/config"
+	"github.com/dushixiang/pika/pkg/agent/updater"
+	"github.com/dushixiang/pika/pkg/agent/utils"
+)
+
+// remoteConfigFileName 最近一次服务端下发的配置模板，服务端不可达时探针启动后仍按模板运行
+const remoteConfigFileName = "remote_config.json"
+
+// remoteConfig 服务端配置模板管理，负责校验、本地持久化以及与本地配置合并
+type remoteConfig struct {
+	mu      sync.Mutex
+	path    string
+	base    *config.Config            // 本地 agent.yaml 配置，撤销模板时恢复
+	current *protocol.AgentConfigData // 当前生效的配置模板，为空表示使用本地配置
+}
+
+func newRemoteConfig(base *config.Config) *remoteConfig {
+	baseCopy := *base
+	return &remoteConfig{
+		path: filepath.Join(utils.GetSafeHomeDir(), ".pika", remoteConfigFileName),
+		base: &baseCopy,
+	}
+}
+
+// LoadPersisted 读取本地保存的配置模板并返回合并后的配置，没有保存或内容无效时返回 nil
+func (r *remoteConfig) LoadPersisted() *config.Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("读取本地配置模板失败", "path", r.path, "error", err)
+		}
+		return nil
+	}
+
+	var payload protocol.AgentConfigData
+	if err := json.Unmarshal(data, &payload); err != nil || payload.ProfileID == "" {
+		slog.Warn("本地配置模板无效，使用本地配置", "path", r.path, "error", err)
+		return nil
+	}
+	cfg, err := r.base.WithRemote(&payload.Config)
+	if err != nil {
+		slog.Warn("本地配置模板校验失败，使用本地配置", "path", r.path, "error", err)
+		return nil
+	}
+
+	r.current = &payload
+	slog.Info("已加载本地保存的配置模板", "profileId", payload.ProfileID, "version", payload.Version)
+	return cfg
+}
+
+// Current 返回当前生效的配置模板，未使用模板时返回 nil
+func (r *remoteConfig) Current() *protocol.AgentConfigData {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Resolve 校验服务端下发的配置模板并保存到本地，返回合并后的配置
+// changed 为 false 表示与当前生效的模板相同，无需重新应用
+func (r *remoteConfig) Resolve(payload *protocol.AgentConfigData) (cfg *config.Config, changed bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.current == nil && payload.ProfileID == "" {
+		return nil, false, nil
+	}
+	if r.current != nil && r.current.ProfileID == payload.ProfileID && r.current.Version == payload.Version {
+		return nil, false, nil
+	}
+
+	if payload.ProfileID == "" {
+		// 服务端撤销了配置模板，恢复本地配置
+		if err := os.Remove(r.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, false, fmt.Errorf("删除本地配置模板失败: %w", err)
+		}
+		r.current = nil
+		baseCopy := *r.base
+		return &baseCopy, true, nil
+	}
+
+	cfg, err = r.base.WithRemote(&payload.Config)
+	if err != nil {
+		return nil, false, err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, false, fmt.Errorf("序列化配置模板失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return nil, false, fmt.Errorf("创建配置目录失败: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0600); err != nil {
+		return nil, false, fmt.Errorf("保存本地配置模板失败: %w", err)
+	}
+
+	r.current = payload
+	return cfg, true, nil
+}
+
+// handleAgentConfig 处理服务端下发的配置模板，校验通过后由采集循环在两次采集之间应用
+func (a *Agent) handleAgentConfig(data json.RawMessage) {
+	var payload protocol.AgentConfigData
+	if err := json.Unmarshal(data, &payload); err != nil {
+		slog.Warn("解析配置模板失败", "error", err)
+		a.sendAgentConfigResult(&payload, false, err.Error())
+		return
+	}
+
+	cfg, changed, err := a.remoteConfig.Resolve(&payload)
+	if err != nil {
+		slog.Warn("应用配置模板失败", "profileId", payload.ProfileID, "error", err)
+		a.sendAgentConfigResult(&payload, false, err.Error())
+		return
+	}
+	if !changed {
+		a.sendAgentConfigResult(&payload, true, "配置未变化")
+		return
+	}
+
+	// 只保留最新一次待应用的配置
+	select {
+	case <-a.configCh:
+	default:
+	}
+	a.configCh <- cfg
+
+	message := "配置模板已应用"
+	if payload.ProfileID == "" {
+		message = "已恢复本地配置"
+	}
+	slog.Info(message, "profileId", payload.ProfileID, "version", payload.Version,
+		"interval", cfg.Collector.Interval, "heartbeatInterval", cfg.Collector.HeartbeatInterval)
+	a.sendAgentConfigResult(&payload, true, message)
+}
+
+// sendAgentConfigResult 发送配置模板应用结果
+func (a *Agent) sendAgentConfigResult(payload *protocol.AgentConfigData, success bool, message string) {
+	conn := a.getActiveConn()
+	if conn == nil {
+		return
+	}
+
+	if err := conn.WriteJSON(protocol.OutboundMessage{
+		Type: protocol.MessageTypeAgentConfigResult,
+		Data: protocol.AgentConfigResult{
+			ProfileID: payload.ProfileID,
+			Version:   payload.Version,
+			Success:   success,
+			Message:   message,
+		},
+	}); err != nil {
+		slog.Warn("发送配置模板应用结果失败", "error", err)
+	}
+}
+
+// applyConfig 将合并后的配置应用到运行中的探针，只能在采集循环中调用
+func (a *Agent) applyConfig(ctx context.Context, cfg *config.Config) {
+	autoUpdateChanged := a.cfg.AutoUpdate != cfg.AutoUpdate
+
+	a.cfg.Collector.Interval = cfg.Collector.Interval
+	a.cfg.Collector.HeartbeatInterval = cfg.Collector.HeartbeatInterval
+	a.cfg.Collector.NetworkInclude = slices.Clone(cfg.Collector.NetworkInclude)
+	a.cfg.Collector.NetworkExclude = slices.Clone(cfg.Collector.NetworkExclude)
+	a.cfg.Collector.DiskInclude = slices.Clone(cfg.Collector.DiskInclude)
+	a.cfg.AutoUpdate = cfg.AutoUpdate
+
+	a.collectInterval.Store(int64(a.cfg.GetCollectorInterval()))
+	a.heartbeatInterval.Store(int64(a.cfg.GetHeartbeatInterval()))
+
+	if autoUpdateChanged {
+		a.startUpdater(ctx)
+	}
+}
+
+// startUpdater 按当前配置（重新）启动自动更新
+func (a *Agent) startUpdater(ctx context.Context) {
+	if a.updaterCancel != nil {
+		a.updaterCancel()
+		a.updaterCancel = nil
+	}
+	if !a.cfg.AutoUpdate.Enabled {
+		return
+	}
+
+	// 更新器使用配置快照，避免与采集循环并发读写
+	snapshot := *a.cfg
+	upd, err := updater.New(&snapshot, GetVersion())
+	if err != nil {
+		slog.Warn("创建更新器失败", "error", err)
+		return
+	}
+	updaterCtx, cancel := context.WithCancel(ctx)
+	a.updaterCancel = cancel
+	go upd.Start(updaterCtx)
+}
@@ -14,7 +14,6 @@ import (
 	"github.com/dushixiang/pika/pkg/agent/id"
 	"github.com/dushixiang/pika/pkg/agent/sshmonitor"
 	"github.com/dushixiang/pika/pkg/agent/sysutil"
-	"github.com/dushixiang/pika/pkg/agent/updater"
 	"github.com/kardianos/service"
 )
 
@@ -34,21 +33,11 @@ func configureICMP() {
 	}
 }
 
-// startAgent 启动 Agent 和自动更新（抽取通用逻辑）
+// startAgent 启动 Agent（抽取通用逻辑），自动更新由 Agent 按配置启动
 func startAgent(ctx context.Context, cfg *config.Config) *Agent {
 	// 创建 Agent 实例
 	a := New(cfg)
 
-	// 启动自动更新（如果启用）
-	if cfg.AutoUpdate.Enabled {
-		upd, err := updater.New(cfg, GetVersion())
-		if err != nil {
-			slog.Warn("创建更新器失败", "error", err)
-		} else {
-			go upd.Start(ctx)
-		}
-	}
-
 	// 在后台启动 Agent
 	go func() {
 		if err := a.Start(ctx); err != nil {
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+const maskedValue = "****"
+
+// sensitiveNameParts 名称中包含这些片段的参数、查询参数视为凭据
+var sensitiveNameParts = []string{"pass", "pwd", "token", "secret", "credential", "apikey", "api_key", "api-key", "access_key", "access-key", "auth", "signature"}
+
+// sensitiveNames 需要完全匹配的凭据名称，避免误伤包含这些片段的普通名称
+var sensitiveNames = map[string]bool{"key": true, "sig": true, "p": true}
+
+// MaskToken 对 Token 进行部分遮蔽显示
+func MaskToken(token string) string {
+	if len(token) <= 8 {
+		return maskedValue
+	}
+	return token[:4] + maskedValue + token[len(token)-4:]
+}
+
+// MaskURL 遮蔽地址中的密码以及凭据类查询参数（如 ?token=），地址无法解析时原样返回
+func MaskURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	masked := false
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), maskedValue)
+			masked = true
+		}
+	}
+	if u.RawQuery != "" {
+		// 逐个替换参数值，保留参数原有的顺序
+		params := strings.Split(u.RawQuery, "&")
+		for i, param := range params {
+			name, _, ok := strings.Cut(param, "=")
+			if !ok {
+				continue
+			}
+			if decoded, err := url.QueryUnescape(name); err == nil && isSensitiveName(decoded) {
+				params[i] = name + "=" + maskedValue
+				masked = true
+			}
+		}
+		u.RawQuery = strings.Join(params, "&")
+	}
+	if !masked {
+		return rawURL
+	}
+	return u.String()
+}
+
+// MaskCommand 遮蔽命令参数中的凭据：地址中的密码与查询参数、--password=xxx / --token xxx 形式的参数值、
+// mysql -pxxx 形式的短参数值，以及 PASSWORD=xxx 形式的环境变量；sh -c 的脚本按空白拆分后逐词处理
+func MaskCommand(command []string) []string {
+	masked := make([]string, len(command))
+	prevSensitive := false
+	for i, arg := range command {
+		if strings.ContainsAny(arg, " \t\n") {
+			words := strings.Fields(arg)
+			wordSensitive := false
+			for j, word := range words {
+				words[j], wordSensitive = maskArg(word, wordSensitive)
+			}
+			masked[i] = strings.Join(words, " ")
+			prevSensitive = false
+			continue
+		}
+		masked[i], prevSensitive = maskArg(arg, prevSensitive)
+	}
+	return masked
+}
+
+// maskArg 遮蔽单个参数，prevSensitive 表示上一个参数是凭据类选项（如 --password），返回值表示下一个参数是否需要遮蔽
+func maskArg(arg string, prevSensitive bool) (string, bool) {
+	if prevSensitive && !strings.HasPrefix(arg, "-") {
+		return maskedValue, false
+	}
+	if strings.Contains(arg, "://") {
+		return MaskURL(arg), false
+	}
+
+	name, value, hasValue := strings.Cut(arg, "=")
+	if hasValue {
+		if isSensitiveName(strings.TrimLeft(name, "-")) && value != "" {
+			return name + "=" + maskedValue, false
+		}
+		return arg, false
+	}
+
+	switch {
+	case strings.HasPrefix(arg, "--"):
+		return arg, isSensitiveName(arg[2:])
+	case strings.HasPrefix(arg, "-") && len(arg) > 2 && arg[1] == 'p':
+		// mysql -pxxx：短参数与值连写
+		return "-p" + maskedValue, false
+	case strings.HasPrefix(arg, "-"):
+		return arg, isSensitiveName(arg[1:])
+	}
+	return arg, false
+}
+
+// isSensitiveName 判断参数名称是否为凭据
+func isSensitiveName(name string) bool {
+	name = strings.ToLower(name)
+	if sensitiveNames[name] {
+		return true
+	}
+	for _, part := range sensitiveNameParts {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}